@@ -0,0 +1,82 @@
+// create-admin 创建管理员账号
+//
+// 服务端不再自动创建默认管理员，首次部署时使用该命令创建第一个管理员，
+// 之后可由已登录的管理员通过 POST /api/admin/admins 创建其他管理员。
+// 密码需符合当前配置的密码策略。
+//
+// 用法：
+//
+//	go run ./cmd/create-admin -username admin [-email admin@example.com] [-contact 13800000000]
+//
+// 未指定 -password 时从标准输入读取密码，避免密码出现在命令行历史中：
+//
+//	echo "$ADMIN_PASSWORD" | go run ./cmd/create-admin -username admin
+package main
+
+import (
+	"bufio"
+	"errors"
+	"feedback-system/internal/config"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/internal/service"
+	"feedback-system/pkg/db"
+	"feedback-system/pkg/password"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	username := flag.String("username", "", "管理员用户名（必填）")
+	pwd := flag.String("password", "", "管理员密码，为空时从标准输入读取")
+	email := flag.String("email", "", "邮箱，用于找回密码")
+	contact := flag.String("contact", "", "联系方式")
+	flag.Parse()
+
+	if strings.TrimSpace(*username) == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *pwd == "" {
+		fmt.Fprint(os.Stderr, "请输入密码: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			log.Fatalf("读取密码失败: %v", err)
+		}
+		*pwd = strings.TrimRight(line, "\r\n")
+	}
+
+	cfg := config.Load()
+	passwordPolicy, err := password.NewPolicy(cfg.Password.MinLength, cfg.Password.MaxLength, cfg.Password.MinClasses, cfg.Password.CheckBreached, cfg.Password.BreachedFile)
+	if err != nil {
+		log.Fatalf("加载密码策略失败: %v", err)
+	}
+
+	database, err := db.NewDB(cfg.DSN)
+	if err != nil {
+		log.Fatalf("连接数据库失败: %v", err)
+	}
+
+	accountService := service.NewAccountService(repository.NewUserRepository(database), repository.NewInviteRepository(database), repository.NewOrganizationRepository(database), repository.NewAuditLogRepository(database), passwordPolicy, cfg.Auth.MerchantSignup)
+
+	// 命令行创建的管理员在审计日志中没有操作者，User-Agent 记为命令名称
+	actor := &service.AuditActor{UserAgent: "create-admin"}
+	user, err := accountService.CreateAdmin(actor, &models.CreateAdminRequest{
+		Username: strings.TrimSpace(*username),
+		Password: *pwd,
+		Contact:  *contact,
+		Email:    *email,
+	})
+	if err != nil {
+		var invalid *password.ValidationError
+		if errors.As(err, &invalid) {
+			log.Fatalf("密码不符合密码策略: %s", invalid.Message)
+		}
+		log.Fatalf("创建管理员失败: %v", err)
+	}
+
+	log.Printf("已创建管理员 %s (id=%d)", user.Username, user.ID)
+}
@@ -1,23 +1,51 @@
 package main
 
 import (
+	"context"
+	"feedback-system/internal/config"
+	"feedback-system/internal/consts"
 	"feedback-system/internal/handler"
 	"feedback-system/internal/middleware"
 	"feedback-system/internal/repository"
 	"feedback-system/internal/service"
+	"feedback-system/pkg/captcha"
 	"feedback-system/pkg/db"
+	"feedback-system/pkg/imaging"
+	"feedback-system/pkg/mailer"
+	"feedback-system/pkg/password"
+	"feedback-system/pkg/ratelimit"
+	"feedback-system/pkg/scheduler"
+	"feedback-system/pkg/signurl"
+	"feedback-system/pkg/storage"
 	"feedback-system/pkg/ws"
 	"log"
 	"net/http"
-	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	// 加载配置
+	cfg := config.Load()
+
 	// 连接数据库
-	dsn := "root:123456@tcp(localhost:3306)/feedback_system?charset=utf8mb4&parseTime=True&loc=Local"
-	db, err := db.NewDB(dsn)
+	db, err := db.NewDB(cfg.DSN)
+	if err != nil {
+		panic(err)
+	}
+
+	// 附件签名密钥没有安全的默认值，未配置时拒绝启动
+	if cfg.Attachment.URLSecret == "" {
+		panic("ATTACHMENT_URL_SECRET is required")
+	}
+	// 访问令牌签名密钥同样必须配置，旧版本的默认密钥已公开，继续使用时拒绝启动
+	if cfg.Auth.JWTSecret == "" || cfg.Auth.JWTSecret == "feedback-system-secret-key" {
+		panic("JWT_SECRET is required and must not be the old default value")
+	}
+
+	// 初始化上传文件存储
+	fileStorage, err := storage.New(cfg.Storage.Driver, cfg.Storage.LocalDir, cfg.Storage.S3)
 	if err != nil {
 		panic(err)
 	}
@@ -26,21 +54,159 @@ func main() {
 	feedbackRepo := repository.NewFeedbackRepository(db)
 	messageRepo := repository.NewFeedbackMessageRepository(db)
 	userRepo := repository.NewUserRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	uploadSessionRepo := repository.NewUploadSessionRepository(db)
+	userSessionRepo := repository.NewUserSessionRepository(db)
+	auditLogRepo := repository.NewAuditLogRepository(db)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
+	inviteRepo := repository.NewInviteRepository(db)
+	identityRepo := repository.NewUserIdentityRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	orgRepo := repository.NewOrganizationRepository(db)
+	feedbackEventRepo := repository.NewFeedbackEventRepository(db)
+	assignmentSettingRepo := repository.NewAssignmentSettingRepository(db)
+	slaPolicyRepo := repository.NewSLAPolicyRepository(db)
+	calendarRepo := repository.NewBusinessCalendarRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	automationRuleRepo := repository.NewAutomationRuleRepository(db)
+
+	// 初始化限流计数存储，配置 REDIS_URL 时多副本共享计数
+	rateLimitStore, err := ratelimit.NewStore(cfg.RateLimit.RedisURL)
+	if err != nil {
+		panic(err)
+	}
+
+	// 初始化登录防暴力破解，未配置验证码密钥时不要求验证码
+	var captchaVerifier captcha.Verifier
+	if cfg.RateLimit.CaptchaSecret != "" {
+		captchaVerifier = captcha.NewSiteVerifier(cfg.RateLimit.CaptchaVerifyURL, cfg.RateLimit.CaptchaSecret)
+	}
+	loginGuard := service.NewLoginGuard(rateLimitStore, auditLogRepo, captchaVerifier, service.LoginPolicy{
+		UserLockThreshold: cfg.RateLimit.LoginUserThreshold,
+		IPLockThreshold:   cfg.RateLimit.LoginIPThreshold,
+		CaptchaThreshold:  cfg.RateLimit.CaptchaThreshold,
+		FailureWindow:     cfg.RateLimit.LoginFailureWindow,
+		LockBase:          cfg.RateLimit.LoginLockBase,
+		LockMax:           cfg.RateLimit.LoginLockMax,
+	})
+
+	// 两步验证策略
+	twoFactorPolicy := service.TwoFactorPolicy{
+		Issuer:   cfg.Auth.TwoFactorIssuer,
+		Required: map[uint8]bool{consts.Admin: cfg.Auth.TwoFactorRequiredAdmin},
+	}
+
+	// 密码策略
+	passwordPolicy, err := password.NewPolicy(cfg.Password.MinLength, cfg.Password.MaxLength, cfg.Password.MinClasses, cfg.Password.CheckBreached, cfg.Password.BreachedFile)
+	if err != nil {
+		panic(err)
+	}
 
 	// 初始化 WebSocket 处理程序
 	wsHandler := ws.NewWSHandler()
 
 	// 初始化 service
-	feedbackService := service.NewFeedbackService(feedbackRepo, messageRepo, userRepo, wsHandler)
-	messageService := service.NewFeedbackMessageService(messageRepo, feedbackRepo, userRepo, wsHandler)
-	userService := service.NewUserService(userRepo)
+	attachmentSigner := signurl.NewSigner(cfg.Attachment.URLSecret, cfg.Attachment.URLTTL)
+	attachmentService := service.NewAttachmentService(attachmentRepo, feedbackRepo, fileStorage, attachmentSigner, cfg.Storage.PresignExpiry, imaging.DefaultOptions, cfg.Upload.AllowedTypes, cfg.Upload.Quota)
+	calendarService := service.NewCalendarService(calendarRepo, orgRepo, auditLogRepo)
+	slaService := service.NewSLAService(slaPolicyRepo, feedbackRepo, feedbackEventRepo, orgRepo, userRepo, auditLogRepo, wsHandler, calendarService)
+	assignmentService := service.NewAssignmentService(feedbackRepo, feedbackEventRepo, userRepo, assignmentSettingRepo, auditLogRepo, wsHandler)
+	automationService := service.NewAutomationService(automationRuleRepo, feedbackRepo, messageRepo, feedbackEventRepo, userRepo, auditLogRepo, wsHandler, assignmentService, slaService, calendarService, cfg.Feedback.WebhookTimeout)
+	slaService.SetBreachHandler(automationService.OnSLABreach)
+	feedbackService := service.NewFeedbackService(feedbackRepo, messageRepo, userRepo, orgRepo, feedbackEventRepo, wsHandler, attachmentService, assignmentService, slaService, automationService)
+	escalationService := service.NewEscalationService(feedbackRepo, messageRepo, feedbackEventRepo, userRepo, wsHandler, cfg.Feedback.EscalationWait)
+	messageService := service.NewFeedbackMessageService(messageRepo, feedbackRepo, userRepo, feedbackEventRepo, wsHandler, attachmentService, assignmentService, slaService, automationService)
+	followUpService := service.NewFollowUpService(feedbackRepo, feedbackEventRepo, userRepo, messageService, slaService, wsHandler, cfg.Feedback.AutoCloseAfter, cfg.Feedback.TargetFollowUpAfter, cfg.Feedback.CreatorFollowUpAfter)
+	userService := service.NewUserService(userRepo, userSessionRepo, recoveryCodeRepo, wsHandler, loginGuard, rateLimitStore, twoFactorPolicy, passwordPolicy, cfg.Auth.JWTSecret, cfg.Auth.AccessTokenTTL, cfg.Auth.RefreshTokenTTL)
+	profileService := service.NewProfileService(userRepo, attachmentService, wsHandler)
+	accountService := service.NewAccountService(userRepo, inviteRepo, orgRepo, auditLogRepo, passwordPolicy, cfg.Auth.MerchantSignup)
+	adminUserService := service.NewAdminUserService(userRepo, feedbackRepo, recoveryCodeRepo, passwordResetRepo, identityRepo, apiKeyRepo, orgRepo, auditLogRepo, userService, feedbackService, attachmentService, passwordPolicy)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo, auditLogRepo)
+	orgService := service.NewOrganizationService(orgRepo, userRepo, auditLogRepo, adminUserService, passwordPolicy)
+	oidcService := service.NewOIDCService(userRepo, identityRepo, orgRepo, auditLogRepo, userService, rateLimitStore, service.OIDCPolicy{
+		Issuer:         cfg.OIDC.Issuer,
+		ClientID:       cfg.OIDC.ClientID,
+		ClientSecret:   cfg.OIDC.ClientSecret,
+		RedirectURL:    cfg.OIDC.RedirectURL,
+		Scopes:         cfg.OIDC.Scopes,
+		ProviderName:   cfg.OIDC.ProviderName,
+		UsernameClaim:  cfg.OIDC.UsernameClaim,
+		GroupsClaim:    cfg.OIDC.GroupsClaim,
+		MerchantGroups: cfg.OIDC.MerchantGroups,
+		AdminGroups:    cfg.OIDC.AdminGroups,
+		AutoProvision:  cfg.OIDC.AutoProvision,
+		LinkByEmail:    cfg.OIDC.LinkByEmail,
+	}, cfg.Auth.JWTSecret)
+	var resetLimiter *ratelimit.Limiter
+	if cfg.Password.ResetLimit > 0 {
+		resetLimiter = ratelimit.NewLimiter(rateLimitStore, "password-reset:", cfg.Password.ResetLimit, time.Hour)
+	}
+	mail := mailer.New(cfg.Mail.SMTPHost, cfg.Mail.SMTPPort, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword, cfg.Mail.From)
+	passwordResetService := service.NewPasswordResetService(userRepo, passwordResetRepo, userService, mail, passwordPolicy, resetLimiter, cfg.BaseURL, cfg.Password.ResetTTL)
+	tusService, err := service.NewTusService(uploadSessionRepo, attachmentService, fileStorage, cfg.Upload.TusDir, cfg.Upload.TusExpiry, cfg.Upload.MaxSize)
+	if err != nil {
+		panic(err)
+	}
+
+	// 后台定时任务：多实例部署时通过数据库锁保证每次执行只在一个实例上运行
+	jobService := service.NewJobService(jobRepo, auditLogRepo, scheduler.SystemClock, cfg.Scheduler.InstanceID, cfg.Scheduler.LockTTL)
+	registerJob := func(name, description string, fn scheduler.JobFunc) {
+		if err := jobService.Register(name, description, cfg.Scheduler.Schedules[name], fn); err != nil {
+			panic(err)
+		}
+	}
+	registerJob(consts.JobTusCleanup, "清理过期的断点续传上传", func(context.Context) (int64, error) {
+		n, err := tusService.CleanupExpired()
+		return int64(n), err
+	})
+	registerJob(consts.JobAttachmentSweep, "清理未被引用的附件", func(ctx context.Context) (int64, error) {
+		n, err := attachmentService.SweepOrphans(ctx, cfg.Attachment.OrphanGrace)
+		return int64(n), err
+	})
+	registerJob(consts.JobSessionCleanup, "清理过期的登录会话", func(context.Context) (int64, error) {
+		return userService.CleanupSessions()
+	})
+	registerJob(consts.JobSLAMonitor, "检查 SLA 预警和超时", func(context.Context) (int64, error) {
+		n, err := slaService.Check()
+		return int64(n), err
+	})
+	registerJob(consts.JobAutoClose, "自动关闭已解决的反馈", func(context.Context) (int64, error) {
+		return followUpService.AutoClose()
+	})
+	registerJob(consts.JobTargetFollowUp, "提醒目标方回复长时间未回复的反馈", func(context.Context) (int64, error) {
+		return followUpService.FollowUpTargets()
+	})
+	registerJob(consts.JobCreatorFollowUp, "询问反馈创建者是否仍需要帮助", func(context.Context) (int64, error) {
+		return followUpService.FollowUpCreators()
+	})
+	registerJob(consts.JobRunCleanup, "清理过期的任务运行记录", func(context.Context) (int64, error) {
+		return jobService.CleanupRuns(cfg.Scheduler.RunRetention)
+	})
+	if cfg.Scheduler.Enabled {
+		jobService.Start()
+	}
 
 	// 初始化 handler
 	feedbackHandler := handler.NewFeedbackHandler(feedbackService)
 	messageHandler := handler.NewFeedbackMessageHandler(messageService)
-	wsHttpHandler := handler.NewWSHandler(wsHandler)
-	userHandler := handler.NewUserHandler(userService)
-	uploadHandler := handler.NewUploadHandler()
+	assignmentHandler := handler.NewAssignmentHandler(assignmentService)
+	escalationHandler := handler.NewEscalationHandler(escalationService)
+	slaPolicyHandler := handler.NewSLAPolicyHandler(slaService)
+	calendarHandler := handler.NewCalendarHandler(calendarService)
+	jobHandler := handler.NewJobHandler(jobService)
+	automationHandler := handler.NewAutomationHandler(automationService)
+	wsHttpHandler := handler.NewWSHandler(wsHandler, userService)
+	userHandler := handler.NewUserHandler(userService, accountService, profileService, orgService)
+	accountHandler := handler.NewAccountHandler(accountService)
+	adminUserHandler := handler.NewAdminUserHandler(adminUserService)
+	oidcHandler := handler.NewOIDCHandler(oidcService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	orgHandler := handler.NewOrganizationHandler(orgService)
+	passwordResetHandler := handler.NewPasswordResetHandler(passwordResetService)
+	uploadHandler := handler.NewUploadHandler(attachmentService)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService)
+	tusHandler := handler.NewTusHandler(tusService, attachmentService)
 
 	// 设置路由
 	router := gin.Default()
@@ -52,10 +218,16 @@ func main() {
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-User-ID, X-User-Type")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, X-User-ID, X-User-Type, Tus-Resumable, Upload-Length, Upload-Metadata, Upload-Offset, X-HTTP-Method-Override")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, HEAD, DELETE")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Location, Tus-Resumable, Tus-Version, Tus-Extension, Tus-Max-Size, Upload-Offset, Upload-Length, Upload-Metadata, Upload-Expires, X-Attachment-ID, X-Attachment-URL, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset")
 
 		if c.Request.Method == "OPTIONS" {
+			// 断点续传接口的 OPTIONS 请求用于协议能力发现
+			if handler.IsTusPath(c.Request.URL.Path) {
+				tusHandler.Options(c)
+				return
+			}
 			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
@@ -66,26 +238,53 @@ func main() {
 	// API 路由组
 	// 前后端对接说明：所有API请求都以 /api 为前缀
 	apiGroup := router.Group("/api")
+	// API限流：internal/middleware/ratelimit.go
+	// 公开接口按客户端IP计数；需要认证的接口在认证之后按用户计数，多个用户共用出口IP时互不影响
+	publicApi := apiGroup.Group("/")
+	// 需要认证的路由（需要Bearer token，商家后端也可以使用 X-API-Key 访问部分接口）
+	// 认证中间件：internal/middleware/auth.go AuthMiddleware
+	authApi := apiGroup.Group("/")
+	authApi.Use(middleware.AuthMiddleware(userService, apiKeyService))
+	// 附件下载路由使用可选认证，携带令牌时按用户计数
+	attachmentApi := apiGroup.Group("/", middleware.OptionalAuthMiddleware(userService))
+	if cfg.RateLimit.APILimit > 0 {
+		apiLimit := middleware.RateLimitMiddleware(ratelimit.NewLimiter(rateLimitStore, "api:", cfg.RateLimit.APILimit, cfg.RateLimit.APIWindow))
+		publicApi.Use(apiLimit)
+		authApi.Use(apiLimit)
+		attachmentApi.Use(apiLimit)
+	}
 	{
 		// 公开路由（无需认证）
 		// 用户相关路由：/api/user/* → internal/handler/user.go
-		userHandler.RegisterRoutes(apiGroup)
-		// WebSocket路由：/api/ws → pkg/ws/handler.go
-		wsHttpHandler.RegisterRoutes(apiGroup)
-
-		// 需要认证的路由（需要Bearer token）
-		// 认证中间件：internal/middleware/auth.go AuthMiddleware
-		authApi := apiGroup.Group("/")
-		authApi.Use(middleware.AuthMiddleware(userService))
+		// 登录、注册、刷新令牌无需认证，退出登录和获取当前用户需要认证
+		userHandler.RegisterRoutes(publicApi, authApi)
+		// 找回密码路由：/api/user/password/forgot、/api/user/password/reset → internal/handler/password_reset.go
+		passwordResetHandler.RegisterRoutes(publicApi)
+		// 单点登录路由：/api/user/oidc/* → internal/handler/oidc.go，未配置 OIDC_ISSUER 时不启用
+		oidcHandler.RegisterRoutes(publicApi, authApi)
+		// WebSocket路由：/api/ws → internal/handler/ws.go，通过 token 查询参数认证
+		wsHttpHandler.RegisterRoutes(publicApi)
+		// 附件下载路由：/api/attachments/* → internal/handler/attachment.go
+		// 签名链接无需令牌，因此使用可选认证中间件，由处理程序校验权限
+		attachmentHandler.RegisterRoutes(attachmentApi)
+
 		{
 			// 所有认证用户都可以访问的路由
 			// 反馈相关路由：/api/feedback/* → internal/handler/feedback.go
 			feedbackHandler.RegisterRoutes(authApi)
+			// 反馈处理人路由：/api/feedback/:id/assign、claim、unassign → internal/handler/assignment.go
+			assignmentHandler.RegisterRoutes(authApi)
+			// 反馈升级路由：/api/feedback/:id/escalate → internal/handler/escalation.go
+			escalationHandler.RegisterRoutes(authApi)
 			// 消息相关路由：/api/message/* → internal/handler/feedback_message.go
 			messageHandler.RegisterRoutes(authApi)
 
 			// 上传路由：/api/upload/image → internal/handler/upload.go UploadImage()
 			authApi.POST("/upload/image", uploadHandler.UploadImage)
+			// 存储用量路由：/api/upload/usage → internal/handler/upload.go Usage()
+			authApi.GET("/upload/usage", uploadHandler.Usage)
+			// 断点续传路由：/api/upload/tus/* → internal/handler/tus.go
+			tusHandler.RegisterRoutes(authApi)
 
 			// 特定角色路由（如果需要的话）
 			// userApi := authApi.Group("/user")
@@ -94,33 +293,55 @@ func main() {
 			//     // 用户专用路由
 			// }
 
-			// merchantApi := authApi.Group("/merchant")
-			// merchantApi.Use(middleware.RoleMiddleware("merchant"))
-			// {
-			//     // 商家专用路由
-			// }
+			// 商家路由：/api/merchant/*
+			merchantApi := authApi.Group("/merchant")
+			merchantApi.Use(middleware.RoleMiddleware("merchant"))
+			{
+				// API密钥管理：/api/merchant/api-keys/* → internal/handler/api_key.go
+				apiKeyHandler.RegisterRoutes(merchantApi)
+				// 商家组织和员工管理：/api/merchant/org/* → internal/handler/organization.go
+				orgHandler.RegisterRoutes(merchantApi)
+				// 组织的自动分配设置：/api/merchant/assignment → internal/handler/assignment.go
+				assignmentHandler.RegisterSettingRoutes(merchantApi)
+				// 组织使用的工作日历：/api/merchant/calendar → internal/handler/calendar.go
+				calendarHandler.RegisterTeamRoutes(merchantApi)
+			}
 
-			// adminApi := authApi.Group("/admin")
-			// adminApi.Use(middleware.RoleMiddleware("admin"))
-			// {
-			//     // 管理员专用路由
-			// }
+			// 管理员路由：/api/admin/*
+			adminApi := authApi.Group("/admin")
+			adminApi.Use(middleware.RoleMiddleware("admin"))
+			{
+				// 商家邀请码、商家审核、创建管理员 → internal/handler/account.go
+				accountHandler.RegisterRoutes(adminApi)
+				// 用户管理：/api/admin/users/* → internal/handler/admin_user.go
+				adminUserHandler.RegisterRoutes(adminApi)
+				// 管理员团队的自动分配设置：/api/admin/assignment → internal/handler/assignment.go
+				assignmentHandler.RegisterSettingRoutes(adminApi)
+				// SLA 策略管理：/api/admin/sla-policies/* → internal/handler/sla_policy.go
+				slaPolicyHandler.RegisterRoutes(adminApi)
+				// 工作日历管理：/api/admin/calendars/*、/api/admin/calendar-assignments、/api/admin/calendar → internal/handler/calendar.go
+				calendarHandler.RegisterRoutes(adminApi)
+				calendarHandler.RegisterTeamRoutes(adminApi)
+				// 后台定时任务：/api/admin/jobs/* → internal/handler/job.go
+				jobHandler.RegisterRoutes(adminApi)
+				// 自动化规则：/api/admin/automation-rules/* → internal/handler/automation.go
+				automationHandler.RegisterRoutes(adminApi)
+			}
 		}
 	}
 
 	// 静态文件服务
 	router.Static("/static", "./static")
 
-	// 确保上传目录存在
-	os.MkdirAll("./static/uploads", 0755)
 	router.StaticFile("/", "./static/index.html")
 	router.StaticFile("/merchant", "./static/merchant.html")
 	router.StaticFile("/admin", "./static/admin.html")
 	router.StaticFile("/multi-user", "./static/multi-user.html")
 	router.StaticFile("/register.html", "./static/register.html")
+	router.StaticFile("/reset-password.html", "./static/reset-password.html")
 
 	// 启动服务器
-	port := "8080"
+	port := cfg.Port
 	log.Printf("Server started on port %s", port)
 	if err := router.Run(":" + port); err != nil {
 		panic(err)
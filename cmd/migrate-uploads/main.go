@@ -0,0 +1,324 @@
+// migrate-uploads 将旧版公开目录 static/uploads 中的文件迁移到附件存储
+//
+// 迁移内容：
+//   - 将文件写入当前配置的存储驱动，并创建附件记录
+//   - 根据反馈图片和图片消息找到文件的上传者和所属反馈
+//   - 将数据库中的 /static/uploads/xxx、/api/files/xxx 地址改写为 /api/attachments/:id
+//   - 记录反馈和消息对附件的引用，未被引用的文件由服务端定时清理
+//
+// 用法：
+//
+//	go run ./cmd/migrate-uploads -src ./static/uploads [-delete] [-dry-run]
+//
+// 重复执行是安全的：已迁移的文件会复用已有的附件记录。
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"feedback-system/internal/config"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/pkg/db"
+	"feedback-system/pkg/storage"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// legacyPrefixes 旧版上传文件地址前缀
+var legacyPrefixes = []string{"/static/uploads/", "/api/files/"}
+
+// owner 文件的上传者和所属反馈
+type owner struct {
+	UploaderID   uint64
+	UploaderType uint8
+	FeedbackID   uint64
+}
+
+func main() {
+	src := flag.String("src", "./static/uploads", "旧版上传目录")
+	deleteSource := flag.Bool("delete", false, "迁移成功后删除源文件")
+	dryRun := flag.Bool("dry-run", false, "只打印迁移计划，不做任何修改")
+	flag.Parse()
+
+	cfg := config.Load()
+
+	database, err := db.NewDB(cfg.DSN)
+	if err != nil {
+		log.Fatalf("连接数据库失败: %v", err)
+	}
+	store, err := storage.New(cfg.Storage.Driver, cfg.Storage.LocalDir, cfg.Storage.S3)
+	if err != nil {
+		log.Fatalf("初始化存储失败: %v", err)
+	}
+
+	entries, err := os.ReadDir(*src)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			log.Printf("目录 %s 不存在，无需迁移", *src)
+			return
+		}
+		log.Fatalf("读取目录失败: %v", err)
+	}
+
+	var feedbacks []*models.Feedback
+	if err := database.Where("images IS NOT NULL").Find(&feedbacks).Error; err != nil {
+		log.Fatalf("读取反馈失败: %v", err)
+	}
+	var messages []*models.FeedbackMessage
+	if err := database.Where("content_type IN ?", []uint8{consts.ImageMessage, consts.ImagesMessage}).Find(&messages).Error; err != nil {
+		log.Fatalf("读取消息失败: %v", err)
+	}
+
+	owners := collectOwners(feedbacks, messages)
+
+	// 迁移文件
+	ctx := context.Background()
+	idByName := make(map[string]uint64)
+	var migrated []string
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		name := entry.Name()
+		id, err := migrateFile(ctx, database, store, filepath.Join(*src, name), name, owners[name], *dryRun)
+		if err != nil {
+			log.Printf("迁移 %s 失败: %v", name, err)
+			continue
+		}
+		idByName[name] = id
+		migrated = append(migrated, name)
+	}
+
+	// 改写数据库中的地址
+	rewritten := 0
+	for _, feedback := range feedbacks {
+		images, changed := rewriteURLs(feedback.Images, idByName)
+		if !changed {
+			continue
+		}
+		rewritten++
+		if *dryRun {
+			continue
+		}
+		data, _ := json.Marshal(images)
+		if err := database.Model(&models.Feedback{}).Where("id = ?", feedback.ID).Update("images", string(data)).Error; err != nil {
+			log.Printf("更新反馈 %d 失败: %v", feedback.ID, err)
+			continue
+		}
+		if err := addReferences(database, consts.AttachmentRefFeedback, feedback.ID, feedback.ID, feedback.Images, idByName); err != nil {
+			log.Printf("记录反馈 %d 的附件引用失败: %v", feedback.ID, err)
+		}
+	}
+	for _, message := range messages {
+		content, changed := rewriteMessageContent(message, idByName)
+		if !changed {
+			continue
+		}
+		rewritten++
+		if *dryRun {
+			continue
+		}
+		if err := database.Model(&models.FeedbackMessage{}).Where("id = ?", message.ID).Update("content", content).Error; err != nil {
+			log.Printf("更新消息 %d 失败: %v", message.ID, err)
+			continue
+		}
+		if err := addReferences(database, consts.AttachmentRefMessage, message.ID, message.FeedbackID, messageURLs(message), idByName); err != nil {
+			log.Printf("记录消息 %d 的附件引用失败: %v", message.ID, err)
+		}
+	}
+
+	if *deleteSource && !*dryRun {
+		for _, name := range migrated {
+			if err := os.Remove(filepath.Join(*src, name)); err != nil {
+				log.Printf("删除源文件 %s 失败: %v", name, err)
+			}
+		}
+	}
+
+	log.Printf("迁移完成：文件 %d 个，改写记录 %d 条（dry-run=%v）", len(migrated), rewritten, *dryRun)
+}
+
+// collectOwners 根据反馈图片和图片消息确定每个文件的上传者
+// 同一文件被多处引用时，以最早出现的引用为准
+func collectOwners(feedbacks []*models.Feedback, messages []*models.FeedbackMessage) map[string]owner {
+	owners := make(map[string]owner)
+	for _, feedback := range feedbacks {
+		for _, u := range feedback.Images {
+			if name, ok := legacyName(u); ok {
+				if _, exists := owners[name]; !exists {
+					owners[name] = owner{UploaderID: feedback.CreatorID, UploaderType: feedback.CreatorType, FeedbackID: feedback.ID}
+				}
+			}
+		}
+	}
+	for _, message := range messages {
+		for _, u := range messageURLs(message) {
+			if name, ok := legacyName(u); ok {
+				if _, exists := owners[name]; !exists {
+					owners[name] = owner{UploaderID: message.SenderID, UploaderType: message.SenderType, FeedbackID: message.FeedbackID}
+				}
+			}
+		}
+	}
+	return owners
+}
+
+// migrateFile 迁移单个文件，返回附件ID
+func migrateFile(ctx context.Context, database *gorm.DB, store storage.Storage, filePath, name string, o owner, dryRun bool) (uint64, error) {
+	key := "attachments/" + name
+
+	// 已迁移过的文件直接复用附件记录
+	var existing models.Attachment
+	err := database.Where("storage_key = ?", key).First(&existing).Error
+	if err == nil {
+		return existing.ID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	contentType := mime.TypeByExtension(strings.ToLower(path.Ext(name)))
+	if contentType == "" {
+		head := make([]byte, 512)
+		n, _ := f.Read(head)
+		contentType = http.DetectContentType(head[:n])
+		if _, err := f.Seek(0, 0); err != nil {
+			return 0, err
+		}
+	}
+
+	if dryRun {
+		log.Printf("[dry-run] %s → %s (uploader=%d/%d feedback=%d)", name, key, o.UploaderID, o.UploaderType, o.FeedbackID)
+		return 0, nil
+	}
+
+	hasher := sha256.New()
+	if err := store.Put(ctx, key, io.TeeReader(f, hasher), fi.Size(), contentType); err != nil {
+		return 0, err
+	}
+
+	attachment := &models.Attachment{
+		StorageKey:   key,
+		ContentHash:  hex.EncodeToString(hasher.Sum(nil)),
+		Filename:     name,
+		ContentType:  contentType,
+		Size:         fi.Size(),
+		UploaderID:   o.UploaderID,
+		UploaderType: o.UploaderType,
+	}
+	if o.FeedbackID != 0 {
+		feedbackID := o.FeedbackID
+		attachment.FeedbackID = &feedbackID
+	}
+	if err := database.Create(attachment).Error; err != nil {
+		return 0, err
+	}
+	return attachment.ID, nil
+}
+
+// addReferences 记录反馈或消息对迁移后附件的引用
+func addReferences(database *gorm.DB, refType uint8, refID, feedbackID uint64, urls []string, idByName map[string]uint64) error {
+	var refs []*models.AttachmentReference
+	for _, u := range urls {
+		if name, ok := legacyName(u); ok {
+			if id, ok := idByName[name]; ok && id != 0 {
+				refs = append(refs, &models.AttachmentReference{
+					AttachmentID: id,
+					RefType:      refType,
+					RefID:        refID,
+					FeedbackID:   feedbackID,
+				})
+			}
+		}
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+	return database.Clauses(clause.OnConflict{DoNothing: true}).Create(&refs).Error
+}
+
+// rewriteURLs 将旧版地址改写为附件地址
+func rewriteURLs(urls []string, idByName map[string]uint64) ([]string, bool) {
+	changed := false
+	result := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if name, ok := legacyName(u); ok {
+			if id, ok := idByName[name]; ok && id != 0 {
+				result = append(result, fmt.Sprintf("/api/attachments/%d", id))
+				changed = true
+				continue
+			}
+		}
+		result = append(result, u)
+	}
+	return result, changed
+}
+
+// rewriteMessageContent 改写图片消息内容中的旧版地址
+func rewriteMessageContent(message *models.FeedbackMessage, idByName map[string]uint64) (string, bool) {
+	urls, changed := rewriteURLs(messageURLs(message), idByName)
+	if !changed {
+		return message.Content, false
+	}
+	if message.ContentType == consts.ImageMessage {
+		return urls[0], true
+	}
+	data, err := json.Marshal(urls)
+	if err != nil {
+		return message.Content, false
+	}
+	return string(data), true
+}
+
+// messageURLs 解析图片消息中的地址
+func messageURLs(message *models.FeedbackMessage) []string {
+	if message.ContentType == consts.ImageMessage {
+		return []string{message.Content}
+	}
+	var urls []string
+	json.Unmarshal([]byte(message.Content), &urls)
+	return urls
+}
+
+// legacyName 从旧版地址中解析文件名
+func legacyName(raw string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", false
+	}
+	for _, prefix := range legacyPrefixes {
+		if strings.HasPrefix(u.Path, prefix) {
+			name := strings.TrimPrefix(u.Path, prefix)
+			if name != "" && !strings.Contains(name, "/") {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,361 @@
+// mock-oidc 本地模拟的 OIDC 身份提供方（IdP），用于开发和测试单点登录
+//
+// 支持授权码模式和 PKCE（S256），授权页面可填写用户名、邮箱和分组后直接登录，不校验密码。
+// 签名密钥在每次启动时随机生成，只在内存中保存授权码。
+//
+// 用法：
+//
+//	go run ./cmd/mock-oidc [-addr :9000] [-issuer http://localhost:9000] [-client-id feedback-system]
+//
+// 然后以下列配置启动服务端：
+//
+//	OIDC_ISSUER=http://localhost:9000 OIDC_CLIENT_ID=feedback-system OIDC_ADMIN_GROUPS=admins go run ./cmd
+//
+// 指定 -auto 时不显示授权页面，直接以该用户登录，便于脚本测试：
+//
+//	go run ./cmd/mock-oidc -auto alice -auto-groups merchants,admins
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"html/template"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	// keyID 签名密钥的标识
+	keyID = "mock-oidc"
+	// codeTTL 授权码有效期
+	codeTTL = time.Minute
+	// tokenTTL ID Token 有效期
+	tokenTTL = time.Hour
+)
+
+// identity 授权页面填写的用户信息
+type identity struct {
+	Username      string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Groups        []string
+}
+
+// authCode 已签发的授权码
+type authCode struct {
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	user          identity
+	expiresAt     time.Time
+}
+
+// provider 模拟的身份提供方
+type provider struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	autoUser     *identity
+	key          *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]*authCode
+}
+
+func main() {
+	addr := flag.String("addr", ":9000", "监听地址")
+	issuer := flag.String("issuer", "http://localhost:9000", "issuer 地址，需与服务端的 OIDC_ISSUER 一致")
+	clientID := flag.String("client-id", "feedback-system", "允许的客户端ID")
+	clientSecret := flag.String("client-secret", "", "客户端密钥，为空时不校验（公共客户端）")
+	auto := flag.String("auto", "", "不显示授权页面，直接以该用户名登录")
+	autoEmail := flag.String("auto-email", "", "-auto 用户的邮箱，默认为 <用户名>@example.com")
+	autoGroups := flag.String("auto-groups", "", "-auto 用户的分组，逗号分隔")
+	flag.Parse()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatalf("生成签名密钥失败: %v", err)
+	}
+
+	p := &provider{
+		issuer:       strings.TrimRight(*issuer, "/"),
+		clientID:     *clientID,
+		clientSecret: *clientSecret,
+		key:          key,
+		codes:        map[string]*authCode{},
+	}
+	if *auto != "" {
+		email := *autoEmail
+		if email == "" {
+			email = *auto + "@example.com"
+		}
+		p.autoUser = &identity{Username: *auto, Email: email, EmailVerified: true, Name: *auto, Groups: splitList(*autoGroups)}
+	}
+
+	log.Printf("模拟 OIDC 身份提供方已启动: issuer=%s client_id=%s", p.issuer, p.clientID)
+	log.Fatal(http.ListenAndServe(*addr, p.handler()))
+}
+
+// handler 身份提供方的路由
+func (p *provider) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+	return mux
+}
+
+// discovery OIDC 发现文档
+func (p *provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.issuer,
+		"authorization_endpoint":                p.issuer + "/authorize",
+		"token_endpoint":                        p.issuer + "/token",
+		"jwks_uri":                              p.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{"openid", "profile", "email", "groups"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+	})
+}
+
+// jwks 签名公钥
+func (p *provider) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := p.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+// authorizePage 授权页面
+var authorizePage = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head><meta charset="UTF-8"><title>模拟 IdP 登录</title></head>
+<body style="font-family: sans-serif; max-width: 420px; margin: 60px auto;">
+<h2>模拟 IdP 登录</h2>
+<p>不校验密码，填写的信息会作为 ID Token 中的声明。</p>
+<form method="post" action="/authorize">
+  {{range $k, $v := .Params}}<input type="hidden" name="{{$k}}" value="{{$v}}">{{end}}
+  <p><label>用户名（preferred_username）<br><input name="username" required></label></p>
+  <p><label>姓名（name）<br><input name="name"></label></p>
+  <p><label>邮箱（email）<br><input name="email" type="email"></label></p>
+  <p><label><input name="email_verified" type="checkbox" checked> 邮箱已验证</label></p>
+  <p><label>分组（groups，逗号分隔）<br><input name="groups"></label></p>
+  <p><button type="submit" name="action" value="approve">登录</button>
+     <button type="submit" name="action" value="deny">取消</button></p>
+</form>
+</body>
+</html>`))
+
+// authorize 授权端点，GET 显示授权页面，POST 签发授权码并跳转回客户端
+func (p *provider) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	params := map[string]string{}
+	for _, name := range []string{"client_id", "redirect_uri", "response_type", "scope", "state", "nonce", "code_challenge", "code_challenge_method"} {
+		params[name] = r.Form.Get(name)
+	}
+
+	// 客户端和跳转地址无效时不能跳转回客户端，直接显示错误
+	if params["client_id"] != p.clientID {
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	}
+	redirectURI, err := url.Parse(params["redirect_uri"])
+	if err != nil || !redirectURI.IsAbs() {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if params["response_type"] != "code" {
+		redirectError(w, r, redirectURI, params["state"], "unsupported_response_type")
+		return
+	}
+	if params["code_challenge"] == "" || params["code_challenge_method"] != "S256" {
+		redirectError(w, r, redirectURI, params["state"], "invalid_request")
+		return
+	}
+
+	var user identity
+	switch {
+	case p.autoUser != nil:
+		user = *p.autoUser
+	case r.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		authorizePage.Execute(w, map[string]interface{}{"Params": params})
+		return
+	case r.Form.Get("action") != "approve":
+		redirectError(w, r, redirectURI, params["state"], "access_denied")
+		return
+	default:
+		user = identity{
+			Username:      strings.TrimSpace(r.Form.Get("username")),
+			Email:         strings.TrimSpace(r.Form.Get("email")),
+			EmailVerified: r.Form.Get("email_verified") != "",
+			Name:          strings.TrimSpace(r.Form.Get("name")),
+			Groups:        splitList(r.Form.Get("groups")),
+		}
+	}
+	if user.Username == "" {
+		redirectError(w, r, redirectURI, params["state"], "access_denied")
+		return
+	}
+
+	code := randomString()
+	p.mu.Lock()
+	p.codes[code] = &authCode{
+		clientID:      params["client_id"],
+		redirectURI:   params["redirect_uri"],
+		nonce:         params["nonce"],
+		codeChallenge: params["code_challenge"],
+		user:          user,
+		expiresAt:     time.Now().Add(codeTTL),
+	}
+	p.mu.Unlock()
+
+	query := redirectURI.Query()
+	query.Set("code", code)
+	query.Set("state", params["state"])
+	redirectURI.RawQuery = query.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+// token 令牌端点，校验授权码、跳转地址、客户端和 PKCE 校验码后签发 ID Token
+func (p *provider) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+	if r.Form.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.Form.Get("client_id"), r.Form.Get("client_secret")
+	}
+	if clientID != p.clientID || (p.clientSecret != "" && subtle.ConstantTimeCompare([]byte(clientSecret), []byte(p.clientSecret)) != 1) {
+		tokenError(w, "invalid_client")
+		return
+	}
+
+	// 授权码只能使用一次
+	p.mu.Lock()
+	code := p.codes[r.Form.Get("code")]
+	delete(p.codes, r.Form.Get("code"))
+	p.mu.Unlock()
+	if code == nil || time.Now().After(code.expiresAt) || code.clientID != clientID || code.redirectURI != r.Form.Get("redirect_uri") {
+		tokenError(w, "invalid_grant")
+		return
+	}
+	challenge := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(challenge[:]) != code.codeChallenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":                p.issuer,
+		"sub":                "mock|" + code.user.Username,
+		"aud":                clientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(tokenTTL).Unix(),
+		"nonce":              code.nonce,
+		"preferred_username": code.user.Username,
+		"name":               code.user.Name,
+		"groups":             code.user.Groups,
+	}
+	if code.user.Email != "" {
+		claims["email"] = code.user.Email
+		claims["email_verified"] = code.user.EmailVerified
+	}
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = keyID
+	signed, err := idToken.SignedString(p.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("签发 ID Token: sub=%s groups=%v", claims["sub"], code.user.Groups)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   int(tokenTTL / time.Second),
+		"id_token":     signed,
+	})
+}
+
+// redirectError 跳转回客户端并携带错误码
+func redirectError(w http.ResponseWriter, r *http.Request, redirectURI *url.URL, state, code string) {
+	query := redirectURI.Query()
+	query.Set("error", code)
+	if state != "" {
+		query.Set("state", state)
+	}
+	redirectURI.RawQuery = query.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+// tokenError 令牌端点的错误响应
+func tokenError(w http.ResponseWriter, code string) {
+	status := http.StatusBadRequest
+	if code == "invalid_client" {
+		status = http.StatusUnauthorized
+	}
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+// writeJSON 输出JSON响应
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// randomString 随机字符串，用于授权码和访问令牌
+func randomString() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// splitList 拆分逗号分隔的列表
+func splitList(s string) []string {
+	list := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/internal/service"
+	"feedback-system/pkg/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// 使用模拟 IdP 走完整的单点登录流程：Begin → /authorize → Callback → Exchange
+
+type fakeUserRepo struct {
+	repository.UserRepository
+	users []*models.User
+}
+
+func (r *fakeUserRepo) Create(user *models.User) error {
+	user.ID = uint64(len(r.users) + 1)
+	r.users = append(r.users, user)
+	return nil
+}
+
+func (r *fakeUserRepo) GetByID(id uint64) (*models.User, error) {
+	for _, user := range r.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (r *fakeUserRepo) GetByUsername(username string, userType uint8) (*models.User, error) {
+	for _, user := range r.users {
+		if user.Username == username && user.UserType == userType {
+			return user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (r *fakeUserRepo) GetByEmail(email string, userType uint8) (*models.User, error) {
+	for _, user := range r.users {
+		if user.Email == email && user.UserType == userType {
+			return user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+type fakeIdentityRepo struct {
+	repository.UserIdentityRepository
+	identities []*models.UserIdentity
+}
+
+func (r *fakeIdentityRepo) Create(identity *models.UserIdentity) error {
+	identity.ID = uint64(len(r.identities) + 1)
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *fakeIdentityRepo) FindBySubject(issuer, subject string, userType uint8) (*models.UserIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Issuer == issuer && identity.Subject == subject && identity.UserType == userType {
+			return identity, nil
+		}
+	}
+	return nil, errors.New("identity not found")
+}
+
+func (r *fakeIdentityRepo) Touch(id uint64, now time.Time) error {
+	return nil
+}
+
+type fakeOrgRepo struct {
+	repository.OrganizationRepository
+	users  *fakeUserRepo
+	nextID uint64
+}
+
+func (r *fakeOrgRepo) CreateWithOwner(org *models.Organization, owner *models.User) error {
+	r.nextID++
+	org.ID = r.nextID
+	owner.OrgID, owner.OrgRole = org.ID, consts.OrgRoleOwner
+	return r.users.Create(owner)
+}
+
+type fakeUserService struct {
+	service.UserService
+}
+
+func (s *fakeUserService) LoginVerified(user *models.User, deviceName, ip, userAgent string) (*models.UserLoginResponse, error) {
+	return &models.UserLoginResponse{User: *user, Token: "access-token"}, nil
+}
+
+type oidcFixture struct {
+	t          *testing.T
+	idp        *provider
+	service    service.OIDCService
+	users      *fakeUserRepo
+	identities *fakeIdentityRepo
+	actor      *service.AuditActor
+}
+
+func newOIDCFixture(t *testing.T, configure func(*service.OIDCPolicy)) *oidcFixture {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &provider{clientID: "feedback-system", key: key, codes: map[string]*authCode{}}
+	srv := httptest.NewServer(idp.handler())
+	t.Cleanup(srv.Close)
+	idp.issuer = srv.URL
+
+	policy := service.OIDCPolicy{
+		Issuer:         srv.URL,
+		ClientID:       "feedback-system",
+		RedirectURL:    "http://app.test/api/user/oidc/callback",
+		Scopes:         []string{"openid", "profile", "email", "groups"},
+		UsernameClaim:  "preferred_username",
+		GroupsClaim:    "groups",
+		MerchantGroups: []string{"merchants"},
+		AdminGroups:    []string{"admins"},
+		LinkByEmail:    true,
+	}
+	if configure != nil {
+		configure(&policy)
+	}
+
+	f := &oidcFixture{
+		t:          t,
+		idp:        idp,
+		users:      &fakeUserRepo{},
+		identities: &fakeIdentityRepo{},
+		actor:      &service.AuditActor{IP: "127.0.0.1", UserAgent: "test"},
+	}
+	f.service = service.NewOIDCService(f.users, f.identities, &fakeOrgRepo{users: f.users}, nil, &fakeUserService{},
+		ratelimit.NewMemoryStore(0), policy, "test-secret")
+	return f
+}
+
+// as 之后的授权请求直接以该用户登录
+func (f *oidcFixture) as(user identity) {
+	f.idp.autoUser = &user
+}
+
+// begin 开始登录，返回签名状态和 IdP 授权地址
+func (f *oidcFixture) begin(userType uint8) *service.OIDCAuthRequest {
+	f.t.Helper()
+	req, err := f.service.Begin(context.Background(), userType, "/merchant", nil)
+	if err != nil {
+		f.t.Fatalf("Begin: %v", err)
+	}
+	return req
+}
+
+// authorize 模拟浏览器打开授权地址，返回 IdP 跳转回客户端时携带的 code 和 state
+func (f *oidcFixture) authorize(authURL string) (code, state string) {
+	f.t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		f.t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if resp.StatusCode != http.StatusFound || err != nil {
+		f.t.Fatalf("authorize: status %d, location %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if e := location.Query().Get("error"); e != "" {
+		f.t.Fatalf("authorize: error %s", e)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+// login 完成一次登录并返回回调结果
+func (f *oidcFixture) login(userType uint8) (*service.OIDCCallbackResult, error) {
+	f.t.Helper()
+	req := f.begin(userType)
+	code, state := f.authorize(req.URL)
+	return f.service.Callback(context.Background(), req.State, state, code, f.actor)
+}
+
+func (f *oidcFixture) addUser(username, email string, userType uint8) *models.User {
+	user := &models.User{Username: username, Email: email, UserType: userType, Status: consts.AccountActive}
+	f.users.Create(user)
+	return user
+}
+
+func TestLoginLinksByVerifiedEmailAndTokenIsSingleUse(t *testing.T) {
+	f := newOIDCFixture(t, nil)
+	alice := f.addUser("alice", "alice@example.com", consts.Merchant)
+	f.as(identity{Username: "alice", Email: "alice@example.com", EmailVerified: true, Groups: []string{"merchants"}})
+
+	result, err := f.login(consts.Merchant)
+	if err != nil {
+		t.Fatalf("Callback: %v", err)
+	}
+	if result.Token == "" || result.ReturnPath != "/merchant" {
+		t.Fatalf("result = %+v", result)
+	}
+	if len(f.identities.identities) != 1 || f.identities.identities[0].UserID != alice.ID || f.identities.identities[0].Subject != "mock|alice" {
+		t.Fatalf("identities = %+v", f.identities.identities)
+	}
+
+	resp, err := f.service.Exchange(result.Token, "", "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if resp.User.ID != alice.ID {
+		t.Errorf("logged in as user %d, want %d", resp.User.ID, alice.ID)
+	}
+	if _, err := f.service.Exchange(result.Token, "", "127.0.0.1", "test"); !errors.Is(err, service.ErrInvalidSSOToken) {
+		t.Errorf("second Exchange: err = %v, want ErrInvalidSSOToken", err)
+	}
+
+	// 已绑定后按外部身份登录，不再依赖邮箱
+	f.as(identity{Username: "alice", Groups: []string{"merchants"}})
+	if _, err := f.login(consts.Merchant); err != nil {
+		t.Fatalf("login by identity: %v", err)
+	}
+	if len(f.identities.identities) != 1 {
+		t.Errorf("identities = %d, want 1", len(f.identities.identities))
+	}
+}
+
+func TestExchangeRejectsTokensNotIssuedByServer(t *testing.T) {
+	f := newOIDCFixture(t, nil)
+	alice := f.addUser("alice", "alice@example.com", consts.Merchant)
+
+	// 知道签名密钥也不能自行签发可用的令牌
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"typ": "sso",
+		"id":  alice.ID,
+		"jti": "forged",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.Exchange(forged, "", "127.0.0.1", "test"); !errors.Is(err, service.ErrInvalidSSOToken) {
+		t.Errorf("Exchange: err = %v, want ErrInvalidSSOToken", err)
+	}
+}
+
+func TestUnverifiedEmailIsNotLinked(t *testing.T) {
+	f := newOIDCFixture(t, nil)
+	f.addUser("alice", "alice@example.com", consts.Merchant)
+	f.as(identity{Username: "alice", Email: "alice@example.com", EmailVerified: false, Groups: []string{"merchants"}})
+
+	if _, err := f.login(consts.Merchant); !errors.Is(err, service.ErrSSOAccountNotFound) {
+		t.Fatalf("err = %v, want ErrSSOAccountNotFound", err)
+	}
+	if len(f.identities.identities) != 0 {
+		t.Errorf("identities = %+v, want none", f.identities.identities)
+	}
+}
+
+func TestCallbackRejectsStateMismatchAndCodeReuse(t *testing.T) {
+	f := newOIDCFixture(t, func(p *service.OIDCPolicy) { p.AutoProvision = true })
+	f.as(identity{Username: "bob", Groups: []string{"merchants"}})
+	ctx := context.Background()
+
+	req := f.begin(consts.Merchant)
+	code, state := f.authorize(req.URL)
+
+	if _, err := f.service.Callback(ctx, req.State, "forged-state", code, f.actor); !errors.Is(err, service.ErrInvalidSSOState) {
+		t.Errorf("state param mismatch: err = %v, want ErrInvalidSSOState", err)
+	}
+	if _, err := f.service.Callback(ctx, req.State+"x", state, code, f.actor); !errors.Is(err, service.ErrInvalidSSOState) {
+		t.Errorf("tampered state token: err = %v, want ErrInvalidSSOState", err)
+	}
+
+	if _, err := f.service.Callback(ctx, req.State, state, code, f.actor); err != nil {
+		t.Fatalf("Callback: %v", err)
+	}
+	if _, err := f.service.Callback(ctx, req.State, state, code, f.actor); err == nil {
+		t.Error("reusing the authorization code succeeded")
+	}
+}
+
+func TestCallbackRequiresMatchingPKCEVerifier(t *testing.T) {
+	f := newOIDCFixture(t, func(p *service.OIDCPolicy) { p.AutoProvision = true })
+	f.as(identity{Username: "bob", Groups: []string{"merchants"}})
+
+	first := f.begin(consts.Merchant)
+	code, _ := f.authorize(first.URL)
+
+	// 另一次登录的状态中保存的是另一个 PKCE 校验码，不能用于兑换第一次登录的授权码
+	second := f.begin(consts.Merchant)
+	u, _ := url.Parse(second.URL)
+	if _, err := f.service.Callback(context.Background(), second.State, u.Query().Get("state"), code, f.actor); err == nil || !strings.Contains(err.Error(), "换取令牌失败") {
+		t.Fatalf("err = %v, want token exchange failure", err)
+	}
+	if len(f.users.users) != 0 {
+		t.Errorf("users = %d, want none", len(f.users.users))
+	}
+}
+
+func TestCallbackRequiresMatchingNonce(t *testing.T) {
+	f := newOIDCFixture(t, func(p *service.OIDCPolicy) { p.AutoProvision = true })
+	f.as(identity{Username: "bob", Groups: []string{"merchants"}})
+
+	req := f.begin(consts.Merchant)
+	u, _ := url.Parse(req.URL)
+	query := u.Query()
+	query.Set("nonce", "forged-nonce")
+	u.RawQuery = query.Encode()
+
+	code, state := f.authorize(u.String())
+	if _, err := f.service.Callback(context.Background(), req.State, state, code, f.actor); err == nil || !strings.Contains(err.Error(), "nonce") {
+		t.Fatalf("err = %v, want nonce mismatch", err)
+	}
+}
+
+func TestGroupPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		userType uint8
+		groups   []string
+		err      error
+	}{
+		{"merchant in merchant group", consts.Merchant, []string{"merchants"}, nil},
+		{"merchant outside merchant group", consts.Merchant, []string{"staff"}, service.ErrSSOUserTypeNotAllowed},
+		{"admin in admin group", consts.Admin, []string{"staff", "admins"}, nil},
+		{"admin outside admin group", consts.Admin, []string{"merchants"}, service.ErrSSOUserTypeNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOIDCFixture(t, func(p *service.OIDCPolicy) { p.AutoProvision = true })
+			f.as(identity{Username: "carol", Groups: tt.groups})
+			result, err := f.login(tt.userType)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && (len(f.users.users) != 1 || f.users.users[0].UserType != tt.userType || result.Token == "") {
+				t.Errorf("users = %+v, result = %+v", f.users.users, result)
+			}
+			if tt.err != nil && len(f.users.users) != 0 {
+				t.Errorf("users = %+v, want none", f.users.users)
+			}
+		})
+	}
+
+	// 未配置管理员分组时不允许管理员单点登录
+	f := newOIDCFixture(t, func(p *service.OIDCPolicy) { p.AdminGroups = nil })
+	if _, err := f.service.Begin(context.Background(), consts.Admin, "/admin", nil); !errors.Is(err, service.ErrSSOUserTypeNotAllowed) {
+		t.Errorf("Begin admin without admin groups: err = %v, want ErrSSOUserTypeNotAllowed", err)
+	}
+}
@@ -0,0 +1,433 @@
+package config
+
+import (
+	"feedback-system/internal/consts"
+	"feedback-system/pkg/storage"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config 应用配置
+// 所有配置项均从环境变量读取，未设置时使用默认值，便于本地直接 go run 启动
+type Config struct {
+	// 服务监听端口
+	Port string
+
+	// 站点访问地址，用于生成邮件中的链接
+	BaseURL string
+
+	// 数据库连接串
+	DSN string
+
+	// 上传文件存储配置
+	Storage StorageConfig
+
+	// 附件访问配置
+	Attachment AttachmentConfig
+
+	// 断点续传上传配置
+	Upload UploadConfig
+
+	// 登录认证配置
+	Auth AuthConfig
+
+	// 限流与防暴力破解配置
+	RateLimit RateLimitConfig
+
+	// 密码策略配置
+	Password PasswordConfig
+
+	// 邮件发送配置
+	Mail MailConfig
+
+	// 单点登录（OIDC）配置
+	OIDC OIDCConfig
+
+	// 反馈处理配置
+	Feedback FeedbackConfig
+
+	// 后台定时任务配置
+	Scheduler SchedulerConfig
+}
+
+// StorageConfig 上传文件存储配置
+type StorageConfig struct {
+	// 存储驱动：local-本地磁盘 s3-S3兼容对象存储（AWS S3 / MinIO 等）
+	Driver string
+
+	// 本地磁盘驱动的根目录
+	LocalDir string
+
+	// S3兼容存储配置（AWS S3 / MinIO 等）
+	S3 storage.S3Config
+
+	// 预签名下载链接有效期
+	PresignExpiry time.Duration
+}
+
+// AttachmentConfig 附件访问配置
+type AttachmentConfig struct {
+	// 附件签名链接的密钥，必须配置，多副本部署时各实例必须一致
+	URLSecret string
+	// 附件签名链接有效期
+	URLTTL time.Duration
+	// 未被引用的附件保留多久后清理
+	OrphanGrace time.Duration
+	// 清理未引用附件的间隔
+	SweepInterval time.Duration
+}
+
+// UploadConfig 断点续传（tus协议）上传配置
+type UploadConfig struct {
+	// 未完成上传的临时文件目录
+	TusDir string
+	// 未完成上传的过期时间，每次续传后重新计时
+	TusExpiry time.Duration
+	// 清理过期上传的间隔
+	TusCleanupInterval time.Duration
+	// 各用户类型的单文件大小上限（字节）
+	MaxSize map[uint8]int64
+	// 图片以外允许上传的文件类型（按文件内容识别）
+	AllowedTypes []string
+	// 各用户类型的存储配额（字节），0 表示不限制
+	Quota map[uint8]int64
+}
+
+// AuthConfig 登录认证配置
+type AuthConfig struct {
+	// JWT签名密钥，多副本部署时各实例必须一致
+	JWTSecret string
+	// 访问令牌有效期
+	AccessTokenTTL time.Duration
+	// 刷新令牌有效期，每次刷新后重新计时
+	RefreshTokenTTL time.Duration
+	// 清理过期会话的间隔
+	SessionCleanupInterval time.Duration
+	// 两步验证在验证器应用中显示的发行方名称
+	TwoFactorIssuer string
+	// 是否强制管理员启用两步验证
+	TwoFactorRequiredAdmin bool
+	// 商家注册方式：invite 只能使用邀请码注册；approval 未填写邀请码时注册为待审核
+	MerchantSignup string
+}
+
+// RateLimitConfig 限流与防暴力破解配置
+type RateLimitConfig struct {
+	// 计数存储的 Redis 地址，为空时使用进程内存（多副本部署时各实例计数不共享）
+	RedisURL string
+	// 每个客户端在一个窗口内允许的API请求数，0 表示不限制
+	APILimit int64
+	// API限流窗口
+	APIWindow time.Duration
+	// 同一账号连续登录失败多少次后锁定，0 表示不锁定
+	LoginUserThreshold int64
+	// 同一IP连续登录失败多少次后锁定，0 表示不锁定
+	LoginIPThreshold int64
+	// 登录失败计数在最后一次失败后保留多久
+	LoginFailureWindow time.Duration
+	// 首次锁定时长，之后每多失败一次翻倍
+	LoginLockBase time.Duration
+	// 最长锁定时长
+	LoginLockMax time.Duration
+	// 账号或IP登录失败多少次后要求验证码，0 表示不要求
+	CaptchaThreshold int64
+	// 验证码服务端校验地址（hCaptcha / reCAPTCHA / Turnstile 的 siteverify 接口）
+	CaptchaVerifyURL string
+	// 验证码服务端密钥，为空时不启用验证码
+	CaptchaSecret string
+}
+
+// PasswordConfig 密码策略配置
+type PasswordConfig struct {
+	// 最小长度
+	MinLength int
+	// 最大长度，0 表示不限制
+	MaxLength int
+	// 小写字母、大写字母、数字、符号中至少包含的类别数
+	MinClasses int
+	// 是否拒绝常见弱密码
+	CheckBreached bool
+	// 追加的弱密码列表文件，每行一个
+	BreachedFile string
+	// 重置密码链接有效期
+	ResetTTL time.Duration
+	// 同一IP或同一账号每小时最多申请重置密码的次数，0 表示不限制
+	ResetLimit int64
+}
+
+// MailConfig 邮件发送配置
+type MailConfig struct {
+	// SMTP服务器地址，为空时邮件只输出到日志
+	SMTPHost string
+	// SMTP服务器端口
+	SMTPPort int
+	// SMTP认证用户名，为空时不认证
+	SMTPUsername string
+	// SMTP认证密码
+	SMTPPassword string
+	// 发件人地址
+	From string
+}
+
+// OIDCConfig 单点登录（OpenID Connect）配置，商家和管理员可以使用企业身份提供方（IdP）登录
+type OIDCConfig struct {
+	// IdP 的 issuer 地址，为空时不启用单点登录
+	Issuer string
+	// 在 IdP 注册的客户端ID
+	ClientID string
+	// 客户端密钥，公共客户端可以为空（仅使用 PKCE）
+	ClientSecret string
+	// 回调地址，需要与在 IdP 登记的一致
+	RedirectURL string
+	// 申请的 scope
+	Scopes []string
+	// 登录按钮上显示的 IdP 名称
+	ProviderName string
+	// 自动创建账号时作为用户名的声明
+	UsernameClaim string
+	// 用户所属分组的声明
+	GroupsClaim string
+	// 允许以商家身份登录的分组，为空时 IdP 中的所有用户都可以以商家身份登录
+	MerchantGroups []string
+	// 允许以管理员身份登录的分组，为空时不允许管理员使用单点登录
+	AdminGroups []string
+	// 首次登录且没有绑定本地账号时是否自动创建账号
+	AutoProvision bool
+	// 首次登录时是否按已验证的邮箱绑定同名邮箱的本地账号
+	LinkByEmail bool
+}
+
+// FeedbackConfig 反馈处理配置
+type FeedbackConfig struct {
+	// 商家多久未回复后，反馈创建者可以将反馈升级到平台管理员
+	EscalationWait time.Duration
+	// 检查 SLA 预警和超时的间隔
+	SLACheckInterval time.Duration
+	// 已解决的反馈多久未重新打开后自动关闭，0 表示不自动关闭
+	AutoCloseAfter time.Duration
+	// 处理中的反馈目标方多久未回复后提醒目标方，0 表示不提醒
+	TargetFollowUpAfter time.Duration
+	// 目标方回复后反馈创建者多久未回复后询问是否仍需要帮助，0 表示不询问
+	CreatorFollowUpAfter time.Duration
+	// 自动化规则调用 Webhook 的超时时间
+	WebhookTimeout time.Duration
+}
+
+// SchedulerConfig 后台定时任务配置
+type SchedulerConfig struct {
+	// 是否在本实例按执行计划运行定时任务，关闭时仍然可以由管理员手动运行
+	Enabled bool
+	// 实例标识，记录在任务锁和运行记录中，默认为主机名和进程ID
+	InstanceID string
+	// 任务锁的有效期，也是单次运行的最长时间；实例在运行中退出时，锁到期后其他实例可以接管
+	LockTTL time.Duration
+	// 任务运行记录的保留时间
+	RunRetention time.Duration
+	// 各任务的执行计划（cron 表达式），为空表示只能手动运行
+	// 默认按各功能的间隔配置生成 @every 计划，可以通过 SCHEDULER_SCHEDULES 覆盖，格式为 任务名=表达式，多个任务用 ; 分隔
+	Schedules map[string]string
+}
+
+// Load 从环境变量加载配置
+func Load() *Config {
+	cfg := &Config{
+		Port:    getEnv("PORT", "8080"),
+		BaseURL: getEnv("APP_BASE_URL", "http://localhost:8080"),
+		DSN:     getEnv("DB_DSN", "root:123456@tcp(localhost:3306)/feedback_system?charset=utf8mb4&parseTime=True&loc=Local"),
+		Storage: StorageConfig{
+			Driver:   getEnv("STORAGE_DRIVER", "local"),
+			LocalDir: getEnv("STORAGE_LOCAL_DIR", "./data/uploads"),
+			S3: storage.S3Config{
+				Endpoint:  getEnv("S3_ENDPOINT", "http://localhost:9000"),
+				Region:    getEnv("S3_REGION", "us-east-1"),
+				Bucket:    getEnv("S3_BUCKET", "feedback-uploads"),
+				AccessKey: getEnv("S3_ACCESS_KEY", ""),
+				SecretKey: getEnv("S3_SECRET_KEY", ""),
+				PathStyle: getEnvBool("S3_PATH_STYLE", true),
+			},
+			PresignExpiry: getEnvDuration("STORAGE_PRESIGN_EXPIRY", 15*time.Minute),
+		},
+		Attachment: AttachmentConfig{
+			URLSecret:     getEnv("ATTACHMENT_URL_SECRET", ""),
+			URLTTL:        getEnvDuration("ATTACHMENT_URL_TTL", time.Hour),
+			OrphanGrace:   getEnvDuration("ATTACHMENT_ORPHAN_GRACE", 24*time.Hour),
+			SweepInterval: getEnvDuration("ATTACHMENT_SWEEP_INTERVAL", time.Hour),
+		},
+		Upload: UploadConfig{
+			TusDir:             getEnv("TUS_DIR", "./data/tus"),
+			TusExpiry:          getEnvDuration("TUS_EXPIRY", 24*time.Hour),
+			TusCleanupInterval: getEnvDuration("TUS_CLEANUP_INTERVAL", time.Hour),
+			MaxSize: map[uint8]int64{
+				consts.User:     getEnvInt64("UPLOAD_MAX_SIZE_USER", 50<<20),
+				consts.Merchant: getEnvInt64("UPLOAD_MAX_SIZE_MERCHANT", 200<<20),
+				consts.Admin:    getEnvInt64("UPLOAD_MAX_SIZE_ADMIN", 500<<20),
+			},
+			AllowedTypes: getEnvList("UPLOAD_ALLOWED_TYPES", []string{
+				"application/pdf", "application/zip", "text/plain",
+				"video/mp4", "video/webm", "audio/mpeg", "audio/wave",
+			}),
+			Quota: map[uint8]int64{
+				consts.User:     getEnvInt64("UPLOAD_QUOTA_USER", 200<<20),
+				consts.Merchant: getEnvInt64("UPLOAD_QUOTA_MERCHANT", 2<<30),
+				consts.Admin:    getEnvInt64("UPLOAD_QUOTA_ADMIN", 0),
+			},
+		},
+		Auth: AuthConfig{
+			JWTSecret:              getEnv("JWT_SECRET", ""),
+			AccessTokenTTL:         getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+			RefreshTokenTTL:        getEnvDuration("REFRESH_TOKEN_TTL", 7*24*time.Hour),
+			SessionCleanupInterval: getEnvDuration("SESSION_CLEANUP_INTERVAL", time.Hour),
+			TwoFactorIssuer:        getEnv("TWO_FACTOR_ISSUER", "Feedback System"),
+			TwoFactorRequiredAdmin: getEnvBool("TWO_FACTOR_REQUIRED_ADMIN", false),
+			MerchantSignup:         getEnv("MERCHANT_SIGNUP", "approval"),
+		},
+		RateLimit: RateLimitConfig{
+			RedisURL:           getEnv("REDIS_URL", ""),
+			APILimit:           getEnvInt64("RATE_LIMIT_API", 600),
+			APIWindow:          getEnvDuration("RATE_LIMIT_API_WINDOW", time.Minute),
+			LoginUserThreshold: getEnvInt64("LOGIN_LOCK_THRESHOLD", 5),
+			LoginIPThreshold:   getEnvInt64("LOGIN_IP_LOCK_THRESHOLD", 20),
+			LoginFailureWindow: getEnvDuration("LOGIN_FAILURE_WINDOW", time.Hour),
+			LoginLockBase:      getEnvDuration("LOGIN_LOCK_BASE", time.Minute),
+			LoginLockMax:       getEnvDuration("LOGIN_LOCK_MAX", time.Hour),
+			CaptchaThreshold:   getEnvInt64("CAPTCHA_THRESHOLD", 3),
+			CaptchaVerifyURL:   getEnv("CAPTCHA_VERIFY_URL", "https://hcaptcha.com/siteverify"),
+			CaptchaSecret:      getEnv("CAPTCHA_SECRET", ""),
+		},
+		Password: PasswordConfig{
+			MinLength:     int(getEnvInt64("PASSWORD_MIN_LENGTH", 8)),
+			MaxLength:     int(getEnvInt64("PASSWORD_MAX_LENGTH", 128)),
+			MinClasses:    int(getEnvInt64("PASSWORD_MIN_CLASSES", 2)),
+			CheckBreached: getEnvBool("PASSWORD_CHECK_BREACHED", true),
+			BreachedFile:  getEnv("PASSWORD_BREACHED_FILE", ""),
+			ResetTTL:      getEnvDuration("PASSWORD_RESET_TTL", 30*time.Minute),
+			ResetLimit:    getEnvInt64("PASSWORD_RESET_LIMIT", 5),
+		},
+		Mail: MailConfig{
+			SMTPHost:     getEnv("SMTP_HOST", ""),
+			SMTPPort:     int(getEnvInt64("SMTP_PORT", 25)),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			From:         getEnv("MAIL_FROM", "Feedback System <noreply@localhost>"),
+		},
+		Feedback: FeedbackConfig{
+			EscalationWait:       getEnvDuration("FEEDBACK_ESCALATION_WAIT", 48*time.Hour),
+			SLACheckInterval:     getEnvDuration("FEEDBACK_SLA_CHECK_INTERVAL", time.Minute),
+			AutoCloseAfter:       getEnvDuration("FEEDBACK_AUTO_CLOSE_AFTER", 7*24*time.Hour),
+			TargetFollowUpAfter:  getEnvDuration("FEEDBACK_TARGET_FOLLOW_UP_AFTER", 48*time.Hour),
+			CreatorFollowUpAfter: getEnvDuration("FEEDBACK_CREATOR_FOLLOW_UP_AFTER", 72*time.Hour),
+			WebhookTimeout:       getEnvDuration("FEEDBACK_WEBHOOK_TIMEOUT", 10*time.Second),
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:      getEnvBool("SCHEDULER_ENABLED", true),
+			InstanceID:   getEnv("SCHEDULER_INSTANCE_ID", defaultInstanceID()),
+			LockTTL:      getEnvDuration("SCHEDULER_LOCK_TTL", 10*time.Minute),
+			RunRetention: getEnvDuration("SCHEDULER_RUN_RETENTION", 30*24*time.Hour),
+		},
+	}
+	cfg.OIDC = OIDCConfig{
+		Issuer:         getEnv("OIDC_ISSUER", ""),
+		ClientID:       getEnv("OIDC_CLIENT_ID", ""),
+		ClientSecret:   getEnv("OIDC_CLIENT_SECRET", ""),
+		RedirectURL:    getEnv("OIDC_REDIRECT_URL", strings.TrimRight(cfg.BaseURL, "/")+"/api/user/oidc/callback"),
+		Scopes:         getEnvList("OIDC_SCOPES", []string{"openid", "profile", "email"}),
+		ProviderName:   getEnv("OIDC_PROVIDER_NAME", "企业账号"),
+		UsernameClaim:  getEnv("OIDC_USERNAME_CLAIM", "preferred_username"),
+		GroupsClaim:    getEnv("OIDC_GROUPS_CLAIM", "groups"),
+		MerchantGroups: getEnvList("OIDC_MERCHANT_GROUPS", nil),
+		AdminGroups:    getEnvList("OIDC_ADMIN_GROUPS", nil),
+		AutoProvision:  getEnvBool("OIDC_AUTO_PROVISION", true),
+		LinkByEmail:    getEnvBool("OIDC_LINK_BY_EMAIL", false),
+	}
+	cfg.Scheduler.Schedules = jobSchedules(cfg)
+	return cfg
+}
+
+// jobSchedules 各定时任务的执行计划，默认按各功能的间隔配置，SCHEDULER_SCHEDULES 中的设置优先
+func jobSchedules(cfg *Config) map[string]string {
+	schedules := map[string]string{
+		consts.JobTusCleanup:      every(cfg.Upload.TusCleanupInterval),
+		consts.JobAttachmentSweep: every(cfg.Attachment.SweepInterval),
+		consts.JobSessionCleanup:  every(cfg.Auth.SessionCleanupInterval),
+		consts.JobSLAMonitor:      every(cfg.Feedback.SLACheckInterval),
+		consts.JobRunCleanup:      "0 4 * * *",
+		consts.JobAutoClose:       "0 * * * *",
+		consts.JobTargetFollowUp:  "0 * * * *",
+		consts.JobCreatorFollowUp: "0 * * * *",
+	}
+	for _, item := range strings.Split(getEnv("SCHEDULER_SCHEDULES", ""), ";") {
+		if name, spec, ok := strings.Cut(item, "="); ok && strings.TrimSpace(name) != "" {
+			schedules[strings.TrimSpace(name)] = strings.TrimSpace(spec)
+		}
+	}
+	return schedules
+}
+
+// every 按间隔执行的计划，间隔不大于 0 时返回空（不按计划执行）
+func every(interval time.Duration) string {
+	if interval <= 0 {
+		return ""
+	}
+	return "@every " + interval.String()
+}
+
+// defaultInstanceID 默认的实例标识：主机名和进程ID
+func defaultInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return host + "-" + strconv.Itoa(os.Getpid())
+}
+
+// getEnv 读取字符串环境变量
+func getEnv(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && strings.TrimSpace(v) != "" {
+		return strings.TrimSpace(v)
+	}
+	return def
+}
+
+// getEnvBool 读取布尔环境变量
+func getEnvBool(key string, def bool) bool {
+	v, err := strconv.ParseBool(getEnv(key, ""))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// getEnvInt64 读取整数环境变量
+func getEnvInt64(key string, def int64) int64 {
+	v, err := strconv.ParseInt(getEnv(key, ""), 10, 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// getEnvList 读取逗号分隔的列表环境变量
+func getEnvList(key string, def []string) []string {
+	v := getEnv(key, "")
+	if v == "" {
+		return def
+	}
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvDuration 读取时长环境变量，格式同 time.ParseDuration，例如 15m、24h
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	v, err := time.ParseDuration(getEnv(key, ""))
+	if err != nil {
+		return def
+	}
+	return v
+}
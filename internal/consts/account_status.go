@@ -0,0 +1,8 @@
+package consts
+
+// 账号状态
+const (
+	AccountActive    = 1 // 正常
+	AccountPending   = 2 // 待审核（未使用邀请码注册的商家）
+	AccountSuspended = 3 // 已停用
+)
@@ -0,0 +1,11 @@
+package consts
+
+// API密钥的权限范围
+const (
+	ScopeFeedbackRead  = "feedback:read"  // 查看反馈和消息
+	ScopeFeedbackWrite = "feedback:write" // 创建反馈、修改反馈状态
+	ScopeMessageWrite  = "message:write"  // 发送消息、标记消息已读
+)
+
+// APIScopes 所有可用的权限范围
+var APIScopes = []string{ScopeFeedbackRead, ScopeFeedbackWrite, ScopeMessageWrite}
@@ -0,0 +1,8 @@
+package consts
+
+// 自动分配策略
+const (
+	AssignStrategyManual      = "manual"       // 不自动分配，由成员认领或手动分配
+	AssignStrategyRoundRobin  = "round_robin"  // 轮流分配
+	AssignStrategyLeastLoaded = "least_loaded" // 分配给未解决反馈最少的成员
+)
@@ -0,0 +1,8 @@
+package consts
+
+// 附件引用类型
+const (
+	AttachmentRefFeedback = 1 // 反馈图片
+	AttachmentRefMessage  = 2 // 消息图片
+	AttachmentRefAvatar   = 3 // 用户头像，引用ID为用户ID
+)
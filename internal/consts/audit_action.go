@@ -0,0 +1,81 @@
+package consts
+
+// 审计日志操作类型
+const (
+	AuditLoginFailed = "login.failed" // 登录失败
+	AuditLoginLocked = "login.locked" // 连续登录失败导致账号或IP被锁定
+)
+
+// 账号开通的审计操作类型
+const (
+	AuditInviteCreate    = "invite.create"    // 创建商家邀请码
+	AuditInviteRevoke    = "invite.revoke"    // 撤销商家邀请码
+	AuditMerchantApprove = "merchant.approve" // 审核通过商家注册
+	AuditMerchantReject  = "merchant.reject"  // 拒绝商家注册
+	AuditAdminCreate     = "admin.create"     // 创建管理员
+)
+
+// 管理员管理用户的审计操作类型
+const (
+	AuditUserList          = "user.list"           // 查询用户列表
+	AuditUserView          = "user.view"           // 查看用户详情
+	AuditUserUpdate        = "user.update"         // 修改用户资料
+	AuditUserSuspend       = "user.suspend"        // 停用账号
+	AuditUserUnsuspend     = "user.unsuspend"      // 恢复账号
+	AuditUserResetPassword = "user.reset_password" // 重置密码
+	AuditUserDelete        = "user.delete"         // 删除账号
+)
+
+// 单点登录的审计操作类型
+const (
+	AuditSSOLogin     = "sso.login"     // 单点登录
+	AuditSSOProvision = "sso.provision" // 单点登录时自动创建账号
+	AuditSSOLink      = "sso.link"      // 绑定外部账号
+	AuditSSOUnlink    = "sso.unlink"    // 解除绑定外部账号
+)
+
+// API密钥的审计操作类型
+const (
+	AuditAPIKeyCreate = "api_key.create" // 创建API密钥
+	AuditAPIKeyRotate = "api_key.rotate" // 轮换API密钥
+	AuditAPIKeyRevoke = "api_key.revoke" // 撤销API密钥
+)
+
+// 商家组织的审计操作类型，删除员工账号记录为 user.delete
+const (
+	AuditOrgUpdate       = "org.update"        // 修改组织信息
+	AuditOrgMemberCreate = "org.member_create" // 创建员工账号
+	AuditOrgMemberUpdate = "org.member_update" // 修改员工角色和技能
+)
+
+// 自动分配设置的审计操作类型
+const (
+	AuditAssignmentUpdate = "assignment.update" // 修改团队的自动分配设置
+)
+
+// SLA 策略的审计操作类型
+const (
+	AuditSLAPolicyCreate = "sla_policy.create" // 创建 SLA 策略
+	AuditSLAPolicyUpdate = "sla_policy.update" // 修改 SLA 策略
+	AuditSLAPolicyDelete = "sla_policy.delete" // 删除 SLA 策略
+)
+
+// 工作日历的审计操作类型
+const (
+	AuditCalendarCreate = "calendar.create" // 创建工作日历
+	AuditCalendarUpdate = "calendar.update" // 修改工作日历
+	AuditCalendarDelete = "calendar.delete" // 删除工作日历
+	AuditCalendarAssign = "calendar.assign" // 修改团队使用的工作日历
+)
+
+// 后台定时任务的审计操作类型
+const (
+	AuditJobTrigger = "job.trigger" // 手动运行定时任务
+)
+
+// 自动化规则的审计操作类型
+const (
+	AuditAutomationRuleCreate = "automation_rule.create" // 创建自动化规则
+	AuditAutomationRuleUpdate = "automation_rule.update" // 修改自动化规则
+	AuditAutomationRuleDelete = "automation_rule.delete" // 删除自动化规则
+)
@@ -0,0 +1,67 @@
+package consts
+
+// 自动化规则的触发事件
+const (
+	RuleTriggerFeedbackCreated = "feedback_created" // 创建反馈
+	RuleTriggerMessageReceived = "message_received" // 收到新消息
+	RuleTriggerStatusChanged   = "status_changed"   // 修改状态
+	RuleTriggerSLABreached     = "sla_breached"     // SLA 超时
+)
+
+// 自动化规则条件的字段
+const (
+	RuleFieldTitle              = "title"                // 反馈标题
+	RuleFieldContent            = "content"              // 反馈内容
+	RuleFieldText               = "text"                 // 反馈标题、内容和触发消息的内容，用于关键词匹配
+	RuleFieldCategory           = "category"             // 分类
+	RuleFieldStatus             = "status"               // 当前状态
+	RuleFieldCreatorType        = "creator_type"         // 创建者类型
+	RuleFieldTargetType         = "target_type"          // 目标类型
+	RuleFieldTargetID           = "target_id"            // 目标ID（商家组织ID）
+	RuleFieldAssigneeID         = "assignee_id"          // 处理人ID，0 表示未分配
+	RuleFieldEscalated          = "escalated"            // 是否已升级
+	RuleFieldTags               = "tags"                 // 标签
+	RuleFieldMessageContent     = "message.content"      // 触发消息的内容，只用于 message_received
+	RuleFieldMessageSenderType  = "message.sender_type"  // 触发消息的发送者类型，只用于 message_received
+	RuleFieldMessageFromCreator = "message.from_creator" // 触发消息是否由反馈创建者发送，只用于 message_received
+	RuleFieldOldStatus          = "old_status"           // 修改前的状态，只用于 status_changed
+	RuleFieldNewStatus          = "new_status"           // 修改后的状态，只用于 status_changed
+	RuleFieldSLAKind            = "sla.kind"             // 超时的时限类型，只用于 sla_breached
+	RuleFieldHour               = "time.hour"            // 当前小时（0-23，服务器时区）
+	RuleFieldWeekday            = "time.weekday"         // 当前星期（0 为周日，服务器时区）
+	RuleFieldBusinessHours      = "time.business_hours"  // 当前是否在目标方的营业时间内
+	RuleFieldAgeMinutes         = "age_minutes"          // 反馈创建至今的分钟数
+)
+
+// 自动化规则条件的运算符
+const (
+	RuleOpEq          = "eq"           // 等于
+	RuleOpNe          = "ne"           // 不等于
+	RuleOpIn          = "in"           // 属于列表中的任一值
+	RuleOpNotIn       = "not_in"       // 不属于列表中的任何值
+	RuleOpGt          = "gt"           // 大于
+	RuleOpGte         = "gte"          // 大于等于
+	RuleOpLt          = "lt"           // 小于
+	RuleOpLte         = "lte"          // 小于等于
+	RuleOpContains    = "contains"     // 包含（文本不区分大小写；标签为包含该标签）
+	RuleOpNotContains = "not_contains" // 不包含
+	RuleOpContainsAny = "contains_any" // 包含列表中的任一关键词或标签
+)
+
+// 自动化规则的动作类型
+const (
+	RuleActionSetStatus = "set_status" // 修改状态
+	RuleActionAssign    = "assign"     // 分配处理人
+	RuleActionAddTag    = "add_tag"    // 添加标签
+	RuleActionReply     = "reply"      // 发送预设回复（系统消息）
+	RuleActionNotify    = "notify"     // 推送通知
+	RuleActionWebhook   = "webhook"    // 调用 Webhook
+)
+
+// 自动化规则通知的接收方
+const (
+	RuleNotifyTarget   = "target"   // 目标方：商家组织的所有员工或所有管理员
+	RuleNotifyAssignee = "assignee" // 处理人
+	RuleNotifyCreator  = "creator"  // 反馈创建者
+	RuleNotifyAdmins   = "admins"   // 所有管理员
+)
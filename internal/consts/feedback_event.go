@@ -0,0 +1,15 @@
+package consts
+
+// 反馈处理记录类型，同时作为处理人变更 WebSocket 事件的 action
+const (
+	FeedbackEventAssign       = "assign"        // 分配处理人
+	FeedbackEventClaim        = "claim"         // 认领
+	FeedbackEventUnassign     = "unassign"      // 取消分配
+	FeedbackEventAutoAssign   = "auto_assign"   // 首次回复时自动分配给回复人
+	FeedbackEventStatusChange = "status_change" // 修改状态
+	FeedbackEventEscalate     = "escalate"      // 升级到平台管理员
+	FeedbackEventSLABreach    = "sla_breach"    // SLA 超时
+	FeedbackEventAutoClose    = "auto_close"    // 已解决超过一定时间后自动关闭
+	FeedbackEventFollowUp     = "follow_up"     // 长时间未回复时发送跟进提醒
+	FeedbackEventAutomation   = "automation"    // 执行自动化规则
+)
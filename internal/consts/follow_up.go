@@ -0,0 +1,7 @@
+package consts
+
+// 跟进提醒的对象
+const (
+	FollowUpTarget  = "target"  // 提醒目标方回复
+	FollowUpCreator = "creator" // 询问反馈创建者是否仍需要帮助
+)
@@ -0,0 +1,20 @@
+package consts
+
+// 后台定时任务名称
+const (
+	JobTusCleanup      = "tus_cleanup"                // 清理过期的断点续传上传
+	JobAttachmentSweep = "attachment_sweep"           // 清理未被引用的附件
+	JobSessionCleanup  = "session_cleanup"            // 清理过期的登录会话
+	JobSLAMonitor      = "sla_monitor"                // 检查 SLA 预警和超时
+	JobRunCleanup      = "job_run_cleanup"            // 清理过期的任务运行记录
+	JobAutoClose       = "feedback_auto_close"        // 自动关闭已解决的反馈
+	JobTargetFollowUp  = "feedback_target_follow_up"  // 提醒目标方回复长时间未回复的反馈
+	JobCreatorFollowUp = "feedback_creator_follow_up" // 询问长时间未回复的反馈创建者是否仍需要帮助
+)
+
+// 定时任务运行状态
+const (
+	JobRunRunning   = "running"   // 运行中
+	JobRunSucceeded = "succeeded" // 成功
+	JobRunFailed    = "failed"    // 失败
+)
@@ -3,6 +3,7 @@ package consts
 // WebSocket消息类型常量
 const (
 	// 消息类型
+	SystemMessage = 0 // 系统消息，由系统自动发送，发送者ID和类型为 0
 	TextMessage   = 1 // 文本消息
 	ImageMessage  = 2 // 图片消息
 	ImagesMessage = 3 // 多图片消息
@@ -16,4 +17,10 @@ const (
 	EventStatusChange   = "status_change"   // 状态变更事件
 	EventFeedbackDelete = "feedback_delete" // 反馈删除事件
 	EventNewFeedback    = "new_feedback"    // 新反馈事件
+	EventAssigned       = "assigned"        // 处理人变更事件
+	EventEscalated      = "escalated"       // 反馈升级事件
+	EventSLAWarning     = "sla_warning"     // SLA 即将超时事件
+	EventSLABreached    = "sla_breached"    // SLA 超时事件
+	EventFollowUp       = "follow_up"       // 跟进提醒事件
+	EventNotification   = "notification"    // 自动化规则的通知事件
 )
@@ -0,0 +1,8 @@
+package consts
+
+// 商家组织中员工的角色
+const (
+	OrgRoleOwner  = "owner"  // 所有者：管理组织信息和员工，处理反馈
+	OrgRoleAgent  = "agent"  // 客服：处理反馈（回复、修改状态）
+	OrgRoleViewer = "viewer" // 只读：只能查看反馈和消息
+)
@@ -0,0 +1,13 @@
+package consts
+
+// SLA 考核的时限类型
+const (
+	SLAFirstResponse = "first_response" // 首次回复
+	SLAResolution    = "resolution"     // 解决
+)
+
+// 反馈列表的 SLA 筛选条件
+const (
+	SLAFilterBreached = "breached" // 已超时
+	SLAFilterWarning  = "warning"  // 即将超时
+)
@@ -4,6 +4,7 @@ const (
 	Open       = 1
 	InProgress = 2
 	Resolved   = 3
+	Closed     = 4 // 已关闭：已解决的反馈超过一定时间未重新打开后自动关闭，关闭后不能再修改状态或发送消息
 )
 
 const (
@@ -0,0 +1,7 @@
+package consts
+
+// 反馈目标类型
+const (
+	TargetMerchant = 1 // 商家
+	TargetAdmin    = 2 // 管理员
+)
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"errors"
+	"feedback-system/internal/models"
+	"feedback-system/internal/service"
+	"feedback-system/pkg/password"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountHandler 账号开通管理处理程序（管理员）
+type AccountHandler struct {
+	accountService service.AccountService
+}
+
+// NewAccountHandler 创建账号开通管理处理程序实例
+func NewAccountHandler(accountService service.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+// RegisterRoutes 注册路由，router 需已应用认证中间件和管理员角色中间件
+// 前后端对接说明：对应前端 CONFIG.ENDPOINTS.ADMIN 中定义的端点
+func (h *AccountHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// POST /api/admin/invites ← 创建商家邀请码，邀请码只在响应中返回一次
+	router.POST("/invites", h.CreateInvite)
+	// GET /api/admin/invites ← 邀请码列表
+	router.GET("/invites", h.ListInvites)
+	// DELETE /api/admin/invites/:id ← 撤销邀请码
+	router.DELETE("/invites/:id", h.RevokeInvite)
+	// GET /api/admin/merchants/pending ← 待审核的商家
+	router.GET("/merchants/pending", h.ListPendingMerchants)
+	// POST /api/admin/merchants/:id/approve ← 审核通过
+	router.POST("/merchants/:id/approve", h.ApproveMerchant)
+	// POST /api/admin/merchants/:id/reject ← 拒绝注册，删除待审核账号
+	router.POST("/merchants/:id/reject", h.RejectMerchant)
+	// POST /api/admin/admins ← 创建管理员
+	router.POST("/admins", h.CreateAdmin)
+}
+
+// CreateInvite 创建商家邀请码
+// 请求数据：{note?: string, max_uses?: number, expires_in_hours?: number}
+// 响应数据：{invite: MerchantInvite, code: string}
+func (h *AccountHandler) CreateInvite(c *gin.Context) {
+	if _, ok := currentUser(c); !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	var req models.CreateInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	response, err := h.accountService.CreateInvite(auditActor(c), &req)
+	if err != nil {
+		ServerError(c, "创建邀请码失败: "+err.Error())
+		return
+	}
+
+	Success(c, response)
+}
+
+// ListInvites 获取邀请码列表
+func (h *AccountHandler) ListInvites(c *gin.Context) {
+	invites, err := h.accountService.ListInvites()
+	if err != nil {
+		ServerError(c, "获取邀请码失败: "+err.Error())
+		return
+	}
+
+	Success(c, invites)
+}
+
+// RevokeInvite 撤销邀请码
+func (h *AccountHandler) RevokeInvite(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		BadRequest(c, "无效的邀请码ID")
+		return
+	}
+
+	if err := h.accountService.RevokeInvite(auditActor(c), id); err != nil {
+		if errors.Is(err, service.ErrInviteNotFound) {
+			NotFound(c, "邀请码不存在或已撤销")
+			return
+		}
+		ServerError(c, "撤销邀请码失败: "+err.Error())
+		return
+	}
+
+	Success(c, nil)
+}
+
+// ListPendingMerchants 获取待审核的商家
+func (h *AccountHandler) ListPendingMerchants(c *gin.Context) {
+	users, err := h.accountService.ListPendingMerchants()
+	if err != nil {
+		ServerError(c, "获取待审核商家失败: "+err.Error())
+		return
+	}
+
+	// 隐藏密码
+	for _, user := range users {
+		user.Password = ""
+	}
+
+	Success(c, users)
+}
+
+// ApproveMerchant 审核通过商家注册
+func (h *AccountHandler) ApproveMerchant(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	user, err := h.accountService.ApproveMerchant(auditActor(c), id)
+	if err != nil {
+		pendingMerchantFailed(c, err)
+		return
+	}
+
+	// 隐藏密码
+	user.Password = ""
+
+	Success(c, user)
+}
+
+// RejectMerchant 拒绝商家注册
+func (h *AccountHandler) RejectMerchant(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	if err := h.accountService.RejectMerchant(auditActor(c), id); err != nil {
+		pendingMerchantFailed(c, err)
+		return
+	}
+
+	Success(c, nil)
+}
+
+// CreateAdmin 创建管理员
+// 请求数据：{username: string, password: string, contact?: string, email?: string}
+func (h *AccountHandler) CreateAdmin(c *gin.Context) {
+	var req models.CreateAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	user, err := h.accountService.CreateAdmin(auditActor(c), &req)
+	if err != nil {
+		registerFailed(c, err)
+		return
+	}
+
+	// 隐藏密码
+	user.Password = ""
+
+	Success(c, user)
+}
+
+// pendingMerchantFailed 审核商家失败响应
+func pendingMerchantFailed(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		NotFound(c, "用户不存在")
+	case errors.Is(err, service.ErrAccountNotPending):
+		BadRequest(c, "该账号不是待审核的商家")
+	default:
+		ServerError(c, "审核失败: "+err.Error())
+	}
+}
+
+// registerFailed 注册或创建账号失败响应
+func registerFailed(c *gin.Context, err error) {
+	var invalid *password.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		BadRequest(c, invalid.Message)
+	case errors.Is(err, service.ErrUsernameTaken):
+		BadRequest(c, "用户名已存在")
+	case errors.Is(err, service.ErrRegistrationForbidden):
+		Forbidden(c, "不支持注册该类型的账号")
+	case errors.Is(err, service.ErrInviteCodeRequired):
+		BadRequest(c, "商家注册需要邀请码")
+	case errors.Is(err, service.ErrInvalidInviteCode):
+		BadRequest(c, "邀请码无效或已过期")
+	default:
+		BadRequest(c, "注册失败: "+err.Error())
+	}
+}
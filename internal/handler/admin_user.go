@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"errors"
+	"feedback-system/internal/models"
+	"feedback-system/internal/service"
+	"feedback-system/pkg/password"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminUserHandler 管理员管理用户处理程序
+type AdminUserHandler struct {
+	adminUserService service.AdminUserService
+}
+
+// NewAdminUserHandler 创建管理员管理用户处理程序实例
+func NewAdminUserHandler(adminUserService service.AdminUserService) *AdminUserHandler {
+	return &AdminUserHandler{
+		adminUserService: adminUserService,
+	}
+}
+
+// RegisterRoutes 注册路由，router 需已应用认证中间件和管理员角色中间件
+// 前后端对接说明：对应前端 CONFIG.ENDPOINTS.ADMIN.USERS，修改操作都会写入审计日志
+func (h *AdminUserHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// GET /api/admin/users ← 用户列表，支持 user_type、status、keyword 筛选和 page、page_size 分页
+	router.GET("/users", h.List)
+	// GET /api/admin/users/:id ← 用户详情
+	router.GET("/users/:id", h.Get)
+	// PUT /api/admin/users/:id ← 修改联系方式和邮箱
+	router.PUT("/users/:id", h.Update)
+	// POST /api/admin/users/:id/suspend ← 停用账号，注销其所有会话
+	router.POST("/users/:id/suspend", h.Suspend)
+	// POST /api/admin/users/:id/unsuspend ← 恢复账号
+	router.POST("/users/:id/unsuspend", h.Unsuspend)
+	// POST /api/admin/users/:id/reset-password ← 重置密码，下次登录必须修改
+	router.POST("/users/:id/reset-password", h.ResetPassword)
+	// DELETE /api/admin/users/:id?feedbacks=keep|delete ← 删除账号，feedbacks 指定其反馈的处理方式
+	router.DELETE("/users/:id", h.Delete)
+}
+
+// List 获取用户列表
+// 响应数据：{items: User[], total: number, page: number, page_size: number}
+func (h *AdminUserHandler) List(c *gin.Context) {
+	var query models.AdminUserQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		BadRequest(c, "无效的查询参数: "+err.Error())
+		return
+	}
+
+	response, err := h.adminUserService.List(auditActor(c), &query)
+	if err != nil {
+		ServerError(c, "获取用户列表失败: "+err.Error())
+		return
+	}
+
+	// 隐藏密码
+	for _, user := range response.Items {
+		user.Password = ""
+	}
+
+	Success(c, response)
+}
+
+// Get 获取用户详情
+func (h *AdminUserHandler) Get(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.adminUserService.Get(auditActor(c), id)
+	if err != nil {
+		adminUserFailed(c, err)
+		return
+	}
+
+	// 隐藏密码
+	user.Password = ""
+
+	Success(c, user)
+}
+
+// Update 修改用户资料
+// 请求数据：{contact?: string, email?: string}，只修改请求中出现的字段
+func (h *AdminUserHandler) Update(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminUpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	user, err := h.adminUserService.Update(auditActor(c), id, &req)
+	if err != nil {
+		adminUserFailed(c, err)
+		return
+	}
+
+	// 隐藏密码
+	user.Password = ""
+
+	Success(c, user)
+}
+
+// Suspend 停用账号
+// 请求数据：{reason?: string}，停用原因记录在审计日志中
+func (h *AdminUserHandler) Suspend(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminSuspendUserRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			BadRequest(c, "无效的请求参数: "+err.Error())
+			return
+		}
+	}
+
+	user, err := h.adminUserService.Suspend(auditActor(c), id, req.Reason)
+	if err != nil {
+		adminUserFailed(c, err)
+		return
+	}
+
+	// 隐藏密码
+	user.Password = ""
+
+	Success(c, user)
+}
+
+// Unsuspend 恢复账号
+func (h *AdminUserHandler) Unsuspend(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.adminUserService.Unsuspend(auditActor(c), id)
+	if err != nil {
+		adminUserFailed(c, err)
+		return
+	}
+
+	// 隐藏密码
+	user.Password = ""
+
+	Success(c, user)
+}
+
+// ResetPassword 重置用户密码
+// 请求数据：{new_password?: string}；不指定时生成临时密码，响应数据：{temporary_password: string}
+func (h *AdminUserHandler) ResetPassword(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminResetPasswordRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			BadRequest(c, "无效的请求参数: "+err.Error())
+			return
+		}
+	}
+
+	response, err := h.adminUserService.ResetPassword(auditActor(c), id, &req)
+	if err != nil {
+		adminUserFailed(c, err)
+		return
+	}
+
+	Success(c, response)
+}
+
+// Delete 删除用户
+// 查询参数 feedbacks：keep（默认）保留该用户创建和收到的反馈，delete 一并删除反馈及其消息
+func (h *AdminUserHandler) Delete(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.adminUserService.Delete(auditActor(c), id, c.Query("feedbacks")); err != nil {
+		adminUserFailed(c, err)
+		return
+	}
+
+	Success(c, nil)
+}
+
+// userIDParam 解析路径中的用户ID，无效时返回 400
+func userIDParam(c *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		BadRequest(c, "无效的用户ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// adminUserFailed 管理用户失败响应
+func adminUserFailed(c *gin.Context, err error) {
+	var invalid *password.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		BadRequest(c, invalid.Message)
+	case errors.Is(err, service.ErrUserNotFound):
+		NotFound(c, "用户不存在")
+	case errors.Is(err, service.ErrCannotModifySelf):
+		BadRequest(c, "不能停用或删除自己的账号")
+	case errors.Is(err, service.ErrLastAdmin):
+		BadRequest(c, "不能停用或删除最后一个管理员")
+	case errors.Is(err, service.ErrOrganizationHasStaff):
+		BadRequest(c, "请先删除该商家组织的其他员工")
+	case errors.Is(err, service.ErrInvalidAccountStatus):
+		BadRequest(c, "当前账号状态不允许该操作")
+	case errors.Is(err, service.ErrInvalidEmail):
+		BadRequest(c, "邮箱格式错误")
+	case errors.Is(err, service.ErrInvalidContact):
+		BadRequest(c, "联系方式必须是手机号或邮箱")
+	case errors.Is(err, service.ErrInvalidFeedbackPolicy):
+		BadRequest(c, "feedbacks 参数只能是 keep 或 delete")
+	default:
+		ServerError(c, "操作失败: "+err.Error())
+	}
+}
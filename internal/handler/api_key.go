@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"errors"
+	"feedback-system/internal/models"
+	"feedback-system/internal/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler 商家API密钥处理程序
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+}
+
+// NewAPIKeyHandler 创建商家API密钥处理程序实例
+func NewAPIKeyHandler(apiKeyService service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// RegisterRoutes 注册路由，router 需已应用认证中间件和商家角色中间件
+// 前后端对接说明：对应前端 CONFIG.ENDPOINTS.MERCHANT.API_KEYS，只能使用登录令牌管理，API密钥本身不能访问这些接口
+func (h *APIKeyHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// POST /api/merchant/api-keys ← 创建API密钥，响应中的 secret 只返回一次
+	router.POST("/api-keys", h.Create)
+	// GET /api/merchant/api-keys ← API密钥列表，包含已撤销和已过期的密钥
+	router.GET("/api-keys", h.List)
+	// POST /api/merchant/api-keys/:id/rotate ← 轮换API密钥，旧密钥在 grace_hours 小时后失效
+	router.POST("/api-keys/:id/rotate", h.Rotate)
+	// DELETE /api/merchant/api-keys/:id ← 撤销API密钥，立即失效
+	router.DELETE("/api-keys/:id", h.Revoke)
+}
+
+// Create 创建API密钥
+// 请求数据：{name: string, scopes: string[], expires_in_days?: number}
+// 响应数据：{key: APIKey, secret: string}
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	response, err := h.apiKeyService.Create(auditActor(c), &req)
+	if err != nil {
+		apiKeyFailed(c, err)
+		return
+	}
+	Success(c, response)
+}
+
+// List 获取当前商家的API密钥
+func (h *APIKeyHandler) List(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "用户未登录")
+		return
+	}
+
+	keys, err := h.apiKeyService.List(user.ID)
+	if err != nil {
+		ServerError(c, "获取API密钥失败: "+err.Error())
+		return
+	}
+	Success(c, keys)
+}
+
+// Rotate 轮换API密钥
+// 请求数据：{grace_hours?: number}
+// 响应数据：{key: APIKey, secret: string}
+func (h *APIKeyHandler) Rotate(c *gin.Context) {
+	id, ok := apiKeyIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.RotateAPIKeyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			BadRequest(c, "无效的请求参数: "+err.Error())
+			return
+		}
+	}
+
+	response, err := h.apiKeyService.Rotate(auditActor(c), id, req.GraceHours)
+	if err != nil {
+		apiKeyFailed(c, err)
+		return
+	}
+	Success(c, response)
+}
+
+// Revoke 撤销API密钥
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	id, ok := apiKeyIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.apiKeyService.Revoke(auditActor(c), id); err != nil {
+		apiKeyFailed(c, err)
+		return
+	}
+	Success(c, nil)
+}
+
+// apiKeyIDParam 解析路径中的API密钥ID
+func apiKeyIDParam(c *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		BadRequest(c, "无效的API密钥ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// apiKeyFailed 根据错误类型返回API密钥操作失败的响应
+func apiKeyFailed(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrAPIKeyNotFound):
+		NotFound(c, "API密钥不存在")
+	case errors.Is(err, service.ErrInvalidAPIScope):
+		BadRequest(c, "无效的权限范围")
+	case errors.Is(err, service.ErrTooManyAPIKeys):
+		BadRequest(c, "有效的API密钥数量已达上限")
+	default:
+		ServerError(c, "API密钥操作失败: "+err.Error())
+	}
+}
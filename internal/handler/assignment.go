@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"errors"
+	"feedback-system/internal/models"
+	"feedback-system/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AssignmentHandler 反馈处理人处理程序
+type AssignmentHandler struct {
+	assignmentService service.AssignmentService
+}
+
+// NewAssignmentHandler 创建反馈处理人处理程序实例
+func NewAssignmentHandler(assignmentService service.AssignmentService) *AssignmentHandler {
+	return &AssignmentHandler{
+		assignmentService: assignmentService,
+	}
+}
+
+// RegisterRoutes 注册路由，router 需已应用认证中间件
+// 前后端对接说明：对应前端 CONFIG.ENDPOINTS.FEEDBACK.ASSIGN、CLAIM、UNASSIGN（拼接在反馈ID之后），处理人变更时推送 assigned 事件
+func (h *AssignmentHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// POST /api/feedback/:id/assign ← 分配给指定处理人
+	router.POST("/feedback/:id/assign", h.Assign)
+	// POST /api/feedback/:id/claim ← 认领（分配给自己）
+	router.POST("/feedback/:id/claim", h.Claim)
+	// POST /api/feedback/:id/unassign ← 取消分配
+	router.POST("/feedback/:id/unassign", h.Unassign)
+}
+
+// RegisterSettingRoutes 注册自动分配设置路由，router 需已应用认证中间件和商家或管理员角色中间件
+// 前后端对接说明：对应前端 CONFIG.ENDPOINTS.MERCHANT.ASSIGNMENT 和 CONFIG.ENDPOINTS.ADMIN.ASSIGNMENT，设置的团队由当前账号决定
+func (h *AssignmentHandler) RegisterSettingRoutes(router *gin.RouterGroup) {
+	// GET /api/merchant/assignment、/api/admin/assignment ← 获取团队的自动分配设置
+	router.GET("/assignment", h.GetSetting)
+	// PUT /api/merchant/assignment、/api/admin/assignment ← 修改团队的自动分配设置，商家组织只有所有者可以修改
+	router.PUT("/assignment", h.UpdateSetting)
+}
+
+// Assign 分配处理人
+// 请求数据：{assignee_id: number}
+// 响应数据：更新后的反馈
+func (h *AssignmentHandler) Assign(c *gin.Context) {
+	id, user, ok := assignmentParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.AssignFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	feedback, err := h.assignmentService.Assign(user, id, req.AssigneeID)
+	if err != nil {
+		assignmentFailed(c, err)
+		return
+	}
+	Success(c, feedback)
+}
+
+// Claim 认领反馈
+func (h *AssignmentHandler) Claim(c *gin.Context) {
+	id, user, ok := assignmentParams(c)
+	if !ok {
+		return
+	}
+
+	feedback, err := h.assignmentService.Claim(user, id)
+	if err != nil {
+		assignmentFailed(c, err)
+		return
+	}
+	Success(c, feedback)
+}
+
+// Unassign 取消分配
+func (h *AssignmentHandler) Unassign(c *gin.Context) {
+	id, user, ok := assignmentParams(c)
+	if !ok {
+		return
+	}
+
+	feedback, err := h.assignmentService.Unassign(user, id)
+	if err != nil {
+		assignmentFailed(c, err)
+		return
+	}
+	Success(c, feedback)
+}
+
+// GetSetting 获取当前账号所在团队的自动分配设置
+// 响应数据：{target_type, target_id, strategy, online_first, skill_match, updated_at}
+func (h *AssignmentHandler) GetSetting(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	setting, err := h.assignmentService.GetSetting(user)
+	if err != nil {
+		assignmentFailed(c, err)
+		return
+	}
+	Success(c, setting)
+}
+
+// UpdateSetting 修改当前账号所在团队的自动分配设置
+// 请求数据：{strategy: "manual"|"round_robin"|"least_loaded", online_first: boolean, skill_match: boolean}
+func (h *AssignmentHandler) UpdateSetting(c *gin.Context) {
+	var req models.UpdateAssignmentSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	setting, err := h.assignmentService.UpdateSetting(auditActor(c), &req)
+	if err != nil {
+		assignmentFailed(c, err)
+		return
+	}
+	Success(c, setting)
+}
+
+// assignmentParams 解析反馈ID并获取当前用户
+func assignmentParams(c *gin.Context) (uint64, *models.User, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		BadRequest(c, "Invalid feedback ID")
+		return 0, nil, false
+	}
+	user, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return 0, nil, false
+	}
+	return id, user, true
+}
+
+// assignmentFailed 根据错误类型返回修改处理人失败的响应
+func assignmentFailed(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrFeedbackNotFound):
+		NotFound(c, "Feedback not found")
+	case errors.Is(err, service.ErrAssignForbidden):
+		Forbidden(c, "不能修改该反馈的处理人")
+	case errors.Is(err, service.ErrInvalidAssignee):
+		BadRequest(c, "处理人必须是反馈目标方可以处理反馈的成员")
+	case errors.Is(err, service.ErrAssignmentConflict):
+		Fail(c, http.StatusConflict, "处理人已被其他人修改，请刷新后重试")
+	case errors.Is(err, service.ErrNoAssignmentTeam):
+		NotFound(c, "当前账号不属于任何处理团队")
+	case errors.Is(err, service.ErrNotOrgOwner):
+		Forbidden(c, "只有组织所有者可以修改自动分配设置")
+	default:
+		ServerError(c, "修改处理人失败: "+err.Error())
+	}
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"errors"
+	"feedback-system/internal/service"
+	"feedback-system/pkg/signurl"
+	"feedback-system/pkg/storage"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AttachmentHandler 附件处理程序
+type AttachmentHandler struct {
+	attachmentService service.AttachmentService
+}
+
+// NewAttachmentHandler 创建附件处理程序
+func NewAttachmentHandler(attachmentService service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+	}
+}
+
+// Download 下载附件
+// 支持两种鉴权方式：
+// - 签名链接：?expires=...&signature=...，供 <img> 等无法携带请求头的场景使用
+// - Bearer令牌：校验当前用户是否为附件所属反馈的参与方
+//
+// 图片附件可通过 ?variant=thumb|small|medium 获取缩略图
+func (h *AttachmentHandler) Download(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		BadRequest(c, "无效的附件ID")
+		return
+	}
+
+	attachment, err := h.attachmentService.GetByID(id)
+	if err != nil {
+		NotFound(c, "附件不存在")
+		return
+	}
+
+	if signature := c.Query(signurl.SignatureParam); signature != "" {
+		if err := h.attachmentService.VerifySignature(id, c.Query(signurl.ExpiresParam), signature); err != nil {
+			if errors.Is(err, signurl.ErrExpired) {
+				Forbidden(c, "链接已过期")
+				return
+			}
+			Forbidden(c, "无效的链接签名")
+			return
+		}
+	} else {
+		userObj, ok := currentUser(c)
+		if !ok {
+			Unauthorized(c, "未认证")
+			return
+		}
+		if !h.attachmentService.CanAccess(attachment, userObj) {
+			Forbidden(c, "没有权限访问此附件")
+			return
+		}
+	}
+
+	redirectURL, reader, info, err := h.attachmentService.Open(c.Request.Context(), attachment, c.Query("variant"))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			NotFound(c, "文件不存在")
+			return
+		}
+		ServerError(c, "读取文件失败: "+err.Error())
+		return
+	}
+	if redirectURL != "" {
+		c.Redirect(http.StatusFound, redirectURL)
+		return
+	}
+	defer reader.Close()
+
+	c.DataFromReader(http.StatusOK, info.Size, info.ContentType, reader, map[string]string{
+		"Cache-Control":          "private, max-age=3600",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// GetSignedURL 获取附件的新签名链接
+// 页面停留时间较长导致签名过期时，前端可通过此接口刷新
+func (h *AttachmentHandler) GetSignedURL(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		BadRequest(c, "无效的附件ID")
+		return
+	}
+
+	userObj, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	attachment, err := h.attachmentService.GetByID(id)
+	if err != nil {
+		NotFound(c, "附件不存在")
+		return
+	}
+	if !h.attachmentService.CanAccess(attachment, userObj) {
+		Forbidden(c, "没有权限访问此附件")
+		return
+	}
+
+	Success(c, gin.H{"id": id, "url": h.attachmentService.SignedURL(id)})
+}
+
+// RegisterRoutes 注册路由
+// 路由组需挂载可选认证中间件：签名链接无需令牌，其余请求需要Bearer令牌
+func (h *AttachmentHandler) RegisterRoutes(router *gin.RouterGroup) {
+	attachmentRouter := router.Group("/attachments")
+	{
+		attachmentRouter.GET("/:id", h.Download)         // 下载附件
+		attachmentRouter.GET("/:id/url", h.GetSignedURL) // 获取签名链接
+	}
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"feedback-system/internal/models"
+	"feedback-system/internal/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AutomationHandler 自动化规则管理处理程序
+type AutomationHandler struct {
+	automationService service.AutomationService
+}
+
+// NewAutomationHandler 创建自动化规则管理处理程序实例
+func NewAutomationHandler(automationService service.AutomationService) *AutomationHandler {
+	return &AutomationHandler{
+		automationService: automationService,
+	}
+}
+
+// RegisterRoutes 注册路由，router 需已应用认证中间件和管理员角色中间件
+// 前后端对接说明：对应前端 CONFIG.ENDPOINTS.ADMIN.AUTOMATION_RULES，修改和删除时拼接规则ID，测试时拼接 /test
+func (h *AutomationHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// GET /api/admin/automation-rules ← 规则列表，按触发事件和执行顺序排序
+	router.GET("/automation-rules", h.List)
+	// POST /api/admin/automation-rules ← 创建规则
+	router.POST("/automation-rules", h.Create)
+	// POST /api/admin/automation-rules/test ← 按反馈的当前数据测试规则，不执行动作
+	router.POST("/automation-rules/test", h.Test)
+	// PUT /api/admin/automation-rules/:id ← 修改规则
+	router.PUT("/automation-rules/:id", h.Update)
+	// DELETE /api/admin/automation-rules/:id ← 删除规则
+	router.DELETE("/automation-rules/:id", h.Delete)
+}
+
+// List 获取所有自动化规则
+func (h *AutomationHandler) List(c *gin.Context) {
+	rules, err := h.automationService.List()
+	if err != nil {
+		ServerError(c, "获取自动化规则失败: "+err.Error())
+		return
+	}
+	Success(c, rules)
+}
+
+// Create 创建自动化规则
+// 请求数据：{name, trigger, conditions: [{field, operator, value}], match_any, actions: [{type, ...}], position, stop_processing, enabled}
+func (h *AutomationHandler) Create(c *gin.Context) {
+	var req models.AutomationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	rule, err := h.automationService.Create(auditActor(c), &req)
+	if err != nil {
+		automationFailed(c, err)
+		return
+	}
+	Success(c, rule)
+}
+
+// Update 修改自动化规则
+// 请求数据同 Create
+func (h *AutomationHandler) Update(c *gin.Context) {
+	id, ok := automationRuleIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.AutomationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	rule, err := h.automationService.Update(auditActor(c), id, &req)
+	if err != nil {
+		automationFailed(c, err)
+		return
+	}
+	Success(c, rule)
+}
+
+// Delete 删除自动化规则
+func (h *AutomationHandler) Delete(c *gin.Context) {
+	id, ok := automationRuleIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.automationService.Delete(auditActor(c), id); err != nil {
+		automationFailed(c, err)
+		return
+	}
+	Success(c, nil)
+}
+
+// Test 测试自动化规则
+// 请求数据：{feedback_id, trigger, rule_id?, rule?, message?: {content, sender_id, sender_type}, old_status?, new_status?, sla_kind?}
+// 响应数据：[{rule_id, name, matched, conditions: [{field, operator, value, actual, matched}], actions}]
+func (h *AutomationHandler) Test(c *gin.Context) {
+	var req models.AutomationTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	results, err := h.automationService.Test(&req)
+	if err != nil {
+		automationFailed(c, err)
+		return
+	}
+	Success(c, results)
+}
+
+// automationRuleIDParam 解析路径中的规则ID
+func automationRuleIDParam(c *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		BadRequest(c, "无效的自动化规则ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// automationFailed 根据错误类型返回自动化规则操作失败的响应
+func automationFailed(c *gin.Context, err error) {
+	var invalid *service.InvalidRuleError
+	switch {
+	case errors.As(err, &invalid):
+		BadRequest(c, "无效的自动化规则: "+invalid.Reason)
+	case errors.Is(err, service.ErrAutomationRuleNotFound):
+		NotFound(c, "自动化规则不存在")
+	case errors.Is(err, service.ErrFeedbackNotFound):
+		NotFound(c, "Feedback not found")
+	default:
+		ServerError(c, "自动化规则操作失败: "+err.Error())
+	}
+}
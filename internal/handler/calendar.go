@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"errors"
+	"feedback-system/internal/models"
+	"feedback-system/internal/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CalendarHandler 工作日历处理程序
+type CalendarHandler struct {
+	calendarService service.CalendarService
+}
+
+// NewCalendarHandler 创建工作日历处理程序实例
+func NewCalendarHandler(calendarService service.CalendarService) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: calendarService,
+	}
+}
+
+// RegisterRoutes 注册工作日历管理路由，router 需已应用认证中间件和管理员角色中间件
+// 前后端对接说明：对应前端 CONFIG.ENDPOINTS.ADMIN.CALENDARS（修改和删除时拼接日历ID）和 CONFIG.ENDPOINTS.ADMIN.CALENDAR_ASSIGNMENTS
+func (h *CalendarHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// GET /api/admin/calendars ← 日历列表
+	router.GET("/calendars", h.List)
+	// POST /api/admin/calendars ← 创建日历
+	router.POST("/calendars", h.Create)
+	// PUT /api/admin/calendars/:id ← 修改日历
+	router.PUT("/calendars/:id", h.Update)
+	// DELETE /api/admin/calendars/:id ← 删除日历
+	router.DELETE("/calendars/:id", h.Delete)
+	// GET /api/admin/calendar-assignments ← 各团队使用的日历
+	router.GET("/calendar-assignments", h.ListAssignments)
+	// PUT /api/admin/calendar-assignments ← 为团队指定日历
+	router.PUT("/calendar-assignments", h.Assign)
+}
+
+// RegisterTeamRoutes 注册团队日历路由，router 需已应用认证中间件和商家或管理员角色中间件
+// 前后端对接说明：对应前端 CONFIG.ENDPOINTS.MERCHANT.CALENDAR 和 CONFIG.ENDPOINTS.ADMIN.CALENDAR，团队由当前账号决定
+func (h *CalendarHandler) RegisterTeamRoutes(router *gin.RouterGroup) {
+	// GET /api/merchant/calendar、/api/admin/calendar ← 获取团队使用的日历和当前的营业状态
+	router.GET("/calendar", h.TeamCalendar)
+}
+
+// TeamCalendar 获取当前账号所在团队使用的工作日历
+// 响应数据：{calendar: 日历或 null（全天营业）, open: boolean, next_open: string}
+func (h *CalendarHandler) TeamCalendar(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	resp, err := h.calendarService.TeamCalendar(user)
+	if err != nil {
+		calendarFailed(c, err)
+		return
+	}
+	Success(c, resp)
+}
+
+// List 获取所有工作日历
+func (h *CalendarHandler) List(c *gin.Context) {
+	calendars, err := h.calendarService.List()
+	if err != nil {
+		ServerError(c, "获取工作日历失败: "+err.Error())
+		return
+	}
+	Success(c, calendars)
+}
+
+// Create 创建工作日历
+// 请求数据：{name, timezone: "Asia/Shanghai", hours: {mon: ["09:00-18:00"], ...}, holidays?: ["2026-10-01"]}
+func (h *CalendarHandler) Create(c *gin.Context) {
+	var req models.BusinessCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	calendar, err := h.calendarService.Create(auditActor(c), &req)
+	if err != nil {
+		calendarFailed(c, err)
+		return
+	}
+	Success(c, calendar)
+}
+
+// Update 修改工作日历
+// 请求数据同 Create
+func (h *CalendarHandler) Update(c *gin.Context) {
+	id, ok := calendarIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.BusinessCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	calendar, err := h.calendarService.Update(auditActor(c), id, &req)
+	if err != nil {
+		calendarFailed(c, err)
+		return
+	}
+	Success(c, calendar)
+}
+
+// Delete 删除工作日历
+func (h *CalendarHandler) Delete(c *gin.Context) {
+	id, ok := calendarIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.calendarService.Delete(auditActor(c), id); err != nil {
+		calendarFailed(c, err)
+		return
+	}
+	Success(c, nil)
+}
+
+// ListAssignments 获取各团队使用的工作日历
+func (h *CalendarHandler) ListAssignments(c *gin.Context) {
+	assignments, err := h.calendarService.ListAssignments()
+	if err != nil {
+		ServerError(c, "获取团队日历失败: "+err.Error())
+		return
+	}
+	Success(c, assignments)
+}
+
+// Assign 为团队指定工作日历
+// 请求数据：{target_type: 1|2, target_id, calendar_id}，calendar_id 为 0 表示取消指定
+func (h *CalendarHandler) Assign(c *gin.Context) {
+	var req models.AssignCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	if err := h.calendarService.Assign(auditActor(c), &req); err != nil {
+		calendarFailed(c, err)
+		return
+	}
+	Success(c, nil)
+}
+
+// calendarIDParam 解析路径中的日历ID
+func calendarIDParam(c *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		BadRequest(c, "无效的日历ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// calendarFailed 根据错误类型返回工作日历操作失败的响应
+func calendarFailed(c *gin.Context, err error) {
+	var invalid *service.InvalidCalendarError
+	switch {
+	case errors.As(err, &invalid):
+		BadRequest(c, "无效的工作日历: "+invalid.Reason)
+	case errors.Is(err, service.ErrCalendarNotFound):
+		NotFound(c, "工作日历不存在")
+	case errors.Is(err, service.ErrInvalidCalendarTarget):
+		BadRequest(c, "无效的团队：管理员团队的 target_id 必须为 0，商家的 target_id 必须为 0 或商家组织ID")
+	case errors.Is(err, service.ErrNoAssignmentTeam):
+		NotFound(c, "当前账号不属于任何处理团队")
+	default:
+		ServerError(c, "工作日历操作失败: "+err.Error())
+	}
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"feedback-system/internal/models"
+	"feedback-system/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUser 从上下文中获取认证中间件设置的用户
+func currentUser(c *gin.Context) (*models.User, bool) {
+	user, exists := c.Get("user")
+	if !exists {
+		return nil, false
+	}
+	userObj, ok := user.(*models.User)
+	return userObj, ok
+}
+
+// currentSession 从上下文中获取认证中间件设置的登录会话
+func currentSession(c *gin.Context) (*models.UserSession, bool) {
+	session, exists := c.Get("session")
+	if !exists {
+		return nil, false
+	}
+	sessionObj, ok := session.(*models.UserSession)
+	return sessionObj, ok
+}
+
+// auditActor 当前用户和客户端信息，用于写入审计日志
+func auditActor(c *gin.Context) *service.AuditActor {
+	user, _ := currentUser(c)
+	return &service.AuditActor{
+		User:      user,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
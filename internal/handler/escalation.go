@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"feedback-system/internal/models"
+	"feedback-system/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EscalationHandler 反馈升级处理程序
+type EscalationHandler struct {
+	escalationService service.EscalationService
+}
+
+// NewEscalationHandler 创建反馈升级处理程序实例
+func NewEscalationHandler(escalationService service.EscalationService) *EscalationHandler {
+	return &EscalationHandler{
+		escalationService: escalationService,
+	}
+}
+
+// RegisterRoutes 注册路由，router 需已应用认证中间件
+// 前后端对接说明：对应前端 CONFIG.ENDPOINTS.FEEDBACK.ESCALATE（拼接在反馈ID之后），升级后推送 escalated 事件
+func (h *EscalationHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// POST /api/feedback/:id/escalate ← 升级到平台管理员
+	router.POST("/feedback/:id/escalate", h.Escalate)
+}
+
+// Escalate 将反馈升级到平台管理员
+// 请求数据：{reason?: string}
+// 响应数据：更新后的反馈；等待时间未到时返回 429 和 {available_at}
+func (h *EscalationHandler) Escalate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		BadRequest(c, "Invalid feedback ID")
+		return
+	}
+	user, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	var req models.EscalateFeedbackRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			BadRequest(c, "无效的请求参数: "+err.Error())
+			return
+		}
+	}
+
+	feedback, err := h.escalationService.Escalate(user, id, req.Reason)
+	if err != nil {
+		escalationFailed(c, err)
+		return
+	}
+	Success(c, feedback)
+}
+
+// escalationFailed 根据错误类型返回升级失败的响应
+func escalationFailed(c *gin.Context, err error) {
+	var tooEarly *service.EscalationTooEarlyError
+	switch {
+	case errors.As(err, &tooEarly):
+		seconds := int64((time.Until(tooEarly.AvailableAt) + time.Second - 1) / time.Second)
+		c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+		c.JSON(http.StatusTooManyRequests, Response{
+			Code:    http.StatusTooManyRequests,
+			Message: "商家回复的等待时间未到，暂时不能升级",
+			Data:    gin.H{"available_at": tooEarly.AvailableAt},
+		})
+	case errors.Is(err, service.ErrFeedbackNotFound):
+		NotFound(c, "Feedback not found")
+	case errors.Is(err, service.ErrEscalationForbidden):
+		Forbidden(c, "不能升级该反馈")
+	case errors.Is(err, service.ErrEscalationUnavailable):
+		BadRequest(c, "只有发给商家且未解决的反馈可以升级")
+	case errors.Is(err, service.ErrAlreadyEscalated):
+		Fail(c, http.StatusConflict, "反馈已经升级")
+	default:
+		ServerError(c, "升级反馈失败: "+err.Error())
+	}
+}
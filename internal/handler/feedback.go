@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"feedback-system/internal/consts"
 	"feedback-system/internal/models"
 	"feedback-system/internal/service"
 
@@ -44,12 +46,21 @@ func (h *FeedbackHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if !service.CanHandleFeedback(userObj) {
+		Forbidden(c, "只读员工不能创建或处理反馈")
+		return
+	}
+
 	// 设置创建者信息
 	feedback.CreatorID = userObj.ID
 	feedback.CreatorType = userObj.UserType
 
 	// 创建反馈
 	err := h.feedbackService.Create(&feedback)
+	if errors.Is(err, service.ErrInvalidAttachment) {
+		BadRequest(c, "只能引用本人上传的附件")
+		return
+	}
 	if err != nil {
 		ServerError(c, "Failed to create feedback: "+err.Error())
 		return
@@ -67,8 +78,14 @@ func (h *FeedbackHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	// 获取反馈详情
-	feedback, err := h.feedbackService.GetByID(id)
+	user, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	// 获取反馈详情，不是反馈的参与方时同样返回 404，不暴露反馈是否存在
+	feedback, err := h.feedbackService.GetByID(user, id)
 	if err != nil {
 		NotFound(c, "Feedback not found")
 		return
@@ -77,7 +94,7 @@ func (h *FeedbackHandler) GetByID(c *gin.Context) {
 	Success(c, feedback)
 }
 
-// GetByCreator 获取用户创建的反馈列表
+// GetByCreator 获取用户创建的反馈列表，只能获取本人创建的反馈，管理员可以获取任何用户的
 func (h *FeedbackHandler) GetByCreator(c *gin.Context) {
 	// 解析请求参数
 	creatorID, err := strconv.ParseUint(c.Query("creator_id"), 10, 64)
@@ -92,8 +109,18 @@ func (h *FeedbackHandler) GetByCreator(c *gin.Context) {
 		return
 	}
 
+	user, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
 	// 获取反馈列表
-	feedbacks, err := h.feedbackService.GetByCreator(creatorID, uint8(creatorType))
+	feedbacks, err := h.feedbackService.GetByCreator(user, creatorID, uint8(creatorType))
+	if errors.Is(err, service.ErrFeedbackForbidden) {
+		Forbidden(c, "只能获取本人创建的反馈")
+		return
+	}
 	if err != nil {
 		ServerError(c, "Failed to get feedbacks: "+err.Error())
 		return
@@ -102,7 +129,9 @@ func (h *FeedbackHandler) GetByCreator(c *gin.Context) {
 	Success(c, feedbacks)
 }
 
-// GetByTarget 获取目标接收的反馈列表
+// GetByTarget 获取目标接收的反馈列表，商家员工只能获取所属组织的，管理员可以获取任何目标的
+// 查询参数：assignee（可选）为 me 时只获取分配给自己的反馈，为 unassigned 时只获取未分配的反馈，也可以是处理人ID
+// sla（可选）为 breached 时只获取已超时的反馈，为 warning 时只获取即将超时的反馈
 func (h *FeedbackHandler) GetByTarget(c *gin.Context) {
 	// 解析请求参数
 	targetID, err := strconv.ParseUint(c.Query("target_id"), 10, 64)
@@ -117,8 +146,27 @@ func (h *FeedbackHandler) GetByTarget(c *gin.Context) {
 		return
 	}
 
+	user, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+	// 商家员工（包括使用API密钥访问）只能获取所属商家组织接收的反馈
+	if user.UserType == consts.Merchant {
+		targetID, targetType = user.OrgID, consts.TargetMerchant
+	}
+
+	filter, ok := feedbackFilter(c)
+	if !ok {
+		return
+	}
+
 	// 获取反馈列表
-	feedbacks, err := h.feedbackService.GetByTarget(targetID, uint8(targetType))
+	feedbacks, err := h.feedbackService.GetByTarget(user, targetID, uint8(targetType), filter)
+	if errors.Is(err, service.ErrFeedbackForbidden) {
+		Forbidden(c, "没有权限获取该目标的反馈")
+		return
+	}
 	if err != nil {
 		ServerError(c, "Failed to get feedbacks: "+err.Error())
 		return
@@ -127,10 +175,26 @@ func (h *FeedbackHandler) GetByTarget(c *gin.Context) {
 	Success(c, feedbacks)
 }
 
-// GetAll 获取所有反馈
+// GetAll 获取所有反馈，只有管理员可以获取
+// 查询参数：assignee、sla（可选）同 GetByTarget
 func (h *FeedbackHandler) GetAll(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	filter, ok := feedbackFilter(c)
+	if !ok {
+		return
+	}
+
 	// 获取所有反馈
-	feedbacks, err := h.feedbackService.GetAll()
+	feedbacks, err := h.feedbackService.GetAll(user, filter)
+	if errors.Is(err, service.ErrFeedbackForbidden) {
+		Forbidden(c, "只有管理员可以获取所有反馈")
+		return
+	}
 	if err != nil {
 		ServerError(c, "Failed to get feedbacks: "+err.Error())
 		return
@@ -149,7 +213,7 @@ func (h *FeedbackHandler) UpdateStatus(c *gin.Context) {
 	}
 
 	var req struct {
-		Status uint8 `json:"status" binding:"required"`
+		Status uint8 `json:"status" binding:"required,oneof=1 2 3"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -171,7 +235,19 @@ func (h *FeedbackHandler) UpdateStatus(c *gin.Context) {
 	}
 
 	// 更新状态
-	err = h.feedbackService.UpdateStatus(id, req.Status, userObj.ID, userObj.UserType)
+	err = h.feedbackService.UpdateStatus(userObj, id, req.Status)
+	if errors.Is(err, service.ErrFeedbackNotFound) {
+		NotFound(c, "Feedback not found")
+		return
+	}
+	if errors.Is(err, service.ErrFeedbackReadOnly) {
+		Forbidden(c, "只读员工不能创建或处理反馈")
+		return
+	}
+	if errors.Is(err, service.ErrFeedbackClosed) {
+		BadRequest(c, "反馈已关闭，不能修改状态")
+		return
+	}
 	if err != nil {
 		ServerError(c, "Failed to update status: "+err.Error())
 		return
@@ -202,8 +278,16 @@ func (h *FeedbackHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	// 删除反馈
-	err = h.feedbackService.Delete(id, userObj.ID, userObj.UserType)
+	// 删除反馈，不是参与方时同样返回 404
+	err = h.feedbackService.Delete(userObj, id)
+	if errors.Is(err, service.ErrFeedbackNotFound) {
+		NotFound(c, "Feedback not found")
+		return
+	}
+	if errors.Is(err, service.ErrFeedbackForbidden) {
+		Forbidden(c, "只有反馈的创建者和管理员可以删除反馈")
+		return
+	}
 	if err != nil {
 		ServerError(c, "Failed to delete feedback: "+err.Error())
 		return
@@ -212,6 +296,72 @@ func (h *FeedbackHandler) Delete(c *gin.Context) {
 	Success(c, gin.H{"id": id})
 }
 
+// History 获取反馈的处理记录（分配处理人、修改状态等）
+func (h *FeedbackHandler) History(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		BadRequest(c, "Invalid feedback ID")
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+	if !h.feedbackService.IsParticipant(id, user) {
+		NotFound(c, "Feedback not found")
+		return
+	}
+
+	events, err := h.feedbackService.History(id)
+	if err != nil {
+		ServerError(c, "Failed to get feedback history: "+err.Error())
+		return
+	}
+
+	Success(c, events)
+}
+
+// feedbackFilter 解析反馈列表的筛选参数
+func feedbackFilter(c *gin.Context) (*models.FeedbackFilter, bool) {
+	assignee, ok := assigneeFilter(c)
+	if !ok {
+		return nil, false
+	}
+	sla := c.Query("sla")
+	if sla != "" && sla != consts.SLAFilterBreached && sla != consts.SLAFilterWarning {
+		BadRequest(c, "Invalid sla filter")
+		return nil, false
+	}
+	return &models.FeedbackFilter{Assignee: assignee, SLA: sla}, true
+}
+
+// assigneeFilter 解析处理人筛选参数，未指定时返回 nil
+func assigneeFilter(c *gin.Context) (*uint64, bool) {
+	var assignee uint64
+	switch value := c.Query("assignee"); value {
+	case "":
+		return nil, true
+	case "me":
+		user, ok := currentUser(c)
+		if !ok {
+			Unauthorized(c, "未认证")
+			return nil, false
+		}
+		assignee = user.ID
+	case "unassigned":
+	default:
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			BadRequest(c, "Invalid assignee")
+			return nil, false
+		}
+		assignee = id
+	}
+	return &assignee, true
+}
+
 // RegisterRoutes 注册路由
 func (h *FeedbackHandler) RegisterRoutes(router *gin.RouterGroup) {
 	feedbackRouter := router.Group("/feedback")
@@ -223,5 +373,6 @@ func (h *FeedbackHandler) RegisterRoutes(router *gin.RouterGroup) {
 		feedbackRouter.GET("", h.GetAll)                  // 获取所有反馈
 		feedbackRouter.PUT("/:id/status", h.UpdateStatus) // 更新反馈状态
 		feedbackRouter.DELETE("/:id", h.Delete)           // 删除反馈
+		feedbackRouter.GET("/:id/history", h.History)     // 获取反馈的处理记录
 	}
 }
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"feedback-system/internal/models"
 	"feedback-system/internal/service"
 
@@ -44,12 +45,20 @@ func (h *FeedbackMessageHandler) Create(c *gin.Context) {
 		return
 	}
 
-	// 设置发送者信息
-	message.SenderID = userObj.ID
-	message.SenderType = userObj.UserType
-
-	// 创建消息
-	err := h.messageService.Create(&message)
+	// 创建消息，发送者为当前用户
+	err := h.messageService.Create(userObj, &message)
+	if errors.Is(err, service.ErrFeedbackNotFound) {
+		NotFound(c, "Feedback not found")
+		return
+	}
+	if errors.Is(err, service.ErrFeedbackReadOnly) {
+		Forbidden(c, "只读员工不能回复反馈")
+		return
+	}
+	if errors.Is(err, service.ErrInvalidAttachment) {
+		BadRequest(c, "只能引用本人上传的附件或该反馈中的附件")
+		return
+	}
 	if err != nil {
 		ServerError(c, "Failed to create message: "+err.Error())
 		return
@@ -67,8 +76,18 @@ func (h *FeedbackMessageHandler) GetByFeedbackID(c *gin.Context) {
 		return
 	}
 
-	// 获取消息列表
-	messages, err := h.messageService.GetByFeedbackID(feedbackID)
+	user, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	// 获取消息列表，不是反馈的参与方时同样返回 404，不暴露反馈是否存在
+	messages, err := h.messageService.GetByFeedbackID(user, feedbackID)
+	if errors.Is(err, service.ErrFeedbackNotFound) {
+		NotFound(c, "Feedback not found")
+		return
+	}
 	if err != nil {
 		ServerError(c, "Failed to get messages: "+err.Error())
 		return
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"feedback-system/internal/models"
+	"feedback-system/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JobHandler 后台定时任务处理程序
+type JobHandler struct {
+	jobService service.JobService
+}
+
+// NewJobHandler 创建后台定时任务处理程序实例
+func NewJobHandler(jobService service.JobService) *JobHandler {
+	return &JobHandler{
+		jobService: jobService,
+	}
+}
+
+// RegisterRoutes 注册路由，router 需已应用认证中间件和管理员角色中间件
+// 前后端对接说明：对应前端 CONFIG.ENDPOINTS.ADMIN.JOBS，运行和查看运行记录时拼接任务名称和 /run、/runs
+func (h *JobHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// GET /api/admin/jobs ← 任务列表，包含执行计划和最近一次运行记录
+	router.GET("/jobs", h.List)
+	// POST /api/admin/jobs/:name/run ← 立即运行任务
+	router.POST("/jobs/:name/run", h.Trigger)
+	// GET /api/admin/jobs/:name/runs ← 任务的运行记录，支持 page、page_size 分页
+	router.GET("/jobs/:name/runs", h.Runs)
+}
+
+// List 获取所有定时任务
+func (h *JobHandler) List(c *gin.Context) {
+	jobs, err := h.jobService.List()
+	if err != nil {
+		ServerError(c, "获取定时任务失败: "+err.Error())
+		return
+	}
+	Success(c, jobs)
+}
+
+// Trigger 立即在后台运行任务
+// 响应数据：新的运行记录（status 为 running），运行结果通过运行记录查看
+func (h *JobHandler) Trigger(c *gin.Context) {
+	run, err := h.jobService.Trigger(auditActor(c), c.Param("name"))
+	if err != nil {
+		jobFailed(c, err)
+		return
+	}
+	Success(c, run)
+}
+
+// Runs 分页获取任务的运行记录
+// 响应数据：{items: JobRun[], total: number, page: number, page_size: number}
+func (h *JobHandler) Runs(c *gin.Context) {
+	var query models.JobRunQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		BadRequest(c, "无效的查询参数: "+err.Error())
+		return
+	}
+
+	response, err := h.jobService.Runs(c.Param("name"), &query)
+	if err != nil {
+		jobFailed(c, err)
+		return
+	}
+	Success(c, response)
+}
+
+// jobFailed 根据错误类型返回定时任务操作失败的响应
+func jobFailed(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrJobNotFound):
+		NotFound(c, "定时任务不存在")
+	case errors.Is(err, service.ErrJobRunning):
+		Fail(c, http.StatusConflict, "任务正在运行")
+	default:
+		ServerError(c, "定时任务操作失败: "+err.Error())
+	}
+}
@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"errors"
+	"feedback-system/internal/models"
+	"feedback-system/internal/service"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// oidcStateCookie 保存单点登录状态的 Cookie 名称
+	oidcStateCookie = "oidc_state"
+	// oidcCookiePath 单点登录状态 Cookie 的路径，只在单点登录接口中发送
+	oidcCookiePath = "/api/user/oidc"
+	// oidcCookieMaxAge 单点登录状态 Cookie 的有效期（秒），与服务端的状态有效期一致
+	oidcCookieMaxAge = 600
+)
+
+// OIDCHandler 单点登录处理程序
+type OIDCHandler struct {
+	oidcService service.OIDCService
+}
+
+// NewOIDCHandler 创建单点登录处理程序实例
+func NewOIDCHandler(oidcService service.OIDCService) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+	}
+}
+
+// RegisterRoutes 注册路由
+// 前后端对接说明：对应前端 CONFIG.ENDPOINTS.USER.OIDC_*，由 SSOUtils（utils.js）调用
+// 登录流程：浏览器打开 /api/user/oidc/login → IdP 登录 → /api/user/oidc/callback → 返回页面携带 sso_token → 调用 /api/user/oidc/exchange 换取令牌
+// router 上注册无需登录的接口，authRouter 已挂载认证中间件，注册需要登录的接口
+func (h *OIDCHandler) RegisterRoutes(router, authRouter *gin.RouterGroup) {
+	oidcGroup := router.Group("/user/oidc")
+	authGroup := authRouter.Group("/user/oidc")
+	{
+		// GET /api/user/oidc/config ← 前端：SSOUtils.init() 决定是否显示单点登录按钮
+		oidcGroup.GET("/config", h.Config)
+		// GET /api/user/oidc/login ← 前端：单点登录按钮，浏览器直接跳转
+		oidcGroup.GET("/login", h.Login)
+		// GET /api/user/oidc/callback ← IdP 登录完成后跳转
+		oidcGroup.GET("/callback", h.Callback)
+		// POST /api/user/oidc/exchange ← 前端：SSOUtils.consume() 使用 sso_token 换取登录令牌
+		oidcGroup.POST("/exchange", h.Exchange)
+		// POST /api/user/oidc/link ← 已登录用户绑定外部账号，返回 IdP 授权地址
+		authGroup.POST("/link", h.Link)
+		// GET /api/user/oidc/identities ← 查看已绑定的外部账号
+		authGroup.GET("/identities", h.ListIdentities)
+		// DELETE /api/user/oidc/identities/:id ← 解除绑定外部账号
+		authGroup.DELETE("/identities/:id", h.Unlink)
+	}
+}
+
+// Config 获取单点登录配置
+// 响应数据：{enabled: bool, provider_name: string, merchant: bool, admin: bool}
+func (h *OIDCHandler) Config(c *gin.Context) {
+	Success(c, h.oidcService.Config())
+}
+
+// Login 跳转到 IdP 登录
+// 查询参数：user_type（2=商家, 3=管理员），return 为登录完成后返回的本站页面，默认为对应的登录页
+// 失败时跳转回返回页面并携带 sso_error 参数
+func (h *OIDCHandler) Login(c *gin.Context) {
+	userType, _ := strconv.ParseUint(c.Query("user_type"), 10, 8)
+	returnPath := service.SafeReturnPath(c.Query("return"), uint8(userType))
+
+	request, err := h.oidcService.Begin(c.Request.Context(), uint8(userType), returnPath, nil)
+	if err != nil {
+		c.Redirect(http.StatusFound, withQuery(returnPath, "sso_error", ssoErrorCode(err)))
+		return
+	}
+	h.setStateCookie(c, request.State, oidcCookieMaxAge)
+	c.Redirect(http.StatusFound, request.URL)
+}
+
+// Link 绑定外部账号
+// 响应数据：{url: string}，前端跳转到该地址，完成后返回 return 参数指定的页面并携带 sso_linked=1
+func (h *OIDCHandler) Link(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "用户未登录")
+		return
+	}
+
+	request, err := h.oidcService.Begin(c.Request.Context(), user.UserType, c.Query("return"), user)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrSSODisabled):
+			NotFound(c, "未启用单点登录")
+		case errors.Is(err, service.ErrSSOUserTypeNotAllowed):
+			Forbidden(c, "当前账号类型不能使用单点登录")
+		default:
+			ServerError(c, "绑定外部账号失败: "+err.Error())
+		}
+		return
+	}
+	h.setStateCookie(c, request.State, oidcCookieMaxAge)
+	Success(c, models.OIDCLinkResponse{URL: request.URL})
+}
+
+// Callback IdP 登录完成后的回调
+// 成功时跳转回返回页面并携带 sso_token（登录）或 sso_linked=1（绑定），失败时携带 sso_error
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	state, _ := c.Cookie(oidcStateCookie)
+	h.setStateCookie(c, "", -1)
+
+	// 用户在 IdP 取消登录或 IdP 拒绝授权，只解析状态以得知返回页面
+	if idpError := c.Query("error"); idpError != "" {
+		log.Printf("单点登录被 IdP 拒绝: %s %s", idpError, c.Query("error_description"))
+		result, _ := h.oidcService.Callback(c.Request.Context(), state, "", "", auditActor(c))
+		c.Redirect(http.StatusFound, withQuery(callbackReturnPath(result), "sso_error", "denied"))
+		return
+	}
+
+	result, err := h.oidcService.Callback(c.Request.Context(), state, c.Query("state"), c.Query("code"), auditActor(c))
+	returnPath := callbackReturnPath(result)
+	switch {
+	case err != nil:
+		log.Printf("单点登录失败: %v", err)
+		c.Redirect(http.StatusFound, withQuery(returnPath, "sso_error", ssoErrorCode(err)))
+	case result.Linked:
+		c.Redirect(http.StatusFound, withQuery(returnPath, "sso_linked", "1"))
+	default:
+		c.Redirect(http.StatusFound, withQuery(returnPath, "sso_token", result.Token))
+	}
+}
+
+// Exchange 使用 sso_token 换取登录令牌
+// 请求数据：{token: string, device_name?: string}
+// 响应数据与 /api/user/login 相同，启用两步验证的账号同样返回 mfa_token
+func (h *OIDCHandler) Exchange(c *gin.Context) {
+	var req models.OIDCExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	response, err := h.oidcService.Exchange(req.Token, req.DeviceName, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSSOToken) {
+			Unauthorized(c, "单点登录已过期，请重新登录")
+			return
+		}
+		loginFailed(c, err)
+		return
+	}
+
+	response.User.Password = ""
+	Success(c, response)
+}
+
+// ListIdentities 获取当前用户绑定的外部账号
+func (h *OIDCHandler) ListIdentities(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "用户未登录")
+		return
+	}
+
+	identities, err := h.oidcService.ListIdentities(user.ID)
+	if err != nil {
+		ServerError(c, "获取外部账号失败: "+err.Error())
+		return
+	}
+	Success(c, identities)
+}
+
+// Unlink 解除绑定外部账号
+func (h *OIDCHandler) Unlink(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		BadRequest(c, "无效的外部账号ID")
+		return
+	}
+
+	if err := h.oidcService.Unlink(auditActor(c), id); err != nil {
+		if errors.Is(err, service.ErrIdentityNotFound) {
+			NotFound(c, "外部账号不存在")
+			return
+		}
+		ServerError(c, "解除绑定失败: "+err.Error())
+		return
+	}
+	Success(c, nil)
+}
+
+// setStateCookie 设置单点登录状态 Cookie，maxAge 为负数时删除
+// 回调是从 IdP 跳转回来的顶级导航请求，SameSite=Lax 的 Cookie 会被发送
+func (h *OIDCHandler) setStateCookie(c *gin.Context, value string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, value, maxAge, oidcCookiePath, "", secure, true)
+}
+
+// callbackReturnPath 回调完成后返回的页面，状态无效时无法得知用户类型，返回首页
+func callbackReturnPath(result *service.OIDCCallbackResult) string {
+	if result == nil || result.ReturnPath == "" {
+		return "/"
+	}
+	return result.ReturnPath
+}
+
+// ssoErrorCode 单点登录失败原因，前端 SSOUtils 据此显示提示
+func ssoErrorCode(err error) string {
+	switch {
+	case errors.Is(err, service.ErrSSODisabled):
+		return "disabled"
+	case errors.Is(err, service.ErrInvalidSSOState):
+		return "expired"
+	case errors.Is(err, service.ErrSSOUserTypeNotAllowed):
+		return "forbidden"
+	case errors.Is(err, service.ErrSSOAccountNotFound):
+		return "no_account"
+	case errors.Is(err, service.ErrIdentityLinked):
+		return "linked"
+	case errors.Is(err, service.ErrAccountPending):
+		return "pending"
+	case errors.Is(err, service.ErrAccountSuspended):
+		return "suspended"
+	}
+	return "failed"
+}
+
+// withQuery 为本站页面地址追加查询参数
+func withQuery(path, key, value string) string {
+	u, err := url.Parse(path)
+	if err != nil {
+		u = &url.URL{Path: "/"}
+	}
+	query := u.Query()
+	query.Set(key, value)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
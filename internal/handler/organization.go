@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"feedback-system/internal/models"
+	"feedback-system/internal/service"
+	"feedback-system/pkg/password"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationHandler 商家组织处理程序
+type OrganizationHandler struct {
+	orgService service.OrganizationService
+}
+
+// NewOrganizationHandler 创建商家组织处理程序实例
+func NewOrganizationHandler(orgService service.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{
+		orgService: orgService,
+	}
+}
+
+// RegisterRoutes 注册路由，router 需已应用认证中间件和商家角色中间件
+// 前后端对接说明：对应前端 CONFIG.ENDPOINTS.MERCHANT.ORG、ORG_MEMBERS；所有员工都可以查看，只有所有者可以修改
+func (h *OrganizationHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// GET /api/merchant/org ← 当前员工所属组织及其所有员工
+	router.GET("/org", h.Get)
+	// PUT /api/merchant/org ← 修改组织名称
+	router.PUT("/org", h.Update)
+	// POST /api/merchant/org/members ← 创建员工账号
+	router.POST("/org/members", h.CreateMember)
+	// PUT /api/merchant/org/members/:id ← 修改员工角色和技能
+	router.PUT("/org/members/:id", h.UpdateMember)
+	// DELETE /api/merchant/org/members/:id ← 删除员工账号
+	router.DELETE("/org/members/:id", h.RemoveMember)
+}
+
+// Get 获取当前员工所属组织
+// 响应数据：{organization: Organization, members: User[]}
+func (h *OrganizationHandler) Get(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "用户未登录")
+		return
+	}
+
+	response, err := h.orgService.Get(user)
+	if err != nil {
+		organizationFailed(c, err)
+		return
+	}
+	Success(c, response)
+}
+
+// Update 修改组织名称
+// 请求数据：{name: string}
+func (h *OrganizationHandler) Update(c *gin.Context) {
+	var req models.UpdateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	org, err := h.orgService.Update(auditActor(c), &req)
+	if err != nil {
+		organizationFailed(c, err)
+		return
+	}
+	Success(c, org)
+}
+
+// CreateMember 创建员工账号，员工首次登录后必须修改密码
+// 请求数据：{username, password, display_name?, email?, role: "agent"|"viewer"}
+func (h *OrganizationHandler) CreateMember(c *gin.Context) {
+	var req models.CreateOrgMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	member, err := h.orgService.CreateMember(auditActor(c), &req)
+	if err != nil {
+		organizationFailed(c, err)
+		return
+	}
+	Success(c, member)
+}
+
+// UpdateMember 修改员工角色和技能，所有者可以修改自己的技能
+// 请求数据：{role?: "agent"|"viewer", skills?: string[]}
+func (h *OrganizationHandler) UpdateMember(c *gin.Context) {
+	id, ok := memberIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateOrgMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	member, err := h.orgService.UpdateMember(auditActor(c), id, &req)
+	if err != nil {
+		organizationFailed(c, err)
+		return
+	}
+	Success(c, member)
+}
+
+// RemoveMember 删除员工账号
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	id, ok := memberIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.orgService.RemoveMember(auditActor(c), id); err != nil {
+		organizationFailed(c, err)
+		return
+	}
+	Success(c, nil)
+}
+
+// memberIDParam 解析路径中的员工ID
+func memberIDParam(c *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		BadRequest(c, "无效的员工ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// organizationFailed 根据错误类型返回商家组织操作失败的响应
+func organizationFailed(c *gin.Context, err error) {
+	var invalid *password.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		BadRequest(c, invalid.Message)
+	case errors.Is(err, service.ErrOrganizationNotFound):
+		NotFound(c, "商家组织不存在")
+	case errors.Is(err, service.ErrNotOrgOwner):
+		Forbidden(c, "只有组织所有者可以管理组织")
+	case errors.Is(err, service.ErrOrgMemberNotFound):
+		NotFound(c, "员工不存在")
+	case errors.Is(err, service.ErrUsernameTaken):
+		BadRequest(c, "用户名已存在")
+	default:
+		ServerError(c, "操作失败: "+err.Error())
+	}
+}
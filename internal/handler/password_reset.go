@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"feedback-system/internal/models"
+	"feedback-system/internal/service"
+	"feedback-system/pkg/password"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasswordResetHandler 找回密码处理程序
+type PasswordResetHandler struct {
+	resetService service.PasswordResetService
+}
+
+// NewPasswordResetHandler 创建找回密码处理程序实例
+func NewPasswordResetHandler(resetService service.PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{
+		resetService: resetService,
+	}
+}
+
+// RegisterRoutes 注册路由
+// 前后端对接说明：对应前端 CONFIG.ENDPOINTS.USER.PASSWORD_FORGOT / PASSWORD_RESET，由 reset-password.js 调用
+func (h *PasswordResetHandler) RegisterRoutes(router *gin.RouterGroup) {
+	userGroup := router.Group("/user")
+	{
+		// POST /api/user/password/forgot ← 前端：reset-password.js handleForgot() 申请重置密码
+		userGroup.POST("/password/forgot", h.Forgot)
+		// POST /api/user/password/reset ← 前端：reset-password.js handleReset() 通过邮件中的链接设置新密码
+		userGroup.POST("/password/reset", h.Reset)
+	}
+}
+
+// Forgot 申请重置密码
+// 请求数据：{username: string, user_type: number}
+// 无论账号是否存在都返回成功，账号设置了邮箱时发送重置链接
+func (h *PasswordResetHandler) Forgot(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	if err := h.resetService.Forgot(&req, c.ClientIP()); err != nil {
+		if errors.Is(err, service.ErrTooManyResetRequests) {
+			Fail(c, http.StatusTooManyRequests, "申请过于频繁，请稍后再试")
+			return
+		}
+		ServerError(c, "申请重置密码失败: "+err.Error())
+		return
+	}
+
+	Success(c, nil)
+}
+
+// Reset 重置密码
+// 请求数据：{token: string, new_password: string}，token 来自邮件中的重置链接
+// 重置成功后该账号所有设备上的会话被注销，需要重新登录
+func (h *PasswordResetHandler) Reset(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	if err := h.resetService.Reset(&req); err != nil {
+		var invalid *password.ValidationError
+		switch {
+		case errors.As(err, &invalid):
+			BadRequest(c, invalid.Message)
+		case errors.Is(err, service.ErrInvalidResetToken):
+			BadRequest(c, "重置链接无效或已过期，请重新申请")
+		default:
+			ServerError(c, "重置密码失败: "+err.Error())
+		}
+		return
+	}
+
+	Success(c, nil)
+}
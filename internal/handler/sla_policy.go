@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"errors"
+	"feedback-system/internal/models"
+	"feedback-system/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SLAPolicyHandler SLA 策略管理处理程序
+type SLAPolicyHandler struct {
+	slaService service.SLAService
+}
+
+// NewSLAPolicyHandler 创建 SLA 策略管理处理程序实例
+func NewSLAPolicyHandler(slaService service.SLAService) *SLAPolicyHandler {
+	return &SLAPolicyHandler{
+		slaService: slaService,
+	}
+}
+
+// RegisterRoutes 注册路由，router 需已应用认证中间件和管理员角色中间件
+// 前后端对接说明：对应前端 CONFIG.ENDPOINTS.ADMIN.SLA_POLICIES，修改和删除时拼接策略ID
+func (h *SLAPolicyHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// GET /api/admin/sla-policies ← 策略列表
+	router.GET("/sla-policies", h.List)
+	// POST /api/admin/sla-policies ← 创建策略
+	router.POST("/sla-policies", h.Create)
+	// PUT /api/admin/sla-policies/:id ← 修改策略
+	router.PUT("/sla-policies/:id", h.Update)
+	// DELETE /api/admin/sla-policies/:id ← 删除策略
+	router.DELETE("/sla-policies/:id", h.Delete)
+}
+
+// List 获取所有 SLA 策略
+func (h *SLAPolicyHandler) List(c *gin.Context) {
+	policies, err := h.slaService.ListPolicies()
+	if err != nil {
+		ServerError(c, "获取SLA策略失败: "+err.Error())
+		return
+	}
+	Success(c, policies)
+}
+
+// Create 创建 SLA 策略
+// 请求数据：{name, target_type: 1|2, target_id, first_response_minutes, resolution_minutes, warning_minutes}
+func (h *SLAPolicyHandler) Create(c *gin.Context) {
+	var req models.SLAPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	policy, err := h.slaService.CreatePolicy(auditActor(c), &req)
+	if err != nil {
+		slaPolicyFailed(c, err)
+		return
+	}
+	Success(c, policy)
+}
+
+// Update 修改 SLA 策略
+// 请求数据同 Create
+func (h *SLAPolicyHandler) Update(c *gin.Context) {
+	id, ok := slaPolicyIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.SLAPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	policy, err := h.slaService.UpdatePolicy(auditActor(c), id, &req)
+	if err != nil {
+		slaPolicyFailed(c, err)
+		return
+	}
+	Success(c, policy)
+}
+
+// Delete 删除 SLA 策略
+func (h *SLAPolicyHandler) Delete(c *gin.Context) {
+	id, ok := slaPolicyIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.slaService.DeletePolicy(auditActor(c), id); err != nil {
+		slaPolicyFailed(c, err)
+		return
+	}
+	Success(c, nil)
+}
+
+// slaPolicyIDParam 解析路径中的策略ID
+func slaPolicyIDParam(c *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		BadRequest(c, "无效的SLA策略ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// slaPolicyFailed 根据错误类型返回 SLA 策略操作失败的响应
+func slaPolicyFailed(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrSLAPolicyNotFound):
+		NotFound(c, "SLA策略不存在")
+	case errors.Is(err, service.ErrSLAPolicyExists):
+		Fail(c, http.StatusConflict, "该目标已有SLA策略")
+	case errors.Is(err, service.ErrInvalidSLATarget):
+		BadRequest(c, "无效的策略目标：管理员团队的 target_id 必须为 0，商家的 target_id 必须为 0 或商家组织ID")
+	default:
+		ServerError(c, "SLA策略操作失败: "+err.Error())
+	}
+}
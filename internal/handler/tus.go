@@ -0,0 +1,264 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/service"
+	"feedback-system/pkg/imaging"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// tusVersion 支持的tus协议版本
+	tusVersion = "1.0.0"
+	// tusExtensions 支持的tus协议扩展
+	tusExtensions = "creation,termination,expiration"
+	// tusBasePath 断点续传接口路径
+	tusBasePath = "/api/upload/tus"
+)
+
+// TusHandler 断点续传上传处理程序（tus 1.0.0 协议）
+// 前端可直接使用 tus-js-client / Uppy，endpoint 设为 /api/upload/tus，并在 headers 中携带 Authorization
+type TusHandler struct {
+	tusService        service.TusService
+	attachmentService service.AttachmentService
+}
+
+// NewTusHandler 创建断点续传上传处理程序
+func NewTusHandler(tusService service.TusService, attachmentService service.AttachmentService) *TusHandler {
+	return &TusHandler{
+		tusService:        tusService,
+		attachmentService: attachmentService,
+	}
+}
+
+// Options 协议能力发现
+// OPTIONS 请求会被全局跨域中间件拦截，由中间件对 tus 路径调用此方法
+func (h *TusHandler) Options(c *gin.Context) {
+	maxSize := h.tusService.MaxSize(consts.User)
+	for _, userType := range []uint8{consts.Merchant, consts.Admin} {
+		if size := h.tusService.MaxSize(userType); size > maxSize {
+			maxSize = size
+		}
+	}
+
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	c.Header("Tus-Max-Size", strconv.FormatInt(maxSize, 10))
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// IsTusPath 判断请求路径是否为断点续传接口
+func IsTusPath(path string) bool {
+	return path == tusBasePath || strings.HasPrefix(path, tusBasePath+"/")
+}
+
+// Create 创建上传
+// 请求头：Upload-Length 文件大小，Upload-Metadata 元数据（filename 为 base64 编码的文件名）
+func (h *TusHandler) Create(c *gin.Context) {
+	userObj, ok := h.begin(c)
+	if !ok {
+		return
+	}
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		BadRequest(c, "无效的Upload-Length")
+		return
+	}
+
+	metadata := c.GetHeader("Upload-Metadata")
+	filename := parseTusMetadata(metadata)["filename"]
+	if filename == "" {
+		filename = parseTusMetadata(metadata)["name"]
+	}
+	if filename == "" {
+		filename = "upload"
+	}
+
+	session, err := h.tusService.Create(userObj, length, filename, metadata)
+	if err != nil {
+		if errors.Is(err, service.ErrUploadTooLarge) {
+			Fail(c, http.StatusRequestEntityTooLarge, "文件大小超过上限")
+			return
+		}
+		if errors.Is(err, service.ErrQuotaExceeded) {
+			Fail(c, http.StatusRequestEntityTooLarge, "存储空间不足")
+			return
+		}
+		ServerError(c, "创建上传失败: "+err.Error())
+		return
+	}
+
+	c.Header("Location", tusBasePath+"/"+session.ID)
+	c.Header("Upload-Expires", tusExpiresHeader(session.ExpiresAt))
+	c.Status(http.StatusCreated)
+}
+
+// Head 查询上传进度
+func (h *TusHandler) Head(c *gin.Context) {
+	userObj, ok := h.begin(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.tusService.Get(c.Param("id"), userObj)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(session.Length, 10))
+	if session.Metadata != "" {
+		c.Header("Upload-Metadata", session.Metadata)
+	}
+	if session.CompletedAt == nil {
+		c.Header("Upload-Expires", tusExpiresHeader(session.ExpiresAt))
+	}
+	h.setAttachmentHeaders(c, session.AttachmentID)
+	c.Status(http.StatusOK)
+}
+
+// Patch 续传数据
+// 请求头：Content-Type: application/offset+octet-stream，Upload-Offset 当前偏移量
+// 上传完成时响应头 X-Attachment-ID / X-Attachment-URL 返回生成的附件
+func (h *TusHandler) Patch(c *gin.Context) {
+	userObj, ok := h.begin(c)
+	if !ok {
+		return
+	}
+
+	if c.ContentType() != "application/offset+octet-stream" {
+		Fail(c, http.StatusUnsupportedMediaType, "Content-Type必须为application/offset+octet-stream")
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		BadRequest(c, "无效的Upload-Offset")
+		return
+	}
+
+	session, err := h.tusService.Append(c.Request.Context(), c.Param("id"), userObj, offset, c.Request.Body)
+	if err != nil {
+		// 中断的请求已写入的数据仍然有效，返回最新偏移量便于客户端继续
+		if session != nil {
+			c.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+		}
+		h.fail(c, err)
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	if session.CompletedAt == nil {
+		c.Header("Upload-Expires", tusExpiresHeader(session.ExpiresAt))
+	}
+	h.setAttachmentHeaders(c, session.AttachmentID)
+	c.Status(http.StatusNoContent)
+}
+
+// Delete 终止上传
+func (h *TusHandler) Delete(c *gin.Context) {
+	userObj, ok := h.begin(c)
+	if !ok {
+		return
+	}
+
+	if err := h.tusService.Terminate(c.Param("id"), userObj); err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RegisterRoutes 注册路由
+func (h *TusHandler) RegisterRoutes(router *gin.RouterGroup) {
+	tusRouter := router.Group("/upload/tus")
+	{
+		tusRouter.POST("", h.Create)       // 创建上传
+		tusRouter.HEAD("/:id", h.Head)     // 查询上传进度
+		tusRouter.PATCH("/:id", h.Patch)   // 续传数据
+		tusRouter.DELETE("/:id", h.Delete) // 终止上传
+	}
+}
+
+// begin 校验协议版本并获取当前用户
+func (h *TusHandler) begin(c *gin.Context) (*models.User, bool) {
+	c.Header("Tus-Resumable", tusVersion)
+	if c.GetHeader("Tus-Resumable") != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		Fail(c, http.StatusPreconditionFailed, "不支持的tus协议版本")
+		return nil, false
+	}
+
+	userObj, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return nil, false
+	}
+	return userObj, true
+}
+
+// fail 将服务错误转换为tus协议规定的状态码
+func (h *TusHandler) fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUploadNotFound):
+		NotFound(c, "上传不存在")
+	case errors.Is(err, service.ErrUploadOffsetMismatch):
+		Fail(c, http.StatusConflict, "Upload-Offset与服务端不一致")
+	case errors.Is(err, service.ErrUploadCompleted):
+		Fail(c, http.StatusConflict, "上传已完成")
+	case errors.Is(err, service.ErrUploadTooLarge):
+		Fail(c, http.StatusRequestEntityTooLarge, "数据超过Upload-Length")
+	case errors.Is(err, service.ErrQuotaExceeded):
+		Fail(c, http.StatusRequestEntityTooLarge, "存储空间不足")
+	case errors.Is(err, service.ErrFileTypeNotAllowed), errors.Is(err, imaging.ErrUnsupportedFormat), errors.Is(err, imaging.ErrTooLarge):
+		Fail(c, http.StatusUnprocessableEntity, "不支持的文件类型")
+	default:
+		ServerError(c, "上传失败: "+err.Error())
+	}
+}
+
+// setAttachmentHeaders 上传完成后返回附件信息
+func (h *TusHandler) setAttachmentHeaders(c *gin.Context, attachmentID *uint64) {
+	if attachmentID == nil {
+		return
+	}
+	c.Header("X-Attachment-ID", strconv.FormatUint(*attachmentID, 10))
+	c.Header("X-Attachment-URL", h.attachmentService.SignedURL(*attachmentID))
+}
+
+// parseTusMetadata 解析 Upload-Metadata：逗号分隔的 "键 base64值" 列表
+func parseTusMetadata(header string) map[string]string {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.Fields(pair)
+		if len(parts) == 0 {
+			continue
+		}
+		value := ""
+		if len(parts) > 1 {
+			decoded, err := base64.StdEncoding.DecodeString(parts[1])
+			if err != nil {
+				continue
+			}
+			value = string(decoded)
+		}
+		metadata[parts[0]] = value
+	}
+	return metadata
+}
+
+// tusExpiresHeader 格式化过期时间
+func tusExpiresHeader(t time.Time) string {
+	return t.UTC().Format(http.TimeFormat)
+}
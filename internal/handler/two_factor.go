@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"errors"
+	"feedback-system/internal/models"
+	"feedback-system/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginTwoFactor 两步验证登录
+// 前后端对接说明：
+// - 请求数据：{mfa_token: string, code: string, device_name?: string}，code 为验证器应用中的6位验证码或恢复码
+// - 响应数据与 /api/user/login 相同；登录时完成绑定的账号额外返回 recovery_codes
+func (h *UserHandler) LoginTwoFactor(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	response, err := h.userService.LoginTwoFactor(&req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		loginFailed(c, err)
+		return
+	}
+
+	// 隐藏密码
+	response.User.Password = ""
+
+	Success(c, response)
+}
+
+// SetupTwoFactorForLogin 强制两步验证的账号首次登录时获取绑定信息
+// 请求数据：{mfa_token: string}，响应数据：{secret, otpauth_url, qr_code}
+func (h *UserHandler) SetupTwoFactorForLogin(c *gin.Context) {
+	var req models.MFATokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	setup, err := h.userService.SetupTwoFactorForLogin(req.MFAToken)
+	if err != nil {
+		twoFactorFailed(c, err)
+		return
+	}
+
+	Success(c, setup)
+}
+
+// SetupTwoFactor 生成两步验证绑定信息
+// 响应数据：{secret, otpauth_url, qr_code}，用验证器应用扫描二维码后调用 /api/user/2fa/enable 确认
+func (h *UserHandler) SetupTwoFactor(c *gin.Context) {
+	userObj, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	setup, err := h.userService.SetupTwoFactor(userObj)
+	if err != nil {
+		twoFactorFailed(c, err)
+		return
+	}
+
+	Success(c, setup)
+}
+
+// EnableTwoFactor 确认绑定并启用两步验证
+// 请求数据：{code: string}，响应数据：{recovery_codes: string[]}，恢复码只显示这一次
+func (h *UserHandler) EnableTwoFactor(c *gin.Context) {
+	userObj, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	codes, err := h.userService.EnableTwoFactor(userObj, req.Code)
+	if err != nil {
+		twoFactorFailed(c, err)
+		return
+	}
+
+	Success(c, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor 关闭两步验证
+// 请求数据：{password: string, code: string}
+func (h *UserHandler) DisableTwoFactor(c *gin.Context) {
+	userObj, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	var req models.TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	if err := h.userService.DisableTwoFactor(userObj, req.Password, req.Code); err != nil {
+		twoFactorFailed(c, err)
+		return
+	}
+
+	Success(c, nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，原有恢复码全部失效
+// 请求数据：{code: string}，响应数据：{recovery_codes: string[]}
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userObj, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	codes, err := h.userService.RegenerateRecoveryCodes(userObj, req.Code)
+	if err != nil {
+		twoFactorFailed(c, err)
+		return
+	}
+
+	Success(c, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// twoFactorFailed 两步验证管理接口的失败响应
+func twoFactorFailed(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidMFAToken):
+		Unauthorized(c, "两步验证已过期，请重新登录")
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		BadRequest(c, "验证码错误")
+	case errors.Is(err, service.ErrInvalidCredentials):
+		BadRequest(c, "密码错误")
+	case errors.Is(err, service.ErrTwoFactorNotAllowed), errors.Is(err, service.ErrTwoFactorMandatory):
+		Forbidden(c, err.Error())
+	case errors.Is(err, service.ErrTwoFactorEnabled), errors.Is(err, service.ErrTwoFactorNotEnabled), errors.Is(err, service.ErrTwoFactorNotSetup):
+		BadRequest(c, err.Error())
+	default:
+		ServerError(c, "两步验证操作失败: "+err.Error())
+	}
+}
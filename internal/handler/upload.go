@@ -1,27 +1,45 @@
 package handler
 
 import (
-	"fmt"
-	"io"
-	"os"
-	"path/filepath"
-	"strings"
-	"time"
+	"errors"
+	"feedback-system/internal/models"
+	"feedback-system/internal/service"
+	"feedback-system/pkg/imaging"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 // UploadHandler 上传处理器
-type UploadHandler struct{}
+type UploadHandler struct {
+	attachmentService service.AttachmentService
+}
 
 // NewUploadHandler 创建上传处理器
-func NewUploadHandler() *UploadHandler {
-	return &UploadHandler{}
+func NewUploadHandler(attachmentService service.AttachmentService) *UploadHandler {
+	return &UploadHandler{
+		attachmentService: attachmentService,
+	}
 }
 
 // UploadImage 上传图片
+// 图片格式按文件内容识别（仅支持JPEG/PNG/GIF），保存前会去除EXIF等元数据并生成缩略图。
+// 响应中的 url 和 variants 均为限时签名链接，可直接用于 <img> 标签；
+// 发送反馈或消息时提交 url 即可，后端保存前会去掉签名参数
 func (h *UploadHandler) UploadImage(c *gin.Context) {
+	// 从认证中间件中获取用户信息
+	user, exists := c.Get("user")
+	if !exists {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	userObj, ok := user.(*models.User)
+	if !ok {
+		ServerError(c, "用户类型断言失败")
+		return
+	}
+
 	// 获取上传的文件
 	file, header, err := c.Request.FormFile("image")
 	if err != nil {
@@ -30,50 +48,53 @@ func (h *UploadHandler) UploadImage(c *gin.Context) {
 	}
 	defer file.Close()
 
-	// 验证文件类型
-	contentType := header.Header.Get("Content-Type")
-	if !strings.HasPrefix(contentType, "image/") {
-		BadRequest(c, "只能上传图片文件")
-		return
-	}
-
 	// 验证文件大小 (5MB)
 	if header.Size > 5*1024*1024 {
 		BadRequest(c, "图片大小不能超过5MB")
 		return
 	}
 
-	// 创建上传目录
-	uploadDir := "./static/uploads"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		ServerError(c, "创建上传目录失败: "+err.Error())
+	// 校验、处理并保存图片
+	attachment, err := h.attachmentService.UploadImage(c.Request.Context(), userObj, header.Filename, file)
+	if err != nil {
+		switch {
+		case errors.Is(err, imaging.ErrUnsupportedFormat):
+			BadRequest(c, "只能上传JPEG、PNG或GIF格式的图片")
+		case errors.Is(err, imaging.ErrTooLarge):
+			BadRequest(c, "图片尺寸过大")
+		case errors.Is(err, service.ErrQuotaExceeded):
+			Fail(c, http.StatusRequestEntityTooLarge, "存储空间不足，请删除不需要的附件后重试")
+		default:
+			ServerError(c, "保存文件失败: "+err.Error())
+		}
 		return
 	}
 
-	// 生成唯一文件名
-	ext := filepath.Ext(header.Filename)
-	filename := fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), ext)
-	filepath := filepath.Join(uploadDir, filename)
+	Success(c, gin.H{
+		"id":           attachment.ID,
+		"url":          attachment.URL,
+		"variants":     attachment.VariantURLs,
+		"filename":     header.Filename,
+		"size":         attachment.Size,
+		"content_type": attachment.ContentType,
+		"width":        attachment.Width,
+		"height":       attachment.Height,
+	})
+}
 
-	// 创建目标文件
-	dst, err := os.Create(filepath)
-	if err != nil {
-		ServerError(c, "创建文件失败: "+err.Error())
+// Usage 获取当前用户的附件存储用量
+// 返回已使用字节数、配额（0 表示不限制）和附件数量
+func (h *UploadHandler) Usage(c *gin.Context) {
+	userObj, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
 		return
 	}
-	defer dst.Close()
 
-	// 复制文件内容
-	if _, err := io.Copy(dst, file); err != nil {
-		ServerError(c, "保存文件失败: "+err.Error())
+	usage, err := h.attachmentService.GetUsage(userObj)
+	if err != nil {
+		ServerError(c, "获取存储用量失败: "+err.Error())
 		return
 	}
-
-	// 返回文件URL
-	fileURL := fmt.Sprintf("/static/uploads/%s", filename)
-	Success(c, gin.H{
-		"url":      fileURL,
-		"filename": header.Filename,
-		"size":     header.Size,
-	})
+	Success(c, usage)
 }
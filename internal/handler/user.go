@@ -1,24 +1,32 @@
 package handler
 
 import (
+	"errors"
 	"feedback-system/internal/models"
 	"feedback-system/internal/service"
+	"feedback-system/pkg/password"
+	"net/http"
 	"strconv"
-
-	// "net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 // UserHandler 用户处理程序
 type UserHandler struct {
-	userService service.UserService
+	userService    service.UserService
+	accountService service.AccountService
+	profileService service.ProfileService
+	orgService     service.OrganizationService
 }
 
 // NewUserHandler 创建用户处理程序实例
-func NewUserHandler(userService service.UserService) *UserHandler {
+func NewUserHandler(userService service.UserService, accountService service.AccountService, profileService service.ProfileService, orgService service.OrganizationService) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:    userService,
+		accountService: accountService,
+		profileService: profileService,
+		orgService:     orgService,
 	}
 }
 
@@ -26,16 +34,41 @@ func NewUserHandler(userService service.UserService) *UserHandler {
 // 前后端对接说明：
 // - 这些路由对应前端 CONFIG.ENDPOINTS.USER 中定义的端点
 // - 前端通过 HttpUtils.post() 和 HttpUtils.get() 调用这些接口
-func (h *UserHandler) RegisterRoutes(router *gin.RouterGroup) {
+// - router 上注册无需登录的接口，authRouter 已挂载认证中间件，注册需要登录的接口
+func (h *UserHandler) RegisterRoutes(router, authRouter *gin.RouterGroup) {
 	userGroup := router.Group("/user")
+	authGroup := authRouter.Group("/user")
 	{
 		// POST /api/user/register ← 前端：user.js, merchant.js, admin.js 注册功能
 		userGroup.POST("/register", h.Register)
 		// POST /api/user/login ← 前端：user.js handleLogin(), merchant.js handleLogin(), admin.js handleLogin()
 		userGroup.POST("/login", h.Login)
+		// POST /api/user/login/2fa ← 前端：TwoFactorUtils.complete() 两步验证登录的第二步
+		userGroup.POST("/login/2fa", h.LoginTwoFactor)
+		// POST /api/user/login/2fa/setup ← 前端：TwoFactorUtils.complete() 强制两步验证的账号首次登录时绑定验证器
+		userGroup.POST("/login/2fa/setup", h.SetupTwoFactorForLogin)
+		// POST /api/user/2fa/* ← 已登录用户绑定、关闭两步验证和重新生成恢复码
+		authGroup.POST("/2fa/setup", h.SetupTwoFactor)
+		authGroup.POST("/2fa/enable", h.EnableTwoFactor)
+		authGroup.POST("/2fa/disable", h.DisableTwoFactor)
+		authGroup.POST("/2fa/recovery-codes", h.RegenerateRecoveryCodes)
+		// POST /api/user/refresh ← 前端：HttpUtils.refreshToken() 访问令牌过期时自动调用
+		userGroup.POST("/refresh", h.Refresh)
+		// POST /api/user/logout ← 前端：handleLogout() 注销当前会话
+		authGroup.POST("/logout", h.Logout)
+		// POST /api/user/logout-all ← 注销当前用户的所有会话（所有设备退出登录）
+		authGroup.POST("/logout-all", h.LogoutAll)
+		// POST /api/user/password ← 前端：PasswordUtils.ensureChanged() 修改密码，需要修改密码的账号登录后只能调用此类接口
+		authGroup.POST("/password", h.ChangePassword)
+		// GET /api/user/sessions ← 查看当前用户已登录的设备
+		authGroup.GET("/sessions", h.ListSessions)
+		// DELETE /api/user/sessions/:id ← 注销指定设备上的会话
+		authGroup.DELETE("/sessions/:id", h.RevokeSession)
 		// GET /api/user/me ← 前端：checkLoginStatus() 验证token有效性
-		userGroup.GET("/me", h.GetCurrentUser)
-		// GET /api/user/merchants ← 前端：user.js 创建反馈时获取商家列表
+		authGroup.GET("/me", h.GetCurrentUser)
+		// PUT /api/user/me ← 修改个人资料（显示名称、联系方式、头像、语言、时区）
+		authGroup.PUT("/me", h.UpdateProfile)
+		// GET /api/user/merchants ← 前端：user.js 创建反馈时获取商家列表（商家组织）
 		userGroup.GET("/merchants", h.GetMerchants)
 		// GET /api/user/info ← 前端：获取用户详细信息（包括联系方式）
 		userGroup.GET("/info", h.GetUserInfo)
@@ -43,6 +76,9 @@ func (h *UserHandler) RegisterRoutes(router *gin.RouterGroup) {
 }
 
 // Register 用户注册
+// 前后端对接说明：
+// - 前端调用：register.js handleRegister()，请求数据：{username, password, contact?, email?, user_type, invite_code?}
+// - 只能注册普通用户和商家；商家填写有效邀请码时直接启用，否则按 MERCHANT_SIGNUP 配置注册为待审核（status=2）或要求邀请码
 func (h *UserHandler) Register(c *gin.Context) {
 	var req models.UserRegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -50,16 +86,10 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// 验证用户类型
-	if req.UserType != 1 && req.UserType != 2 && req.UserType != 3 {
-		BadRequest(c, "无效的用户类型")
-		return
-	}
-
 	// 注册用户
-	user, err := h.userService.Register(&req)
+	user, err := h.accountService.Register(&req)
 	if err != nil {
-		BadRequest(c, "注册失败: "+err.Error())
+		registerFailed(c, err)
 		return
 	}
 
@@ -73,8 +103,12 @@ func (h *UserHandler) Register(c *gin.Context) {
 // 前后端对接说明：
 // - 前端调用：HttpUtils.post(CONFIG.ENDPOINTS.USER.LOGIN, {username, password, user_type})
 // - 请求数据：{username: string, password: string, user_type: number}
-// - 响应数据：{code: 200, message: "success", data: {user: User对象, token: string}}
+// - 响应数据：{code: 200, message: "success", data: {user: User对象, token: string, refresh_token: string, expires_in: number}}
 // - 用户类型：1=用户, 2=商家, 3=管理员
+// - token 为短期访问令牌，过期后使用 refresh_token 调用 /api/user/refresh 换取新令牌
+// - 启用两步验证的账号返回 {two_factor_required: true, mfa_token}，不返回令牌，需调用 /api/user/login/2fa 完成登录
+// - 连续登录失败后需要在 captcha 字段中提交验证码（响应 403，data.captcha_required 为 true）
+// - 失败次数过多时账号或IP被临时锁定（响应 429，Retry-After 头为解锁前的秒数）
 func (h *UserHandler) Login(c *gin.Context) {
 	var req models.UserLoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -89,9 +123,9 @@ func (h *UserHandler) Login(c *gin.Context) {
 	}
 
 	// 登录用户
-	response, err := h.userService.Login(&req)
+	response, err := h.userService.Login(&req, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
-		Unauthorized(c, "登录失败: "+err.Error())
+		loginFailed(c, err)
 		return
 	}
 
@@ -101,6 +135,175 @@ func (h *UserHandler) Login(c *gin.Context) {
 	Success(c, response)
 }
 
+// loginFailed 登录失败响应
+func loginFailed(c *gin.Context, err error) {
+	var locked *service.LoginLockedError
+	switch {
+	case errors.As(err, &locked):
+		seconds := int64((locked.RetryAfter + time.Second - 1) / time.Second)
+		c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+		Fail(c, http.StatusTooManyRequests, "登录失败次数过多，请"+strconv.FormatInt(seconds, 10)+"秒后再试")
+	case errors.Is(err, service.ErrCaptchaRequired):
+		c.JSON(http.StatusForbidden, Response{Code: http.StatusForbidden, Message: "请输入验证码", Data: gin.H{"captcha_required": true}})
+	case errors.Is(err, service.ErrCaptchaInvalid):
+		c.JSON(http.StatusForbidden, Response{Code: http.StatusForbidden, Message: "验证码错误", Data: gin.H{"captcha_required": true}})
+	case errors.Is(err, service.ErrInvalidCredentials):
+		Unauthorized(c, "登录失败: "+err.Error())
+	case errors.Is(err, service.ErrAccountPending):
+		Forbidden(c, "账号正在等待管理员审核")
+	case errors.Is(err, service.ErrAccountSuspended):
+		Forbidden(c, "账号已被停用")
+	case errors.Is(err, service.ErrInvalidMFAToken):
+		Unauthorized(c, "两步验证已过期，请重新登录")
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		Unauthorized(c, "验证码错误")
+	default:
+		ServerError(c, "登录失败: "+err.Error())
+	}
+}
+
+// Refresh 刷新访问令牌
+// 前后端对接说明：
+// - 前端调用：HttpUtils.refreshToken()，请求数据：{refresh_token: string}
+// - 响应数据：{code: 200, message: "success", data: {token, refresh_token, expires_in}}
+// - 刷新令牌只能使用一次，前端必须保存响应中新的 refresh_token
+func (h *UserHandler) Refresh(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	tokens, err := h.userService.Refresh(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) {
+			Unauthorized(c, "刷新令牌无效或已过期，请重新登录")
+			return
+		}
+		ServerError(c, "刷新令牌失败: "+err.Error())
+		return
+	}
+
+	Success(c, tokens)
+}
+
+// Logout 注销当前会话
+// 当前会话的访问令牌和刷新令牌立即失效，该会话的WebSocket连接会被断开
+func (h *UserHandler) Logout(c *gin.Context) {
+	session, ok := currentSession(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	if err := h.userService.Logout(session.ID); err != nil {
+		ServerError(c, "退出登录失败: "+err.Error())
+		return
+	}
+
+	Success(c, nil)
+}
+
+// LogoutAll 注销当前用户的所有会话
+func (h *UserHandler) LogoutAll(c *gin.Context) {
+	userObj, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	if err := h.userService.LogoutAll(userObj.ID, userObj.UserType); err != nil {
+		ServerError(c, "退出登录失败: "+err.Error())
+		return
+	}
+
+	Success(c, nil)
+}
+
+// ChangePassword 修改密码
+// 请求数据：{old_password: string, new_password: string}
+// 新密码需符合密码策略；修改成功后当前会话保持登录，其他设备上的会话被注销
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	userObj, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+	session, ok := currentSession(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	if err := h.userService.ChangePassword(userObj, session.ID, req.OldPassword, req.NewPassword); err != nil {
+		var invalid *password.ValidationError
+		switch {
+		case errors.As(err, &invalid):
+			BadRequest(c, invalid.Message)
+		case errors.Is(err, service.ErrInvalidCredentials):
+			BadRequest(c, "原密码错误")
+		case errors.Is(err, service.ErrPasswordUnchanged):
+			BadRequest(c, "新密码不能与原密码相同")
+		default:
+			ServerError(c, "修改密码失败: "+err.Error())
+		}
+		return
+	}
+
+	Success(c, nil)
+}
+
+// ListSessions 获取当前用户的登录会话列表
+// 响应数据：[{id, device, ip, user_agent, created_at, last_used_at, expires_at, current}]，current 标记发起请求的会话
+func (h *UserHandler) ListSessions(c *gin.Context) {
+	userObj, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	sessions, err := h.userService.ListSessions(userObj.ID, userObj.UserType)
+	if err != nil {
+		ServerError(c, "获取会话列表失败: "+err.Error())
+		return
+	}
+
+	if current, ok := currentSession(c); ok {
+		for _, session := range sessions {
+			session.Current = session.ID == current.ID
+		}
+	}
+
+	Success(c, sessions)
+}
+
+// RevokeSession 注销指定会话
+// 被注销设备的令牌立即失效，其WebSocket连接会被断开；注销当前会话等同于退出登录
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	userObj, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	if err := h.userService.RevokeSession(userObj.ID, userObj.UserType, c.Param("id")); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			NotFound(c, "会话不存在")
+			return
+		}
+		ServerError(c, "注销会话失败: "+err.Error())
+		return
+	}
+
+	Success(c, nil)
+}
+
 // GetCurrentUser 获取当前用户信息
 // 前后端对接说明：
 // - 前端调用：HttpUtils.get(CONFIG.ENDPOINTS.USER.CURRENT) 在checkLoginStatus()中
@@ -124,24 +327,62 @@ func (h *UserHandler) GetCurrentUser(c *gin.Context) {
 
 	// 隐藏密码
 	userObj.Password = ""
+	h.profileService.SignAvatar(userObj)
 
 	Success(c, userObj)
 }
 
-// GetMerchants 获取商家列表
-func (h *UserHandler) GetMerchants(c *gin.Context) {
-	merchants, err := h.userService.GetMerchants()
+// UpdateProfile 修改个人资料
+// 前后端对接说明：
+// - 请求数据：{display_name?, contact?, avatar_id?, language?, timezone?}，只修改请求中出现的字段
+// - contact 只接受手机号或邮箱；avatar_id 为上传接口返回的图片附件ID，0 表示清除头像
+// - 响应数据为修改后的用户对象，avatar_url 为头像签名链接
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		Unauthorized(c, "未认证")
+		return
+	}
+
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	user, err := h.profileService.UpdateProfile(user, &req)
 	if err != nil {
-		ServerError(c, "获取商家列表失败: "+err.Error())
+		switch {
+		case errors.Is(err, service.ErrInvalidContact):
+			BadRequest(c, "联系方式必须是手机号或邮箱")
+		case errors.Is(err, service.ErrInvalidLanguage):
+			BadRequest(c, "无效的语言代码")
+		case errors.Is(err, service.ErrInvalidTimezone):
+			BadRequest(c, "无效的时区")
+		case errors.Is(err, service.ErrInvalidAvatar):
+			BadRequest(c, "头像必须是本人上传的图片")
+		default:
+			ServerError(c, "修改个人资料失败: "+err.Error())
+		}
 		return
 	}
 
 	// 隐藏密码
-	for _, merchant := range merchants {
-		merchant.Password = ""
+	user.Password = ""
+
+	Success(c, user)
+}
+
+// GetMerchants 获取商家列表
+// 反馈以商家组织为目标，响应数据：[{id: 组织ID, name: 组织名称}]
+func (h *UserHandler) GetMerchants(c *gin.Context) {
+	orgs, err := h.orgService.ListActive()
+	if err != nil {
+		ServerError(c, "获取商家列表失败: "+err.Error())
+		return
 	}
 
-	Success(c, merchants)
+	Success(c, orgs)
 }
 
 // GetUserInfo 获取用户信息（包括联系方式）
@@ -1,28 +1,49 @@
 package handler
 
 import (
+	"feedback-system/internal/service"
 	"feedback-system/pkg/ws"
+
 	"github.com/gin-gonic/gin"
 )
 
 // WSHandler WebSocket处理程序
 type WSHandler struct {
-	wsHandler *ws.WSHandler
+	wsHandler   *ws.WSHandler
+	userService service.UserService
 }
 
 // NewWSHandler 创建WebSocket处理程序
-func NewWSHandler(wsHandler *ws.WSHandler) *WSHandler {
+func NewWSHandler(wsHandler *ws.WSHandler, userService service.UserService) *WSHandler {
 	return &WSHandler{
-		wsHandler: wsHandler,
+		wsHandler:   wsHandler,
+		userService: userService,
 	}
 }
 
 // HandleConnection 处理WebSocket连接
+// 浏览器的 WebSocket API 无法设置请求头，访问令牌通过 token 查询参数传递
 func (h *WSHandler) HandleConnection(c *gin.Context) {
-	h.wsHandler.HandleConnection(c)
+	token := c.Query("token")
+	if token == "" {
+		Unauthorized(c, "未提供认证令牌")
+		return
+	}
+
+	user, session, err := h.userService.ValidateToken(token)
+	if err != nil {
+		Unauthorized(c, "认证令牌无效或已过期")
+		return
+	}
+	if user.MustChangePassword {
+		Forbidden(c, "请先修改密码")
+		return
+	}
+
+	h.wsHandler.HandleConnection(c, user.ID, user.UserType, user.Name(), session.ID)
 }
 
 // RegisterRoutes 注册路由
 func (h *WSHandler) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("/ws", h.HandleConnection) // WebSocket连接
-}
\ No newline at end of file
+}
@@ -1,22 +1,55 @@
 package middleware
 
 import (
+	"feedback-system/internal/consts"
 	"feedback-system/internal/handler"
 	"feedback-system/internal/models"
 	"feedback-system/internal/service"
+	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+// passwordChangeRoutes 需要修改密码的账号可以访问的接口，键为 请求方法+空格+路由
+var passwordChangeRoutes = map[string]bool{
+	"POST /api/user/password":   true,
+	"GET /api/user/me":          true,
+	"POST /api/user/logout":     true,
+	"POST /api/user/logout-all": true,
+}
+
+// apiKeyRoutes 可以使用API密钥访问的接口及所需的权限范围，拥有其中任意一项权限即可访问
+// 值为 nil 表示任何有效的API密钥都可以访问，不在表中的接口不能使用API密钥访问
+var apiKeyRoutes = map[string][]string{
+	"GET /api/user/me":                       nil,
+	"POST /api/feedback":                     {consts.ScopeFeedbackWrite},
+	"GET /api/feedback/:id":                  {consts.ScopeFeedbackRead},
+	"GET /api/feedback/target":               {consts.ScopeFeedbackRead},
+	"GET /api/feedback/:id/history":          {consts.ScopeFeedbackRead},
+	"PUT /api/feedback/:id/status":           {consts.ScopeFeedbackWrite},
+	"POST /api/feedback/:id/escalate":        {consts.ScopeFeedbackWrite},
+	"POST /api/message":                      {consts.ScopeMessageWrite},
+	"GET /api/message/feedback/:feedback_id": {consts.ScopeFeedbackRead},
+	"POST /api/upload/image":                 {consts.ScopeFeedbackWrite, consts.ScopeMessageWrite},
+}
+
 // AuthMiddleware 认证中间件
-func AuthMiddleware(userService service.UserService) gin.HandlerFunc {
+// 需要修改密码的账号（如被管理员重置密码或新建的员工账号）只能访问 passwordChangeRoutes 中的接口
+// 未携带 Authorization 请求头时可以通过 X-API-Key 请求头使用商家的API密钥认证，只能访问 apiKeyRoutes 中的接口
+func AuthMiddleware(userService service.UserService, apiKeyService service.APIKeyService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if secret := c.GetHeader("X-API-Key"); secret != "" && c.GetHeader("Authorization") == "" {
+			authenticateAPIKey(c, apiKeyService, secret)
+			return
+		}
+
 		// 从请求头中获取Authorization
 		authorization := c.GetHeader("Authorization")
 		if authorization == "" {
 			handler.Unauthorized(c, "未提供认证令牌")
+			c.Abort()
 			return
 		}
 
@@ -24,20 +57,27 @@ func AuthMiddleware(userService service.UserService) gin.HandlerFunc {
 		parts := strings.Split(authorization, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
 			handler.Unauthorized(c, "无效的认证令牌格式")
+			c.Abort()
 			return
 		}
 
 		tokenString := parts[1]
 
 		// 验证令牌
-		user, err := userService.ValidateToken(tokenString)
+		user, session, err := userService.ValidateToken(tokenString)
 		if err != nil {
 			handler.Unauthorized(c, "认证令牌无效或已过期")
+			c.Abort()
+			return
+		}
+
+		if abortIfMustChangePassword(c, user) {
 			return
 		}
 
-		// 将用户信息存储在上下文中
+		// 将用户信息和会话存储在上下文中
 		c.Set("user", user)
+		c.Set("session", session)
 
 		// 设置用户ID和类型到请求头中，供后续处理程序使用
 		c.Request.Header.Set("X-User-ID", strconv.FormatUint(user.ID, 10))
@@ -47,6 +87,82 @@ func AuthMiddleware(userService service.UserService) gin.HandlerFunc {
 	}
 }
 
+// authenticateAPIKey 使用API密钥认证并检查权限范围
+func authenticateAPIKey(c *gin.Context, apiKeyService service.APIKeyService, secret string) {
+	user, key, err := apiKeyService.Authenticate(secret, c.ClientIP())
+	if err != nil {
+		handler.Unauthorized(c, "API密钥无效或已过期")
+		c.Abort()
+		return
+	}
+
+	if abortIfMustChangePassword(c, user) {
+		return
+	}
+
+	scopes, allowed := apiKeyRoutes[c.Request.Method+" "+c.FullPath()]
+	if !allowed {
+		handler.Forbidden(c, "API密钥不能访问此接口")
+		c.Abort()
+		return
+	}
+	if len(scopes) > 0 {
+		granted := false
+		for _, scope := range scopes {
+			if key.HasScope(scope) {
+				granted = true
+				break
+			}
+		}
+		if !granted {
+			c.AbortWithStatusJSON(http.StatusForbidden, handler.Response{
+				Code:    http.StatusForbidden,
+				Message: "API密钥没有访问此接口的权限",
+				Data:    gin.H{"required_scopes": scopes},
+			})
+			return
+		}
+	}
+
+	// API密钥请求没有登录会话
+	c.Set("user", user)
+	c.Set("api_key", key)
+
+	c.Request.Header.Set("X-User-ID", strconv.FormatUint(user.ID, 10))
+	c.Request.Header.Set("X-User-Type", strconv.FormatUint(uint64(user.UserType), 10))
+
+	c.Next()
+}
+
+// abortIfMustChangePassword 需要修改密码的账号访问 passwordChangeRoutes 以外的接口时返回 403
+func abortIfMustChangePassword(c *gin.Context, user *models.User) bool {
+	if !user.MustChangePassword || passwordChangeRoutes[c.Request.Method+" "+c.FullPath()] {
+		return false
+	}
+	c.AbortWithStatusJSON(http.StatusForbidden, handler.Response{
+		Code:    http.StatusForbidden,
+		Message: "请先修改密码",
+		Data:    gin.H{"must_change_password": true},
+	})
+	return true
+}
+
+// OptionalAuthMiddleware 可选认证中间件
+// 携带有效令牌时设置用户信息，未携带或令牌无效时直接放行，由处理程序自行决定是否需要认证
+func OptionalAuthMiddleware(userService service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if user, session, err := userService.ValidateToken(parts[1]); err == nil && !user.MustChangePassword {
+				c.Set("user", user)
+				c.Set("session", session)
+			}
+		}
+
+		c.Next()
+	}
+}
+
 // RoleMiddleware 角色授权中间件
 func RoleMiddleware(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -54,6 +170,7 @@ func RoleMiddleware(roles ...string) gin.HandlerFunc {
 		user, exists := c.Get("user")
 		if !exists {
 			handler.Unauthorized(c, "未认证")
+			c.Abort()
 			return
 		}
 
@@ -61,6 +178,7 @@ func RoleMiddleware(roles ...string) gin.HandlerFunc {
 		userObj, ok := user.(*models.User)
 		if !ok {
 			handler.ServerError(c, "用户类型断言失败")
+			c.Abort()
 			return
 		}
 
@@ -86,6 +204,7 @@ func RoleMiddleware(roles ...string) gin.HandlerFunc {
 
 		if !hasRole {
 			handler.Forbidden(c, "没有权限访问此资源")
+			c.Abort()
 			return
 		}
 
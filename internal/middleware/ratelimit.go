@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"feedback-system/internal/handler"
+	"feedback-system/internal/models"
+	"feedback-system/pkg/ratelimit"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitMiddleware API限流中间件
+// 已认证的请求按用户计数，未认证的请求按客户端IP计数，超过限制时返回 429。
+// 响应头 X-RateLimit-Limit / X-RateLimit-Remaining / X-RateLimit-Reset 返回当前窗口的配额和重置秒数；
+// 计数存储不可用时放行请求
+func RateLimitMiddleware(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := limiter.Allow(c.Request.Context(), rateLimitKey(c))
+		if err != nil {
+			log.Printf("API限流计数失败: %v", err)
+			c.Next()
+			return
+		}
+
+		reset := strconv.FormatInt(int64((result.Reset+time.Second-1)/time.Second), 10)
+		c.Header("X-RateLimit-Limit", strconv.FormatInt(result.Limit, 10))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+		c.Header("X-RateLimit-Reset", reset)
+
+		if !result.Allowed {
+			c.Header("Retry-After", reset)
+			handler.Fail(c, http.StatusTooManyRequests, "请求过于频繁，请稍后再试")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitKey 限流计数键
+func rateLimitKey(c *gin.Context) string {
+	if user, exists := c.Get("user"); exists {
+		if userObj, ok := user.(*models.User); ok {
+			return "user:" + strconv.FormatUint(uint64(userObj.UserType), 10) + ":" + strconv.FormatUint(userObj.ID, 10)
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
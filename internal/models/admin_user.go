@@ -0,0 +1,42 @@
+package models
+
+// AdminUserQuery 管理员查询用户列表的条件，均为可选
+type AdminUserQuery struct {
+	UserType uint8  `form:"user_type" binding:"omitempty,oneof=1 2 3"`
+	Status   uint8  `form:"status" binding:"omitempty,oneof=1 2 3"`
+	Keyword  string `form:"keyword" binding:"max=100"` // 模糊匹配用户名、联系方式和邮箱
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// AdminUserListResponse 用户列表分页响应
+type AdminUserListResponse struct {
+	Items    []*User `json:"items"`
+	Total    int64   `json:"total"`
+	Page     int     `json:"page"`
+	PageSize int     `json:"page_size"`
+}
+
+// AdminUpdateUserRequest 管理员修改用户资料请求，只修改请求中出现的字段
+type AdminUpdateUserRequest struct {
+	Contact *string `json:"contact" binding:"omitempty,max=100"`
+	Email   *string `json:"email" binding:"omitempty,max=255"`
+	// 技能（反馈分类），用于按技能自动分配
+	Skills *[]string `json:"skills" binding:"omitempty,max=20,dive,max=50"`
+}
+
+// AdminSuspendUserRequest 停用账号请求
+type AdminSuspendUserRequest struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// AdminResetPasswordRequest 管理员重置用户密码请求
+// 不指定新密码时生成随机临时密码；重置后用户下次登录必须修改密码
+type AdminResetPasswordRequest struct {
+	NewPassword string `json:"new_password"`
+}
+
+// AdminResetPasswordResponse 管理员重置用户密码响应，临时密码只返回这一次
+type AdminResetPasswordResponse struct {
+	TemporaryPassword string `json:"temporary_password,omitempty"`
+}
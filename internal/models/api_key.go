@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// APIKey 商家的API密钥，用于商家后端直接调用接口（服务器对服务器集成）
+// 请求时通过 X-API-Key 请求头携带，权限等同于所属商家但受 Scopes 限制；数据库中只保存密钥的哈希值
+type APIKey struct {
+	ID         uint64     `gorm:"primaryKey;autoIncrement;not null" json:"id"`
+	UserID     uint64     `gorm:"not null;index;comment:所属商家ID" json:"user_id"`
+	Name       string     `gorm:"type:varchar(100);not null;default:'';comment:名称，如接入的系统名称" json:"name"`
+	Prefix     string     `gorm:"type:varchar(16);not null;comment:密钥前几位，用于识别密钥" json:"prefix"`
+	KeyHash    string     `gorm:"type:char(64);not null;uniqueIndex;comment:密钥的SHA-256" json:"-"`
+	Scopes     []string   `gorm:"type:json;serializer:json;comment:权限范围" json:"scopes"`
+	ExpiresAt  *time.Time `gorm:"default:null;comment:过期时间，为空表示不过期" json:"expires_at"`
+	LastUsedAt *time.Time `gorm:"default:null" json:"last_used_at"`
+	LastUsedIP string     `gorm:"type:varchar(45);not null;default:''" json:"last_used_ip"`
+	RevokedAt  *time.Time `gorm:"default:null" json:"revoked_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Active 密钥是否仍然有效
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// HasScope 密钥是否拥有指定权限
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPIKeyRequest 创建API密钥请求
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1"` // 默认不过期
+}
+
+// RotateAPIKeyRequest 轮换API密钥请求
+type RotateAPIKeyRequest struct {
+	GraceHours int `json:"grace_hours" binding:"omitempty,min=0,max=720"` // 旧密钥继续有效的小时数，默认立即失效
+}
+
+// APIKeySecretResponse 创建或轮换API密钥的响应，完整密钥只返回这一次
+type APIKeySecretResponse struct {
+	Key    *APIKey `json:"key"`
+	Secret string  `json:"secret"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// AssignmentSetting 团队的自动分配设置，团队为商家组织（TargetType=1, TargetID=组织ID）或管理员团队（TargetType=2, TargetID=0）
+// 新反馈创建时先按 SkillMatch、OnlineFirst 缩小候选人范围，再按 Strategy 选出处理人
+type AssignmentSetting struct {
+	ID             uint64    `gorm:"primaryKey;autoIncrement;not null" json:"-"`
+	TargetType     uint8     `gorm:"not null;uniqueIndex:idx_assignment_team;comment:团队类型：1-商家组织 2-管理员" json:"target_type"`
+	TargetID       uint64    `gorm:"not null;uniqueIndex:idx_assignment_team;comment:商家组织ID，管理员团队为0" json:"target_id"`
+	Strategy       string    `gorm:"type:varchar(20);not null;default:'manual';comment:分配策略：manual round_robin least_loaded" json:"strategy"`
+	OnlineFirst    bool      `gorm:"not null;default:false;comment:优先分配给在线成员" json:"online_first"`
+	SkillMatch     bool      `gorm:"not null;default:false;comment:优先分配给技能与反馈分类匹配的成员" json:"skill_match"`
+	LastAssigneeID uint64    `gorm:"not null;default:0;comment:轮流分配时上一次分配的成员ID" json:"-"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// UpdateAssignmentSettingRequest 修改自动分配设置请求
+type UpdateAssignmentSettingRequest struct {
+	Strategy    string `json:"strategy" binding:"required,oneof=manual round_robin least_loaded"`
+	OnlineFirst bool   `json:"online_first"`
+	SkillMatch  bool   `json:"skill_match"`
+}
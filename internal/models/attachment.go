@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Attachment 附件（上传的图片等文件）
+// 文件本身保存在存储驱动中，这里只记录元信息和归属关系，用于下载时的权限校验
+type Attachment struct {
+	ID           uint64    `gorm:"primaryKey;autoIncrement;not null" json:"id"`
+	StorageKey   string    `gorm:"type:varchar(255);not null;index:idx_attachment_storage_key;comment:存储对象键，内容相同的附件共用同一对象" json:"-"`
+	ContentHash  string    `gorm:"type:char(64);not null;default:'';index;comment:上传内容的SHA-256，用于去重" json:"-"`
+	Filename     string    `gorm:"type:varchar(255);not null;comment:原始文件名" json:"filename"`
+	ContentType  string    `gorm:"type:varchar(100);not null" json:"content_type"`
+	Size         int64     `gorm:"not null" json:"size"`
+	Width        int       `gorm:"not null;default:0;comment:图片宽度" json:"width"`
+	Height       int       `gorm:"not null;default:0;comment:图片高度" json:"height"`
+	UploaderID   uint64    `gorm:"not null;index:idx_uploader" json:"uploader_id"`
+	UploaderType uint8     `gorm:"not null;index:idx_uploader;comment:上传者类型：1-用户 2-商家 3-管理员" json:"uploader_type"`
+	FeedbackID   *uint64   `gorm:"index;default:null;comment:所属反馈ID，发送到反馈或消息中后绑定" json:"feedback_id"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	// 缩略图
+	Variants []AttachmentVariant `gorm:"foreignKey:AttachmentID" json:"-"`
+
+	// 非数据库字段，用于API返回
+	URL         string            `gorm:"-" json:"url"`
+	VariantURLs map[string]string `gorm:"-" json:"variants,omitempty"`
+}
+
+// AttachmentVariant 图片附件的缩略图
+type AttachmentVariant struct {
+	ID           uint64 `gorm:"primaryKey;autoIncrement;not null" json:"id"`
+	AttachmentID uint64 `gorm:"not null;uniqueIndex:idx_attachment_variant" json:"attachment_id"`
+	Name         string `gorm:"type:varchar(32);not null;uniqueIndex:idx_attachment_variant;comment:规格名称：thumb/small/medium" json:"name"`
+	StorageKey   string `gorm:"type:varchar(255);not null;index:idx_attachment_variant_storage_key" json:"-"`
+	ContentType  string `gorm:"type:varchar(100);not null" json:"content_type"`
+	Size         int64  `gorm:"not null" json:"size"`
+	Width        int    `gorm:"not null" json:"width"`
+	Height       int    `gorm:"not null" json:"height"`
+}
+
+// AttachmentReference 附件引用记录
+// 附件被反馈或消息引用时记录，没有任何引用的附件超过保留期后由定时任务清理
+type AttachmentReference struct {
+	ID           uint64    `gorm:"primaryKey;autoIncrement;not null" json:"id"`
+	AttachmentID uint64    `gorm:"not null;uniqueIndex:idx_attachment_ref" json:"attachment_id"`
+	RefType      uint8     `gorm:"not null;uniqueIndex:idx_attachment_ref;comment:引用类型：1-反馈 2-消息" json:"ref_type"`
+	RefID        uint64    `gorm:"not null;uniqueIndex:idx_attachment_ref;comment:反馈ID或消息ID" json:"ref_id"`
+	FeedbackID   uint64    `gorm:"not null;index;comment:所属反馈ID，删除反馈时一并删除引用" json:"feedback_id"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// StorageUsage 用户附件存储用量
+type StorageUsage struct {
+	Used  int64 `json:"used"`  // 已使用字节数（按上传文件大小计算，不含缩略图）
+	Quota int64 `json:"quota"` // 配额字节数，0 表示不限制
+	Count int64 `json:"count"` // 附件数量
+}
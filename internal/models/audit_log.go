@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// AuditLog 审计日志
+// 记录安全相关的操作，如登录失败、账号锁定等；匿名操作的 ActorID 为 0
+type AuditLog struct {
+	ID         uint64    `gorm:"primaryKey;autoIncrement;not null" json:"id"`
+	ActorID    uint64    `gorm:"not null;default:0;index:idx_audit_actor;comment:操作者ID，未登录时为0" json:"actor_id"`
+	ActorType  uint8     `gorm:"not null;default:0;index:idx_audit_actor;comment:操作者类型：1-用户 2-商家 3-管理员" json:"actor_type"`
+	Action     string    `gorm:"type:varchar(64);not null;index;comment:操作类型，如 login.failed" json:"action"`
+	TargetType string    `gorm:"type:varchar(32);not null;default:'';index:idx_audit_target;comment:操作对象类型" json:"target_type"`
+	TargetID   string    `gorm:"type:varchar(100);not null;default:'';index:idx_audit_target;comment:操作对象标识" json:"target_id"`
+	IP         string    `gorm:"type:varchar(45);not null;default:''" json:"ip"`
+	UserAgent  string    `gorm:"type:varchar(255);not null;default:''" json:"user_agent"`
+	Detail     string    `gorm:"type:text;comment:操作详情（JSON）" json:"detail"`
+	CreatedAt  time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
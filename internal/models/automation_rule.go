@@ -0,0 +1,91 @@
+package models
+
+import "time"
+
+// AutomationRule 自动化规则：触发事件发生时，反馈满足条件则依次执行动作
+// 规则按 Position、ID 顺序执行，StopProcessing 为 true 的规则匹配后不再执行后续规则
+type AutomationRule struct {
+	ID             uint64          `gorm:"primaryKey;autoIncrement;not null" json:"id"`
+	Name           string          `gorm:"type:varchar(100);not null;default:''" json:"name"`
+	Trigger        string          `gorm:"type:varchar(32);not null;index;comment:触发事件" json:"trigger"`
+	Conditions     []RuleCondition `gorm:"type:json;serializer:json;comment:条件" json:"conditions"`
+	MatchAny       bool            `gorm:"not null;default:false;comment:任一条件满足即匹配，默认需要全部满足" json:"match_any"`
+	Actions        []RuleAction    `gorm:"type:json;serializer:json;comment:动作" json:"actions"`
+	Position       int             `gorm:"not null;default:0;comment:执行顺序，越小越先执行" json:"position"`
+	StopProcessing bool            `gorm:"not null;default:false;comment:匹配后不再执行后续规则" json:"stop_processing"`
+	Enabled        bool            `gorm:"not null;default:true" json:"enabled"`
+	CreatedAt      time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// RuleCondition 规则条件：字段 运算符 值，in/not_in/contains_any 的值为数组
+type RuleCondition struct {
+	Field    string      `json:"field"`
+	Operator string      `json:"operator"`
+	Value    interface{} `json:"value"`
+}
+
+// RuleAction 规则动作，按 Type 使用对应的字段
+// set_status: Status；assign: AssigneeID（0 表示按团队的自动分配设置分配）；add_tag: Tag；
+// reply: Content；notify: Recipients、Content；webhook: URL、Secret（不为空时请求带 HMAC-SHA256 签名）
+type RuleAction struct {
+	Type       string `json:"type"`
+	Status     uint8  `json:"status,omitempty"`
+	AssigneeID uint64 `json:"assignee_id,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+	Content    string `json:"content,omitempty"`
+	Recipients string `json:"recipients,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Secret     string `json:"secret,omitempty"`
+}
+
+// AutomationRuleRequest 创建或修改自动化规则请求
+type AutomationRuleRequest struct {
+	Name           string          `json:"name" binding:"required,max=100"`
+	Trigger        string          `json:"trigger" binding:"required"`
+	Conditions     []RuleCondition `json:"conditions"`
+	MatchAny       bool            `json:"match_any"`
+	Actions        []RuleAction    `json:"actions" binding:"required,min=1"`
+	Position       int             `json:"position"`
+	StopProcessing bool            `json:"stop_processing"`
+	Enabled        *bool           `json:"enabled"` // 为空时启用
+}
+
+// AutomationTestRequest 测试自动化规则请求：按反馈的当前数据模拟触发事件，只计算匹配结果，不执行动作
+// Rule 不为空时测试未保存的规则，RuleID 不为 0 时测试指定规则，都为空时测试该触发事件的所有已启用规则
+type AutomationTestRequest struct {
+	FeedbackID uint64                 `json:"feedback_id" binding:"required"`
+	Trigger    string                 `json:"trigger" binding:"required"`
+	RuleID     uint64                 `json:"rule_id"`
+	Rule       *AutomationRuleRequest `json:"rule"`
+	// message_received 的触发消息，发送者为空时按反馈创建者发送
+	Message *AutomationTestMessage `json:"message"`
+	// status_changed 修改前后的状态，修改后的状态为空时使用反馈的当前状态
+	OldStatus uint8 `json:"old_status"`
+	NewStatus uint8 `json:"new_status"`
+	// sla_breached 超时的时限类型
+	SLAKind string `json:"sla_kind"`
+}
+
+// AutomationTestMessage 测试时模拟的触发消息
+type AutomationTestMessage struct {
+	Content    string `json:"content"`
+	SenderID   uint64 `json:"sender_id"`
+	SenderType uint8  `json:"sender_type"`
+}
+
+// AutomationTestResult 单条规则的测试结果
+type AutomationTestResult struct {
+	RuleID     uint64                `json:"rule_id"`
+	Name       string                `json:"name"`
+	Matched    bool                  `json:"matched"`
+	Conditions []RuleConditionResult `json:"conditions"`
+	Actions    []RuleAction          `json:"actions"` // 匹配时会执行的动作
+}
+
+// RuleConditionResult 单个条件的测试结果，Actual 为字段的实际值
+type RuleConditionResult struct {
+	RuleCondition
+	Actual  interface{} `json:"actual"`
+	Matched bool        `json:"matched"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// BusinessCalendar 工作日历：某个时区的每周营业时间和节假日，用于按营业时间计算 SLA 截止时间等
+// Hours 的键为星期（mon、tue、wed、thu、fri、sat、sun），值为当天的营业时段，如 ["09:00-12:00", "13:00-18:00"]
+type BusinessCalendar struct {
+	ID        uint64              `gorm:"primaryKey;autoIncrement;not null" json:"id"`
+	Name      string              `gorm:"type:varchar(100);not null;default:''" json:"name"`
+	Timezone  string              `gorm:"type:varchar(64);not null;comment:IANA时区名称" json:"timezone"`
+	Hours     map[string][]string `gorm:"type:json;serializer:json;comment:每周营业时间" json:"hours"`
+	Holidays  []string            `gorm:"type:json;serializer:json;comment:节假日（YYYY-MM-DD）" json:"holidays"`
+	CreatedAt time.Time           `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time           `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// CalendarAssignment 团队使用的工作日历，团队为商家组织（TargetType=1, TargetID=组织ID）或管理员团队（TargetType=2, TargetID=0）
+// 没有指定日历的团队按全天营业计算
+type CalendarAssignment struct {
+	ID         uint64    `gorm:"primaryKey;autoIncrement;not null" json:"-"`
+	TargetType uint8     `gorm:"not null;uniqueIndex:idx_calendar_team;comment:团队类型：1-商家组织 2-管理员" json:"target_type"`
+	TargetID   uint64    `gorm:"not null;uniqueIndex:idx_calendar_team;comment:商家组织ID，管理员团队为0" json:"target_id"`
+	CalendarID uint64    `gorm:"not null;index;comment:工作日历ID" json:"calendar_id"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BusinessCalendarRequest 创建或修改工作日历请求
+type BusinessCalendarRequest struct {
+	Name     string              `json:"name" binding:"required,max=100"`
+	Timezone string              `json:"timezone" binding:"required,max=64"`
+	Hours    map[string][]string `json:"hours" binding:"required"`
+	Holidays []string            `json:"holidays" binding:"max=1000"`
+}
+
+// AssignCalendarRequest 为团队指定工作日历请求，CalendarID 为 0 表示取消指定（全天营业）
+type AssignCalendarRequest struct {
+	TargetType uint8  `json:"target_type" binding:"required,oneof=1 2"`
+	TargetID   uint64 `json:"target_id"`
+	CalendarID uint64 `json:"calendar_id"`
+}
+
+// TeamCalendarResponse 团队当前使用的工作日历，Calendar 为空表示全天营业
+type TeamCalendarResponse struct {
+	Calendar *BusinessCalendar `json:"calendar"`
+	Open     bool              `json:"open"`      // 当前是否在营业时间内
+	NextOpen time.Time         `json:"next_open"` // 下一个营业时间，当前营业时为当前时间
+}
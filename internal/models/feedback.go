@@ -3,20 +3,52 @@ package models
 import "time"
 
 type Feedback struct {
-	ID          uint64    `gorm:"primaryKey;autoIncrement;not null" json:"id"`
-	Title       string    `gorm:"type:varchar(255);not null" json:"title"`
-	Content     string    `gorm:"type:text;not null" json:"content"`
-	Contact     string    `gorm:"type:varchar(100);default:null;comment:联系方式（手机/邮箱）" json:"contact"`
-	CreatorID   uint64    `gorm:"not null" json:"creator_id"`
-	CreatorType uint8     `gorm:"not null;comment:创建者类型：1-用户 2-商家 3-管理员" json:"creator_type"`
-	CreatorName string    `gorm:"-" json:"creator_name"` // 不存储到数据库，仅用于API返回
-	TargetID    uint64    `gorm:"not null;comment:目标ID（商家/管理员ID）" json:"target_id"`
-	TargetType  uint8     `gorm:"not null;comment:目标类型：1-商家 2-管理员" json:"target_type"`
-	TargetName  string    `gorm:"-" json:"target_name"` // 不存储到数据库，仅用于API返回
-	Status      uint8     `gorm:"not null;default:1;comment:状态：1-open 2-in_progress 3-resolved" json:"status"`
-	Images      []string  `gorm:"type:json;default:null;comment:初始反馈图片数组（JSON格式存储URL数组）" json:"images,omitempty"`
-	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID           uint64 `gorm:"primaryKey;autoIncrement;not null" json:"id"`
+	Title        string `gorm:"type:varchar(255);not null" json:"title"`
+	Content      string `gorm:"type:text;not null" json:"content"`
+	Contact      string `gorm:"type:varchar(100);default:null;comment:联系方式（手机/邮箱）" json:"contact"`
+	Category     string `gorm:"type:varchar(50);not null;default:'';comment:分类，按技能自动分配时使用" json:"category"`
+	CreatorID    uint64 `gorm:"not null" json:"creator_id"`
+	CreatorType  uint8  `gorm:"not null;comment:创建者类型：1-用户 2-商家 3-管理员" json:"creator_type"`
+	CreatorName  string `gorm:"-" json:"creator_name"` // 不存储到数据库，仅用于API返回
+	TargetID     uint64 `gorm:"not null;comment:目标ID（商家/管理员ID）" json:"target_id"`
+	TargetType   uint8  `gorm:"not null;comment:目标类型：1-商家 2-管理员" json:"target_type"`
+	TargetName   string `gorm:"-" json:"target_name"` // 不存储到数据库，仅用于API返回
+	Status       uint8  `gorm:"not null;default:1;comment:状态：1-open 2-in_progress 3-resolved 4-closed" json:"status"`
+	AssigneeID   uint64 `gorm:"not null;default:0;index;comment:处理人ID（商家员工或管理员），0 表示未分配" json:"assignee_id"`
+	AssigneeName string `gorm:"-" json:"assignee_name,omitempty"` // 不存储到数据库，仅用于API返回
+	// 升级到平台管理员的时间，为空表示未升级；升级后管理员团队成为参与方，商家仍然留在会话中
+	EscalatedAt *time.Time `gorm:"default:null;index;comment:升级到平台管理员的时间" json:"escalated_at"`
+	// SLA：创建时按目标方适用的 SLA 策略计算截止时间，SLAPolicyID 为 0 表示没有适用的策略
+	// 等待客户回复和已解决期间解决计时暂停，恢复时按剩余时间顺延解决截止时间
+	SLAPolicyID           uint64     `gorm:"not null;default:0;comment:SLA策略ID" json:"sla_policy_id"`
+	FirstResponseDue      *time.Time `gorm:"default:null;comment:首次回复截止时间" json:"first_response_due"`
+	FirstRespondedAt      *time.Time `gorm:"default:null;comment:目标方首次回复时间" json:"first_responded_at"`
+	ResolutionDue         *time.Time `gorm:"default:null;comment:解决截止时间" json:"resolution_due"`
+	SLAPausedAt           *time.Time `gorm:"default:null;comment:解决计时暂停的时间" json:"sla_paused_at"`
+	FirstResponseWarned   bool       `gorm:"not null;default:false;comment:已发送首次回复超时预警" json:"-"`
+	FirstResponseBreached bool       `gorm:"not null;default:false;comment:首次回复超时" json:"first_response_breached"`
+	ResolutionWarned      bool       `gorm:"not null;default:false;comment:已发送解决超时预警" json:"-"`
+	ResolutionBreached    bool       `gorm:"not null;default:false;comment:解决超时" json:"resolution_breached"`
+	// 自动关闭和跟进提醒：状态变更时间、双方最近一次回复时间和最近一次提醒时间，每段未回复期间只提醒一次
+	StatusChangedAt     *time.Time `gorm:"default:null;comment:最近一次状态变更时间" json:"status_changed_at"`
+	ClosedAt            *time.Time `gorm:"default:null;comment:关闭时间" json:"closed_at"`
+	LastTargetReplyAt   *time.Time `gorm:"default:null;comment:目标方最近一次回复时间" json:"last_target_reply_at"`
+	LastCreatorReplyAt  *time.Time `gorm:"default:null;comment:反馈创建者最近一次回复时间" json:"last_creator_reply_at"`
+	TargetFollowedUpAt  *time.Time `gorm:"default:null;comment:最近一次提醒目标方回复的时间" json:"-"`
+	CreatorFollowedUpAt *time.Time `gorm:"default:null;comment:最近一次询问反馈创建者的时间" json:"-"`
+	Images              []string   `gorm:"type:json;serializer:json;default:null;comment:初始反馈图片数组（JSON格式存储URL数组）" json:"images,omitempty"`
+	Tags                []string   `gorm:"type:json;serializer:json;default:null;comment:标签，由自动化规则添加" json:"tags"`
+	CreatedAt           time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// FeedbackFilter 反馈列表的筛选条件，均为可选
+type FeedbackFilter struct {
+	// 处理人ID，0 表示未分配；为 nil 时不按处理人筛选
+	Assignee *uint64
+	// SLA 状态：breached 已超时，warning 即将超时（已预警、未超时且未解决）；为空时不按 SLA 筛选
+	SLA string
 }
 
 // 数据库映射需求：
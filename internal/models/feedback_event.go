@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// FeedbackEvent 反馈的处理记录，如分配处理人、修改状态
+type FeedbackEvent struct {
+	ID         uint64    `gorm:"primaryKey;autoIncrement;not null" json:"id"`
+	FeedbackID uint64    `gorm:"not null;index;comment:反馈ID" json:"feedback_id"`
+	Type       string    `gorm:"type:varchar(32);not null;comment:记录类型" json:"type"`
+	ActorID    uint64    `gorm:"not null;default:0;comment:操作者ID，0 表示系统自动操作" json:"actor_id"`
+	ActorType  uint8     `gorm:"not null;default:0;comment:操作者类型" json:"actor_type"`
+	ActorName  string    `gorm:"-" json:"actor_name,omitempty"` // 不存储到数据库，仅用于API返回
+	Detail     string    `gorm:"type:text;comment:详情（JSON）" json:"detail"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// EscalateFeedbackRequest 升级反馈请求
+type EscalateFeedbackRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// AssignFeedbackRequest 分配处理人请求
+type AssignFeedbackRequest struct {
+	AssigneeID uint64 `json:"assignee_id" binding:"required"`
+}
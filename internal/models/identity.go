@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// UserIdentity 外部身份，记录单点登录（OIDC）账号与本地用户的绑定关系
+// 同一个 IdP 账号（issuer + subject）在每种用户类型下只能绑定一个本地用户，一个本地用户可以绑定多个 IdP 账号
+type UserIdentity struct {
+	ID          uint64    `gorm:"primaryKey;autoIncrement;not null" json:"id"`
+	UserID      uint64    `gorm:"not null;index" json:"user_id"`
+	UserType    uint8     `gorm:"not null;uniqueIndex:idx_identity_subject" json:"user_type"`
+	Issuer      string    `gorm:"type:varchar(191);not null;uniqueIndex:idx_identity_subject;comment:IdP 的 issuer 地址" json:"issuer"`
+	Subject     string    `gorm:"type:varchar(191);not null;uniqueIndex:idx_identity_subject;comment:IdP 中的用户标识 sub" json:"subject"`
+	Email       string    `gorm:"type:varchar(255);not null;default:''" json:"email"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	LastLoginAt time.Time `gorm:"not null" json:"last_login_at"`
+}
+
+// OIDCExchangeRequest 单点登录回调后换取令牌的请求
+type OIDCExchangeRequest struct {
+	Token      string `json:"token" binding:"required"` // 回调跳转地址中的 sso_token 参数
+	DeviceName string `json:"device_name"`
+}
+
+// OIDCConfigResponse 单点登录配置，前端据此决定是否显示单点登录按钮
+type OIDCConfigResponse struct {
+	Enabled      bool   `json:"enabled"`
+	ProviderName string `json:"provider_name,omitempty"`
+	Merchant     bool   `json:"merchant"` // 商家是否可以单点登录
+	Admin        bool   `json:"admin"`    // 管理员是否可以单点登录
+}
+
+// OIDCLinkResponse 绑定外部账号的跳转地址
+type OIDCLinkResponse struct {
+	URL string `json:"url"`
+}
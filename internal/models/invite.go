@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// MerchantInvite 商家邀请码
+// 管理员创建邀请码后发给商家，商家注册时填写邀请码即可直接启用账号；数据库中只保存邀请码的哈希值
+type MerchantInvite struct {
+	ID        uint64     `gorm:"primaryKey;autoIncrement;not null" json:"id"`
+	CodeHash  string     `gorm:"type:char(64);not null;uniqueIndex;comment:邀请码的SHA-256" json:"-"`
+	Note      string     `gorm:"type:varchar(255);not null;default:'';comment:备注，如邀请的商家名称" json:"note"`
+	MaxUses   int        `gorm:"not null;default:1;comment:最多可使用次数" json:"max_uses"`
+	UsedCount int        `gorm:"not null;default:0" json:"used_count"`
+	CreatedBy uint64     `gorm:"not null;comment:创建邀请码的管理员ID" json:"created_by"`
+	ExpiresAt *time.Time `gorm:"default:null;comment:过期时间，为空表示不过期" json:"expires_at"`
+	RevokedAt *time.Time `gorm:"default:null" json:"revoked_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// CreateInviteRequest 创建邀请码请求
+type CreateInviteRequest struct {
+	Note           string `json:"note" binding:"max=255"`
+	MaxUses        int    `json:"max_uses" binding:"omitempty,min=1"`         // 默认 1
+	ExpiresInHours int    `json:"expires_in_hours" binding:"omitempty,min=1"` // 默认不过期
+}
+
+// CreateInviteResponse 创建邀请码响应，邀请码只返回这一次
+type CreateInviteResponse struct {
+	Invite *MerchantInvite `json:"invite"`
+	Code   string          `json:"code"`
+}
+
+// CreateAdminRequest 创建管理员请求
+type CreateAdminRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Contact  string `json:"contact"`
+	Email    string `json:"email" binding:"omitempty,email,max=255"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// JobLock 定时任务锁，多实例部署时保证同一任务同一时间只在一个实例上运行
+type JobLock struct {
+	Name        string     `gorm:"primaryKey;type:varchar(64);comment:任务名称"`
+	Owner       string     `gorm:"type:varchar(128);not null;default:'';comment:持有锁的实例"`
+	LockedUntil time.Time  `gorm:"not null;comment:锁的到期时间"`
+	LastSlot    *time.Time `gorm:"comment:最近一次按计划执行的计划时间，同一计划时间只执行一次"`
+}
+
+// JobRun 定时任务运行记录
+type JobRun struct {
+	ID         uint64     `gorm:"primaryKey;autoIncrement;not null" json:"id"`
+	Job        string     `gorm:"type:varchar(64);not null;index:idx_job_run_job;comment:任务名称" json:"job"`
+	Trigger    string     `gorm:"type:varchar(16);not null;comment:触发方式：schedule-按计划 manual-手动" json:"trigger"`
+	Instance   string     `gorm:"type:varchar(128);not null;default:'';comment:运行任务的实例" json:"instance"`
+	Status     string     `gorm:"type:varchar(16);not null;comment:状态：running-运行中 succeeded-成功 failed-失败" json:"status"`
+	Processed  int64      `gorm:"not null;default:0;comment:处理的数量" json:"processed"`
+	Error      string     `gorm:"type:text;comment:失败原因" json:"error,omitempty"`
+	StartedAt  time.Time  `gorm:"not null;index:idx_job_run_job;index" json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// JobStatus 定时任务的状态
+type JobStatus struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Schedule    string     `json:"schedule"`    // 执行计划，为空表示只能手动运行
+	NextRunAt   *time.Time `json:"next_run_at"` // 本实例下一次计划执行时间
+	LastRun     *JobRun    `json:"last_run"`    // 最近一次运行记录（可能在其他实例上运行）
+}
+
+// JobRunQuery 查询任务运行记录的分页条件
+type JobRunQuery struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// JobRunListResponse 任务运行记录分页响应
+type JobRunListResponse struct {
+	Items    []*JobRun `json:"items"`
+	Total    int64     `json:"total"`
+	Page     int       `json:"page"`
+	PageSize int       `json:"page_size"`
+}
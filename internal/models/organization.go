@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Organization 商家组织
+// 一个商家组织有多个员工账号（商家类型的用户，通过 User.OrgID 关联），用户发给商家的反馈以组织为目标（Feedback.TargetID 为组织ID）
+type Organization struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement;not null" json:"id"`
+	Name      string    `gorm:"type:varchar(100);not null;comment:组织名称，用户创建反馈时选择" json:"name"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// OrganizationResponse 组织详情，包含所有员工
+type OrganizationResponse struct {
+	Organization *Organization `json:"organization"`
+	Members      []*User       `json:"members"`
+}
+
+// UpdateOrganizationRequest 修改组织信息请求
+type UpdateOrganizationRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// CreateOrgMemberRequest 创建员工账号请求，员工首次登录后必须修改密码
+type CreateOrgMemberRequest struct {
+	Username    string `json:"username" binding:"required,max=100"`
+	Password    string `json:"password" binding:"required"`
+	DisplayName string `json:"display_name" binding:"max=50"`
+	Email       string `json:"email" binding:"omitempty,email,max=255"`
+	Role        string `json:"role" binding:"required,oneof=agent viewer"`
+}
+
+// UpdateOrgMemberRequest 修改员工角色和技能请求，只修改请求中出现的字段
+type UpdateOrgMemberRequest struct {
+	Role   string    `json:"role" binding:"omitempty,oneof=agent viewer"`
+	Skills *[]string `json:"skills" binding:"omitempty,max=20,dive,max=50"`
+}
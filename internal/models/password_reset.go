@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// PasswordResetToken 密码重置令牌
+// 令牌通过邮件发送，数据库中只保存哈希值；令牌只能使用一次，重新申请时旧令牌失效
+type PasswordResetToken struct {
+	ID        uint64     `gorm:"primaryKey;autoIncrement;not null" json:"id"`
+	UserID    uint64     `gorm:"not null;index" json:"user_id"`
+	TokenHash string     `gorm:"type:char(64);not null;uniqueIndex;comment:重置令牌的SHA-256" json:"-"`
+	IP        string     `gorm:"type:varchar(45);not null;default:'';comment:申请重置的IP" json:"ip"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `gorm:"default:null" json:"used_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ForgotPasswordRequest 忘记密码请求
+type ForgotPasswordRequest struct {
+	Username string `json:"username" binding:"required"`
+	UserType uint8  `json:"user_type" binding:"required"`
+}
+
+// ResetPasswordRequest 重置密码请求
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
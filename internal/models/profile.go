@@ -0,0 +1,10 @@
+package models
+
+// UpdateProfileRequest 修改个人资料请求，只修改请求中出现的字段
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name" binding:"omitempty,max=50"`
+	Contact     *string `json:"contact" binding:"omitempty,max=100"` // 手机号或邮箱，空字符串表示清除
+	AvatarID    *uint64 `json:"avatar_id"`                           // 通过上传接口上传的图片附件ID，0 表示清除头像
+	Language    *string `json:"language" binding:"omitempty,max=16"` // 如 zh-CN、en
+	Timezone    *string `json:"timezone" binding:"omitempty,max=64"` // IANA 时区名称，如 Asia/Shanghai
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// SLAPolicy SLA 策略，规定目标方首次回复和解决反馈的时限
+// TargetType=1 时 TargetID 为商家组织ID，0 表示所有商家的默认策略；TargetType=2 为管理员团队的策略，TargetID 固定为 0
+type SLAPolicy struct {
+	ID                   uint64    `gorm:"primaryKey;autoIncrement;not null" json:"id"`
+	Name                 string    `gorm:"type:varchar(100);not null;default:''" json:"name"`
+	TargetType           uint8     `gorm:"not null;uniqueIndex:idx_sla_target;comment:目标类型：1-商家 2-管理员" json:"target_type"`
+	TargetID             uint64    `gorm:"not null;uniqueIndex:idx_sla_target;comment:商家组织ID，0 表示该目标类型的默认策略" json:"target_id"`
+	FirstResponseMinutes int       `gorm:"not null;default:0;comment:首次回复时限（分钟），0 表示不考核" json:"first_response_minutes"`
+	ResolutionMinutes    int       `gorm:"not null;default:0;comment:解决时限（分钟），0 表示不考核" json:"resolution_minutes"`
+	WarningMinutes       int       `gorm:"not null;default:0;comment:截止前多少分钟发送预警，0 表示不预警" json:"warning_minutes"`
+	CreatedAt            time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// SLAPolicyRequest 创建或修改 SLA 策略请求
+type SLAPolicyRequest struct {
+	Name                 string `json:"name" binding:"required,max=100"`
+	TargetType           uint8  `json:"target_type" binding:"required,oneof=1 2"`
+	TargetID             uint64 `json:"target_id"`
+	FirstResponseMinutes int    `json:"first_response_minutes" binding:"min=0"`
+	ResolutionMinutes    int    `json:"resolution_minutes" binding:"min=0"`
+	WarningMinutes       int    `json:"warning_minutes" binding:"min=0"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// RecoveryCode 两步验证恢复码
+// 无法使用验证器应用时可用恢复码代替验证码登录，每个恢复码只能使用一次，数据库中只保存哈希值
+type RecoveryCode struct {
+	ID        uint64     `gorm:"primaryKey;autoIncrement;not null" json:"id"`
+	UserID    uint64     `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"type:char(64);not null;uniqueIndex;comment:恢复码的SHA-256" json:"-"`
+	UsedAt    *time.Time `gorm:"default:null" json:"used_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TwoFactorSetupResponse 两步验证绑定信息
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`      // Base32密钥，无法扫码时手动输入
+	OtpauthURL string `json:"otpauth_url"` // otpauth:// 绑定地址
+	QRCode     string `json:"qr_code"`     // 绑定地址的二维码（PNG data URI）
+}
+
+// TwoFactorCodeRequest 两步验证码请求
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorDisableRequest 关闭两步验证请求，需要同时验证密码和验证码
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// TwoFactorLoginRequest 两步验证登录请求
+type TwoFactorLoginRequest struct {
+	MFAToken   string `json:"mfa_token" binding:"required"`
+	Code       string `json:"code" binding:"required"` // 验证器应用中的6位验证码或恢复码
+	DeviceName string `json:"device_name"`
+}
+
+// MFATokenRequest 两步验证临时令牌请求
+type MFATokenRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// RecoveryCodesResponse 恢复码响应
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// UploadSession 断点续传上传会话（tus协议）
+// 上传完成前每次续传的数据作为一个分片保存在存储驱动中，多副本部署时任意实例都能继续上传
+// 完成后按顺序合并分片转交附件存储并记录附件ID
+type UploadSession struct {
+	ID           string     `gorm:"type:varchar(36);primaryKey" json:"id"`
+	OwnerID      uint64     `gorm:"not null;index:idx_owner" json:"owner_id"`
+	OwnerType    uint8      `gorm:"not null;index:idx_owner;comment:上传者类型：1-用户 2-商家 3-管理员" json:"owner_type"`
+	Length       int64      `gorm:"not null;comment:文件总大小" json:"length"`
+	Offset       int64      `gorm:"not null;default:0;comment:已接收字节数" json:"offset"`
+	Filename     string     `gorm:"type:varchar(255);not null" json:"filename"`
+	Metadata     string     `gorm:"type:text;comment:客户端提交的Upload-Metadata原文" json:"-"`
+	Parts        string     `gorm:"type:text;comment:已接收分片的存储对象键，按偏移量顺序以换行分隔" json:"-"`
+	AttachmentID *uint64    `gorm:"default:null;comment:上传完成后生成的附件ID" json:"attachment_id"`
+	ExpiresAt    time.Time  `gorm:"not null;index" json:"expires_at"`
+	CompletedAt  *time.Time `gorm:"default:null" json:"completed_at"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// PartKeys 已接收分片的存储对象键，按偏移量顺序排列
+func (s *UploadSession) PartKeys() []string {
+	return strings.Fields(s.Parts)
+}
@@ -10,28 +10,77 @@ type User struct {
 	Username  string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_username_type" json:"username"`
 	Password  string    `gorm:"type:varchar(255);not null" json:"password,omitempty"` // 在JSON序列化时省略密码字段
 	Contact   string    `gorm:"type:varchar(100);comment:联系方式" json:"contact"`
+	Email     string    `gorm:"type:varchar(255);not null;default:'';comment:邮箱，用于找回密码" json:"email"`
 	UserType  uint8     `gorm:"not null;comment:用户类型：1-用户 2-商家 3-管理员;uniqueIndex:idx_username_type" json:"user_type"`
+	Status    uint8     `gorm:"not null;default:1;index;comment:账号状态：1-正常 2-待审核 3-已停用" json:"status"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// 商家组织，只有商家账号属于组织；OrgID 为 0 表示不属于任何组织
+	OrgID   uint64 `gorm:"not null;default:0;index;comment:所属商家组织ID" json:"org_id"`
+	OrgRole string `gorm:"type:varchar(20);not null;default:'';comment:组织角色：owner agent viewer" json:"org_role"`
+	// 技能（反馈分类），团队开启按技能自动分配时优先分配给技能匹配的成员
+	Skills []string `gorm:"type:json;serializer:json;default:null;comment:技能" json:"skills,omitempty"`
+
+	// 需要修改密码，为 true 时登录会话只能访问修改密码等少数接口
+	MustChangePassword bool `gorm:"not null;default:false;comment:是否需要修改密码" json:"must_change_password"`
+
+	// 两步验证（TOTP）
+	TwoFactorEnabled bool   `gorm:"not null;default:false;comment:是否已启用两步验证" json:"two_factor_enabled"`
+	TOTPSecret       string `gorm:"type:varchar(64);not null;default:'';comment:TOTP密钥，未启用时为待确认的密钥" json:"-"`
+	TOTPLastStep     int64  `gorm:"not null;default:0;comment:最后一次使用的TOTP时间步，防止验证码重放" json:"-"`
+
+	// 个人资料
+	DisplayName string  `gorm:"type:varchar(50);not null;default:'';comment:显示名称，为空时显示用户名" json:"display_name"`
+	AvatarID    *uint64 `gorm:"default:null;comment:头像附件ID" json:"avatar_id"`
+	AvatarURL   string  `gorm:"-" json:"avatar_url,omitempty"` // 头像签名链接，不存储到数据库
+	Language    string  `gorm:"type:varchar(16);not null;default:'';comment:界面语言，如 zh-CN" json:"language"`
+	Timezone    string  `gorm:"type:varchar(64);not null;default:'';comment:时区，如 Asia/Shanghai" json:"timezone"`
+}
+
+// Name 对外显示的名称，设置了显示名称时使用显示名称，否则使用用户名
+func (u *User) Name() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	return u.Username
 }
 
 // UserLoginRequest 用户登录请求
 type UserLoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
-	UserType uint8  `json:"user_type" binding:"required"`
+	Username   string `json:"username" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+	UserType   uint8  `json:"user_type" binding:"required"`
+	DeviceName string `json:"device_name"` // 可选，客户端自定义的设备名称
+	Captcha    string `json:"captcha"`     // 验证码响应，登录失败次数较多时必填
 }
 
 // UserLoginResponse 用户登录响应
+// 账号启用了两步验证时不返回令牌，而是返回 mfa_token，客户端需再调用 /api/user/login/2fa 完成登录
 type UserLoginResponse struct {
-	User  User   `json:"user"`
-	Token string `json:"token"`
+	User         User   `json:"user"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"` // 访问令牌有效期（秒）
+
+	TwoFactorRequired      bool     `json:"two_factor_required,omitempty"`       // 需要输入两步验证码
+	TwoFactorSetupRequired bool     `json:"two_factor_setup_required,omitempty"` // 当前用户类型强制两步验证，需要先绑定验证器
+	MFAToken               string   `json:"mfa_token,omitempty"`                 // 两步验证临时令牌，几分钟内有效
+	RecoveryCodes          []string `json:"recovery_codes,omitempty"`            // 登录时完成绑定后返回的恢复码，只显示一次
+}
+
+// ChangePasswordRequest 修改密码请求
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
 }
 
 // UserRegisterRequest 用户注册请求
 type UserRegisterRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
-	Contact  string `json:"contact"`
-	UserType uint8  `json:"user_type" binding:"required"`
+	Username   string `json:"username" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+	Contact    string `json:"contact"`
+	Email      string `json:"email" binding:"omitempty,email,max=255"`
+	UserType   uint8  `json:"user_type" binding:"required"`
+	InviteCode string `json:"invite_code"` // 商家邀请码，填写后无需管理员审核
 }
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// UserSession 用户登录会话
+// 每次登录创建一个会话，访问令牌中携带会话ID（sid），会话被撤销后访问令牌立即失效。
+// 刷新令牌只保存哈希值，每次刷新都会轮换，上一个刷新令牌再次使用时视为泄露并撤销整个会话
+type UserSession struct {
+	ID                   string     `gorm:"type:varchar(36);primaryKey" json:"id"`
+	UserID               uint64     `gorm:"not null;index:idx_session_user" json:"user_id"`
+	UserType             uint8      `gorm:"not null;index:idx_session_user;comment:用户类型：1-用户 2-商家 3-管理员" json:"user_type"`
+	RefreshTokenHash     string     `gorm:"type:char(64);not null;uniqueIndex;comment:当前刷新令牌的SHA-256" json:"-"`
+	PrevRefreshTokenHash string     `gorm:"type:char(64);not null;default:'';index;comment:上一个刷新令牌的SHA-256，用于检测重放" json:"-"`
+	Device               string     `gorm:"type:varchar(100);not null;default:'';comment:设备名称，客户端未提供时根据User-Agent推断" json:"device"`
+	IP                   string     `gorm:"type:varchar(45);not null;default:'';comment:登录IP" json:"ip"`
+	UserAgent            string     `gorm:"type:varchar(255);not null;default:''" json:"user_agent"`
+	ExpiresAt            time.Time  `gorm:"not null;index;comment:刷新令牌过期时间" json:"expires_at"`
+	LastUsedAt           time.Time  `gorm:"not null" json:"last_used_at"`
+	RevokedAt            *time.Time `gorm:"default:null" json:"revoked_at,omitempty"`
+	CreatedAt            time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// 非数据库字段，用于API返回
+	Current bool `gorm:"-" json:"current"` // 是否为发起请求的会话
+}
+
+// Active 会话是否仍然有效
+func (s *UserSession) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// RefreshTokenRequest 刷新令牌请求
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenResponse 令牌响应
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // 访问令牌有效期（秒）
+}
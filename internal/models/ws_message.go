@@ -55,3 +55,46 @@ type ReadData struct {
 type FeedbackDeleteData struct {
 	FeedbackID uint64 `json:"feedback_id"`
 }
+
+// EscalationData 反馈升级数据
+type EscalationData struct {
+	FeedbackID  uint64    `json:"feedback_id"`
+	Title       string    `json:"title"`
+	Reason      string    `json:"reason"`
+	EscalatedAt time.Time `json:"escalated_at"`
+}
+
+// SLAEventData SLA 预警和超时事件数据
+type SLAEventData struct {
+	FeedbackID uint64    `json:"feedback_id"`
+	Title      string    `json:"title"`
+	Kind       string    `json:"kind"` // first_response 或 resolution
+	DueAt      time.Time `json:"due_at"`
+}
+
+// AssignmentData 处理人变更数据，AssigneeID 为 0 表示取消分配
+type AssignmentData struct {
+	FeedbackID         uint64 `json:"feedback_id"`
+	Title              string `json:"title"`
+	Action             string `json:"action"`
+	AssigneeID         uint64 `json:"assignee_id"`
+	AssigneeName       string `json:"assignee_name"`
+	PreviousAssigneeID uint64 `json:"previous_assignee_id"`
+}
+
+// FollowUpData 跟进提醒事件数据
+type FollowUpData struct {
+	FeedbackID uint64    `json:"feedback_id"`
+	Title      string    `json:"title"`
+	Kind       string    `json:"kind"`       // target-提醒目标方回复 creator-询问反馈创建者是否仍需要帮助
+	IdleSince  time.Time `json:"idle_since"` // 对方最近一次回复或状态变更的时间
+}
+
+// NotificationData 自动化规则的通知事件数据
+type NotificationData struct {
+	FeedbackID uint64 `json:"feedback_id"`
+	Title      string `json:"title"`
+	Content    string `json:"content"`
+	RuleID     uint64 `json:"rule_id"`
+	RuleName   string `json:"rule_name"`
+}
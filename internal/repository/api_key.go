@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"feedback-system/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository API密钥仓库接口
+type APIKeyRepository interface {
+	Create(key *models.APIKey) error
+	GetByID(id, userID uint64) (*models.APIKey, error)
+	FindByHash(hash string) (*models.APIKey, error)
+	ListByUser(userID uint64) ([]*models.APIKey, error)
+	CountActive(userID uint64, now time.Time) (int64, error)
+	Touch(id uint64, ip string, now time.Time) error
+	Expire(id uint64, expiresAt time.Time) error
+	Revoke(id, userID uint64) (bool, error)
+	DeleteByUser(userID uint64) error
+}
+
+// apiKeyRepository API密钥仓库实现
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository 创建API密钥仓库实例
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+// Create 创建API密钥
+func (r *apiKeyRepository) Create(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+// GetByID 获取用户的指定API密钥
+func (r *apiKeyRepository) GetByID(id, userID uint64) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(key).Error; err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// FindByHash 根据密钥哈希获取API密钥
+func (r *apiKeyRepository) FindByHash(hash string) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	if err := r.db.Where("key_hash = ?", hash).First(key).Error; err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ListByUser 获取用户的所有API密钥，最新创建的在前
+func (r *apiKeyRepository) ListByUser(userID uint64) (keys []*models.APIKey, err error) {
+	return keys, r.db.Where("user_id = ?", userID).Order("id DESC").Find(&keys).Error
+}
+
+// CountActive 统计用户未撤销且未过期的API密钥数量
+func (r *apiKeyRepository) CountActive(userID uint64, now time.Time) (count int64, err error) {
+	return count, r.db.Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, now).
+		Count(&count).Error
+}
+
+// Touch 更新最后使用时间和IP
+func (r *apiKeyRepository) Touch(id uint64, ip string, now time.Time) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_used_at": now,
+		"last_used_ip": ip,
+	}).Error
+}
+
+// Expire 设置过期时间，轮换密钥时旧密钥在宽限期后过期
+func (r *apiKeyRepository) Expire(id uint64, expiresAt time.Time) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ?", id).Update("expires_at", expiresAt).Error
+}
+
+// Revoke 撤销用户的API密钥，密钥不存在或已撤销时返回 false
+func (r *apiKeyRepository) Revoke(id, userID uint64) (bool, error) {
+	result := r.db.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteByUser 删除用户的所有API密钥
+func (r *apiKeyRepository) DeleteByUser(userID uint64) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.APIKey{}).Error
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"feedback-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AssignmentSettingRepository 自动分配设置仓库接口
+type AssignmentSettingRepository interface {
+	Get(targetType uint8, targetID uint64) (*models.AssignmentSetting, error)
+	Save(setting *models.AssignmentSetting) error
+	AdvanceLastAssignee(id, oldAssigneeID, assigneeID uint64) (bool, error)
+}
+
+// assignmentSettingRepository 自动分配设置仓库实现
+type assignmentSettingRepository struct {
+	db *gorm.DB
+}
+
+// NewAssignmentSettingRepository 创建自动分配设置仓库实例
+func NewAssignmentSettingRepository(db *gorm.DB) AssignmentSettingRepository {
+	return &assignmentSettingRepository{db: db}
+}
+
+// Get 获取团队的自动分配设置，未设置时返回 gorm.ErrRecordNotFound
+func (r *assignmentSettingRepository) Get(targetType uint8, targetID uint64) (*models.AssignmentSetting, error) {
+	var setting models.AssignmentSetting
+	err := r.db.Where("target_type = ? AND target_id = ?", targetType, targetID).First(&setting).Error
+	if err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+// Save 保存团队的自动分配设置，已存在时覆盖策略和开关，保留轮流分配的位置
+func (r *assignmentSettingRepository) Save(setting *models.AssignmentSetting) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "target_type"}, {Name: "target_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"strategy", "online_first", "skill_match", "updated_at"}),
+	}).Create(setting).Error
+}
+
+// AdvanceLastAssignee 记录轮流分配最后分配的成员，只有位置仍为 oldAssigneeID 时才修改
+// 位置已被并发的分配修改时返回 false
+func (r *assignmentSettingRepository) AdvanceLastAssignee(id, oldAssigneeID, assigneeID uint64) (bool, error) {
+	result := r.db.Model(&models.AssignmentSetting{}).
+		Where("id = ? AND last_assignee_id = ?", id, oldAssigneeID).
+		Update("last_assignee_id", assigneeID)
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AttachmentRepository 附件仓库接口
+type AttachmentRepository interface {
+	Create(attachment *models.Attachment) error
+	FindByID(id uint64) (*models.Attachment, error)
+	FindByIDs(ids []uint64) ([]*models.Attachment, error)
+	FindByStorageKey(key string) (*models.Attachment, error)
+	BindFeedback(ids []uint64, feedbackID uint64, uploaderID uint64, uploaderType uint8) error
+	FindByContentHash(hash string) (*models.Attachment, error)
+	FindByUploaderAndHash(uploaderID uint64, uploaderType uint8, hash string) (*models.Attachment, error)
+	GetUsage(uploaderID uint64, uploaderType uint8) (used int64, count int64, err error)
+	CountByStorageKey(key string) (int64, error)
+	Delete(attachment *models.Attachment) error
+	AddReferences(refs []*models.AttachmentReference) error
+	DeleteReferencesByFeedback(feedbackID uint64) error
+	DeleteReferencesByRef(refType uint8, refID uint64) error
+	FindUnreferenced(before time.Time, afterID uint64, limit int) ([]*models.Attachment, error)
+	FindContentReferences(attachmentID uint64, path string) ([]*models.AttachmentReference, error)
+}
+
+// attachmentRepository 附件仓库实现
+type attachmentRepository struct {
+	db *gorm.DB
+}
+
+// NewAttachmentRepository 创建附件仓库实例
+func NewAttachmentRepository(db *gorm.DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+// Create 创建附件记录（同时创建缩略图记录）
+func (r *attachmentRepository) Create(attachment *models.Attachment) error {
+	return r.db.Create(attachment).Error
+}
+
+// FindByID 根据ID获取附件
+func (r *attachmentRepository) FindByID(id uint64) (*models.Attachment, error) {
+	attachment := &models.Attachment{}
+	if err := r.db.Preload("Variants").First(attachment, id).Error; err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// FindByIDs 批量获取附件
+func (r *attachmentRepository) FindByIDs(ids []uint64) (attachments []*models.Attachment, err error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return attachments, r.db.Preload("Variants").Where("id IN ?", ids).Find(&attachments).Error
+}
+
+// FindByStorageKey 根据存储对象键获取附件
+func (r *attachmentRepository) FindByStorageKey(key string) (*models.Attachment, error) {
+	attachment := &models.Attachment{}
+	if err := r.db.Preload("Variants").Where("storage_key = ?", key).First(attachment).Error; err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// BindFeedback 将上传者本人尚未绑定的附件绑定到反馈
+// 他人的附件或已绑定到其他反馈的附件保持不变，避免通过引用他人附件获取访问权限
+func (r *attachmentRepository) BindFeedback(ids []uint64, feedbackID uint64, uploaderID uint64, uploaderType uint8) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.Attachment{}).
+		Where("id IN ? AND feedback_id IS NULL AND uploader_id = ? AND uploader_type = ?", ids, uploaderID, uploaderType).
+		Update("feedback_id", feedbackID).Error
+}
+
+// FindByContentHash 根据内容哈希获取任一附件
+func (r *attachmentRepository) FindByContentHash(hash string) (*models.Attachment, error) {
+	attachment := &models.Attachment{}
+	if err := r.db.Preload("Variants").Where("content_hash = ?", hash).Order("id").First(attachment).Error; err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// FindByUploaderAndHash 根据内容哈希获取上传者本人尚未绑定到反馈的附件
+func (r *attachmentRepository) FindByUploaderAndHash(uploaderID uint64, uploaderType uint8, hash string) (*models.Attachment, error) {
+	attachment := &models.Attachment{}
+	if err := r.db.Preload("Variants").
+		Where("uploader_id = ? AND uploader_type = ? AND content_hash = ? AND feedback_id IS NULL", uploaderID, uploaderType, hash).
+		Order("id").First(attachment).Error; err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// GetUsage 统计上传者的附件总大小和数量
+func (r *attachmentRepository) GetUsage(uploaderID uint64, uploaderType uint8) (used int64, count int64, err error) {
+	var result struct {
+		Used  int64
+		Count int64
+	}
+	err = r.db.Model(&models.Attachment{}).
+		Select("COALESCE(SUM(size), 0) AS used, COUNT(*) AS count").
+		Where("uploader_id = ? AND uploader_type = ?", uploaderID, uploaderType).
+		Scan(&result).Error
+	return result.Used, result.Count, err
+}
+
+// CountByStorageKey 统计仍在使用某个存储对象的附件和缩略图数量
+func (r *attachmentRepository) CountByStorageKey(key string) (int64, error) {
+	var attachments, variants int64
+	if err := r.db.Model(&models.Attachment{}).Where("storage_key = ?", key).Count(&attachments).Error; err != nil {
+		return 0, err
+	}
+	if err := r.db.Model(&models.AttachmentVariant{}).Where("storage_key = ?", key).Count(&variants).Error; err != nil {
+		return 0, err
+	}
+	return attachments + variants, nil
+}
+
+// Delete 删除附件记录及其缩略图、引用记录
+func (r *attachmentRepository) Delete(attachment *models.Attachment) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("attachment_id = ?", attachment.ID).Delete(&models.AttachmentVariant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("attachment_id = ?", attachment.ID).Delete(&models.AttachmentReference{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Attachment{}, attachment.ID).Error
+	})
+}
+
+// AddReferences 添加附件引用记录，已存在的引用忽略
+func (r *attachmentRepository) AddReferences(refs []*models.AttachmentReference) error {
+	if len(refs) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&refs).Error
+}
+
+// DeleteReferencesByFeedback 删除反馈及其消息对附件的引用
+func (r *attachmentRepository) DeleteReferencesByFeedback(feedbackID uint64) error {
+	return r.db.Where("feedback_id = ?", feedbackID).Delete(&models.AttachmentReference{}).Error
+}
+
+// DeleteReferencesByRef 删除指定引用方对附件的引用
+func (r *attachmentRepository) DeleteReferencesByRef(refType uint8, refID uint64) error {
+	return r.db.Where("ref_type = ? AND ref_id = ?", refType, refID).Delete(&models.AttachmentReference{}).Error
+}
+
+// FindUnreferenced 按ID顺序获取指定时间之前创建、且没有引用记录的附件
+func (r *attachmentRepository) FindUnreferenced(before time.Time, afterID uint64, limit int) (attachments []*models.Attachment, err error) {
+	return attachments, r.db.Preload("Variants").
+		Where("created_at < ? AND id > ?", before, afterID).
+		Where("NOT EXISTS (SELECT 1 FROM attachment_references WHERE attachment_references.attachment_id = attachments.id)").
+		Order("id").Limit(limit).
+		Find(&attachments).Error
+}
+
+// FindContentReferences 在反馈图片和图片消息内容中查找对附件的引用
+// 用于清理前的二次确认，并补全引用记录缺失的历史数据（如迁移命令导入的附件）
+func (r *attachmentRepository) FindContentReferences(attachmentID uint64, path string) ([]*models.AttachmentReference, error) {
+	var refs []*models.AttachmentReference
+
+	var feedbackIDs []uint64
+	if err := r.db.Model(&models.Feedback{}).
+		Where("images IS NOT NULL AND JSON_CONTAINS(images, JSON_QUOTE(?))", path).
+		Pluck("id", &feedbackIDs).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range feedbackIDs {
+		refs = append(refs, &models.AttachmentReference{
+			AttachmentID: attachmentID,
+			RefType:      consts.AttachmentRefFeedback,
+			RefID:        id,
+			FeedbackID:   id,
+		})
+	}
+
+	// 图片消息内容为单个地址，多图片消息内容为JSON格式的地址数组
+	var messages []*models.FeedbackMessage
+	if err := r.db.Select("id", "feedback_id").
+		Where("(content_type = ? AND content = ?) OR (content_type = ? AND content LIKE ?)",
+			consts.ImageMessage, path, consts.ImagesMessage, "%\""+path+"\"%").
+		Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range messages {
+		refs = append(refs, &models.AttachmentReference{
+			AttachmentID: attachmentID,
+			RefType:      consts.AttachmentRefMessage,
+			RefID:        m.ID,
+			FeedbackID:   m.FeedbackID,
+		})
+	}
+
+	return refs, nil
+}
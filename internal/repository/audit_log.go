@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"feedback-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AuditLogRepository 审计日志仓库接口
+type AuditLogRepository interface {
+	Create(log *models.AuditLog) error
+}
+
+// auditLogRepository 审计日志仓库实现
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository 创建审计日志仓库实例
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+// Create 写入审计日志
+func (r *auditLogRepository) Create(log *models.AuditLog) error {
+	return r.db.Create(log).Error
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"feedback-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AutomationRuleRepository 自动化规则仓库接口
+type AutomationRuleRepository interface {
+	Create(rule *models.AutomationRule) error
+	GetByID(id uint64) (*models.AutomationRule, error)
+	List() ([]*models.AutomationRule, error)
+	ListEnabled(trigger string) ([]*models.AutomationRule, error)
+	Update(rule *models.AutomationRule) error
+	Delete(id uint64) error
+}
+
+// automationRuleRepository 自动化规则仓库实现
+type automationRuleRepository struct {
+	db *gorm.DB
+}
+
+// NewAutomationRuleRepository 创建自动化规则仓库实例
+func NewAutomationRuleRepository(db *gorm.DB) AutomationRuleRepository {
+	return &automationRuleRepository{db: db}
+}
+
+// Create 创建规则
+func (r *automationRuleRepository) Create(rule *models.AutomationRule) error {
+	return r.db.Create(rule).Error
+}
+
+// GetByID 根据ID获取规则
+func (r *automationRuleRepository) GetByID(id uint64) (*models.AutomationRule, error) {
+	var rule models.AutomationRule
+	if err := r.db.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// List 获取所有规则，按触发事件和执行顺序排序
+func (r *automationRuleRepository) List() ([]*models.AutomationRule, error) {
+	var rules []*models.AutomationRule
+	err := r.db.Order("`trigger`, position, id").Find(&rules).Error
+	return rules, err
+}
+
+// ListEnabled 获取触发事件的所有已启用规则，按执行顺序排序
+func (r *automationRuleRepository) ListEnabled(trigger string) ([]*models.AutomationRule, error) {
+	var rules []*models.AutomationRule
+	err := r.db.Where("`trigger` = ? AND enabled = ?", trigger, true).Order("position, id").Find(&rules).Error
+	return rules, err
+}
+
+// Update 保存规则
+func (r *automationRuleRepository) Update(rule *models.AutomationRule) error {
+	return r.db.Save(rule).Error
+}
+
+// Delete 删除规则
+func (r *automationRuleRepository) Delete(id uint64) error {
+	return r.db.Delete(&models.AutomationRule{}, id).Error
+}
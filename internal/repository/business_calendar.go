@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"feedback-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BusinessCalendarRepository 工作日历仓库接口
+type BusinessCalendarRepository interface {
+	Create(calendar *models.BusinessCalendar) error
+	GetByID(id uint64) (*models.BusinessCalendar, error)
+	List() ([]*models.BusinessCalendar, error)
+	Update(calendar *models.BusinessCalendar) error
+	Delete(id uint64) error
+
+	// 团队使用的日历
+	GetAssignment(targetType uint8, targetID uint64) (*models.CalendarAssignment, error)
+	ListAssignments() ([]*models.CalendarAssignment, error)
+	Assign(assignment *models.CalendarAssignment) error
+	Unassign(targetType uint8, targetID uint64) error
+}
+
+// businessCalendarRepository 工作日历仓库实现
+type businessCalendarRepository struct {
+	db *gorm.DB
+}
+
+// NewBusinessCalendarRepository 创建工作日历仓库实例
+func NewBusinessCalendarRepository(db *gorm.DB) BusinessCalendarRepository {
+	return &businessCalendarRepository{db: db}
+}
+
+// Create 创建日历
+func (r *businessCalendarRepository) Create(calendar *models.BusinessCalendar) error {
+	return r.db.Create(calendar).Error
+}
+
+// GetByID 根据ID获取日历
+func (r *businessCalendarRepository) GetByID(id uint64) (*models.BusinessCalendar, error) {
+	var calendar models.BusinessCalendar
+	if err := r.db.First(&calendar, id).Error; err != nil {
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+// List 获取所有日历
+func (r *businessCalendarRepository) List() ([]*models.BusinessCalendar, error) {
+	var calendars []*models.BusinessCalendar
+	err := r.db.Order("id").Find(&calendars).Error
+	return calendars, err
+}
+
+// Update 保存日历
+func (r *businessCalendarRepository) Update(calendar *models.BusinessCalendar) error {
+	return r.db.Save(calendar).Error
+}
+
+// Delete 删除日历，使用该日历的团队改为全天营业
+func (r *businessCalendarRepository) Delete(id uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("calendar_id = ?", id).Delete(&models.CalendarAssignment{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.BusinessCalendar{}, id).Error
+	})
+}
+
+// GetAssignment 获取团队使用的日历，未指定时返回 gorm.ErrRecordNotFound
+func (r *businessCalendarRepository) GetAssignment(targetType uint8, targetID uint64) (*models.CalendarAssignment, error) {
+	var assignment models.CalendarAssignment
+	err := r.db.Where("target_type = ? AND target_id = ?", targetType, targetID).First(&assignment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// ListAssignments 获取所有团队使用的日历
+func (r *businessCalendarRepository) ListAssignments() ([]*models.CalendarAssignment, error) {
+	var assignments []*models.CalendarAssignment
+	err := r.db.Order("target_type, target_id").Find(&assignments).Error
+	return assignments, err
+}
+
+// Assign 为团队指定日历，已指定时覆盖
+func (r *businessCalendarRepository) Assign(assignment *models.CalendarAssignment) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "target_type"}, {Name: "target_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"calendar_id", "updated_at"}),
+	}).Create(assignment).Error
+}
+
+// Unassign 取消团队的日历
+func (r *businessCalendarRepository) Unassign(targetType uint8, targetID uint64) error {
+	return r.db.Where("target_type = ? AND target_id = ?", targetType, targetID).Delete(&models.CalendarAssignment{}).Error
+}
@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"encoding/json"
+	"feedback-system/internal/consts"
 	"feedback-system/internal/models"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -12,8 +15,30 @@ type FeedbackRepository interface {
 	FindByCreator(creatorID uint64, creatorType uint8) ([]*models.Feedback, error)
 	FindByTarget(targetID uint64, targetType uint8) ([]*models.Feedback, error)
 	FindAll() ([]*models.Feedback, error)
-	UpdateStatus(id uint64, status uint8) error
+	UpdateStatus(id uint64, status uint8) (bool, error)
 	Delete(id uint64) error
+
+	FindByFilter(targetID uint64, targetType uint8, filter *models.FeedbackFilter) ([]*models.Feedback, error)
+
+	// 处理人
+	UpdateAssignee(id, assigneeID, currentAssigneeID uint64) (bool, error)
+	ClearAssignee(assigneeID uint64) error
+	CountOpenByAssignees(assigneeIDs []uint64) (map[uint64]int64, error)
+
+	// 升级
+	MarkEscalated(id uint64, at time.Time) (bool, error)
+
+	// SLA
+	UpdateFields(id uint64, fields map[string]interface{}) error
+	UpdateTags(id uint64, tags []string) error
+	MarkSLAFlag(id uint64, column string) (bool, error)
+	FindSLAActive() ([]*models.Feedback, error)
+
+	// 自动关闭和跟进提醒
+	CloseResolved(id uint64, at time.Time) (bool, error)
+	FindResolvedBefore(before time.Time) ([]*models.Feedback, error)
+	FindAwaitingTarget(before time.Time) ([]*models.Feedback, error)
+	FindAwaitingCreator(before time.Time) ([]*models.Feedback, error)
 }
 
 type feedbackRepository struct {
@@ -67,11 +92,169 @@ func (r *feedbackRepository) FindAll() (feedbacks []*models.Feedback, err error)
 	return
 }
 
-func (r *feedbackRepository) UpdateStatus(id uint64, status uint8) (err error) {
-	result := r.db.Table("feedbacks").Where("id = ?", id).Update("status", status)
-	return result.Error
+// UpdateStatus 修改反馈状态，已关闭的反馈不修改，返回是否修改成功
+// 关闭条件放在同一条 UPDATE 中，避免与自动关闭同时进行时重新打开已关闭的反馈
+func (r *feedbackRepository) UpdateStatus(id uint64, status uint8) (bool, error) {
+	result := r.db.Table("feedbacks").
+		Where("id = ? AND status <> ?", id, consts.Closed).
+		Updates(map[string]interface{}{
+			"status":            status,
+			"status_changed_at": time.Now(),
+		})
+	return result.RowsAffected == 1, result.Error
 }
 
 func (r *feedbackRepository) Delete(id uint64) (err error) {
 	return r.db.Delete(&models.Feedback{}, id).Error
 }
+
+// FindByFilter 按筛选条件获取反馈，targetType 为 0 时获取所有目标的反馈
+func (r *feedbackRepository) FindByFilter(tId uint64, tType uint8, filter *models.FeedbackFilter) (feedbacks []*models.Feedback, err error) {
+	query := r.db.Model(&models.Feedback{})
+	if tType != 0 {
+		query = query.Where("target_id = ? and target_type = ?", tId, tType)
+	}
+	if filter != nil {
+		if filter.Assignee != nil {
+			query = query.Where("assignee_id = ?", *filter.Assignee)
+		}
+		switch filter.SLA {
+		case consts.SLAFilterBreached:
+			query = query.Where("(first_response_breached = ? OR resolution_breached = ?)", true, true)
+		case consts.SLAFilterWarning:
+			query = query.Where("status NOT IN ? AND first_response_breached = ? AND resolution_breached = ?", []uint8{consts.Resolved, consts.Closed}, false, false).
+				Where("((first_response_warned = ? AND first_responded_at IS NULL) OR (resolution_warned = ? AND sla_paused_at IS NULL))", true, true)
+		}
+	}
+	err = query.Find(&feedbacks).Error
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// UpdateAssignee 修改处理人，只有当前处理人仍为 currentAssigneeID 时才修改，返回是否修改成功
+// 避免两个人同时认领同一条反馈
+func (r *feedbackRepository) UpdateAssignee(id, assigneeID, currentAssigneeID uint64) (bool, error) {
+	result := r.db.Table("feedbacks").
+		Where("id = ? AND assignee_id = ?", id, currentAssigneeID).
+		Update("assignee_id", assigneeID)
+	return result.RowsAffected == 1, result.Error
+}
+
+// ClearAssignee 取消指定处理人的所有分配，用于删除账号
+func (r *feedbackRepository) ClearAssignee(assigneeID uint64) error {
+	return r.db.Table("feedbacks").Where("assignee_id = ?", assigneeID).Update("assignee_id", 0).Error
+}
+
+// CountOpenByAssignees 统计每个处理人未解决的反馈数量，没有未解决反馈的处理人不在结果中
+func (r *feedbackRepository) CountOpenByAssignees(assigneeIDs []uint64) (map[uint64]int64, error) {
+	counts := make(map[uint64]int64, len(assigneeIDs))
+	if len(assigneeIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		AssigneeID uint64
+		Count      int64
+	}
+	err := r.db.Table("feedbacks").
+		Select("assignee_id, COUNT(*) AS count").
+		Where("assignee_id IN ? AND status NOT IN ?", assigneeIDs, []uint8{consts.Resolved, consts.Closed}).
+		Group("assignee_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.AssigneeID] = row.Count
+	}
+	return counts, nil
+}
+
+// MarkEscalated 将反馈标记为已升级，只有尚未升级时才修改，返回是否修改成功
+func (r *feedbackRepository) MarkEscalated(id uint64, at time.Time) (bool, error) {
+	result := r.db.Table("feedbacks").
+		Where("id = ? AND escalated_at IS NULL", id).
+		Update("escalated_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+// UpdateFields 修改反馈的指定字段
+func (r *feedbackRepository) UpdateFields(id uint64, fields map[string]interface{}) error {
+	return r.db.Table("feedbacks").Where("id = ?", id).Updates(fields).Error
+}
+
+// UpdateTags 修改反馈的标签
+func (r *feedbackRepository) UpdateTags(id uint64, tags []string) error {
+	value, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	return r.db.Table("feedbacks").Where("id = ?", id).Update("tags", string(value)).Error
+}
+
+// MarkSLAFlag 将 SLA 预警或超时标记字段设为 true，只有尚未标记时才修改，返回是否修改成功
+// 避免多个检查同时发送重复的通知
+func (r *feedbackRepository) MarkSLAFlag(id uint64, column string) (bool, error) {
+	result := r.db.Table("feedbacks").
+		Where("id = ? AND "+column+" = ?", id, false).
+		Update(column, true)
+	return result.RowsAffected == 1, result.Error
+}
+
+// FindSLAActive 获取未解决且仍有 SLA 时限在计时的反馈：尚未首次回复，或解决计时未暂停且未超时
+func (r *feedbackRepository) FindSLAActive() (feedbacks []*models.Feedback, err error) {
+	err = r.db.Where("status NOT IN ?", []uint8{consts.Resolved, consts.Closed}).
+		Where("((first_response_due IS NOT NULL AND first_responded_at IS NULL AND first_response_breached = ?) OR (resolution_due IS NOT NULL AND sla_paused_at IS NULL AND resolution_breached = ?))", false, false).
+		Find(&feedbacks).Error
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// targetIdleSince 目标方开始未回复的时间：最近一次状态变更和目标方最近一次回复中较晚的一个
+// 早期的反馈没有记录状态变更时间，使用修改时间代替
+const targetIdleSince = "GREATEST(COALESCE(status_changed_at, updated_at), COALESCE(last_target_reply_at, created_at))"
+
+// CloseResolved 关闭已解决的反馈，只有仍为已解决状态时才修改，返回是否修改成功
+// 避免关闭的同时反馈被重新打开
+func (r *feedbackRepository) CloseResolved(id uint64, at time.Time) (bool, error) {
+	result := r.db.Table("feedbacks").
+		Where("id = ? AND status = ?", id, consts.Resolved).
+		Updates(map[string]interface{}{
+			"status":            consts.Closed,
+			"status_changed_at": at,
+			"closed_at":         at,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// FindResolvedBefore 获取在 before 之前解决且之后没有重新打开的反馈
+func (r *feedbackRepository) FindResolvedBefore(before time.Time) (feedbacks []*models.Feedback, err error) {
+	err = r.db.Where("status = ? AND COALESCE(status_changed_at, updated_at) <= ?", consts.Resolved, before).
+		Find(&feedbacks).Error
+	return
+}
+
+// FindAwaitingTarget 获取处理中、目标方自 before 之前起未回复且在这段时间内尚未提醒过目标方的反馈
+// 目标方回复后等待反馈创建者回复的反馈不在结果中
+func (r *feedbackRepository) FindAwaitingTarget(before time.Time) (feedbacks []*models.Feedback, err error) {
+	err = r.db.Where("status = ?", consts.InProgress).
+		Where("(last_target_reply_at IS NULL OR last_creator_reply_at > last_target_reply_at)").
+		Where(targetIdleSince+" <= ?", before).
+		Where("(target_followed_up_at IS NULL OR target_followed_up_at < " + targetIdleSince + ")").
+		Find(&feedbacks).Error
+	return
+}
+
+// FindAwaitingCreator 获取目标方在 before 之前回复后，反馈创建者一直未回复且尚未询问过的未解决反馈
+func (r *feedbackRepository) FindAwaitingCreator(before time.Time) (feedbacks []*models.Feedback, err error) {
+	err = r.db.Where("status IN ?", []uint8{consts.Open, consts.InProgress}).
+		Where("last_target_reply_at IS NOT NULL AND last_target_reply_at <= ?", before).
+		Where("(last_creator_reply_at IS NULL OR last_creator_reply_at < last_target_reply_at)").
+		Where("(creator_followed_up_at IS NULL OR creator_followed_up_at < last_target_reply_at)").
+		Find(&feedbacks).Error
+	return
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"feedback-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// FeedbackEventRepository 反馈处理记录仓库接口
+type FeedbackEventRepository interface {
+	Create(event *models.FeedbackEvent) error
+	ListByFeedback(feedbackID uint64) ([]*models.FeedbackEvent, error)
+	DeleteByFeedback(feedbackID uint64) error
+}
+
+// feedbackEventRepository 反馈处理记录仓库实现
+type feedbackEventRepository struct {
+	db *gorm.DB
+}
+
+// NewFeedbackEventRepository 创建反馈处理记录仓库实例
+func NewFeedbackEventRepository(db *gorm.DB) FeedbackEventRepository {
+	return &feedbackEventRepository{db: db}
+}
+
+// Create 添加处理记录
+func (r *feedbackEventRepository) Create(event *models.FeedbackEvent) error {
+	return r.db.Create(event).Error
+}
+
+// ListByFeedback 获取反馈的处理记录，按时间排序
+func (r *feedbackEventRepository) ListByFeedback(feedbackID uint64) ([]*models.FeedbackEvent, error) {
+	var events []*models.FeedbackEvent
+	err := r.db.Where("feedback_id = ?", feedbackID).Order("id").Find(&events).Error
+	return events, err
+}
+
+// DeleteByFeedback 删除反馈的所有处理记录
+func (r *feedbackEventRepository) DeleteByFeedback(feedbackID uint64) error {
+	return r.db.Where("feedback_id = ?", feedbackID).Delete(&models.FeedbackEvent{}).Error
+}
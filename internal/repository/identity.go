@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"feedback-system/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserIdentityRepository 外部身份仓库接口
+type UserIdentityRepository interface {
+	Create(identity *models.UserIdentity) error
+	FindBySubject(issuer, subject string, userType uint8) (*models.UserIdentity, error)
+	ListByUser(userID uint64) ([]*models.UserIdentity, error)
+	Touch(id uint64, now time.Time) error
+	Delete(id, userID uint64) (bool, error)
+	DeleteByUser(userID uint64) error
+}
+
+// userIdentityRepository 外部身份仓库实现
+type userIdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewUserIdentityRepository 创建外部身份仓库实例
+func NewUserIdentityRepository(db *gorm.DB) UserIdentityRepository {
+	return &userIdentityRepository{db: db}
+}
+
+// Create 绑定外部身份
+func (r *userIdentityRepository) Create(identity *models.UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+// FindBySubject 根据 IdP 和用户标识获取外部身份
+func (r *userIdentityRepository) FindBySubject(issuer, subject string, userType uint8) (*models.UserIdentity, error) {
+	identity := &models.UserIdentity{}
+	if err := r.db.Where("issuer = ? AND subject = ?", issuer, subject).First(identity).Error; err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// ListByUser 获取用户绑定的外部身份
+func (r *userIdentityRepository) ListByUser(userID uint64) (identities []*models.UserIdentity, err error) {
+	return identities, r.db.Where("user_id = ?", userID).Order("id").Find(&identities).Error
+}
+
+// Touch 更新最后登录时间
+func (r *userIdentityRepository) Touch(id uint64, now time.Time) error {
+	return r.db.Model(&models.UserIdentity{}).Where("id = ?", id).Update("last_login_at", now).Error
+}
+
+// Delete 解除用户的指定外部身份，不存在或不属于该用户时返回 false
+func (r *userIdentityRepository) Delete(id, userID uint64) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.UserIdentity{})
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteByUser 删除用户的所有外部身份
+func (r *userIdentityRepository) DeleteByUser(userID uint64) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.UserIdentity{}).Error
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"feedback-system/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// InviteRepository 商家邀请码仓库接口
+type InviteRepository interface {
+	Create(invite *models.MerchantInvite) error
+	List() ([]*models.MerchantInvite, error)
+	FindByCodeHash(hash string) (*models.MerchantInvite, error)
+	Redeem(id uint64, now time.Time, user *models.User, org *models.Organization) (bool, error)
+	Revoke(id uint64) (bool, error)
+}
+
+// inviteRepository 商家邀请码仓库实现
+type inviteRepository struct {
+	db *gorm.DB
+}
+
+// NewInviteRepository 创建邀请码仓库实例
+func NewInviteRepository(db *gorm.DB) InviteRepository {
+	return &inviteRepository{db: db}
+}
+
+// Create 创建邀请码
+func (r *inviteRepository) Create(invite *models.MerchantInvite) error {
+	return r.db.Create(invite).Error
+}
+
+// List 获取所有邀请码，最新创建的在前
+func (r *inviteRepository) List() (invites []*models.MerchantInvite, err error) {
+	return invites, r.db.Order("id DESC").Find(&invites).Error
+}
+
+// FindByCodeHash 根据邀请码哈希获取邀请码
+func (r *inviteRepository) FindByCodeHash(hash string) (*models.MerchantInvite, error) {
+	invite := &models.MerchantInvite{}
+	if err := r.db.Where("code_hash = ?", hash).First(invite).Error; err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+// Redeem 在同一事务中使用一次邀请码并创建账号，org 不为 nil 时同时创建以该账号为所有者的组织
+// 邀请码已用完、已过期或已撤销时不创建账号并返回 false；创建账号或组织失败时邀请码的使用次数不变
+func (r *inviteRepository) Redeem(id uint64, now time.Time, user *models.User, org *models.Organization) (bool, error) {
+	redeemed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.MerchantInvite{}).
+			Where("id = ? AND used_count < max_uses AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", id, now).
+			Update("used_count", gorm.Expr("used_count + 1"))
+		if result.Error != nil || result.RowsAffected != 1 {
+			return result.Error
+		}
+		if org != nil {
+			if err := createWithOwner(tx, org, user); err != nil {
+				return err
+			}
+		} else if err := createUser(tx, user); err != nil {
+			return err
+		}
+		redeemed = true
+		return nil
+	})
+	return redeemed, err
+}
+
+// Revoke 撤销邀请码，邀请码不存在或已撤销时返回 false
+func (r *inviteRepository) Revoke(id uint64) (bool, error) {
+	result := r.db.Model(&models.MerchantInvite{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobRepository 定时任务锁和运行记录仓库接口
+type JobRepository interface {
+	// 任务锁
+	Lock(job, owner string, slot *time.Time, now, until time.Time) (bool, error)
+	Unlock(job, owner string, now time.Time) error
+
+	// 运行记录
+	CreateRun(run *models.JobRun) error
+	FinishRun(id uint64, at time.Time, processed int64, runErr string) error
+	GetRun(id uint64) (*models.JobRun, error)
+	LastRuns(jobs []string) (map[string]*models.JobRun, error)
+	ListRuns(job string, offset, limit int) ([]*models.JobRun, int64, error)
+	DeleteRunsBefore(before time.Time) (int64, error)
+}
+
+// jobRepository 定时任务仓库实现
+type jobRepository struct {
+	db *gorm.DB
+}
+
+// NewJobRepository 创建定时任务仓库实例
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+// Lock 获取任务锁：锁未被持有或已到期时更新持有者；slot 不为空时还要求该计划时间尚未执行过
+// 锁记录不存在时插入，多个实例同时插入时只有一个成功
+func (r *jobRepository) Lock(job, owner string, slot *time.Time, now, until time.Time) (bool, error) {
+	updates := map[string]interface{}{"owner": owner, "locked_until": until}
+	db := r.db.Model(&models.JobLock{}).Where("name = ? AND locked_until <= ?", job, now)
+	if slot != nil {
+		updates["last_slot"] = *slot
+		db = db.Where("(last_slot IS NULL OR last_slot < ?)", *slot)
+	}
+	result := db.Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	result = r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.JobLock{
+		Name:        job,
+		Owner:       owner,
+		LockedUntil: until,
+		LastSlot:    slot,
+	})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Unlock 释放本实例持有的任务锁，保留最近一次的计划时间
+func (r *jobRepository) Unlock(job, owner string, now time.Time) error {
+	return r.db.Model(&models.JobLock{}).
+		Where("name = ? AND owner = ?", job, owner).
+		Update("locked_until", now).Error
+}
+
+// CreateRun 创建运行记录
+func (r *jobRepository) CreateRun(run *models.JobRun) error {
+	return r.db.Create(run).Error
+}
+
+// FinishRun 记录运行结果，runErr 为空表示成功
+func (r *jobRepository) FinishRun(id uint64, at time.Time, processed int64, runErr string) error {
+	status := consts.JobRunSucceeded
+	if runErr != "" {
+		status = consts.JobRunFailed
+	}
+	return r.db.Model(&models.JobRun{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":      status,
+		"processed":   processed,
+		"error":       runErr,
+		"finished_at": at,
+	}).Error
+}
+
+// GetRun 根据ID获取运行记录
+func (r *jobRepository) GetRun(id uint64) (*models.JobRun, error) {
+	var run models.JobRun
+	if err := r.db.First(&run, id).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// LastRuns 获取各任务最近一次的运行记录
+func (r *jobRepository) LastRuns(jobs []string) (map[string]*models.JobRun, error) {
+	result := make(map[string]*models.JobRun, len(jobs))
+	if len(jobs) == 0 {
+		return result, nil
+	}
+
+	var runs []*models.JobRun
+	latest := r.db.Model(&models.JobRun{}).Select("MAX(id)").Where("job IN ?", jobs).Group("job")
+	if err := r.db.Where("id IN (?)", latest).Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	for _, run := range runs {
+		result[run.Job] = run
+	}
+	return result, nil
+}
+
+// ListRuns 分页获取任务的运行记录，最新的在前
+func (r *jobRepository) ListRuns(job string, offset, limit int) ([]*models.JobRun, int64, error) {
+	db := r.db.Model(&models.JobRun{}).Where("job = ?", job)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var runs []*models.JobRun
+	err := db.Order("id DESC").Offset(offset).Limit(limit).Find(&runs).Error
+	return runs, total, err
+}
+
+// DeleteRunsBefore 删除 before 之前开始的运行记录，返回删除的数量
+func (r *jobRepository) DeleteRunsBefore(before time.Time) (int64, error) {
+	result := r.db.Where("started_at < ?", before).Delete(&models.JobRun{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// OrganizationRepository 商家组织仓库接口
+type OrganizationRepository interface {
+	CreateWithOwner(org *models.Organization, owner *models.User) error
+	GetByID(id uint64) (*models.Organization, error)
+	UpdateName(id uint64, name string) error
+	ListActive() ([]*models.Organization, error)
+	Delete(id uint64) error
+}
+
+// organizationRepository 商家组织仓库实现
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+// NewOrganizationRepository 创建商家组织仓库实例
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+// CreateWithOwner 在同一事务中创建组织和作为组织所有者的商家账号，任一步失败时都不创建
+func (r *organizationRepository) CreateWithOwner(org *models.Organization, owner *models.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return createWithOwner(tx, org, owner)
+	})
+}
+
+// createWithOwner 创建组织和作为组织所有者的商家账号，需在事务中调用
+func createWithOwner(tx *gorm.DB, org *models.Organization, owner *models.User) error {
+	if err := tx.Create(org).Error; err != nil {
+		return err
+	}
+	owner.OrgID, owner.OrgRole = org.ID, consts.OrgRoleOwner
+	if err := createUser(tx, owner); err != nil {
+		owner.OrgID, owner.OrgRole = 0, ""
+		return err
+	}
+	return nil
+}
+
+// GetByID 根据ID获取组织
+func (r *organizationRepository) GetByID(id uint64) (*models.Organization, error) {
+	org := &models.Organization{}
+	if err := r.db.First(org, id).Error; err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// UpdateName 修改组织名称
+func (r *organizationRepository) UpdateName(id uint64, name string) error {
+	return r.db.Model(&models.Organization{}).Where("id = ?", id).Update("name", name).Error
+}
+
+// ListActive 获取所有者账号正常的组织，按名称排序
+func (r *organizationRepository) ListActive() ([]*models.Organization, error) {
+	var orgs []*models.Organization
+	owners := r.db.Model(&models.User{}).Select("org_id").
+		Where("user_type = ? AND org_role = ? AND status = ?", consts.Merchant, consts.OrgRoleOwner, consts.AccountActive)
+	err := r.db.Where("id IN (?)", owners).Order("name").Find(&orgs).Error
+	return orgs, err
+}
+
+// Delete 删除组织及其自动分配设置、SLA 策略和工作日历设置
+func (r *organizationRepository) Delete(id uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("target_type = ? AND target_id = ?", consts.TargetMerchant, id).Delete(&models.AssignmentSetting{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("target_type = ? AND target_id = ?", consts.TargetMerchant, id).Delete(&models.SLAPolicy{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("target_type = ? AND target_id = ?", consts.TargetMerchant, id).Delete(&models.CalendarAssignment{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Organization{}, id).Error
+	})
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"feedback-system/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PasswordResetRepository 密码重置令牌仓库接口
+type PasswordResetRepository interface {
+	Create(token *models.PasswordResetToken) error
+	FindByHash(hash string) (*models.PasswordResetToken, error)
+	ResetPassword(id, userID uint64, passwordHash string) (bool, error)
+	DeleteByUser(userID uint64) error
+}
+
+// passwordResetRepository 密码重置令牌仓库实现
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetRepository 创建密码重置令牌仓库实例
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+// Create 创建重置令牌
+func (r *passwordResetRepository) Create(token *models.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+// FindByHash 根据令牌哈希获取重置令牌
+func (r *passwordResetRepository) FindByHash(hash string) (*models.PasswordResetToken, error) {
+	token := &models.PasswordResetToken{}
+	if err := r.db.Where("token_hash = ?", hash).First(token).Error; err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// ResetPassword 在同一事务中将令牌标记为已使用、更新用户密码并删除用户的其他重置令牌
+// 令牌已被使用时不做任何修改并返回 false
+func (r *passwordResetRepository) ResetPassword(id, userID uint64, passwordHash string) (bool, error) {
+	used := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", id).
+			Update("used_at", time.Now())
+		if result.Error != nil || result.RowsAffected != 1 {
+			return result.Error
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"password":             passwordHash,
+			"must_change_password": false,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? AND id <> ?", userID, id).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		used = true
+		return nil
+	})
+	return used, err
+}
+
+// DeleteByUser 删除用户的所有重置令牌
+func (r *passwordResetRepository) DeleteByUser(userID uint64) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.PasswordResetToken{}).Error
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"feedback-system/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeRepository 两步验证恢复码仓库接口
+type RecoveryCodeRepository interface {
+	Replace(userID uint64, hashes []string) error
+	Use(userID uint64, hash string) (bool, error)
+	DeleteByUser(userID uint64) error
+}
+
+// recoveryCodeRepository 两步验证恢复码仓库实现
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewRecoveryCodeRepository 创建恢复码仓库实例
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+// Replace 删除用户原有的恢复码并保存新的恢复码
+func (r *recoveryCodeRepository) Replace(userID uint64, hashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]*models.RecoveryCode, 0, len(hashes))
+		for _, hash := range hashes {
+			codes = append(codes, &models.RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Use 使用恢复码，恢复码不存在或已使用时返回 false
+func (r *recoveryCodeRepository) Use(userID uint64, hash string) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteByUser 删除用户的所有恢复码
+func (r *recoveryCodeRepository) DeleteByUser(userID uint64) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"feedback-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SLAPolicyRepository SLA 策略仓库接口
+type SLAPolicyRepository interface {
+	Create(policy *models.SLAPolicy) error
+	GetByID(id uint64) (*models.SLAPolicy, error)
+	GetByTarget(targetType uint8, targetID uint64) (*models.SLAPolicy, error)
+	List() ([]*models.SLAPolicy, error)
+	Update(policy *models.SLAPolicy) error
+	Delete(id uint64) error
+}
+
+// slaPolicyRepository SLA 策略仓库实现
+type slaPolicyRepository struct {
+	db *gorm.DB
+}
+
+// NewSLAPolicyRepository 创建 SLA 策略仓库实例
+func NewSLAPolicyRepository(db *gorm.DB) SLAPolicyRepository {
+	return &slaPolicyRepository{db: db}
+}
+
+// Create 创建策略
+func (r *slaPolicyRepository) Create(policy *models.SLAPolicy) error {
+	return r.db.Create(policy).Error
+}
+
+// GetByID 根据ID获取策略
+func (r *slaPolicyRepository) GetByID(id uint64) (*models.SLAPolicy, error) {
+	var policy models.SLAPolicy
+	if err := r.db.First(&policy, id).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// GetByTarget 获取指定目标的策略，不存在时返回 gorm.ErrRecordNotFound
+func (r *slaPolicyRepository) GetByTarget(targetType uint8, targetID uint64) (*models.SLAPolicy, error) {
+	var policy models.SLAPolicy
+	err := r.db.Where("target_type = ? AND target_id = ?", targetType, targetID).First(&policy).Error
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// List 获取所有策略，按目标排序，默认策略排在前面
+func (r *slaPolicyRepository) List() ([]*models.SLAPolicy, error) {
+	var policies []*models.SLAPolicy
+	err := r.db.Order("target_type, target_id").Find(&policies).Error
+	return policies, err
+}
+
+// Update 保存策略
+func (r *slaPolicyRepository) Update(policy *models.SLAPolicy) error {
+	return r.db.Save(policy).Error
+}
+
+// Delete 删除策略，已按该策略计算的反馈截止时间不变
+func (r *slaPolicyRepository) Delete(id uint64) error {
+	return r.db.Delete(&models.SLAPolicy{}, id).Error
+}
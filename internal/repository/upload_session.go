@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"feedback-system/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UploadSessionRepository 断点续传上传会话仓库接口
+type UploadSessionRepository interface {
+	Create(session *models.UploadSession) error
+	FindByID(id string) (*models.UploadSession, error)
+	UpdateOffset(id string, oldOffset, newOffset int64, part string, expiresAt time.Time) (bool, error)
+	Complete(id string, attachmentID uint64) error
+	Delete(id string) error
+	FindExpired(before time.Time, limit int) ([]*models.UploadSession, error)
+}
+
+// uploadSessionRepository 断点续传上传会话仓库实现
+type uploadSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewUploadSessionRepository 创建断点续传上传会话仓库实例
+func NewUploadSessionRepository(db *gorm.DB) UploadSessionRepository {
+	return &uploadSessionRepository{db: db}
+}
+
+// Create 创建上传会话
+func (r *uploadSessionRepository) Create(session *models.UploadSession) error {
+	return r.db.Create(session).Error
+}
+
+// FindByID 根据ID获取上传会话
+func (r *uploadSessionRepository) FindByID(id string) (*models.UploadSession, error) {
+	session := &models.UploadSession{}
+	if err := r.db.Where("id = ?", id).First(session).Error; err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// UpdateOffset 更新已接收字节数并追加分片
+// 仅当数据库中的偏移量仍为 oldOffset 时才更新，多个实例并发续传时只有一个能成功
+func (r *uploadSessionRepository) UpdateOffset(id string, oldOffset, newOffset int64, part string, expiresAt time.Time) (bool, error) {
+	result := r.db.Model(&models.UploadSession{}).
+		Where("id = ? AND `offset` = ?", id, oldOffset).
+		Updates(map[string]interface{}{
+			"offset":     newOffset,
+			"parts":      gorm.Expr("CONCAT(COALESCE(parts, ''), ?)", part+"\n"),
+			"expires_at": expiresAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Complete 标记上传完成
+func (r *uploadSessionRepository) Complete(id string, attachmentID uint64) error {
+	return r.db.Model(&models.UploadSession{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"attachment_id": attachmentID, "completed_at": time.Now()}).Error
+}
+
+// Delete 删除上传会话
+func (r *uploadSessionRepository) Delete(id string) error {
+	return r.db.Where("id = ?", id).Delete(&models.UploadSession{}).Error
+}
+
+// FindExpired 获取已过期的上传会话
+func (r *uploadSessionRepository) FindExpired(before time.Time, limit int) (sessions []*models.UploadSession, err error) {
+	return sessions, r.db.Where("expires_at < ?", before).Order("expires_at").Limit(limit).Find(&sessions).Error
+}
@@ -1,12 +1,14 @@
 package repository
 
 import (
-	"crypto/md5"
-	"encoding/hex"
 	"errors"
+	"feedback-system/internal/consts"
 	"feedback-system/internal/models"
+	"log"
+	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserRepository 用户仓库接口
@@ -14,11 +16,21 @@ type UserRepository interface {
 	Create(user *models.User) error
 	GetByID(id uint64) (*models.User, error)
 	GetByUsername(username string, userType uint8) (*models.User, error)
+	GetByEmail(email string, userType uint8) (*models.User, error)
 	Update(user *models.User) error
 	Delete(id uint64) error
 	List() ([]*models.User, error)
 	GetAdmins() ([]*models.User, error)
 	GetMerchants() ([]*models.User, error)
+	ListByOrg(orgID uint64) ([]*models.User, error)
+	UpdatePassword(id uint64, passwordHash string, mustChange bool) error
+	UpdateTwoFactor(id uint64, enabled bool, secret string) error
+	UseTOTPStep(id uint64, step int64) (bool, error)
+	ListByStatus(userType, status uint8) ([]*models.User, error)
+	UpdateStatus(id uint64, status uint8) error
+	UpdateStatusUnlessLastAdmin(id uint64, status uint8) (bool, error)
+	UpdateFields(id uint64, fields map[string]interface{}) error
+	Search(query *models.AdminUserQuery, offset, limit int) ([]*models.User, int64, error)
 }
 
 // userRepository 用户仓库实现
@@ -36,28 +48,28 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return repo
 }
 
-// initDefaultAdmin 初始化默认管理员用户
+// initDefaultAdmin 检查管理员账号
+// 不再自动创建默认管理员，没有管理员时提示使用 create-admin 命令创建
 func (r *userRepository) initDefaultAdmin() {
 	// 检查是否已有管理员用户
 	var count int64
-	r.db.Model(&models.User{}).Where("user_type = ?", 3).Count(&count)
+	r.db.Model(&models.User{}).Where("user_type = ?", consts.Admin).Count(&count)
 
 	if count == 0 {
-		// 添加默认管理员用户
-		adminUser := &models.User{
-			Username: "admin",
-			Password: hashPassword("admin123"), // 使用加密密码
-			UserType: 3,                        // 管理员
-		}
-		r.db.Create(adminUser)
+		log.Println("尚未创建管理员账号，请运行 go run ./cmd/create-admin -username <用户名> 创建")
 	}
 }
 
 // Create 创建用户
 func (r *userRepository) Create(user *models.User) error {
+	return createUser(r.db, user)
+}
+
+// createUser 检查用户名在同一用户类型下未被使用后创建用户，可在事务中调用
+func createUser(db *gorm.DB, user *models.User) error {
 	// 检查用户名在同一用户类型下是否已存在
 	var existingUser models.User
-	result := r.db.Where("username = ? AND user_type = ?", user.Username, user.UserType).First(&existingUser)
+	result := db.Where("username = ? AND user_type = ?", user.Username, user.UserType).First(&existingUser)
 	if result.Error == nil {
 		return errors.New("username already exists for this user type")
 	}
@@ -67,7 +79,7 @@ func (r *userRepository) Create(user *models.User) error {
 	}
 
 	// 创建用户
-	return r.db.Create(user).Error
+	return db.Create(user).Error
 }
 
 // GetByID 根据ID获取用户
@@ -96,6 +108,19 @@ func (r *userRepository) GetByUsername(username string, userType uint8) (*models
 	return &user, nil
 }
 
+// GetByEmail 根据邮箱和用户类型获取用户，邮箱不区分大小写
+func (r *userRepository) GetByEmail(email string, userType uint8) (*models.User, error) {
+	var user models.User
+	result := r.db.Where("LOWER(email) = LOWER(?) AND user_type = ?", email, userType).First(&user)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, result.Error
+	}
+	return &user, nil
+}
+
 // Update 更新用户
 func (r *userRepository) Update(user *models.User) error {
 	return r.db.Save(user).Error
@@ -123,12 +148,108 @@ func (r *userRepository) GetAdmins() ([]*models.User, error) {
 // GetMerchants 获取所有商家用户
 func (r *userRepository) GetMerchants() ([]*models.User, error) {
 	var merchants []*models.User
-	result := r.db.Where("user_type = ?", 2).Find(&merchants)
+	result := r.db.Where("user_type = ? AND status = ?", 2, consts.AccountActive).Find(&merchants)
 	return merchants, result.Error
 }
 
-// hashPassword 对密码进行MD5加密
-func hashPassword(password string) string {
-	hash := md5.Sum([]byte(password))
-	return hex.EncodeToString(hash[:])
+// ListByOrg 获取商家组织的所有员工，所有者排在最前
+func (r *userRepository) ListByOrg(orgID uint64) ([]*models.User, error) {
+	var users []*models.User
+	result := r.db.Where("user_type = ? AND org_id = ?", consts.Merchant, orgID).
+		Order("org_role = '" + consts.OrgRoleOwner + "' DESC, id").
+		Find(&users)
+	return users, result.Error
+}
+
+// UpdatePassword 更新密码哈希和是否需要修改密码
+func (r *userRepository) UpdatePassword(id uint64, passwordHash string, mustChange bool) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password":             passwordHash,
+		"must_change_password": mustChange,
+	}).Error
+}
+
+// UpdateTwoFactor 更新两步验证状态和密钥，同时重置最后使用的时间步
+func (r *userRepository) UpdateTwoFactor(id uint64, enabled bool, secret string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"two_factor_enabled": enabled,
+		"totp_secret":        secret,
+		"totp_last_step":     0,
+	}).Error
+}
+
+// UseTOTPStep 记录已使用的TOTP时间步，时间步不大于上次记录时返回 false（验证码已被使用）
+func (r *userRepository) UseTOTPStep(id uint64, step int64) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+// ListByStatus 获取指定类型和账号状态的用户，按注册时间排序
+func (r *userRepository) ListByStatus(userType, status uint8) ([]*models.User, error) {
+	var users []*models.User
+	result := r.db.Where("user_type = ? AND status = ?", userType, status).Order("created_at").Find(&users)
+	return users, result.Error
+}
+
+// UpdateStatus 更新账号状态
+func (r *userRepository) UpdateStatus(id uint64, status uint8) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("status", status).Error
+}
+
+// UpdateStatusUnlessLastAdmin 修改账号状态，修改后没有其他正常状态的管理员时不修改并返回 false
+// 在事务中锁定所有正常状态的管理员记录后再检查和修改，并发停用或删除不同管理员时不会全部成功
+func (r *userRepository) UpdateStatusUnlessLastAdmin(id uint64, status uint8) (bool, error) {
+	updated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var adminIDs []uint64
+		if err := tx.Model(&models.User{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_type = ? AND status = ?", consts.Admin, consts.AccountActive).
+			Pluck("id", &adminIDs).Error; err != nil {
+			return err
+		}
+		if status != consts.AccountActive && len(adminIDs) == 1 && adminIDs[0] == id {
+			return nil
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", id).Update("status", status).Error; err != nil {
+			return err
+		}
+		updated = true
+		return nil
+	})
+	return updated, err
+}
+
+// UpdateFields 更新用户的指定字段
+func (r *userRepository) UpdateFields(id uint64, fields map[string]interface{}) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(fields).Error
+}
+
+// Search 按条件分页查询用户，返回当前页的用户和符合条件的总数，最新注册的在前
+func (r *userRepository) Search(query *models.AdminUserQuery, offset, limit int) ([]*models.User, int64, error) {
+	db := r.db.Model(&models.User{})
+	if query.UserType != 0 {
+		db = db.Where("user_type = ?", query.UserType)
+	}
+	if query.Status != 0 {
+		db = db.Where("status = ?", query.Status)
+	}
+	if query.Keyword != "" {
+		like := "%" + escapeLike(query.Keyword) + "%"
+		db = db.Where("username LIKE ? OR contact LIKE ? OR email LIKE ?", like, like, like)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []*models.User
+	err := db.Order("id DESC").Offset(offset).Limit(limit).Find(&users).Error
+	return users, total, err
+}
+
+// escapeLike 转义 LIKE 模式中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
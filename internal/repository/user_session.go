@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"feedback-system/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserSessionRepository 用户会话仓库接口
+type UserSessionRepository interface {
+	Create(session *models.UserSession) error
+	FindByID(id string) (*models.UserSession, error)
+	FindActiveByUser(userID uint64, userType uint8, now time.Time) ([]*models.UserSession, error)
+	FindByRefreshTokenHash(hash string) (*models.UserSession, error)
+	FindByPrevRefreshTokenHash(hash string) (*models.UserSession, error)
+	Rotate(id, oldHash, newHash string, expiresAt time.Time) (bool, error)
+	Touch(id string, at time.Time) error
+	Revoke(id string) error
+	RevokeByUser(userID uint64, userType uint8) ([]string, error)
+	RevokeOthers(userID uint64, userType uint8, keepID string) ([]string, error)
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+// userSessionRepository 用户会话仓库实现
+type userSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewUserSessionRepository 创建用户会话仓库实例
+func NewUserSessionRepository(db *gorm.DB) UserSessionRepository {
+	return &userSessionRepository{db: db}
+}
+
+// Create 创建会话
+func (r *userSessionRepository) Create(session *models.UserSession) error {
+	return r.db.Create(session).Error
+}
+
+// FindByID 根据ID获取会话
+func (r *userSessionRepository) FindByID(id string) (*models.UserSession, error) {
+	session := &models.UserSession{}
+	if err := r.db.Where("id = ?", id).First(session).Error; err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// FindActiveByUser 获取用户未撤销且未过期的会话，最近活跃的在前
+func (r *userSessionRepository) FindActiveByUser(userID uint64, userType uint8, now time.Time) (sessions []*models.UserSession, err error) {
+	return sessions, r.db.
+		Where("user_id = ? AND user_type = ? AND revoked_at IS NULL AND expires_at > ?", userID, userType, now).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+}
+
+// FindByRefreshTokenHash 根据当前刷新令牌哈希获取会话
+func (r *userSessionRepository) FindByRefreshTokenHash(hash string) (*models.UserSession, error) {
+	session := &models.UserSession{}
+	if err := r.db.Where("refresh_token_hash = ?", hash).First(session).Error; err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// FindByPrevRefreshTokenHash 根据上一个刷新令牌哈希获取会话
+func (r *userSessionRepository) FindByPrevRefreshTokenHash(hash string) (*models.UserSession, error) {
+	session := &models.UserSession{}
+	if err := r.db.Where("prev_refresh_token_hash = ?", hash).First(session).Error; err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Rotate 轮换刷新令牌
+// 仅当数据库中的刷新令牌仍为 oldHash 时才更新，同一刷新令牌并发使用时只有一个请求成功
+func (r *userSessionRepository) Rotate(id, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	result := r.db.Model(&models.UserSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", id, oldHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":      newHash,
+			"prev_refresh_token_hash": oldHash,
+			"expires_at":              expiresAt,
+			"last_used_at":            time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Touch 更新会话最后活跃时间
+func (r *userSessionRepository) Touch(id string, at time.Time) error {
+	return r.db.Model(&models.UserSession{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
+
+// Revoke 撤销会话
+func (r *userSessionRepository) Revoke(id string) error {
+	return r.db.Model(&models.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeByUser 撤销用户的所有会话，返回被撤销的会话ID
+func (r *userSessionRepository) RevokeByUser(userID uint64, userType uint8) ([]string, error) {
+	return r.revoke("user_id = ? AND user_type = ? AND revoked_at IS NULL", userID, userType)
+}
+
+// RevokeOthers 撤销用户除 keepID 以外的所有会话，返回被撤销的会话ID
+func (r *userSessionRepository) RevokeOthers(userID uint64, userType uint8, keepID string) ([]string, error) {
+	return r.revoke("user_id = ? AND user_type = ? AND revoked_at IS NULL AND id <> ?", userID, userType, keepID)
+}
+
+// revoke 撤销符合条件的会话，返回被撤销的会话ID
+func (r *userSessionRepository) revoke(query string, args ...interface{}) ([]string, error) {
+	var ids []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserSession{}).
+			Where(query, args...).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&models.UserSession{}).Where("id IN ?", ids).Update("revoked_at", time.Now()).Error
+	})
+	return ids, err
+}
+
+// DeleteExpired 删除刷新令牌已过期的会话
+func (r *userSessionRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", before).Delete(&models.UserSession{})
+	return result.RowsAffected, result.Error
+}
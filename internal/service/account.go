@@ -0,0 +1,278 @@
+package service
+
+import (
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/password"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrRegistrationForbidden 该用户类型不允许公开注册
+	ErrRegistrationForbidden = errors.New("registration of this user type is not allowed")
+	// ErrInviteCodeRequired 商家注册需要邀请码
+	ErrInviteCodeRequired = errors.New("invite code required")
+	// ErrInvalidInviteCode 邀请码无效、已用完、已过期或已撤销
+	ErrInvalidInviteCode = errors.New("invalid or expired invite code")
+	// ErrUsernameTaken 同一用户类型下用户名已存在
+	ErrUsernameTaken = errors.New("username already exists for this user type")
+	// ErrInviteNotFound 邀请码不存在或已撤销
+	ErrInviteNotFound = errors.New("invite not found")
+	// ErrUserNotFound 用户不存在
+	ErrUserNotFound = errors.New("user not found")
+	// ErrAccountNotPending 账号不是待审核的商家
+	ErrAccountNotPending = errors.New("account is not a pending merchant")
+)
+
+// auditTargetInvite 审计日志中商家邀请码的操作对象类型
+const auditTargetInvite = "invite"
+
+// 商家注册方式
+const (
+	// MerchantSignupInvite 商家只能使用邀请码注册
+	MerchantSignupInvite = "invite"
+	// MerchantSignupApproval 商家可使用邀请码直接注册，未填写邀请码时注册为待审核，由管理员审核后启用
+	MerchantSignupApproval = "approval"
+)
+
+// AccountService 账号开通服务接口
+// 公开注册只开放给普通用户；商家通过邀请码或管理员审核开通；管理员只能由已有管理员或命令行创建
+type AccountService interface {
+	// 公开注册
+	Register(req *models.UserRegisterRequest) (*models.User, error)
+
+	// 创建管理员
+	CreateAdmin(actor *AuditActor, req *models.CreateAdminRequest) (*models.User, error)
+
+	// 商家邀请码
+	CreateInvite(actor *AuditActor, req *models.CreateInviteRequest) (*models.CreateInviteResponse, error)
+	ListInvites() ([]*models.MerchantInvite, error)
+	RevokeInvite(actor *AuditActor, id uint64) error
+
+	// 商家审核
+	ListPendingMerchants() ([]*models.User, error)
+	ApproveMerchant(actor *AuditActor, id uint64) (*models.User, error)
+	RejectMerchant(actor *AuditActor, id uint64) error
+}
+
+// accountService 账号开通服务实现
+type accountService struct {
+	userRepo       repository.UserRepository
+	inviteRepo     repository.InviteRepository
+	orgRepo        repository.OrganizationRepository
+	auditRepo      repository.AuditLogRepository
+	passwords      *password.Policy
+	merchantSignup string
+}
+
+// NewAccountService 创建账号开通服务，merchantSignup 为 MerchantSignupInvite 或 MerchantSignupApproval
+func NewAccountService(userRepo repository.UserRepository, inviteRepo repository.InviteRepository, orgRepo repository.OrganizationRepository, auditRepo repository.AuditLogRepository, passwords *password.Policy, merchantSignup string) AccountService {
+	return &accountService{
+		userRepo:       userRepo,
+		inviteRepo:     inviteRepo,
+		orgRepo:        orgRepo,
+		auditRepo:      auditRepo,
+		passwords:      passwords,
+		merchantSignup: merchantSignup,
+	}
+}
+
+// Register 公开注册
+// 普通用户注册后直接启用；商家填写有效邀请码时直接启用，否则按注册方式注册为待审核或拒绝注册；不能注册管理员
+func (s *accountService) Register(req *models.UserRegisterRequest) (*models.User, error) {
+	status := uint8(consts.AccountActive)
+	var invite *models.MerchantInvite
+
+	switch req.UserType {
+	case consts.User:
+	case consts.Merchant:
+		code := normalizeRecoveryCode(req.InviteCode)
+		if code != "" {
+			var err error
+			invite, err = s.inviteRepo.FindByCodeHash(hashToken(code))
+			if err != nil {
+				return nil, ErrInvalidInviteCode
+			}
+		} else if s.merchantSignup == MerchantSignupApproval {
+			status = consts.AccountPending
+		} else {
+			return nil, ErrInviteCodeRequired
+		}
+	default:
+		return nil, ErrRegistrationForbidden
+	}
+
+	if err := s.checkNewUser(req.Username, req.UserType, req.Password); err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username: req.Username,
+		Password: hashPassword(req.Password),
+		Contact:  req.Contact,
+		Email:    req.Email,
+		UserType: req.UserType,
+		Status:   status,
+	}
+	// 商家注册时在创建账号的同一事务中创建以其为所有者的组织，待审核商家的组织在审核通过前不会出现在商家列表中
+	var org *models.Organization
+	if user.UserType == consts.Merchant {
+		org = ownOrganization(user)
+	}
+	switch {
+	case invite != nil:
+		// 使用邀请码和创建账号在同一事务中完成，创建失败时不消耗邀请码
+		ok, err := s.inviteRepo.Redeem(invite.ID, time.Now(), user, org)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrInvalidInviteCode
+		}
+	case org != nil:
+		if err := s.orgRepo.CreateWithOwner(org, user); err != nil {
+			return nil, err
+		}
+	default:
+		if err := s.userRepo.Create(user); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// CreateAdmin 创建管理员
+func (s *accountService) CreateAdmin(actor *AuditActor, req *models.CreateAdminRequest) (*models.User, error) {
+	if err := s.checkNewUser(req.Username, consts.Admin, req.Password); err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username: req.Username,
+		Password: hashPassword(req.Password),
+		Contact:  req.Contact,
+		Email:    req.Email,
+		UserType: consts.Admin,
+		Status:   consts.AccountActive,
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditAdminCreate, auditTargetUser, userTarget(user), map[string]interface{}{
+		"username": user.Username,
+	})
+	return user, nil
+}
+
+// CreateInvite 创建商家邀请码，邀请码格式与恢复码相同，校验时忽略大小写、空格和连字符
+func (s *accountService) CreateInvite(actor *AuditActor, req *models.CreateInviteRequest) (*models.CreateInviteResponse, error) {
+	code, err := generateRecoveryCode()
+	if err != nil {
+		return nil, err
+	}
+
+	invite := &models.MerchantInvite{
+		CodeHash:  hashToken(normalizeRecoveryCode(code)),
+		Note:      req.Note,
+		MaxUses:   req.MaxUses,
+		CreatedBy: actor.User.ID,
+	}
+	if invite.MaxUses <= 0 {
+		invite.MaxUses = 1
+	}
+	if req.ExpiresInHours > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour)
+		invite.ExpiresAt = &expiresAt
+	}
+	if err := s.inviteRepo.Create(invite); err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditInviteCreate, auditTargetInvite, strconv.FormatUint(invite.ID, 10), map[string]interface{}{
+		"note":       invite.Note,
+		"max_uses":   invite.MaxUses,
+		"expires_at": invite.ExpiresAt,
+	})
+	return &models.CreateInviteResponse{Invite: invite, Code: code}, nil
+}
+
+// ListInvites 获取邀请码列表
+func (s *accountService) ListInvites() ([]*models.MerchantInvite, error) {
+	return s.inviteRepo.List()
+}
+
+// RevokeInvite 撤销邀请码
+func (s *accountService) RevokeInvite(actor *AuditActor, id uint64) error {
+	ok, err := s.inviteRepo.Revoke(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInviteNotFound
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditInviteRevoke, auditTargetInvite, strconv.FormatUint(id, 10), nil)
+	return nil
+}
+
+// ListPendingMerchants 获取待审核的商家
+func (s *accountService) ListPendingMerchants() ([]*models.User, error) {
+	return s.userRepo.ListByStatus(consts.Merchant, consts.AccountPending)
+}
+
+// ApproveMerchant 审核通过商家注册
+func (s *accountService) ApproveMerchant(actor *AuditActor, id uint64) (*models.User, error) {
+	user, err := s.pendingMerchant(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateStatus(user.ID, consts.AccountActive); err != nil {
+		return nil, err
+	}
+	user.Status = consts.AccountActive
+	writeAudit(s.auditRepo, actor, consts.AuditMerchantApprove, auditTargetUser, userTarget(user), map[string]interface{}{
+		"username": user.Username,
+	})
+	return user, nil
+}
+
+// RejectMerchant 拒绝商家注册，删除待审核的账号
+func (s *accountService) RejectMerchant(actor *AuditActor, id uint64) error {
+	user, err := s.pendingMerchant(id)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.Delete(user.ID); err != nil {
+		return err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditMerchantReject, auditTargetUser, userTarget(user), map[string]interface{}{
+		"username": user.Username,
+		"contact":  user.Contact,
+		"email":    user.Email,
+	})
+	if user.OrgID != 0 {
+		return s.orgRepo.Delete(user.OrgID)
+	}
+	return nil
+}
+
+// pendingMerchant 获取待审核的商家
+func (s *accountService) pendingMerchant(id uint64) (*models.User, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.UserType != consts.Merchant || user.Status != consts.AccountPending {
+		return nil, ErrAccountNotPending
+	}
+	return user, nil
+}
+
+// checkNewUser 检查用户名是否可用、密码是否符合密码策略
+func (s *accountService) checkNewUser(username string, userType uint8, pwd string) error {
+	if _, err := s.userRepo.GetByUsername(username, userType); err == nil {
+		return ErrUsernameTaken
+	}
+	return s.passwords.Validate(pwd, username)
+}
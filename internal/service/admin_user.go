@@ -0,0 +1,416 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/password"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrCannotModifySelf 管理员不能停用或删除自己的账号
+	ErrCannotModifySelf = errors.New("cannot suspend or delete your own account")
+	// ErrLastAdmin 不能停用或删除最后一个正常状态的管理员
+	ErrLastAdmin = errors.New("cannot suspend or delete the last active admin")
+	// ErrInvalidEmail 邮箱格式错误
+	ErrInvalidEmail = errors.New("invalid email address")
+	// ErrInvalidAccountStatus 当前账号状态不允许该操作
+	ErrInvalidAccountStatus = errors.New("operation not allowed in the current account status")
+	// ErrInvalidFeedbackPolicy 删除用户时反馈的处理方式无效
+	ErrInvalidFeedbackPolicy = errors.New("invalid feedback policy")
+)
+
+// 删除用户时其反馈的处理方式
+const (
+	// FeedbackPolicyKeep 保留用户创建和收到的反馈
+	FeedbackPolicyKeep = "keep"
+	// FeedbackPolicyDelete 删除用户创建和收到的反馈及其消息
+	FeedbackPolicyDelete = "delete"
+)
+
+const (
+	// adminUserPageSize 用户列表默认每页数量
+	adminUserPageSize = 20
+	// auditTargetUser 审计日志中用户对象的类型
+	auditTargetUser = "user"
+)
+
+// AdminUserService 管理员管理用户服务接口，查询和修改操作都写入审计日志
+type AdminUserService interface {
+	List(actor *AuditActor, query *models.AdminUserQuery) (*models.AdminUserListResponse, error)
+	Get(actor *AuditActor, id uint64) (*models.User, error)
+	Update(actor *AuditActor, id uint64, req *models.AdminUpdateUserRequest) (*models.User, error)
+	Suspend(actor *AuditActor, id uint64, reason string) (*models.User, error)
+	Unsuspend(actor *AuditActor, id uint64) (*models.User, error)
+	ResetPassword(actor *AuditActor, id uint64, req *models.AdminResetPasswordRequest) (*models.AdminResetPasswordResponse, error)
+	Delete(actor *AuditActor, id uint64, feedbackPolicy string) error
+}
+
+// adminUserService 管理员管理用户服务实现
+type adminUserService struct {
+	userRepo          repository.UserRepository
+	feedbackRepo      repository.FeedbackRepository
+	recoveryRepo      repository.RecoveryCodeRepository
+	resetRepo         repository.PasswordResetRepository
+	identityRepo      repository.UserIdentityRepository
+	apiKeyRepo        repository.APIKeyRepository
+	orgRepo           repository.OrganizationRepository
+	auditRepo         repository.AuditLogRepository
+	userService       UserService
+	feedbackService   FeedbackService
+	attachmentService AttachmentService
+	passwords         *password.Policy
+}
+
+// NewAdminUserService 创建管理员管理用户服务
+func NewAdminUserService(userRepo repository.UserRepository, feedbackRepo repository.FeedbackRepository, recoveryRepo repository.RecoveryCodeRepository, resetRepo repository.PasswordResetRepository, identityRepo repository.UserIdentityRepository, apiKeyRepo repository.APIKeyRepository, orgRepo repository.OrganizationRepository, auditRepo repository.AuditLogRepository, userService UserService, feedbackService FeedbackService, attachmentService AttachmentService, passwords *password.Policy) AdminUserService {
+	return &adminUserService{
+		userRepo:          userRepo,
+		feedbackRepo:      feedbackRepo,
+		recoveryRepo:      recoveryRepo,
+		resetRepo:         resetRepo,
+		identityRepo:      identityRepo,
+		apiKeyRepo:        apiKeyRepo,
+		orgRepo:           orgRepo,
+		auditRepo:         auditRepo,
+		userService:       userService,
+		feedbackService:   feedbackService,
+		attachmentService: attachmentService,
+		passwords:         passwords,
+	}
+}
+
+// List 按条件分页查询用户
+func (s *adminUserService) List(actor *AuditActor, query *models.AdminUserQuery) (*models.AdminUserListResponse, error) {
+	page, pageSize := query.Page, query.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = adminUserPageSize
+	}
+	query.Keyword = strings.TrimSpace(query.Keyword)
+
+	users, total, err := s.userRepo.Search(query, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditUserList, auditTargetUser, "", map[string]interface{}{
+		"user_type": query.UserType,
+		"status":    query.Status,
+		"keyword":   query.Keyword,
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+	})
+	return &models.AdminUserListResponse{
+		Items:    users,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// Get 获取用户详情
+func (s *adminUserService) Get(actor *AuditActor, id uint64) (*models.User, error) {
+	user, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditUserView, auditTargetUser, userTarget(user), nil)
+	return user, nil
+}
+
+// find 获取用户
+func (s *adminUserService) find(id uint64) (*models.User, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// Update 修改用户的联系方式和邮箱，审计日志中记录修改前后的值
+func (s *adminUserService) Update(actor *AuditActor, id uint64, req *models.AdminUpdateUserRequest) (*models.User, error) {
+	user, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	changes := map[string]interface{}{}
+	if req.Contact != nil {
+		contact, err := normalizeContact(*req.Contact)
+		if err != nil {
+			return nil, err
+		}
+		if contact != user.Contact {
+			fields["contact"] = contact
+			changes["contact"] = []string{user.Contact, contact}
+			user.Contact = contact
+		}
+	}
+	if req.Email != nil && strings.TrimSpace(*req.Email) != user.Email {
+		email := strings.TrimSpace(*req.Email)
+		if email != "" {
+			if _, err := mail.ParseAddress(email); err != nil {
+				return nil, ErrInvalidEmail
+			}
+		}
+		fields["email"] = email
+		changes["email"] = []string{user.Email, email}
+		user.Email = email
+	}
+	if req.Skills != nil {
+		skills := normalizeSkills(*req.Skills)
+		encoded, err := json.Marshal(skills)
+		if err != nil {
+			return nil, err
+		}
+		fields["skills"] = string(encoded)
+		changes["skills"] = [][]string{user.Skills, skills}
+		user.Skills = skills
+	}
+	if len(fields) == 0 {
+		return user, nil
+	}
+
+	if err := s.userRepo.UpdateFields(user.ID, fields); err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditUserUpdate, auditTargetUser, userTarget(user), map[string]interface{}{"changes": changes})
+	return user, nil
+}
+
+// Suspend 停用账号，并注销该账号的所有会话
+func (s *adminUserService) Suspend(actor *AuditActor, id uint64, reason string) (*models.User, error) {
+	user, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != consts.AccountActive {
+		return nil, ErrInvalidAccountStatus
+	}
+	if err := s.checkRemovable(actor, user); err != nil {
+		return nil, err
+	}
+
+	if err := s.deactivate(user); err != nil {
+		return nil, err
+	}
+	user.Status = consts.AccountSuspended
+	if err := s.userService.LogoutAll(user.ID, user.UserType); err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditUserSuspend, auditTargetUser, userTarget(user), map[string]interface{}{"reason": reason})
+	return user, nil
+}
+
+// Unsuspend 恢复已停用的账号
+func (s *adminUserService) Unsuspend(actor *AuditActor, id uint64) (*models.User, error) {
+	user, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != consts.AccountSuspended {
+		return nil, ErrInvalidAccountStatus
+	}
+
+	if err := s.userRepo.UpdateStatus(user.ID, consts.AccountActive); err != nil {
+		return nil, err
+	}
+	user.Status = consts.AccountActive
+	writeAudit(s.auditRepo, actor, consts.AuditUserUnsuspend, auditTargetUser, userTarget(user), nil)
+	return user, nil
+}
+
+// ResetPassword 重置用户密码
+// 未指定新密码时生成随机临时密码并在响应中返回；重置后用户下次登录必须修改密码，已登录的会话全部注销
+func (s *adminUserService) ResetPassword(actor *AuditActor, id uint64, req *models.AdminResetPasswordRequest) (*models.AdminResetPasswordResponse, error) {
+	user, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.AdminResetPasswordResponse{}
+	newPassword := req.NewPassword
+	if newPassword == "" {
+		if newPassword, err = generateTemporaryPassword(); err != nil {
+			return nil, err
+		}
+		response.TemporaryPassword = newPassword
+	} else if err := s.passwords.Validate(newPassword, user.Username); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdatePassword(user.ID, hashPassword(newPassword), true); err != nil {
+		return nil, err
+	}
+	if err := s.resetRepo.DeleteByUser(user.ID); err != nil {
+		return nil, err
+	}
+	if err := s.userService.LogoutAll(user.ID, user.UserType); err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditUserResetPassword, auditTargetUser, userTarget(user), map[string]interface{}{
+		"generated": response.TemporaryPassword != "",
+	})
+	return response, nil
+}
+
+// Delete 删除用户
+// feedbackPolicy 为 FeedbackPolicyKeep 时保留该用户创建和收到的反馈，为 FeedbackPolicyDelete 时一并删除
+func (s *adminUserService) Delete(actor *AuditActor, id uint64, feedbackPolicy string) error {
+	if feedbackPolicy == "" {
+		feedbackPolicy = FeedbackPolicyKeep
+	}
+	if feedbackPolicy != FeedbackPolicyKeep && feedbackPolicy != FeedbackPolicyDelete {
+		return ErrInvalidFeedbackPolicy
+	}
+
+	user, err := s.find(id)
+	if err != nil {
+		return err
+	}
+	if err := s.checkRemovable(actor, user); err != nil {
+		return err
+	}
+	// 组织所有者需要在删除其他员工后才能删除，删除最后一个员工时一并删除组织
+	var orgStaff []*models.User
+	if user.OrgID != 0 {
+		if orgStaff, err = s.userRepo.ListByOrg(user.OrgID); err != nil {
+			return err
+		}
+		if user.OrgRole == consts.OrgRoleOwner && len(orgStaff) > 1 {
+			return ErrOrganizationHasStaff
+		}
+	}
+
+	// 先停用账号，最后一个管理员的检查和停用在同一事务中完成，删除过程中账号也无法登录
+	if err := s.deactivate(user); err != nil {
+		return err
+	}
+
+	deleted := 0
+	if feedbackPolicy == FeedbackPolicyDelete {
+		if deleted, err = s.deleteFeedbacks(actor, user); err != nil {
+			return err
+		}
+	}
+
+	if err := s.userService.LogoutAll(user.ID, user.UserType); err != nil {
+		return err
+	}
+	if err := s.recoveryRepo.DeleteByUser(user.ID); err != nil {
+		return err
+	}
+	if err := s.resetRepo.DeleteByUser(user.ID); err != nil {
+		return err
+	}
+	if err := s.identityRepo.DeleteByUser(user.ID); err != nil {
+		return err
+	}
+	if err := s.apiKeyRepo.DeleteByUser(user.ID); err != nil {
+		return err
+	}
+	if err := s.feedbackRepo.ClearAssignee(user.ID); err != nil {
+		return err
+	}
+	if err := s.attachmentService.BindAvatar(user, 0); err != nil {
+		return err
+	}
+	if err := s.userRepo.Delete(user.ID); err != nil {
+		return err
+	}
+	if user.OrgID != 0 && len(orgStaff) <= 1 {
+		if err := s.orgRepo.Delete(user.OrgID); err != nil {
+			return err
+		}
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditUserDelete, auditTargetUser, userTarget(user), map[string]interface{}{
+		"username":          user.Username,
+		"user_type":         user.UserType,
+		"feedback_policy":   feedbackPolicy,
+		"deleted_feedbacks": deleted,
+	})
+	return nil
+}
+
+// deleteFeedbacks 删除用户创建和收到的反馈，返回删除的数量
+func (s *adminUserService) deleteFeedbacks(actor *AuditActor, user *models.User) (int, error) {
+	feedbacks, err := s.feedbackRepo.FindByCreator(user.ID, user.UserType)
+	if err != nil {
+		return 0, err
+	}
+	// 商家收到的反馈属于组织，只在删除组织的最后一个员工时删除
+	var received []*models.Feedback
+	switch user.UserType {
+	case consts.Merchant:
+		if user.OrgID != 0 && user.OrgRole == consts.OrgRoleOwner {
+			received, err = s.feedbackRepo.FindByTarget(user.OrgID, consts.TargetMerchant)
+		}
+	case consts.Admin:
+		received, err = s.feedbackRepo.FindByTarget(user.ID, consts.TargetAdmin)
+	}
+	if err != nil {
+		return 0, err
+	}
+	feedbacks = append(feedbacks, received...)
+
+	deleted := make(map[uint64]bool, len(feedbacks))
+	for _, feedback := range feedbacks {
+		if deleted[feedback.ID] {
+			continue
+		}
+		if err := s.feedbackService.Delete(actor.User, feedback.ID); err != nil {
+			return len(deleted), fmt.Errorf("删除反馈 %d 失败: %w", feedback.ID, err)
+		}
+		deleted[feedback.ID] = true
+	}
+	return len(deleted), nil
+}
+
+// checkRemovable 检查账号能否被停用或删除：不能操作自己
+// 最后一个正常状态的管理员由 deactivate 在修改状态时原子检查
+func (s *adminUserService) checkRemovable(actor *AuditActor, user *models.User) error {
+	if actor != nil && actor.User != nil && actor.User.ID == user.ID {
+		return ErrCannotModifySelf
+	}
+	return nil
+}
+
+// deactivate 停用账号，账号是最后一个正常状态的管理员时返回 ErrLastAdmin
+func (s *adminUserService) deactivate(user *models.User) error {
+	ok, err := s.userRepo.UpdateStatusUnlessLastAdmin(user.ID, consts.AccountSuspended)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// userTarget 审计日志中用户对象的标识
+func userTarget(user *models.User) string {
+	return strconv.FormatUint(user.ID, 10)
+}
+
+// generateTemporaryPassword 生成临时密码，用户登录后必须修改，因此不校验密码策略
+func generateTemporaryPassword() (string, error) {
+	first, err := generateRecoveryCode()
+	if err != nil {
+		return "", err
+	}
+	second, err := generateRecoveryCode()
+	if err != nil {
+		return "", err
+	}
+	return first + "-" + second, nil
+}
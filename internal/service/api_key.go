@@ -0,0 +1,224 @@
+package service
+
+import (
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidAPIKey API密钥无效、已过期或已撤销
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrAPIKeyNotFound API密钥不存在或不属于当前用户
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrInvalidAPIScope 权限范围无效
+	ErrInvalidAPIScope = errors.New("invalid api key scope")
+	// ErrTooManyAPIKeys 有效的API密钥数量已达上限
+	ErrTooManyAPIKeys = errors.New("too many api keys")
+)
+
+const (
+	// apiKeyPrefix API密钥的固定前缀，便于在代码和日志中识别泄露的密钥
+	apiKeyPrefix = "fbk_"
+	// apiKeyDisplayLength 列表中显示的密钥前缀长度（包含固定前缀）
+	apiKeyDisplayLength = 12
+	// maxActiveAPIKeys 每个商家最多同时有效的API密钥数量
+	maxActiveAPIKeys = 20
+	// apiKeyTouchInterval 最后使用时间的更新间隔，避免每个请求都写数据库
+	apiKeyTouchInterval = time.Minute
+	// auditTargetAPIKey 审计日志中API密钥对象的类型
+	auditTargetAPIKey = "api_key"
+)
+
+// APIKeyService API密钥服务接口
+// 商家创建API密钥后，其后端可以通过 X-API-Key 请求头以商家身份调用权限范围内的接口
+type APIKeyService interface {
+	Create(actor *AuditActor, req *models.CreateAPIKeyRequest) (*models.APIKeySecretResponse, error)
+	List(userID uint64) ([]*models.APIKey, error)
+	Rotate(actor *AuditActor, id uint64, graceHours int) (*models.APIKeySecretResponse, error)
+	Revoke(actor *AuditActor, id uint64) error
+
+	// 校验请求携带的API密钥，返回密钥所属的用户
+	Authenticate(secret, ip string) (*models.User, *models.APIKey, error)
+}
+
+// apiKeyService API密钥服务实现
+type apiKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+	userRepo   repository.UserRepository
+	auditRepo  repository.AuditLogRepository
+}
+
+// NewAPIKeyService 创建API密钥服务
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository, userRepo repository.UserRepository, auditRepo repository.AuditLogRepository) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+		auditRepo:  auditRepo,
+	}
+}
+
+// Create 创建API密钥，完整密钥只在响应中返回一次
+func (s *apiKeyService) Create(actor *AuditActor, req *models.CreateAPIKeyRequest) (*models.APIKeySecretResponse, error) {
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().Add(time.Duration(req.ExpiresInDays) * 24 * time.Hour)
+		expiresAt = &t
+	}
+	response, err := s.issue(actor.User.ID, strings.TrimSpace(req.Name), scopes, expiresAt, maxActiveAPIKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	writeAudit(s.auditRepo, actor, consts.AuditAPIKeyCreate, auditTargetAPIKey, apiKeyTarget(response.Key), map[string]interface{}{
+		"name":   response.Key.Name,
+		"scopes": scopes,
+	})
+	return response, nil
+}
+
+// List 获取用户的API密钥
+func (s *apiKeyService) List(userID uint64) ([]*models.APIKey, error) {
+	return s.apiKeyRepo.ListByUser(userID)
+}
+
+// Rotate 轮换API密钥：创建名称、权限和过期时间相同的新密钥，旧密钥在宽限期后失效
+// 宽限期内新旧密钥都可以使用，便于商家后端不停机切换；graceHours 为 0 时旧密钥立即失效
+func (s *apiKeyService) Rotate(actor *AuditActor, id uint64, graceHours int) (*models.APIKeySecretResponse, error) {
+	old, err := s.apiKeyRepo.GetByID(id, actor.User.ID)
+	if err != nil {
+		return nil, ErrAPIKeyNotFound
+	}
+	now := time.Now()
+	if !old.Active(now) {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	// 新密钥不占用旧密钥的名额
+	response, err := s.issue(actor.User.ID, old.Name, old.Scopes, old.ExpiresAt, maxActiveAPIKeys+1)
+	if err != nil {
+		return nil, err
+	}
+
+	oldExpiresAt := now.Add(time.Duration(graceHours) * time.Hour)
+	if old.ExpiresAt == nil || oldExpiresAt.Before(*old.ExpiresAt) {
+		if err := s.apiKeyRepo.Expire(old.ID, oldExpiresAt); err != nil {
+			return nil, err
+		}
+	}
+
+	writeAudit(s.auditRepo, actor, consts.AuditAPIKeyRotate, auditTargetAPIKey, apiKeyTarget(old), map[string]interface{}{
+		"new_key_id":  response.Key.ID,
+		"grace_hours": graceHours,
+	})
+	return response, nil
+}
+
+// Revoke 撤销API密钥，立即失效
+func (s *apiKeyService) Revoke(actor *AuditActor, id uint64) error {
+	ok, err := s.apiKeyRepo.Revoke(id, actor.User.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditAPIKeyRevoke, auditTargetAPIKey, strconv.FormatUint(id, 10), nil)
+	return nil
+}
+
+// Authenticate 校验API密钥，只有正常状态的商家账号可以使用
+func (s *apiKeyService) Authenticate(secret, ip string) (*models.User, *models.APIKey, error) {
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	key, err := s.apiKeyRepo.FindByHash(hashToken(secret))
+	if err != nil {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	now := time.Now()
+	if !key.Active(now) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.userRepo.GetByID(key.UserID)
+	if err != nil || user.UserType != consts.Merchant || checkAccountStatus(user) != nil {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval || key.LastUsedIP != ip {
+		s.apiKeyRepo.Touch(key.ID, truncate(ip, 45), now)
+		key.LastUsedAt, key.LastUsedIP = &now, ip
+	}
+	return user, key, nil
+}
+
+// issue 有效密钥数量未达到 limit 时生成并保存新的API密钥
+func (s *apiKeyService) issue(userID uint64, name string, scopes []string, expiresAt *time.Time, limit int64) (*models.APIKeySecretResponse, error) {
+	count, err := s.apiKeyRepo.CountActive(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if count >= limit {
+		return nil, ErrTooManyAPIKeys
+	}
+
+	random, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	secret := apiKeyPrefix + random
+	key := &models.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    secret[:apiKeyDisplayLength],
+		KeyHash:   hashToken(secret),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.apiKeyRepo.Create(key); err != nil {
+		return nil, err
+	}
+	return &models.APIKeySecretResponse{Key: key, Secret: secret}, nil
+}
+
+// normalizeScopes 校验权限范围并去重
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := map[string]bool{}
+	var result []string
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		valid := false
+		for _, s := range consts.APIScopes {
+			if scope == s {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, ErrInvalidAPIScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	if len(result) == 0 {
+		return nil, ErrInvalidAPIScope
+	}
+	return result, nil
+}
+
+// apiKeyTarget 审计日志中API密钥对象的标识
+func apiKeyTarget(key *models.APIKey) string {
+	return strconv.FormatUint(key.ID, 10)
+}
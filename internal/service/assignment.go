@@ -0,0 +1,376 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/ws"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrAssignForbidden 当前用户不能修改该反馈的处理人
+	ErrAssignForbidden = errors.New("not allowed to change the assignee of this feedback")
+	// ErrInvalidAssignee 处理人必须是反馈目标方中可以处理反馈的正常账号
+	ErrInvalidAssignee = errors.New("invalid assignee for this feedback")
+	// ErrAssignmentConflict 处理人已被其他人修改
+	ErrAssignmentConflict = errors.New("feedback assignee has been changed by someone else")
+	// ErrNoAssignmentTeam 当前账号不属于任何可以处理反馈的团队
+	ErrNoAssignmentTeam = errors.New("account does not belong to a feedback handling team")
+)
+
+// auditTargetAssignment 审计日志中自动分配设置对象的类型，对象ID为 "团队类型:团队ID"
+const auditTargetAssignment = "assignment_setting"
+
+// maxRouteAttempts 自动分配时轮流分配位置被并发修改后的最多尝试次数
+const maxRouteAttempts = 3
+
+// AssignmentService 反馈处理人服务接口
+// 处理人是反馈目标方的成员：发给商家的反馈为该商家组织的所有者或客服，发给管理员的反馈为管理员
+type AssignmentService interface {
+	// 分配、认领和取消分配，actor 为操作者
+	Assign(actor *models.User, feedbackID, assigneeID uint64) (*models.Feedback, error)
+	Claim(actor *models.User, feedbackID uint64) (*models.Feedback, error)
+	Unassign(actor *models.User, feedbackID uint64) (*models.Feedback, error)
+
+	// 目标方首次回复时，未分配的反馈自动分配给回复人
+	AutoAssign(feedback *models.Feedback, responder *models.User)
+
+	// 新反馈按目标团队的自动分配设置选择处理人
+	Route(feedback *models.Feedback)
+
+	// 自动化规则分配处理人，assigneeID 为 0 时按目标团队的自动分配设置选择处理人
+	AssignByRule(feedback *models.Feedback, assigneeID uint64, detail map[string]interface{}) error
+
+	// 团队的自动分配设置：商家组织由所有者设置，管理员团队由管理员设置
+	GetSetting(actor *models.User) (*models.AssignmentSetting, error)
+	UpdateSetting(actor *AuditActor, req *models.UpdateAssignmentSettingRequest) (*models.AssignmentSetting, error)
+}
+
+// assignmentService 反馈处理人服务实现
+type assignmentService struct {
+	feedbackRepo repository.FeedbackRepository
+	eventRepo    repository.FeedbackEventRepository
+	userRepo     repository.UserRepository
+	settingRepo  repository.AssignmentSettingRepository
+	auditRepo    repository.AuditLogRepository
+	wsHandler    *ws.WSHandler
+}
+
+// NewAssignmentService 创建反馈处理人服务
+func NewAssignmentService(feedbackRepo repository.FeedbackRepository, eventRepo repository.FeedbackEventRepository, userRepo repository.UserRepository, settingRepo repository.AssignmentSettingRepository, auditRepo repository.AuditLogRepository, wsHandler *ws.WSHandler) AssignmentService {
+	return &assignmentService{
+		feedbackRepo: feedbackRepo,
+		eventRepo:    eventRepo,
+		userRepo:     userRepo,
+		settingRepo:  settingRepo,
+		auditRepo:    auditRepo,
+		wsHandler:    wsHandler,
+	}
+}
+
+// Assign 将反馈分配给指定处理人，目标方可以处理反馈的成员和管理员可以分配
+func (s *assignmentService) Assign(actor *models.User, feedbackID, assigneeID uint64) (*models.Feedback, error) {
+	feedback, err := s.feedback(actor, feedbackID)
+	if err != nil {
+		return nil, err
+	}
+	assignee, err := s.userRepo.GetByID(assigneeID)
+	if err != nil || !canBeAssigned(feedback, assignee) {
+		return nil, ErrInvalidAssignee
+	}
+	return s.change(actor, feedback, assignee, consts.FeedbackEventAssign, nil)
+}
+
+// Claim 将反馈分配给自己
+func (s *assignmentService) Claim(actor *models.User, feedbackID uint64) (*models.Feedback, error) {
+	feedback, err := s.feedback(actor, feedbackID)
+	if err != nil {
+		return nil, err
+	}
+	if !canBeAssigned(feedback, actor) {
+		return nil, ErrInvalidAssignee
+	}
+	return s.change(actor, feedback, actor, consts.FeedbackEventClaim, nil)
+}
+
+// Unassign 取消分配
+func (s *assignmentService) Unassign(actor *models.User, feedbackID uint64) (*models.Feedback, error) {
+	feedback, err := s.feedback(actor, feedbackID)
+	if err != nil {
+		return nil, err
+	}
+	return s.change(actor, feedback, nil, consts.FeedbackEventUnassign, nil)
+}
+
+// AutoAssign 未分配的反馈自动分配给首次回复的目标方成员，失败时只记录日志
+func (s *assignmentService) AutoAssign(feedback *models.Feedback, responder *models.User) {
+	if feedback.AssigneeID != 0 || !canBeAssigned(feedback, responder) {
+		return
+	}
+	if _, err := s.change(nil, feedback, responder, consts.FeedbackEventAutoAssign, nil); err != nil && !errors.Is(err, ErrAssignmentConflict) {
+		log.Printf("自动分配反馈 %d 失败: %v", feedback.ID, err)
+	}
+}
+
+// Route 按目标团队的自动分配设置为新反馈选择处理人，团队未开启自动分配或没有可分配的成员时不分配，失败时只记录日志
+func (s *assignmentService) Route(feedback *models.Feedback) {
+	targetType, targetID := feedback.TargetType, feedback.TargetID
+	if targetType == consts.TargetAdmin {
+		targetID = 0
+	}
+	setting, err := s.settingRepo.Get(targetType, targetID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("获取自动分配设置失败: %v", err)
+		}
+		return
+	}
+	if _, ok := assignmentStrategies[setting.Strategy]; !ok {
+		return
+	}
+
+	candidates, err := s.candidates(feedback)
+	if err != nil {
+		log.Printf("获取反馈 %d 的候选处理人失败: %v", feedback.ID, err)
+		return
+	}
+
+	// 先推进轮流分配的位置再分配：位置已被同时创建的反馈推进时重新读取位置并重新选择，避免两条反馈分配给同一成员
+	var picked *AssignmentCandidate
+	for attempt := 1; ; attempt++ {
+		picked = selectAssignee(setting, feedback, candidates)
+		if picked == nil {
+			return
+		}
+		if picked.User.ID == setting.LastAssigneeID {
+			break
+		}
+		ok, err := s.settingRepo.AdvanceLastAssignee(setting.ID, setting.LastAssigneeID, picked.User.ID)
+		if err != nil {
+			log.Printf("记录自动分配位置失败: %v", err)
+			break
+		}
+		if ok {
+			break
+		}
+		if attempt == maxRouteAttempts {
+			log.Printf("反馈 %d 的自动分配位置多次被并发修改，按当前选择分配", feedback.ID)
+			break
+		}
+		if setting, err = s.settingRepo.Get(targetType, targetID); err != nil {
+			log.Printf("获取自动分配设置失败: %v", err)
+			return
+		}
+		if _, ok := assignmentStrategies[setting.Strategy]; !ok {
+			return
+		}
+	}
+
+	detail := map[string]interface{}{"strategy": setting.Strategy}
+	if _, err := s.change(nil, feedback, picked.User, consts.FeedbackEventAutoAssign, detail); err != nil {
+		log.Printf("自动分配反馈 %d 失败: %v", feedback.ID, err)
+	}
+}
+
+// AssignByRule 按自动化规则将反馈分配给指定处理人，处理记录为 auto_assign，detail 中包含规则信息
+func (s *assignmentService) AssignByRule(feedback *models.Feedback, assigneeID uint64, detail map[string]interface{}) error {
+	if assigneeID == 0 {
+		s.Route(feedback)
+		return nil
+	}
+	assignee, err := s.userRepo.GetByID(assigneeID)
+	if err != nil || !canBeAssigned(feedback, assignee) {
+		return ErrInvalidAssignee
+	}
+	_, err = s.change(nil, feedback, assignee, consts.FeedbackEventAutoAssign, detail)
+	return err
+}
+
+// candidates 获取反馈目标团队中可以分配的成员及其未解决反馈数量和在线状态
+func (s *assignmentService) candidates(feedback *models.Feedback) ([]*AssignmentCandidate, error) {
+	var members []*models.User
+	var err error
+	if feedback.TargetType == consts.TargetMerchant {
+		members, err = s.userRepo.ListByOrg(feedback.TargetID)
+	} else {
+		members, err = s.userRepo.GetAdmins()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*AssignmentCandidate
+	var ids []uint64
+	for _, member := range members {
+		if !canBeAssigned(feedback, member) {
+			continue
+		}
+		candidate := &AssignmentCandidate{User: member}
+		if s.wsHandler != nil {
+			candidate.Online = s.wsHandler.IsOnline(member.ID, member.UserType)
+		}
+		candidates = append(candidates, candidate)
+		ids = append(ids, member.ID)
+	}
+
+	counts, err := s.feedbackRepo.CountOpenByAssignees(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range candidates {
+		candidate.OpenCount = counts[candidate.User.ID]
+	}
+	return candidates, nil
+}
+
+// GetSetting 获取操作者所在团队的自动分配设置，未设置时返回手动分配
+func (s *assignmentService) GetSetting(actor *models.User) (*models.AssignmentSetting, error) {
+	targetType, targetID, err := assignmentTeam(actor)
+	if err != nil {
+		return nil, err
+	}
+	setting, err := s.settingRepo.Get(targetType, targetID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.AssignmentSetting{TargetType: targetType, TargetID: targetID, Strategy: consts.AssignStrategyManual}, nil
+	}
+	return setting, err
+}
+
+// UpdateSetting 修改操作者所在团队的自动分配设置，商家组织只有所有者可以修改
+func (s *assignmentService) UpdateSetting(actor *AuditActor, req *models.UpdateAssignmentSettingRequest) (*models.AssignmentSetting, error) {
+	targetType, targetID, err := assignmentTeam(actor.User)
+	if err != nil {
+		return nil, err
+	}
+	if targetType == consts.TargetMerchant && actor.User.OrgRole != consts.OrgRoleOwner {
+		return nil, ErrNotOrgOwner
+	}
+
+	setting := &models.AssignmentSetting{
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Strategy:    req.Strategy,
+		OnlineFirst: req.OnlineFirst,
+		SkillMatch:  req.SkillMatch,
+	}
+	if err := s.settingRepo.Save(setting); err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditAssignmentUpdate, auditTargetAssignment, fmt.Sprintf("%d:%d", targetType, targetID), map[string]interface{}{
+		"strategy":     req.Strategy,
+		"online_first": req.OnlineFirst,
+		"skill_match":  req.SkillMatch,
+	})
+	return s.GetSetting(actor.User)
+}
+
+// feedback 获取反馈并检查操作者能否修改其处理人
+func (s *assignmentService) feedback(actor *models.User, feedbackID uint64) (*models.Feedback, error) {
+	feedback, err := s.feedbackRepo.FindByID(feedbackID)
+	if err != nil {
+		return nil, ErrFeedbackNotFound
+	}
+	if actor.UserType != consts.Admin && !canBeAssigned(feedback, actor) {
+		return nil, ErrAssignForbidden
+	}
+	return feedback, nil
+}
+
+// change 修改处理人，写入处理记录并通知新旧处理人；actor 为 nil 表示系统自动操作，detail 为处理记录的附加信息
+func (s *assignmentService) change(actor *models.User, feedback *models.Feedback, assignee *models.User, action string, detail map[string]interface{}) (*models.Feedback, error) {
+	var assigneeID uint64
+	var assigneeName string
+	if assignee != nil {
+		assigneeID, assigneeName = assignee.ID, assignee.Name()
+	}
+	previousID := feedback.AssigneeID
+	if previousID == assigneeID {
+		feedback.AssigneeName = assigneeName
+		return feedback, nil
+	}
+
+	ok, err := s.feedbackRepo.UpdateAssignee(feedback.ID, assigneeID, previousID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrAssignmentConflict
+	}
+	feedback.AssigneeID, feedback.AssigneeName = assigneeID, assigneeName
+
+	if detail == nil {
+		detail = map[string]interface{}{}
+	}
+	detail["assignee_id"], detail["previous_assignee_id"] = assigneeID, previousID
+	recordFeedbackEvent(s.eventRepo, feedback.ID, actor, action, detail)
+	s.notify(actor, feedback, action, previousID)
+	return feedback, nil
+}
+
+// notify 发送处理人变更事件给新处理人和原处理人
+func (s *assignmentService) notify(actor *models.User, feedback *models.Feedback, action string, previousID uint64) {
+	if s.wsHandler == nil {
+		return
+	}
+
+	message := models.WSMessage{
+		Event:     consts.EventAssigned,
+		Timestamp: time.Now(),
+		Data: &models.AssignmentData{
+			FeedbackID:         feedback.ID,
+			Title:              feedback.Title,
+			Action:             action,
+			AssigneeID:         feedback.AssigneeID,
+			AssigneeName:       feedback.AssigneeName,
+			PreviousAssigneeID: previousID,
+		},
+	}
+	if actor != nil {
+		message.Sender = &models.Sender{ID: actor.ID, Type: actor.UserType, Name: actor.Name()}
+	}
+	jsonMessage, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+
+	for _, id := range []uint64{feedback.AssigneeID, previousID} {
+		if id == 0 {
+			continue
+		}
+		if user, err := s.userRepo.GetByID(id); err == nil {
+			s.wsHandler.SendMessageToUser(user.ID, user.UserType, jsonMessage)
+		}
+	}
+}
+
+// canBeAssigned 用户能否作为反馈的处理人：发给商家的反馈为该组织的所有者或客服，发给管理员的反馈为管理员，账号须为正常状态
+func canBeAssigned(feedback *models.Feedback, user *models.User) bool {
+	if user == nil || user.Status != consts.AccountActive {
+		return false
+	}
+	switch feedback.TargetType {
+	case consts.TargetMerchant:
+		return user.UserType == consts.Merchant && user.OrgID == feedback.TargetID && CanHandleFeedback(user)
+	case consts.TargetAdmin:
+		return user.UserType == consts.Admin
+	}
+	return false
+}
+
+// assignmentTeam 用户所在的处理团队：商家所属的组织，或管理员团队（TargetID 为 0）
+func assignmentTeam(user *models.User) (uint8, uint64, error) {
+	switch {
+	case user.UserType == consts.Merchant && user.OrgID != 0:
+		return consts.TargetMerchant, user.OrgID, nil
+	case user.UserType == consts.Admin:
+		return consts.TargetAdmin, 0, nil
+	}
+	return 0, 0, ErrNoAssignmentTeam
+}
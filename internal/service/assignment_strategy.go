@@ -0,0 +1,134 @@
+package service
+
+import (
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"sort"
+	"strings"
+)
+
+// AssignmentCandidate 自动分配的候选处理人
+type AssignmentCandidate struct {
+	User      *models.User
+	OpenCount int64 // 未解决的反馈数量
+	Online    bool  // 是否有在线的 WebSocket 连接
+}
+
+// AssignmentStrategy 自动分配策略，从候选人中选出处理人
+// candidates 不为空且按用户ID升序排列；相同输入必须返回相同结果，不能依赖随机数或当前时间
+type AssignmentStrategy interface {
+	Pick(setting *models.AssignmentSetting, candidates []*AssignmentCandidate) *AssignmentCandidate
+}
+
+// candidateFilter 按条件缩小候选人范围，返回满足条件的候选人，保持原有顺序
+type candidateFilter func(feedback *models.Feedback, candidates []*AssignmentCandidate) []*AssignmentCandidate
+
+// assignmentStrategies 可用的自动分配策略，新增策略时在这里注册并加入 UpdateAssignmentSettingRequest 的校验
+var assignmentStrategies = map[string]AssignmentStrategy{
+	consts.AssignStrategyRoundRobin:  roundRobinStrategy{},
+	consts.AssignStrategyLeastLoaded: leastLoadedStrategy{},
+}
+
+// roundRobinStrategy 轮流分配：选择ID大于上一次分配成员的第一个候选人，没有时从头开始
+type roundRobinStrategy struct{}
+
+func (roundRobinStrategy) Pick(setting *models.AssignmentSetting, candidates []*AssignmentCandidate) *AssignmentCandidate {
+	for _, candidate := range candidates {
+		if candidate.User.ID > setting.LastAssigneeID {
+			return candidate
+		}
+	}
+	return candidates[0]
+}
+
+// leastLoadedStrategy 分配给未解决反馈最少的候选人，数量相同时按轮流分配的顺序选择
+type leastLoadedStrategy struct{}
+
+func (leastLoadedStrategy) Pick(setting *models.AssignmentSetting, candidates []*AssignmentCandidate) *AssignmentCandidate {
+	min := candidates[0].OpenCount
+	for _, candidate := range candidates[1:] {
+		if candidate.OpenCount < min {
+			min = candidate.OpenCount
+		}
+	}
+
+	var least []*AssignmentCandidate
+	for _, candidate := range candidates {
+		if candidate.OpenCount == min {
+			least = append(least, candidate)
+		}
+	}
+	return roundRobinStrategy{}.Pick(setting, least)
+}
+
+// skillMatchFilter 保留技能包含反馈分类的候选人（不区分大小写），反馈没有分类时不筛选
+func skillMatchFilter(feedback *models.Feedback, candidates []*AssignmentCandidate) []*AssignmentCandidate {
+	category := strings.ToLower(strings.TrimSpace(feedback.Category))
+	if category == "" {
+		return candidates
+	}
+
+	var matched []*AssignmentCandidate
+	for _, candidate := range candidates {
+		for _, skill := range candidate.User.Skills {
+			if strings.EqualFold(skill, category) {
+				matched = append(matched, candidate)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// onlineFilter 保留在线的候选人
+func onlineFilter(_ *models.Feedback, candidates []*AssignmentCandidate) []*AssignmentCandidate {
+	var online []*AssignmentCandidate
+	for _, candidate := range candidates {
+		if candidate.Online {
+			online = append(online, candidate)
+		}
+	}
+	return online
+}
+
+// selectAssignee 按团队设置从候选人中选出处理人，策略为手动分配或没有候选人时返回 nil
+// 先按技能、再按在线状态缩小范围，某一步没有满足条件的候选人时忽略该条件，保证有候选人时总能分配
+func selectAssignee(setting *models.AssignmentSetting, feedback *models.Feedback, candidates []*AssignmentCandidate) *AssignmentCandidate {
+	strategy, ok := assignmentStrategies[setting.Strategy]
+	if !ok || len(candidates) == 0 {
+		return nil
+	}
+
+	sorted := make([]*AssignmentCandidate, len(candidates))
+	copy(sorted, candidates)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].User.ID < sorted[j].User.ID })
+
+	var filters []candidateFilter
+	if setting.SkillMatch {
+		filters = append(filters, skillMatchFilter)
+	}
+	if setting.OnlineFirst {
+		filters = append(filters, onlineFilter)
+	}
+	for _, filter := range filters {
+		if narrowed := filter(feedback, sorted); len(narrowed) > 0 {
+			sorted = narrowed
+		}
+	}
+	return strategy.Pick(setting, sorted)
+}
+
+// normalizeSkills 整理技能列表：去除首尾空白、转为小写、去掉空值和重复项
+func normalizeSkills(skills []string) []string {
+	normalized := make([]string, 0, len(skills))
+	seen := make(map[string]bool, len(skills))
+	for _, skill := range skills {
+		skill = strings.ToLower(strings.TrimSpace(skill))
+		if skill == "" || seen[skill] {
+			continue
+		}
+		seen[skill] = true
+		normalized = append(normalized, skill)
+	}
+	return normalized
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"testing"
+)
+
+// candidate 构造候选人，skills 为成员技能
+func candidate(id uint64, openCount int64, online bool, skills ...string) *AssignmentCandidate {
+	return &AssignmentCandidate{
+		User:      &models.User{ID: id, Skills: skills},
+		OpenCount: openCount,
+		Online:    online,
+	}
+}
+
+func candidateIDs(candidates []*AssignmentCandidate) []uint64 {
+	ids := make([]uint64, 0, len(candidates))
+	for _, c := range candidates {
+		ids = append(ids, c.User.ID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRoundRobinStrategy(t *testing.T) {
+	candidates := []*AssignmentCandidate{candidate(2, 0, false), candidate(5, 0, false), candidate(9, 0, false)}
+	tests := []struct {
+		name string
+		last uint64
+		want uint64
+	}{
+		{"first assignment", 0, 2},
+		{"next member", 2, 5},
+		{"skips to next larger ID", 6, 9},
+		{"wraps around after last member", 9, 2},
+		{"wraps around when last member left", 12, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := roundRobinStrategy{}.Pick(&models.AssignmentSetting{LastAssigneeID: tt.last}, candidates)
+			if got.User.ID != tt.want {
+				t.Errorf("Pick() = %d, want %d", got.User.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundRobinStrategyCyclesThroughMembers(t *testing.T) {
+	candidates := []*AssignmentCandidate{candidate(1, 0, false), candidate(2, 0, false), candidate(3, 0, false)}
+	setting := &models.AssignmentSetting{}
+	var got []uint64
+	for i := 0; i < 7; i++ {
+		picked := roundRobinStrategy{}.Pick(setting, candidates)
+		setting.LastAssigneeID = picked.User.ID
+		got = append(got, picked.User.ID)
+	}
+	if want := []uint64{1, 2, 3, 1, 2, 3, 1}; !equalIDs(got, want) {
+		t.Errorf("picks = %v, want %v", got, want)
+	}
+}
+
+func TestLeastLoadedStrategy(t *testing.T) {
+	tests := []struct {
+		name       string
+		last       uint64
+		candidates []*AssignmentCandidate
+		want       uint64
+	}{
+		{"single least loaded", 0, []*AssignmentCandidate{candidate(1, 3, false), candidate(2, 1, false), candidate(3, 2, false)}, 2},
+		{"tie picks first after last assignee", 1, []*AssignmentCandidate{candidate(1, 1, false), candidate(2, 4, false), candidate(3, 1, false), candidate(4, 1, false)}, 3},
+		{"tie wraps around", 4, []*AssignmentCandidate{candidate(1, 1, false), candidate(2, 4, false), candidate(3, 1, false), candidate(4, 1, false)}, 1},
+		{"last assignee not among least loaded", 2, []*AssignmentCandidate{candidate(1, 0, false), candidate(2, 0, false), candidate(3, 5, false)}, 1},
+		{"all zero", 0, []*AssignmentCandidate{candidate(7, 0, false), candidate(8, 0, false)}, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := leastLoadedStrategy{}.Pick(&models.AssignmentSetting{LastAssigneeID: tt.last}, tt.candidates)
+			if got.User.ID != tt.want {
+				t.Errorf("Pick() = %d, want %d", got.User.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkillMatchFilter(t *testing.T) {
+	candidates := []*AssignmentCandidate{
+		candidate(1, 0, false, "billing"),
+		candidate(2, 0, false, "shipping", "refund"),
+		candidate(3, 0, false),
+		candidate(4, 0, false, "Refund"),
+	}
+	tests := []struct {
+		name     string
+		category string
+		want     []uint64
+	}{
+		{"no category keeps everyone", "", []uint64{1, 2, 3, 4}},
+		{"blank category keeps everyone", "  ", []uint64{1, 2, 3, 4}},
+		{"case and space insensitive", " REFUND ", []uint64{2, 4}},
+		{"no match", "delivery", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := candidateIDs(skillMatchFilter(&models.Feedback{Category: tt.category}, candidates))
+			if !equalIDs(got, tt.want) {
+				t.Errorf("skillMatchFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOnlineFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []*AssignmentCandidate
+		want       []uint64
+	}{
+		{"keeps online in order", []*AssignmentCandidate{candidate(1, 0, true), candidate(2, 0, false), candidate(3, 0, true)}, []uint64{1, 3}},
+		{"nobody online", []*AssignmentCandidate{candidate(1, 0, false), candidate(2, 0, false)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := candidateIDs(onlineFilter(&models.Feedback{}, tt.candidates))
+			if !equalIDs(got, tt.want) {
+				t.Errorf("onlineFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectAssignee(t *testing.T) {
+	members := func() []*AssignmentCandidate {
+		return []*AssignmentCandidate{
+			candidate(1, 2, false, "billing"),
+			candidate(2, 0, true, "refund"),
+			candidate(3, 1, true, "billing"),
+			candidate(4, 0, false),
+		}
+	}
+	tests := []struct {
+		name       string
+		setting    models.AssignmentSetting
+		category   string
+		candidates []*AssignmentCandidate
+		want       uint64 // 0 表示不分配
+	}{
+		{"manual strategy", models.AssignmentSetting{Strategy: consts.AssignStrategyManual}, "", members(), 0},
+		{"unknown strategy", models.AssignmentSetting{Strategy: "random"}, "", members(), 0},
+		{"no candidates", models.AssignmentSetting{Strategy: consts.AssignStrategyRoundRobin}, "", nil, 0},
+		{"round robin", models.AssignmentSetting{Strategy: consts.AssignStrategyRoundRobin, LastAssigneeID: 2}, "", members(), 3},
+		{"least loaded tie", models.AssignmentSetting{Strategy: consts.AssignStrategyLeastLoaded, LastAssigneeID: 2}, "", members(), 4},
+		{"skill match", models.AssignmentSetting{Strategy: consts.AssignStrategyLeastLoaded, SkillMatch: true}, "billing", members(), 3},
+		{"skill then online", models.AssignmentSetting{Strategy: consts.AssignStrategyRoundRobin, SkillMatch: true, OnlineFirst: true}, "billing", members(), 3},
+		{"online first", models.AssignmentSetting{Strategy: consts.AssignStrategyLeastLoaded, OnlineFirst: true}, "", members(), 2},
+		{"skill match falls back when nobody matches", models.AssignmentSetting{Strategy: consts.AssignStrategyLeastLoaded, SkillMatch: true}, "shipping", members(), 2},
+		{"online falls back when nobody online", models.AssignmentSetting{Strategy: consts.AssignStrategyRoundRobin, OnlineFirst: true, LastAssigneeID: 1},
+			"", []*AssignmentCandidate{candidate(1, 0, false), candidate(2, 0, false)}, 2},
+		{"online fallback keeps skill match", models.AssignmentSetting{Strategy: consts.AssignStrategyRoundRobin, SkillMatch: true, OnlineFirst: true, LastAssigneeID: 2},
+			"refund", []*AssignmentCandidate{candidate(1, 0, false, "refund"), candidate(2, 0, false, "refund"), candidate(3, 0, true)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setting := tt.setting
+			got := selectAssignee(&setting, &models.Feedback{Category: tt.category}, tt.candidates)
+			var gotID uint64
+			if got != nil {
+				gotID = got.User.ID
+			}
+			if gotID != tt.want {
+				t.Errorf("selectAssignee() = %d, want %d", gotID, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectAssigneeIgnoresInputOrder(t *testing.T) {
+	settings := []models.AssignmentSetting{
+		{Strategy: consts.AssignStrategyRoundRobin},
+		{Strategy: consts.AssignStrategyRoundRobin, LastAssigneeID: 3},
+		{Strategy: consts.AssignStrategyLeastLoaded, LastAssigneeID: 1},
+		{Strategy: consts.AssignStrategyLeastLoaded, SkillMatch: true, OnlineFirst: true},
+	}
+	orders := [][]int{{0, 1, 2, 3}, {3, 2, 1, 0}, {2, 0, 3, 1}, {1, 3, 0, 2}}
+	members := []*AssignmentCandidate{
+		candidate(1, 1, true, "billing"),
+		candidate(3, 1, false, "billing"),
+		candidate(5, 2, true),
+		candidate(8, 1, true, "billing"),
+	}
+	feedback := &models.Feedback{Category: "billing"}
+
+	for _, setting := range settings {
+		setting := setting
+		var want uint64
+		for i, order := range orders {
+			candidates := make([]*AssignmentCandidate, len(order))
+			for j, k := range order {
+				candidates[j] = members[k]
+			}
+			got := selectAssignee(&setting, feedback, candidates).User.ID
+			if i == 0 {
+				want = got
+			} else if got != want {
+				t.Errorf("%+v: order %v picked %d, order %v picked %d", setting, orders[0], want, order, got)
+			}
+		}
+		// 选择时不能修改调用方的切片
+		if ids := candidateIDs(members); !equalIDs(ids, []uint64{1, 3, 5, 8}) {
+			t.Fatalf("members reordered: %v", ids)
+		}
+	}
+}
@@ -0,0 +1,742 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/imaging"
+	"feedback-system/pkg/signurl"
+	"feedback-system/pkg/storage"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// attachmentPathPrefix 附件下载地址前缀，数据库中保存不带签名的规范地址
+	attachmentPathPrefix = "/api/attachments/"
+
+	// attachmentVariantParam 缩略图查询参数，签名只覆盖附件本身，同一签名可访问所有规格
+	attachmentVariantParam = "variant"
+)
+
+var (
+	// ErrFileTypeNotAllowed 文件类型不在允许上传的范围内
+	ErrFileTypeNotAllowed = errors.New("file type not allowed")
+	// ErrQuotaExceeded 超出用户的存储配额
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+	// ErrInvalidAvatar 头像附件不存在、不是本人上传或不是图片
+	ErrInvalidAvatar = errors.New("invalid avatar attachment")
+	// ErrInvalidAttachment 反馈或消息引用的附件不存在，或既不是本人上传的未使用附件，也不属于该反馈
+	ErrInvalidAttachment = errors.New("invalid attachment reference")
+)
+
+// AttachmentService 附件服务接口
+type AttachmentService interface {
+	// 上传图片附件：校验文件内容、去除元数据并生成缩略图
+	UploadImage(ctx context.Context, uploader *models.User, filename string, r io.Reader) (*models.Attachment, error)
+
+	// 上传任意允许类型的附件：图片走图片处理流程，其余类型按文件内容校验后原样保存
+	UploadFile(ctx context.Context, uploader *models.User, filename string, size int64, r io.Reader) (*models.Attachment, error)
+
+	// 获取附件
+	GetByID(id uint64) (*models.Attachment, error)
+
+	// 检查用户是否有权访问附件
+	CanAccess(attachment *models.Attachment, user *models.User) bool
+
+	// 打开附件内容（variant 为空时打开原图），存储驱动支持预签名时返回跳转地址，否则返回文件内容
+	Open(ctx context.Context, attachment *models.Attachment, variant string) (redirectURL string, reader io.ReadCloser, info *storage.ObjectInfo, err error)
+
+	// 校验附件签名链接
+	VerifySignature(id uint64, expires, signature string) error
+
+	// 生成附件签名链接
+	SignedURL(id uint64) string
+
+	// 生成附件各缩略图规格的签名链接
+	SignedVariantURLs(id uint64) map[string]string
+
+	// 将URL列表转换为规范地址，并返回其中引用的附件ID
+	NormalizeURLs(urls []string) ([]string, []uint64)
+
+	// 为URL列表中的附件地址加上签名，只签名属于该反馈或 viewer 有权访问的附件
+	SignURLs(feedbackID uint64, viewer *models.User, urls []string) []string
+
+	// 规范化消息内容中的图片地址，并返回其中引用的附件ID
+	NormalizeMessageContent(contentType uint8, content string) (string, []uint64)
+
+	// 为消息内容中的图片地址加上签名，签名范围同 SignURLs
+	SignMessageContent(feedbackID uint64, viewer *models.User, contentType uint8, content string) string
+
+	// 检查反馈或消息引用的附件能否绑定到反馈，feedbackID 为 0 表示新建的反馈
+	CheckBindable(feedbackID uint64, attachmentIDs []uint64, uploaderID uint64, uploaderType uint8) error
+
+	// 将附件绑定到反馈，并记录反馈或消息对附件的引用
+	BindToFeedback(feedbackID uint64, refType uint8, refID uint64, attachmentIDs []uint64, uploaderID uint64, uploaderType uint8) error
+
+	// 删除反馈及其消息对附件的引用
+	ReleaseFeedback(feedbackID uint64) error
+
+	// 设置用户头像对附件的引用，attachmentID 为 0 时只删除原头像的引用
+	BindAvatar(user *models.User, attachmentID uint64) error
+
+	// 检查上传指定大小的文件后是否超出存储配额
+	CheckQuota(user *models.User, size int64) error
+
+	// 获取用户的存储用量
+	GetUsage(user *models.User) (*models.StorageUsage, error)
+
+	// 清理超过保留期且未被引用的附件
+	SweepOrphans(ctx context.Context, grace time.Duration) (int, error)
+}
+
+// attachmentService 附件服务实现
+type attachmentService struct {
+	attachmentRepo repository.AttachmentRepository
+	feedbackRepo   repository.FeedbackRepository
+	storage        storage.Storage
+	signer         *signurl.Signer
+	presignExpiry  time.Duration
+	imageOptions   imaging.Options
+	allowedTypes   []string
+	quota          map[uint8]int64
+}
+
+// NewAttachmentService 创建附件服务
+// allowedTypes 为图片以外允许上传的文件类型（按文件内容识别的MIME类型），quota 为各用户类型的存储配额（0 表示不限制）
+func NewAttachmentService(attachmentRepo repository.AttachmentRepository, feedbackRepo repository.FeedbackRepository, store storage.Storage, signer *signurl.Signer, presignExpiry time.Duration, imageOptions imaging.Options, allowedTypes []string, quota map[uint8]int64) AttachmentService {
+	return &attachmentService{
+		attachmentRepo: attachmentRepo,
+		feedbackRepo:   feedbackRepo,
+		storage:        store,
+		signer:         signer,
+		presignExpiry:  presignExpiry,
+		imageOptions:   imageOptions,
+		allowedTypes:   allowedTypes,
+		quota:          quota,
+	}
+}
+
+// UploadImage 上传图片附件
+// 不信任客户端提供的Content-Type和扩展名：按文件头识别格式并完整解码，
+// 重新编码后保存（去除EXIF/GPS等元数据），同时生成多种尺寸的缩略图
+// 图片文件超过 imageOptions.MaxBytes 时不再继续读取，返回 imaging.ErrTooLarge
+func (s *attachmentService) UploadImage(ctx context.Context, uploader *models.User, filename string, r io.Reader) (*models.Attachment, error) {
+	data, err := imaging.ReadAll(r, s.imageOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按原始内容去重，重复上传不再重新处理图片
+	hash := contentHash(data)
+	if attachment, err := s.reuse(uploader, filename, hash, int64(len(data))); attachment != nil || err != nil {
+		return attachment, err
+	}
+	if err := s.CheckQuota(uploader, int64(len(data))); err != nil {
+		return nil, err
+	}
+
+	result, err := imaging.Process(bytes.NewReader(data), s.imageOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	base := "attachments/" + uuid.New().String()
+	attachment := &models.Attachment{
+		StorageKey:   base + result.Original.Ext,
+		ContentHash:  hash,
+		Filename:     filepath.Base(filename),
+		ContentType:  result.Original.ContentType,
+		Size:         int64(len(result.Original.Data)),
+		Width:        result.Original.Width,
+		Height:       result.Original.Height,
+		UploaderID:   uploader.ID,
+		UploaderType: uploader.UserType,
+	}
+	for _, v := range result.Variants {
+		attachment.Variants = append(attachment.Variants, models.AttachmentVariant{
+			Name:        v.Name,
+			StorageKey:  base + "_" + v.Name + v.Ext,
+			ContentType: v.ContentType,
+			Size:        int64(len(v.Data)),
+			Width:       v.Width,
+			Height:      v.Height,
+		})
+	}
+
+	// 写入存储，任一文件失败时清理已写入的文件
+	stored := make([]string, 0, len(result.Variants)+1)
+	cleanup := func() {
+		for _, key := range stored {
+			s.storage.Delete(ctx, key)
+		}
+	}
+	if err := s.storage.Put(ctx, attachment.StorageKey, bytes.NewReader(result.Original.Data), attachment.Size, attachment.ContentType); err != nil {
+		return nil, err
+	}
+	stored = append(stored, attachment.StorageKey)
+	for i, v := range result.Variants {
+		variant := attachment.Variants[i]
+		if err := s.storage.Put(ctx, variant.StorageKey, bytes.NewReader(v.Data), variant.Size, variant.ContentType); err != nil {
+			cleanup()
+			return nil, err
+		}
+		stored = append(stored, variant.StorageKey)
+	}
+
+	if err := s.attachmentRepo.Create(attachment); err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	s.sign(attachment)
+	return attachment, nil
+}
+
+// UploadFile 上传任意允许类型的附件
+func (s *attachmentService) UploadFile(ctx context.Context, uploader *models.User, filename string, size int64, r io.Reader) (*models.Attachment, error) {
+	// 读取文件头识别真实类型
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+	body := io.MultiReader(bytes.NewReader(head), r)
+
+	if _, err := imaging.Sniff(head); err == nil {
+		// 图片需要读入内存处理，按声明的大小提前拒绝超大文件
+		if s.imageOptions.MaxBytes > 0 && size > s.imageOptions.MaxBytes {
+			return nil, imaging.ErrTooLarge
+		}
+		return s.UploadImage(ctx, uploader, filename, body)
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !s.isAllowedType(contentType) {
+		return nil, ErrFileTypeNotAllowed
+	}
+	if err := s.CheckQuota(uploader, size); err != nil {
+		return nil, err
+	}
+
+	// 边写入边计算哈希，大文件无需先读入内存
+	hasher := sha256.New()
+	attachment := &models.Attachment{
+		StorageKey:   "attachments/" + uuid.New().String() + strings.ToLower(filepath.Ext(filename)),
+		Filename:     filepath.Base(filename),
+		ContentType:  contentType,
+		Size:         size,
+		UploaderID:   uploader.ID,
+		UploaderType: uploader.UserType,
+	}
+	if err := s.storage.Put(ctx, attachment.StorageKey, io.TeeReader(body, hasher), size, contentType); err != nil {
+		return nil, err
+	}
+	attachment.ContentHash = hex.EncodeToString(hasher.Sum(nil))
+
+	// 内容已存在时删除刚写入的对象，改为引用已有对象
+	if existing, err := s.reuse(uploader, filename, attachment.ContentHash, 0); existing != nil || err != nil {
+		s.storage.Delete(ctx, attachment.StorageKey)
+		return existing, err
+	}
+
+	if err := s.attachmentRepo.Create(attachment); err != nil {
+		s.storage.Delete(ctx, attachment.StorageKey)
+		return nil, err
+	}
+
+	s.sign(attachment)
+	return attachment, nil
+}
+
+// reuse 查找内容相同的已有附件
+// 上传者本人已上传过且尚未绑定到反馈时直接返回原附件；否则创建新的附件记录并共用存储对象，
+// 附件记录仍按上传者区分，访问权限不受影响。size 大于 0 时按该大小检查配额
+func (s *attachmentService) reuse(uploader *models.User, filename, hash string, size int64) (*models.Attachment, error) {
+	if existing, err := s.attachmentRepo.FindByUploaderAndHash(uploader.ID, uploader.UserType, hash); err == nil {
+		s.sign(existing)
+		return existing, nil
+	}
+
+	source, err := s.attachmentRepo.FindByContentHash(hash)
+	if err != nil {
+		return nil, nil
+	}
+	if size > 0 {
+		if err := s.CheckQuota(uploader, size); err != nil {
+			return nil, err
+		}
+	}
+
+	attachment := &models.Attachment{
+		StorageKey:   source.StorageKey,
+		ContentHash:  hash,
+		Filename:     filepath.Base(filename),
+		ContentType:  source.ContentType,
+		Size:         source.Size,
+		Width:        source.Width,
+		Height:       source.Height,
+		UploaderID:   uploader.ID,
+		UploaderType: uploader.UserType,
+	}
+	for _, v := range source.Variants {
+		v.ID, v.AttachmentID = 0, 0
+		attachment.Variants = append(attachment.Variants, v)
+	}
+	if err := s.attachmentRepo.Create(attachment); err != nil {
+		return nil, err
+	}
+
+	s.sign(attachment)
+	return attachment, nil
+}
+
+// CheckQuota 检查上传指定大小的文件后是否超出存储配额
+func (s *attachmentService) CheckQuota(user *models.User, size int64) error {
+	quota := s.quota[user.UserType]
+	if quota <= 0 {
+		return nil
+	}
+	used, _, err := s.attachmentRepo.GetUsage(user.ID, user.UserType)
+	if err != nil {
+		return err
+	}
+	if used+size > quota {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// GetUsage 获取用户的存储用量
+func (s *attachmentService) GetUsage(user *models.User) (*models.StorageUsage, error) {
+	used, count, err := s.attachmentRepo.GetUsage(user.ID, user.UserType)
+	if err != nil {
+		return nil, err
+	}
+	return &models.StorageUsage{
+		Used:  used,
+		Quota: s.quota[user.UserType],
+		Count: count,
+	}, nil
+}
+
+// GetByID 获取附件
+func (s *attachmentService) GetByID(id uint64) (*models.Attachment, error) {
+	return s.attachmentRepo.FindByID(id)
+}
+
+// CanAccess 检查用户是否有权访问附件
+// 上传者本人和管理员始终可以访问；绑定到反馈后，反馈的参与方均可访问
+func (s *attachmentService) CanAccess(attachment *models.Attachment, user *models.User) bool {
+	if user == nil {
+		return false
+	}
+	if user.UserType == consts.Admin {
+		return true
+	}
+	if attachment.UploaderID == user.ID && attachment.UploaderType == user.UserType {
+		return true
+	}
+	if attachment.FeedbackID == nil {
+		return false
+	}
+
+	feedback, err := s.feedbackRepo.FindByID(*attachment.FeedbackID)
+	if err != nil {
+		return false
+	}
+	return isFeedbackParticipant(feedback, user)
+}
+
+// Open 打开附件内容
+// 请求的缩略图不存在时（原图本身已小于该规格）返回原图
+func (s *attachmentService) Open(ctx context.Context, attachment *models.Attachment, variant string) (string, io.ReadCloser, *storage.ObjectInfo, error) {
+	key, contentType := attachment.StorageKey, attachment.ContentType
+	for _, v := range attachment.Variants {
+		if v.Name == variant {
+			key, contentType = v.StorageKey, v.ContentType
+			break
+		}
+	}
+
+	presignedURL, err := s.storage.PresignGet(ctx, key, s.presignExpiry)
+	if err == nil {
+		return presignedURL, nil, nil, nil
+	}
+	if !errors.Is(err, storage.ErrPresignNotSupported) {
+		return "", nil, nil, err
+	}
+
+	reader, info, err := s.storage.Get(ctx, key)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	// 以记录中的内容类型为准，本地驱动只能按扩展名推断
+	if contentType != "" {
+		info.ContentType = contentType
+	}
+	return "", reader, info, nil
+}
+
+// VerifySignature 校验附件签名链接
+func (s *attachmentService) VerifySignature(id uint64, expires, signature string) error {
+	return s.signer.Verify(attachmentPath(id), nil, expires, signature)
+}
+
+// SignedURL 生成附件签名链接
+func (s *attachmentService) SignedURL(id uint64) string {
+	return s.signer.Sign(attachmentPath(id), nil)
+}
+
+// SignedVariantURLs 生成附件各缩略图规格的签名链接
+// 返回所有配置的规格，未生成的规格由下载接口回退到原图
+func (s *attachmentService) SignedVariantURLs(id uint64) map[string]string {
+	signed := s.SignedURL(id)
+	urls := make(map[string]string, len(s.imageOptions.Variants))
+	for _, spec := range s.imageOptions.Variants {
+		urls[spec.Name] = signed + "&" + url.Values{attachmentVariantParam: {spec.Name}}.Encode()
+	}
+	return urls
+}
+
+// NormalizeURLs 将URL列表转换为规范地址
+// 前端提交的是上传接口返回的签名链接，保存前去掉签名参数，读取时再重新签名
+func (s *attachmentService) NormalizeURLs(urls []string) ([]string, []uint64) {
+	if len(urls) == 0 {
+		return urls, nil
+	}
+	normalized := make([]string, 0, len(urls))
+	var ids []uint64
+	for _, u := range urls {
+		if id, ok := parseAttachmentURL(u); ok {
+			normalized = append(normalized, attachmentPath(id))
+			ids = append(ids, id)
+			continue
+		}
+		normalized = append(normalized, u)
+	}
+	return normalized, ids
+}
+
+// SignURLs 为URL列表中的附件地址加上签名
+// 只签名已绑定到该反馈或 viewer 有权访问的附件，其余附件地址保持不带签名的规范地址，下载时按令牌鉴权
+func (s *attachmentService) SignURLs(feedbackID uint64, viewer *models.User, urls []string) []string {
+	if len(urls) == 0 {
+		return urls
+	}
+	var ids []uint64
+	for _, u := range urls {
+		if id, ok := parseAttachmentURL(u); ok {
+			ids = append(ids, id)
+		}
+	}
+	signable := s.signable(feedbackID, viewer, ids)
+
+	signed := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if id, ok := parseAttachmentURL(u); ok {
+			if signable[id] {
+				signed = append(signed, s.SignedURL(id))
+			} else {
+				signed = append(signed, attachmentPath(id))
+			}
+			continue
+		}
+		signed = append(signed, u)
+	}
+	return signed
+}
+
+// signable 返回可以签名的附件ID：已绑定到该反馈，或 viewer 有权访问
+func (s *attachmentService) signable(feedbackID uint64, viewer *models.User, ids []uint64) map[uint64]bool {
+	result := make(map[uint64]bool, len(ids))
+	if len(ids) == 0 {
+		return result
+	}
+	attachments, err := s.attachmentRepo.FindByIDs(ids)
+	if err != nil {
+		return result
+	}
+	for _, attachment := range attachments {
+		bound := feedbackID != 0 && attachment.FeedbackID != nil && *attachment.FeedbackID == feedbackID
+		if bound || s.CanAccess(attachment, viewer) {
+			result[attachment.ID] = true
+		}
+	}
+	return result
+}
+
+// NormalizeMessageContent 规范化消息内容中的图片地址
+func (s *attachmentService) NormalizeMessageContent(contentType uint8, content string) (string, []uint64) {
+	return s.mapMessageImages(contentType, content, s.NormalizeURLs)
+}
+
+// SignMessageContent 为消息内容中的图片地址加上签名
+func (s *attachmentService) SignMessageContent(feedbackID uint64, viewer *models.User, contentType uint8, content string) string {
+	signed, _ := s.mapMessageImages(contentType, content, func(urls []string) ([]string, []uint64) {
+		return s.SignURLs(feedbackID, viewer, urls), nil
+	})
+	return signed
+}
+
+// CheckBindable 检查反馈或消息引用的附件能否绑定到反馈
+// 附件必须是上传者本人尚未绑定到反馈的附件，或已绑定到该反馈的附件（如在会话中再次发送）；
+// 引用他人的附件或其他反馈的附件时返回 ErrInvalidAttachment，不能借此获取这些附件的签名链接
+func (s *attachmentService) CheckBindable(feedbackID uint64, attachmentIDs []uint64, uploaderID uint64, uploaderType uint8) error {
+	if len(attachmentIDs) == 0 {
+		return nil
+	}
+	attachments, err := s.attachmentRepo.FindByIDs(attachmentIDs)
+	if err != nil {
+		return err
+	}
+	found := make(map[uint64]*models.Attachment, len(attachments))
+	for _, attachment := range attachments {
+		found[attachment.ID] = attachment
+	}
+	for _, id := range attachmentIDs {
+		attachment, ok := found[id]
+		if !ok {
+			return ErrInvalidAttachment
+		}
+		own := attachment.FeedbackID == nil && attachment.UploaderID == uploaderID && attachment.UploaderType == uploaderType
+		bound := feedbackID != 0 && attachment.FeedbackID != nil && *attachment.FeedbackID == feedbackID
+		if !own && !bound {
+			return ErrInvalidAttachment
+		}
+	}
+	return nil
+}
+
+// BindToFeedback 将附件绑定到反馈，并记录反馈或消息对附件的引用
+func (s *attachmentService) BindToFeedback(feedbackID uint64, refType uint8, refID uint64, attachmentIDs []uint64, uploaderID uint64, uploaderType uint8) error {
+	if len(attachmentIDs) == 0 {
+		return nil
+	}
+	if err := s.attachmentRepo.BindFeedback(attachmentIDs, feedbackID, uploaderID, uploaderType); err != nil {
+		return err
+	}
+
+	// 只为上传者本人且已绑定到该反馈的附件记录引用，他人的附件或其他反馈的附件不产生引用
+	attachments, err := s.attachmentRepo.FindByIDs(attachmentIDs)
+	if err != nil {
+		return err
+	}
+	refs := make([]*models.AttachmentReference, 0, len(attachments))
+	for _, attachment := range attachments {
+		if attachment.FeedbackID == nil || *attachment.FeedbackID != feedbackID ||
+			attachment.UploaderID != uploaderID || attachment.UploaderType != uploaderType {
+			continue
+		}
+		refs = append(refs, &models.AttachmentReference{
+			AttachmentID: attachment.ID,
+			RefType:      refType,
+			RefID:        refID,
+			FeedbackID:   feedbackID,
+		})
+	}
+	return s.attachmentRepo.AddReferences(refs)
+}
+
+// ReleaseFeedback 删除反馈及其消息对附件的引用，不再被引用的附件由定时清理删除
+func (s *attachmentService) ReleaseFeedback(feedbackID uint64) error {
+	return s.attachmentRepo.DeleteReferencesByFeedback(feedbackID)
+}
+
+// BindAvatar 设置用户头像对附件的引用
+// 头像必须是用户本人上传的图片；原头像的引用被删除，不再被引用的附件由定时清理删除
+func (s *attachmentService) BindAvatar(user *models.User, attachmentID uint64) error {
+	if attachmentID != 0 {
+		attachment, err := s.attachmentRepo.FindByID(attachmentID)
+		if err != nil {
+			return ErrInvalidAvatar
+		}
+		if attachment.UploaderID != user.ID || attachment.UploaderType != user.UserType || !strings.HasPrefix(attachment.ContentType, "image/") {
+			return ErrInvalidAvatar
+		}
+	}
+
+	if err := s.attachmentRepo.DeleteReferencesByRef(consts.AttachmentRefAvatar, user.ID); err != nil {
+		return err
+	}
+	if attachmentID == 0 {
+		return nil
+	}
+	return s.attachmentRepo.AddReferences([]*models.AttachmentReference{{
+		AttachmentID: attachmentID,
+		RefType:      consts.AttachmentRefAvatar,
+		RefID:        user.ID,
+	}})
+}
+
+// SweepOrphans 清理超过保留期且未被任何反馈或消息引用的附件
+// 删除前会在反馈图片和消息内容中再次确认，找到引用时补全引用记录而不删除
+func (s *attachmentService) SweepOrphans(ctx context.Context, grace time.Duration) (int, error) {
+	const batchSize = 100
+	before := time.Now().Add(-grace)
+
+	deleted := 0
+	var afterID uint64
+	for {
+		attachments, err := s.attachmentRepo.FindUnreferenced(before, afterID, batchSize)
+		if err != nil {
+			return deleted, err
+		}
+		for _, attachment := range attachments {
+			afterID = attachment.ID
+
+			refs, err := s.attachmentRepo.FindContentReferences(attachment.ID, attachmentPath(attachment.ID))
+			if err != nil {
+				return deleted, err
+			}
+			if len(refs) > 0 {
+				if err := s.attachmentRepo.AddReferences(refs); err != nil {
+					return deleted, err
+				}
+				continue
+			}
+
+			if err := s.deleteAttachment(ctx, attachment); err != nil {
+				log.Printf("删除未引用的附件失败: id=%d, err=%v", attachment.ID, err)
+				continue
+			}
+			deleted++
+		}
+		if len(attachments) < batchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// deleteAttachment 删除附件记录，存储对象不再被其他附件共用时一并删除
+func (s *attachmentService) deleteAttachment(ctx context.Context, attachment *models.Attachment) error {
+	if err := s.attachmentRepo.Delete(attachment); err != nil {
+		return err
+	}
+
+	keys := []string{attachment.StorageKey}
+	for _, v := range attachment.Variants {
+		keys = append(keys, v.StorageKey)
+	}
+	for _, key := range keys {
+		count, err := s.attachmentRepo.CountByStorageKey(key)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		if err := s.storage.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// sign 为附件及其缩略图生成签名链接
+func (s *attachmentService) sign(attachment *models.Attachment) {
+	attachment.URL = s.SignedURL(attachment.ID)
+	if len(attachment.Variants) > 0 || strings.HasPrefix(attachment.ContentType, "image/") {
+		attachment.VariantURLs = s.SignedVariantURLs(attachment.ID)
+	}
+}
+
+// mapMessageImages 对图片消息中的地址做转换
+// 图片消息内容为单个URL，多图片消息内容为JSON格式的URL数组，文本消息保持不变
+func (s *attachmentService) mapMessageImages(contentType uint8, content string, fn func([]string) ([]string, []uint64)) (string, []uint64) {
+	switch contentType {
+	case consts.ImageMessage:
+		urls, ids := fn([]string{content})
+		return urls[0], ids
+	case consts.ImagesMessage:
+		var urls []string
+		if err := json.Unmarshal([]byte(content), &urls); err != nil {
+			return content, nil
+		}
+		urls, ids := fn(urls)
+		data, err := json.Marshal(urls)
+		if err != nil {
+			return content, nil
+		}
+		return string(data), ids
+	default:
+		return content, nil
+	}
+}
+
+// isAllowedType 检查文件类型是否允许上传
+func (s *attachmentService) isAllowedType(contentType string) bool {
+	for _, allowed := range s.allowedTypes {
+		if strings.EqualFold(allowed, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// contentHash 计算上传内容的哈希
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// attachmentPath 附件规范地址
+func attachmentPath(id uint64) string {
+	return attachmentPathPrefix + strconv.FormatUint(id, 10)
+}
+
+// parseAttachmentURL 从附件地址（可带域名和签名参数）中解析附件ID
+func parseAttachmentURL(raw string) (uint64, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || !strings.HasPrefix(u.Path, attachmentPathPrefix) {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(u.Path, attachmentPathPrefix), 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// isFeedbackParticipant 判断用户是否为反馈的参与方（创建者、目标方或管理员）
+func isFeedbackParticipant(feedback *models.Feedback, user *models.User) bool {
+	if user.UserType == consts.Admin {
+		return true
+	}
+	if feedback.CreatorID == user.ID && feedback.CreatorType == user.UserType {
+		return true
+	}
+	// 发给商家的反馈以商家组织为目标，组织的所有员工都是参与方
+	if feedback.TargetType == consts.TargetMerchant {
+		return user.UserType == consts.Merchant && user.OrgID != 0 && feedback.TargetID == user.OrgID
+	}
+	return feedback.TargetID == user.ID && targetUserType(feedback.TargetType) == user.UserType
+}
+
+// targetUserType 将反馈目标类型转换为用户类型
+func targetUserType(targetType uint8) uint8 {
+	switch targetType {
+	case consts.TargetMerchant:
+		return consts.Merchant
+	case consts.TargetAdmin:
+		return consts.Admin
+	default:
+		return targetType
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/imaging"
+	"feedback-system/pkg/signurl"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeAttachmentRepo 只实现 FindByIDs
+type fakeAttachmentRepo struct {
+	repository.AttachmentRepository
+	attachments map[uint64]*models.Attachment
+}
+
+func (r *fakeAttachmentRepo) FindByIDs(ids []uint64) ([]*models.Attachment, error) {
+	var attachments []*models.Attachment
+	for _, id := range ids {
+		if attachment, ok := r.attachments[id]; ok {
+			attachments = append(attachments, attachment)
+		}
+	}
+	return attachments, nil
+}
+
+// fakeAttachmentFeedbackRepo 只实现 FindByID
+type fakeAttachmentFeedbackRepo struct {
+	repository.FeedbackRepository
+	feedbacks map[uint64]*models.Feedback
+}
+
+func (r *fakeAttachmentFeedbackRepo) FindByID(id uint64) (*models.Feedback, error) {
+	if feedback, ok := r.feedbacks[id]; ok {
+		return feedback, nil
+	}
+	return nil, errors.New("record not found")
+}
+
+func newTestAttachmentService() *attachmentService {
+	bound := func(id uint64) *uint64 { return &id }
+	attachments := &fakeAttachmentRepo{attachments: map[uint64]*models.Attachment{
+		1: {ID: 1, UploaderID: 7, UploaderType: consts.User},                        // 用户 7 未使用的附件
+		2: {ID: 2, UploaderID: 8, UploaderType: consts.User},                        // 用户 8 未使用的附件
+		3: {ID: 3, UploaderID: 8, UploaderType: consts.User, FeedbackID: bound(10)}, // 反馈 10 中用户 8 的附件
+		4: {ID: 4, UploaderID: 7, UploaderType: consts.User, FeedbackID: bound(11)}, // 反馈 11 中用户 7 的附件
+		5: {ID: 5, UploaderID: 7, UploaderType: consts.Merchant},                    // 同 ID 的商家上传的附件
+	}}
+	feedbacks := &fakeAttachmentFeedbackRepo{feedbacks: map[uint64]*models.Feedback{
+		10: {ID: 10, CreatorID: 8, CreatorType: consts.User, TargetID: 3, TargetType: consts.TargetMerchant},
+		11: {ID: 11, CreatorID: 7, CreatorType: consts.User, TargetID: 3, TargetType: consts.TargetMerchant},
+	}}
+	return NewAttachmentService(attachments, feedbacks, nil, signurl.NewSigner("secret", time.Hour), 0, imaging.Options{}, nil, nil).(*attachmentService)
+}
+
+func TestCheckBindable(t *testing.T) {
+	s := newTestAttachmentService()
+	tests := []struct {
+		name       string
+		feedbackID uint64
+		ids        []uint64
+		wantErr    bool
+	}{
+		{"no attachments", 0, nil, false},
+		{"own unused attachment", 0, []uint64{1}, false},
+		{"someone else's attachment", 0, []uint64{1, 2}, true},
+		{"attachment of another feedback", 0, []uint64{4}, true},
+		{"same ID of another user type", 0, []uint64{5}, true},
+		{"missing attachment", 0, []uint64{99}, true},
+		// 消息可以引用该反馈中已有的附件，包括其他参与方的附件
+		{"attachment of the same feedback", 10, []uint64{3, 1}, false},
+		{"attachment of the same feedback from another", 11, []uint64{3}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.CheckBindable(tt.feedbackID, tt.ids, 7, consts.User)
+			if tt.wantErr && !errors.Is(err, ErrInvalidAttachment) {
+				t.Errorf("err = %v, want ErrInvalidAttachment", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("err = %v", err)
+			}
+		})
+	}
+}
+
+func TestSignURLsOnlySignsAccessibleAttachments(t *testing.T) {
+	s := newTestAttachmentService()
+	urls := []string{"/api/attachments/3?expires=1&signature=x", "/api/attachments/2", "/api/attachments/4", "https://example.com/a.png"}
+	signed := func(u string) bool { return strings.Contains(u, signurl.SignatureParam+"=") }
+
+	tests := []struct {
+		name       string
+		feedbackID uint64
+		viewer     *models.User
+		want       []bool
+	}{
+		// 只签名属于该反馈的附件，其余附件去掉签名
+		{"feedback 10 without viewer", 10, nil, []bool{true, false, false, false}},
+		// 上传者本人可以访问自己的附件
+		{"uploader of attachment 2", 10, &models.User{ID: 8, UserType: consts.User}, []bool{true, true, false, false}},
+		// 反馈 11 的参与方可以访问其中的附件
+		{"participant of feedback 11", 10, &models.User{ID: 20, UserType: consts.Merchant, OrgID: 3}, []bool{true, false, true, false}},
+		{"unrelated user", 12, &models.User{ID: 9, UserType: consts.User}, []bool{false, false, false, false}},
+		{"admin", 12, &models.User{ID: 1, UserType: consts.Admin}, []bool{true, true, true, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.SignURLs(tt.feedbackID, tt.viewer, urls)
+			for i, u := range got {
+				if signed(u) != tt.want[i] {
+					t.Errorf("url %d = %q, want signed %v", i, u, tt.want[i])
+				}
+			}
+			if got[1] != "/api/attachments/2" && !tt.want[1] {
+				t.Errorf("unsigned url = %q, want canonical path", got[1])
+			}
+			if got[3] != urls[3] {
+				t.Errorf("external url changed: %q", got[3])
+			}
+		})
+	}
+}
+
+func TestUploadFileRejectsOversizedImageBeforeReading(t *testing.T) {
+	s := newTestAttachmentService()
+	s.imageOptions.MaxBytes = 1 << 10
+	// PNG 文件头之后的内容不应被读取
+	head := []byte("\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 504))
+	r := strings.NewReader(string(head) + strings.Repeat("\x00", 4<<10))
+	_, err := s.UploadFile(context.Background(), &models.User{ID: 7, UserType: consts.User}, "a.png", int64(len(head))+4<<10, r)
+	if !errors.Is(err, imaging.ErrTooLarge) {
+		t.Fatalf("UploadFile() = %v, want imaging.ErrTooLarge", err)
+	}
+	if read := int64(len(head)) + 4<<10 - int64(r.Len()); read != int64(len(head)) {
+		t.Errorf("read %d bytes, want only the %d byte header", read, len(head))
+	}
+}
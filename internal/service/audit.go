@@ -0,0 +1,48 @@
+package service
+
+import (
+	"encoding/json"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"log"
+)
+
+// AuditActor 执行操作的用户及其客户端信息，写入审计日志
+type AuditActor struct {
+	User      *models.User
+	IP        string
+	UserAgent string
+}
+
+// as 以指定用户身份执行操作的客户端信息，用于登录等操作前尚未认证的场景
+func (a *AuditActor) as(user *models.User) *AuditActor {
+	return &AuditActor{User: user, IP: a.IP, UserAgent: a.UserAgent}
+}
+
+// writeAudit 写入审计日志，写入失败只记录错误日志，不影响操作本身
+func writeAudit(repo repository.AuditLogRepository, actor *AuditActor, action, targetType, targetID string, detail map[string]interface{}) {
+	if repo == nil {
+		return
+	}
+
+	entry := &models.AuditLog{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   truncate(targetID, 100),
+	}
+	if actor != nil {
+		if actor.User != nil {
+			entry.ActorID = actor.User.ID
+			entry.ActorType = actor.User.UserType
+		}
+		entry.IP = truncate(actor.IP, 45)
+		entry.UserAgent = truncate(actor.UserAgent, 255)
+	}
+	if len(detail) > 0 {
+		data, _ := json.Marshal(detail)
+		entry.Detail = string(data)
+	}
+	if err := repo.Create(entry); err != nil {
+		log.Printf("写入审计日志失败: action=%s err=%v", action, err)
+	}
+}
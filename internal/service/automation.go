@@ -0,0 +1,997 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/ws"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	// ErrAutomationRuleNotFound 自动化规则不存在
+	ErrAutomationRuleNotFound = errors.New("automation rule not found")
+	// ErrWebhookAddress Webhook 的地址不是公网地址
+	ErrWebhookAddress = errors.New("webhook address is not public")
+)
+
+// InvalidRuleError 自动化规则的触发事件、条件或动作无效
+type InvalidRuleError struct {
+	Reason string
+}
+
+func (e *InvalidRuleError) Error() string {
+	return "invalid automation rule: " + e.Reason
+}
+
+// auditTargetAutomationRule 审计日志中自动化规则对象的类型
+const auditTargetAutomationRule = "automation_rule"
+
+// 规则的条件和动作数量上限
+const (
+	maxRuleConditions = 20
+	maxRuleActions    = 10
+	maxRuleTagLength  = 32
+	maxRuleContentLen = 2000
+)
+
+// ruleSecretMask 响应中代替 Webhook 密钥的占位值，修改规则时原样提交表示保留原密钥
+const ruleSecretMask = "******"
+
+// 条件字段的值类型，决定可用的运算符和条件值的类型
+const (
+	ruleKindText   = "text"
+	ruleKindNumber = "number"
+	ruleKindBool   = "bool"
+	ruleKindTags   = "tags"
+)
+
+// ruleField 条件字段的定义，trigger 不为空时只能用于该触发事件
+type ruleField struct {
+	kind    string
+	trigger string
+}
+
+// ruleFields 支持的条件字段
+var ruleFields = map[string]ruleField{
+	consts.RuleFieldTitle:              {kind: ruleKindText},
+	consts.RuleFieldContent:            {kind: ruleKindText},
+	consts.RuleFieldText:               {kind: ruleKindText},
+	consts.RuleFieldCategory:           {kind: ruleKindText},
+	consts.RuleFieldStatus:             {kind: ruleKindNumber},
+	consts.RuleFieldCreatorType:        {kind: ruleKindNumber},
+	consts.RuleFieldTargetType:         {kind: ruleKindNumber},
+	consts.RuleFieldTargetID:           {kind: ruleKindNumber},
+	consts.RuleFieldAssigneeID:         {kind: ruleKindNumber},
+	consts.RuleFieldEscalated:          {kind: ruleKindBool},
+	consts.RuleFieldTags:               {kind: ruleKindTags},
+	consts.RuleFieldMessageContent:     {kind: ruleKindText, trigger: consts.RuleTriggerMessageReceived},
+	consts.RuleFieldMessageSenderType:  {kind: ruleKindNumber, trigger: consts.RuleTriggerMessageReceived},
+	consts.RuleFieldMessageFromCreator: {kind: ruleKindBool, trigger: consts.RuleTriggerMessageReceived},
+	consts.RuleFieldOldStatus:          {kind: ruleKindNumber, trigger: consts.RuleTriggerStatusChanged},
+	consts.RuleFieldNewStatus:          {kind: ruleKindNumber, trigger: consts.RuleTriggerStatusChanged},
+	consts.RuleFieldSLAKind:            {kind: ruleKindText, trigger: consts.RuleTriggerSLABreached},
+	consts.RuleFieldHour:               {kind: ruleKindNumber},
+	consts.RuleFieldWeekday:            {kind: ruleKindNumber},
+	consts.RuleFieldBusinessHours:      {kind: ruleKindBool},
+	consts.RuleFieldAgeMinutes:         {kind: ruleKindNumber},
+}
+
+// ruleOperators 各值类型可用的运算符
+var ruleOperators = map[string][]string{
+	ruleKindText:   {consts.RuleOpEq, consts.RuleOpNe, consts.RuleOpIn, consts.RuleOpNotIn, consts.RuleOpContains, consts.RuleOpNotContains, consts.RuleOpContainsAny},
+	ruleKindNumber: {consts.RuleOpEq, consts.RuleOpNe, consts.RuleOpIn, consts.RuleOpNotIn, consts.RuleOpGt, consts.RuleOpGte, consts.RuleOpLt, consts.RuleOpLte},
+	ruleKindBool:   {consts.RuleOpEq, consts.RuleOpNe},
+	ruleKindTags:   {consts.RuleOpContains, consts.RuleOpNotContains, consts.RuleOpContainsAny},
+}
+
+// ruleTriggers 支持的触发事件
+var ruleTriggers = map[string]bool{
+	consts.RuleTriggerFeedbackCreated: true,
+	consts.RuleTriggerMessageReceived: true,
+	consts.RuleTriggerStatusChanged:   true,
+	consts.RuleTriggerSLABreached:     true,
+}
+
+// AutomationEvent 触发自动化规则的事件，规则按反馈的最新数据求值
+type AutomationEvent struct {
+	Trigger    string
+	FeedbackID uint64
+	Message    *models.FeedbackMessage // message_received 的触发消息
+	OldStatus  uint8                   // status_changed 修改前的状态
+	NewStatus  uint8                   // status_changed 修改后的状态
+	SLAKind    string                  // sla_breached 超时的时限类型
+}
+
+// AutomationService 自动化规则服务接口
+// 管理员配置“触发事件 → 条件 → 动作”规则：创建反馈、收到新消息、修改状态、SLA 超时时，按顺序执行条件满足的已启用规则
+// 动作包括修改状态、分配处理人、添加标签、发送预设回复、推送通知和调用 Webhook；规则执行的动作不会再次触发规则
+type AutomationService interface {
+	// 触发事件，执行匹配的规则；失败时只记录日志，不影响触发事件的操作本身
+	Fire(event *AutomationEvent)
+	// SLA 超时时触发 sla_breached，由 SLA 服务调用
+	OnSLABreach(feedback *models.Feedback, kind string)
+
+	// 自动化规则管理
+	List() ([]*models.AutomationRule, error)
+	Create(actor *AuditActor, req *models.AutomationRuleRequest) (*models.AutomationRule, error)
+	Update(actor *AuditActor, id uint64, req *models.AutomationRuleRequest) (*models.AutomationRule, error)
+	Delete(actor *AuditActor, id uint64) error
+
+	// 按反馈的当前数据测试规则，只计算匹配结果，不执行动作
+	Test(req *models.AutomationTestRequest) ([]*models.AutomationTestResult, error)
+}
+
+// automationService 自动化规则服务实现
+type automationService struct {
+	ruleRepo     repository.AutomationRuleRepository
+	feedbackRepo repository.FeedbackRepository
+	messageRepo  repository.FeedbackMessageRepository
+	eventRepo    repository.FeedbackEventRepository
+	userRepo     repository.UserRepository
+	auditRepo    repository.AuditLogRepository
+	wsHandler    *ws.WSHandler
+
+	assignmentService AssignmentService
+	slaService        SLAService
+	calendarService   CalendarService
+	httpClient        *http.Client
+	now               func() time.Time
+}
+
+// NewAutomationService 创建自动化规则服务，webhookTimeout 为调用 Webhook 的超时时间
+func NewAutomationService(ruleRepo repository.AutomationRuleRepository, feedbackRepo repository.FeedbackRepository, messageRepo repository.FeedbackMessageRepository, eventRepo repository.FeedbackEventRepository, userRepo repository.UserRepository, auditRepo repository.AuditLogRepository, wsHandler *ws.WSHandler, assignmentService AssignmentService, slaService SLAService, calendarService CalendarService, webhookTimeout time.Duration) AutomationService {
+	return &automationService{
+		ruleRepo:     ruleRepo,
+		feedbackRepo: feedbackRepo,
+		messageRepo:  messageRepo,
+		eventRepo:    eventRepo,
+		userRepo:     userRepo,
+		auditRepo:    auditRepo,
+		wsHandler:    wsHandler,
+
+		assignmentService: assignmentService,
+		slaService:        slaService,
+		calendarService:   calendarService,
+		httpClient:        newWebhookClient(webhookTimeout),
+		now:               time.Now,
+	}
+}
+
+// newWebhookClient 创建调用 Webhook 的客户端：解析域名后只连接公网地址，不使用代理，不跟随重定向
+func newWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: dialPublicOnly}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// dialPublicOnly 在建立连接前检查解析后的地址，拒绝回环、内网、链路本地（包括 169.254.169.254 元数据地址）等非公网地址
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrWebhookAddress, host)
+	}
+	return nil
+}
+
+// nonPublicNetworks net.IP 的方法没有覆盖的保留网段
+var nonPublicNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{"0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "198.18.0.0/15", "240.0.0.0/4", "64:ff9b::/96", "64:ff9b:1::/48"} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// isPublicIP 是否为公网地址
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleContext 规则求值的上下文
+type ruleContext struct {
+	trigger   string
+	feedback  *models.Feedback
+	message   *models.FeedbackMessage
+	oldStatus uint8
+	newStatus uint8
+	slaKind   string
+	now       time.Time
+}
+
+// Fire 按顺序执行触发事件的已启用规则，动作对反馈的修改对后续规则可见
+func (s *automationService) Fire(event *AutomationEvent) {
+	rules, err := s.ruleRepo.ListEnabled(event.Trigger)
+	if err != nil {
+		log.Printf("获取自动化规则失败: %v", err)
+		return
+	}
+	if len(rules) == 0 {
+		return
+	}
+	feedback, err := s.feedbackRepo.FindByID(event.FeedbackID)
+	if err != nil {
+		return
+	}
+
+	ctx := &ruleContext{
+		trigger:   event.Trigger,
+		feedback:  feedback,
+		message:   event.Message,
+		oldStatus: event.OldStatus,
+		newStatus: event.NewStatus,
+		slaKind:   event.SLAKind,
+		now:       s.now(),
+	}
+	for _, rule := range rules {
+		if !s.matches(ctx, rule, nil) {
+			continue
+		}
+		s.execute(ctx, rule)
+		if rule.StopProcessing {
+			break
+		}
+	}
+}
+
+// OnSLABreach 反馈的时限超时时执行 sla_breached 规则
+func (s *automationService) OnSLABreach(feedback *models.Feedback, kind string) {
+	s.Fire(&AutomationEvent{Trigger: consts.RuleTriggerSLABreached, FeedbackID: feedback.ID, SLAKind: kind})
+}
+
+// matches 判断规则的条件是否满足，results 不为 nil 时记录每个条件的结果；没有条件的规则总是匹配
+func (s *automationService) matches(ctx *ruleContext, rule *models.AutomationRule, results *[]models.RuleConditionResult) bool {
+	if len(rule.Conditions) == 0 {
+		return true
+	}
+
+	matched := !rule.MatchAny
+	for _, condition := range rule.Conditions {
+		actual, ok := s.fieldValue(ctx, condition.Field)
+		result := ok && compareRuleValue(condition.Operator, actual, condition.Value)
+		if results != nil {
+			*results = append(*results, models.RuleConditionResult{RuleCondition: condition, Actual: actual, Matched: result})
+		}
+		if rule.MatchAny && result {
+			matched = true
+		} else if !rule.MatchAny && !result {
+			matched = false
+		}
+		// 不需要记录每个条件的结果时，结果确定后不再计算后续条件
+		if results == nil && matched == rule.MatchAny {
+			break
+		}
+	}
+	return matched
+}
+
+// fieldValue 获取条件字段的实际值：文本为 string，数值为 float64，标签为 []string；字段不适用于当前事件时返回 false
+func (s *automationService) fieldValue(ctx *ruleContext, field string) (interface{}, bool) {
+	feedback := ctx.feedback
+	switch field {
+	case consts.RuleFieldTitle:
+		return feedback.Title, true
+	case consts.RuleFieldContent:
+		return feedback.Content, true
+	case consts.RuleFieldText:
+		text := feedback.Title + "\n" + feedback.Content
+		if ctx.message != nil {
+			text += "\n" + ctx.message.Content
+		}
+		return text, true
+	case consts.RuleFieldCategory:
+		return feedback.Category, true
+	case consts.RuleFieldStatus:
+		return float64(feedback.Status), true
+	case consts.RuleFieldCreatorType:
+		return float64(feedback.CreatorType), true
+	case consts.RuleFieldTargetType:
+		return float64(feedback.TargetType), true
+	case consts.RuleFieldTargetID:
+		return float64(feedback.TargetID), true
+	case consts.RuleFieldAssigneeID:
+		return float64(feedback.AssigneeID), true
+	case consts.RuleFieldEscalated:
+		return feedback.EscalatedAt != nil, true
+	case consts.RuleFieldTags:
+		return feedback.Tags, true
+	case consts.RuleFieldHour:
+		return float64(ctx.now.Hour()), true
+	case consts.RuleFieldWeekday:
+		return float64(ctx.now.Weekday()), true
+	case consts.RuleFieldBusinessHours:
+		if s.calendarService == nil {
+			return true, true
+		}
+		return s.calendarService.ForFeedback(feedback).IsOpen(ctx.now), true
+	case consts.RuleFieldAgeMinutes:
+		return ctx.now.Sub(feedback.CreatedAt).Minutes(), true
+	}
+
+	switch {
+	case ctx.trigger == consts.RuleTriggerMessageReceived && ctx.message != nil:
+		switch field {
+		case consts.RuleFieldMessageContent:
+			return ctx.message.Content, true
+		case consts.RuleFieldMessageSenderType:
+			return float64(ctx.message.SenderType), true
+		case consts.RuleFieldMessageFromCreator:
+			return ctx.message.SenderID == feedback.CreatorID && ctx.message.SenderType == feedback.CreatorType, true
+		}
+	case ctx.trigger == consts.RuleTriggerStatusChanged:
+		switch field {
+		case consts.RuleFieldOldStatus:
+			return float64(ctx.oldStatus), true
+		case consts.RuleFieldNewStatus:
+			return float64(ctx.newStatus), true
+		}
+	case ctx.trigger == consts.RuleTriggerSLABreached:
+		if field == consts.RuleFieldSLAKind {
+			return ctx.slaKind, true
+		}
+	}
+	return nil, false
+}
+
+// compareRuleValue 按运算符比较字段的实际值和条件值，文本比较不区分大小写
+func compareRuleValue(op string, actual, expected interface{}) bool {
+	switch op {
+	case consts.RuleOpEq:
+		return equalRuleValue(actual, expected)
+	case consts.RuleOpNe:
+		return !equalRuleValue(actual, expected)
+	case consts.RuleOpIn, consts.RuleOpNotIn:
+		values, _ := expected.([]interface{})
+		found := false
+		for _, value := range values {
+			if equalRuleValue(actual, value) {
+				found = true
+				break
+			}
+		}
+		return found == (op == consts.RuleOpIn)
+	case consts.RuleOpGt, consts.RuleOpGte, consts.RuleOpLt, consts.RuleOpLte:
+		a, ok1 := actual.(float64)
+		b, ok2 := expected.(float64)
+		if !ok1 || !ok2 {
+			return false
+		}
+		switch op {
+		case consts.RuleOpGt:
+			return a > b
+		case consts.RuleOpGte:
+			return a >= b
+		case consts.RuleOpLt:
+			return a < b
+		default:
+			return a <= b
+		}
+	case consts.RuleOpContains:
+		return containsRuleValue(actual, expected)
+	case consts.RuleOpNotContains:
+		return !containsRuleValue(actual, expected)
+	case consts.RuleOpContainsAny:
+		values, _ := expected.([]interface{})
+		for _, value := range values {
+			if containsRuleValue(actual, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// equalRuleValue 比较两个同类型的值是否相等
+func equalRuleValue(actual, expected interface{}) bool {
+	switch a := actual.(type) {
+	case string:
+		b, ok := expected.(string)
+		return ok && strings.EqualFold(a, b)
+	case float64:
+		b, ok := expected.(float64)
+		return ok && a == b
+	case bool:
+		b, ok := expected.(bool)
+		return ok && a == b
+	}
+	return false
+}
+
+// containsRuleValue 文本是否包含关键词，或标签中是否有该标签
+func containsRuleValue(actual, expected interface{}) bool {
+	keyword, ok := expected.(string)
+	if !ok || keyword == "" {
+		return false
+	}
+	switch a := actual.(type) {
+	case string:
+		return strings.Contains(strings.ToLower(a), strings.ToLower(keyword))
+	case []string:
+		for _, tag := range a {
+			if strings.EqualFold(tag, keyword) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// execute 依次执行规则的动作并写入处理记录，单个动作失败时只记录日志，继续执行后续动作
+func (s *automationService) execute(ctx *ruleContext, rule *models.AutomationRule) {
+	feedback := ctx.feedback
+	var executed []string
+	for _, action := range rule.Actions {
+		if err := s.apply(ctx, rule, action); err != nil {
+			log.Printf("执行自动化规则 %d 的动作 %s 失败: feedback=%d err=%v", rule.ID, action.Type, feedback.ID, err)
+			continue
+		}
+		executed = append(executed, action.Type)
+	}
+
+	recordFeedbackEvent(s.eventRepo, feedback.ID, nil, consts.FeedbackEventAutomation, map[string]interface{}{
+		"rule_id": rule.ID,
+		"rule":    rule.Name,
+		"trigger": ctx.trigger,
+		"actions": executed,
+	})
+}
+
+// apply 执行单个动作
+func (s *automationService) apply(ctx *ruleContext, rule *models.AutomationRule, action models.RuleAction) error {
+	feedback := ctx.feedback
+	switch action.Type {
+	case consts.RuleActionSetStatus:
+		return s.setStatus(feedback, rule, action.Status)
+	case consts.RuleActionAssign:
+		return s.assignmentService.AssignByRule(feedback, action.AssigneeID, map[string]interface{}{"rule_id": rule.ID})
+	case consts.RuleActionAddTag:
+		return s.addTag(feedback, action.Tag)
+	case consts.RuleActionReply:
+		_, err := createSystemMessage(s.messageRepo, s.wsHandler, s.userRepo, feedback, action.Content)
+		return err
+	case consts.RuleActionNotify:
+		return s.notify(feedback, rule, action)
+	case consts.RuleActionWebhook:
+		return s.webhook(ctx, rule, action)
+	}
+	return fmt.Errorf("unknown action type %q", action.Type)
+}
+
+// setStatus 修改反馈状态并通知反馈的所有参与方，已关闭的反馈不修改
+func (s *automationService) setStatus(feedback *models.Feedback, rule *models.AutomationRule, status uint8) error {
+	if feedback.Status == status {
+		return nil
+	}
+	if feedback.Status == consts.Closed {
+		return ErrFeedbackClosed
+	}
+	ok, err := s.feedbackRepo.UpdateStatus(feedback.ID, status)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrFeedbackClosed
+	}
+
+	oldStatus := feedback.Status
+	recordFeedbackEvent(s.eventRepo, feedback.ID, nil, consts.FeedbackEventStatusChange, map[string]interface{}{
+		"old_status": oldStatus,
+		"new_status": status,
+		"rule_id":    rule.ID,
+	})
+	if s.slaService != nil {
+		s.slaService.OnStatusChange(feedback, status)
+	}
+	now := s.now()
+	feedback.Status, feedback.StatusChangedAt = status, &now
+
+	if s.wsHandler == nil {
+		return nil
+	}
+	message := models.WSMessage{
+		Event:     consts.EventStatusChange,
+		Timestamp: now,
+		Sender:    &models.Sender{Name: systemSenderName},
+		Data: &models.StatusChangeData{
+			FeedbackID: feedback.ID,
+			OldStatus:  oldStatus,
+			NewStatus:  status,
+		},
+	}
+	jsonMessage, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	sendToParticipants(s.wsHandler, s.userRepo, feedback, jsonMessage)
+	return nil
+}
+
+// addTag 为反馈添加标签，已有该标签时不重复添加
+func (s *automationService) addTag(feedback *models.Feedback, tag string) error {
+	for _, existing := range feedback.Tags {
+		if strings.EqualFold(existing, tag) {
+			return nil
+		}
+	}
+	tags := append(append([]string{}, feedback.Tags...), tag)
+	if err := s.feedbackRepo.UpdateTags(feedback.ID, tags); err != nil {
+		return err
+	}
+	feedback.Tags = tags
+	return nil
+}
+
+// notify 推送通知事件给动作指定的接收方
+func (s *automationService) notify(feedback *models.Feedback, rule *models.AutomationRule, action models.RuleAction) error {
+	if s.wsHandler == nil {
+		return nil
+	}
+
+	message := models.WSMessage{
+		Event:     consts.EventNotification,
+		Timestamp: s.now(),
+		Data: &models.NotificationData{
+			FeedbackID: feedback.ID,
+			Title:      feedback.Title,
+			Content:    action.Content,
+			RuleID:     rule.ID,
+			RuleName:   rule.Name,
+		},
+	}
+	jsonMessage, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	switch action.Recipients {
+	case consts.RuleNotifyTarget:
+		sendToTarget(s.wsHandler, s.userRepo, feedback, jsonMessage, 0)
+	case consts.RuleNotifyAssignee:
+		if feedback.AssigneeID != 0 {
+			s.wsHandler.SendMessageToUser(feedback.AssigneeID, targetUserType(feedback.TargetType), jsonMessage)
+		}
+	case consts.RuleNotifyCreator:
+		s.wsHandler.SendMessageToUser(feedback.CreatorID, feedback.CreatorType, jsonMessage)
+	case consts.RuleNotifyAdmins:
+		sendToAdmins(s.wsHandler, s.userRepo, jsonMessage, 0)
+	}
+	return nil
+}
+
+// automationWebhookPayload Webhook 请求数据
+type automationWebhookPayload struct {
+	Trigger   string                  `json:"trigger"`
+	RuleID    uint64                  `json:"rule_id"`
+	RuleName  string                  `json:"rule_name"`
+	Feedback  *models.Feedback        `json:"feedback"`
+	Message   *models.FeedbackMessage `json:"message,omitempty"`
+	OldStatus uint8                   `json:"old_status,omitempty"`
+	NewStatus uint8                   `json:"new_status,omitempty"`
+	SLAKind   string                  `json:"sla_kind,omitempty"`
+	Timestamp time.Time               `json:"timestamp"`
+}
+
+// webhook 异步调用 Webhook，请求体为 JSON；配置了密钥时在 X-Feedback-Signature 头中带上请求体的 HMAC-SHA256 签名
+func (s *automationService) webhook(ctx *ruleContext, rule *models.AutomationRule, action models.RuleAction) error {
+	snapshot := *ctx.feedback
+	body, err := json.Marshal(&automationWebhookPayload{
+		Trigger:   ctx.trigger,
+		RuleID:    rule.ID,
+		RuleName:  rule.Name,
+		Feedback:  &snapshot,
+		Message:   ctx.message,
+		OldStatus: ctx.oldStatus,
+		NewStatus: ctx.newStatus,
+		SLAKind:   ctx.slaKind,
+		Timestamp: ctx.now,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, action.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Feedback-Event", ctx.trigger)
+	if action.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(action.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Feedback-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	go func() {
+		resp, err := s.httpClient.Do(req)
+		if err != nil {
+			log.Printf("调用自动化规则 %d 的 Webhook 失败: %v", rule.ID, err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			log.Printf("自动化规则 %d 的 Webhook 返回状态 %d", rule.ID, resp.StatusCode)
+		}
+	}()
+	return nil
+}
+
+// List 获取所有自动化规则，Webhook 密钥以占位值代替
+func (s *automationService) List() ([]*models.AutomationRule, error) {
+	rules, err := s.ruleRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	for i, rule := range rules {
+		rules[i] = maskRule(rule)
+	}
+	return rules, nil
+}
+
+// Create 创建自动化规则
+func (s *automationService) Create(actor *AuditActor, req *models.AutomationRuleRequest) (*models.AutomationRule, error) {
+	rule := &models.AutomationRule{}
+	if err := s.applyRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.ruleRepo.Create(rule); err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditAutomationRuleCreate, auditTargetAutomationRule, strconv.FormatUint(rule.ID, 10), ruleDetail(rule))
+	return maskRule(rule), nil
+}
+
+// Update 修改自动化规则
+func (s *automationService) Update(actor *AuditActor, id uint64, req *models.AutomationRuleRequest) (*models.AutomationRule, error) {
+	rule, err := s.ruleRepo.GetByID(id)
+	if err != nil {
+		return nil, ErrAutomationRuleNotFound
+	}
+	if err := s.applyRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.ruleRepo.Update(rule); err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditAutomationRuleUpdate, auditTargetAutomationRule, strconv.FormatUint(rule.ID, 10), ruleDetail(rule))
+	return maskRule(rule), nil
+}
+
+// Delete 删除自动化规则
+func (s *automationService) Delete(actor *AuditActor, id uint64) error {
+	rule, err := s.ruleRepo.GetByID(id)
+	if err != nil {
+		return ErrAutomationRuleNotFound
+	}
+	if err := s.ruleRepo.Delete(id); err != nil {
+		return err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditAutomationRuleDelete, auditTargetAutomationRule, strconv.FormatUint(rule.ID, 10), ruleDetail(rule))
+	return nil
+}
+
+// Test 按反馈的当前数据模拟触发事件，返回每条规则的匹配结果
+// 匹配规则的修改状态、分配和添加标签动作只作用于模拟的反馈副本，使后续规则按修改后的数据求值；不写入数据库，不发送消息和请求
+func (s *automationService) Test(req *models.AutomationTestRequest) ([]*models.AutomationTestResult, error) {
+	if !ruleTriggers[req.Trigger] {
+		return nil, &InvalidRuleError{Reason: fmt.Sprintf("unknown trigger %q", req.Trigger)}
+	}
+	feedback, err := s.feedbackRepo.FindByID(req.FeedbackID)
+	if err != nil {
+		return nil, ErrFeedbackNotFound
+	}
+
+	var rules []*models.AutomationRule
+	switch {
+	case req.Rule != nil:
+		rule := &models.AutomationRule{}
+		if err := s.applyRuleRequest(rule, req.Rule); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	case req.RuleID != 0:
+		rule, err := s.ruleRepo.GetByID(req.RuleID)
+		if err != nil {
+			return nil, ErrAutomationRuleNotFound
+		}
+		rules = append(rules, rule)
+	default:
+		if rules, err = s.ruleRepo.ListEnabled(req.Trigger); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := &ruleContext{
+		trigger:   req.Trigger,
+		feedback:  feedback,
+		oldStatus: req.OldStatus,
+		newStatus: req.NewStatus,
+		slaKind:   req.SLAKind,
+		now:       s.now(),
+	}
+	if ctx.newStatus == 0 {
+		ctx.newStatus = feedback.Status
+	}
+	if req.Message != nil {
+		ctx.message = &models.FeedbackMessage{
+			FeedbackID:  feedback.ID,
+			SenderID:    req.Message.SenderID,
+			SenderType:  req.Message.SenderType,
+			ContentType: consts.TextMessage,
+			Content:     req.Message.Content,
+		}
+		if ctx.message.SenderType == 0 {
+			ctx.message.SenderID, ctx.message.SenderType = feedback.CreatorID, feedback.CreatorType
+		}
+	}
+
+	results := make([]*models.AutomationTestResult, 0, len(rules))
+	for _, rule := range rules {
+		result := &models.AutomationTestResult{RuleID: rule.ID, Name: rule.Name, Conditions: []models.RuleConditionResult{}}
+		if rule.Trigger == req.Trigger {
+			result.Matched = s.matches(ctx, rule, &result.Conditions)
+		}
+		results = append(results, result)
+		if !result.Matched {
+			continue
+		}
+		result.Actions = maskRuleActions(rule.Actions)
+		for _, action := range rule.Actions {
+			simulateRuleAction(feedback, action)
+		}
+		if rule.StopProcessing {
+			break
+		}
+	}
+	return results, nil
+}
+
+// simulateRuleAction 在反馈副本上模拟动作对反馈字段的修改
+func simulateRuleAction(feedback *models.Feedback, action models.RuleAction) {
+	switch action.Type {
+	case consts.RuleActionSetStatus:
+		if feedback.Status != consts.Closed {
+			feedback.Status = action.Status
+		}
+	case consts.RuleActionAssign:
+		if action.AssigneeID != 0 {
+			feedback.AssigneeID = action.AssigneeID
+		}
+	case consts.RuleActionAddTag:
+		if !containsRuleValue(feedback.Tags, action.Tag) {
+			feedback.Tags = append(feedback.Tags, action.Tag)
+		}
+	}
+}
+
+// maskRule 返回 Webhook 密钥以占位值代替的规则副本
+func maskRule(rule *models.AutomationRule) *models.AutomationRule {
+	masked := *rule
+	masked.Actions = maskRuleActions(rule.Actions)
+	return &masked
+}
+
+// maskRuleActions 返回 Webhook 密钥以占位值代替的动作副本
+func maskRuleActions(actions []models.RuleAction) []models.RuleAction {
+	masked := make([]models.RuleAction, len(actions))
+	copy(masked, actions)
+	for i := range masked {
+		if masked[i].Secret != "" {
+			masked[i].Secret = ruleSecretMask
+		}
+	}
+	return masked
+}
+
+// keptWebhookSecret 查找规则中同一地址的 Webhook 的原密钥，地址改变后需要重新填写密钥
+func keptWebhookSecret(actions []models.RuleAction, webhookURL string) (string, bool) {
+	for _, action := range actions {
+		if action.Type == consts.RuleActionWebhook && action.URL == webhookURL && action.Secret != "" {
+			return action.Secret, true
+		}
+	}
+	return "", false
+}
+
+// applyRuleRequest 校验请求中的规则并写入记录；Webhook 密钥为占位值时保留 rule 中同一地址的原密钥
+func (s *automationService) applyRuleRequest(rule *models.AutomationRule, req *models.AutomationRuleRequest) error {
+	trigger := strings.TrimSpace(req.Trigger)
+	if !ruleTriggers[trigger] {
+		return &InvalidRuleError{Reason: fmt.Sprintf("unknown trigger %q", req.Trigger)}
+	}
+	if len(req.Conditions) > maxRuleConditions {
+		return &InvalidRuleError{Reason: fmt.Sprintf("at most %d conditions", maxRuleConditions)}
+	}
+	if len(req.Actions) == 0 || len(req.Actions) > maxRuleActions {
+		return &InvalidRuleError{Reason: fmt.Sprintf("between 1 and %d actions", maxRuleActions)}
+	}
+
+	conditions := make([]models.RuleCondition, 0, len(req.Conditions))
+	for _, condition := range req.Conditions {
+		condition.Field = strings.TrimSpace(condition.Field)
+		condition.Operator = strings.TrimSpace(condition.Operator)
+		if err := checkRuleCondition(trigger, condition); err != nil {
+			return err
+		}
+		conditions = append(conditions, condition)
+	}
+	actions := make([]models.RuleAction, 0, len(req.Actions))
+	for _, action := range req.Actions {
+		action.Tag = strings.TrimSpace(action.Tag)
+		action.Content = strings.TrimSpace(action.Content)
+		action.URL = strings.TrimSpace(action.URL)
+		if action.Type == consts.RuleActionWebhook && action.Secret == ruleSecretMask {
+			secret, ok := keptWebhookSecret(rule.Actions, action.URL)
+			if !ok {
+				return &InvalidRuleError{Reason: "webhook secret must be re-entered when the url changes"}
+			}
+			action.Secret = secret
+		}
+		if err := s.checkRuleAction(action); err != nil {
+			return err
+		}
+		actions = append(actions, action)
+	}
+
+	rule.Name = strings.TrimSpace(req.Name)
+	rule.Trigger = trigger
+	rule.Conditions = conditions
+	rule.MatchAny = req.MatchAny
+	rule.Actions = actions
+	rule.Position = req.Position
+	rule.StopProcessing = req.StopProcessing
+	rule.Enabled = req.Enabled == nil || *req.Enabled
+	return nil
+}
+
+// checkRuleCondition 校验条件的字段、运算符和值的类型
+func checkRuleCondition(trigger string, condition models.RuleCondition) error {
+	field, ok := ruleFields[condition.Field]
+	if !ok {
+		return &InvalidRuleError{Reason: fmt.Sprintf("unknown field %q", condition.Field)}
+	}
+	if field.trigger != "" && field.trigger != trigger {
+		return &InvalidRuleError{Reason: fmt.Sprintf("field %q is only available for %s", condition.Field, field.trigger)}
+	}
+	allowed := false
+	for _, op := range ruleOperators[field.kind] {
+		allowed = allowed || op == condition.Operator
+	}
+	if !allowed {
+		return &InvalidRuleError{Reason: fmt.Sprintf("operator %q is not supported for field %q", condition.Operator, condition.Field)}
+	}
+
+	// 标签按文本匹配
+	kind := field.kind
+	if kind == ruleKindTags {
+		kind = ruleKindText
+	}
+	switch condition.Operator {
+	case consts.RuleOpIn, consts.RuleOpNotIn, consts.RuleOpContainsAny:
+		values, ok := condition.Value.([]interface{})
+		if !ok || len(values) == 0 {
+			return &InvalidRuleError{Reason: fmt.Sprintf("operator %q requires a non-empty array value", condition.Operator)}
+		}
+		for _, value := range values {
+			if !ruleValueKind(value, kind) {
+				return &InvalidRuleError{Reason: fmt.Sprintf("invalid value for field %q", condition.Field)}
+			}
+		}
+	default:
+		if !ruleValueKind(condition.Value, kind) {
+			return &InvalidRuleError{Reason: fmt.Sprintf("invalid value for field %q", condition.Field)}
+		}
+	}
+	return nil
+}
+
+// ruleValueKind 条件值是否为指定的类型，文本值不能为空
+func ruleValueKind(value interface{}, kind string) bool {
+	switch kind {
+	case ruleKindText:
+		text, ok := value.(string)
+		return ok && text != ""
+	case ruleKindNumber:
+		_, ok := value.(float64)
+		return ok
+	case ruleKindBool:
+		_, ok := value.(bool)
+		return ok
+	}
+	return false
+}
+
+// checkRuleAction 校验动作的类型和参数
+func (s *automationService) checkRuleAction(action models.RuleAction) error {
+	switch action.Type {
+	case consts.RuleActionSetStatus:
+		// 关闭只由自动关闭任务执行
+		if action.Status < consts.Open || action.Status > consts.Resolved {
+			return &InvalidRuleError{Reason: "set_status requires status 1, 2 or 3"}
+		}
+	case consts.RuleActionAssign:
+		if action.AssigneeID != 0 {
+			assignee, err := s.userRepo.GetByID(action.AssigneeID)
+			if err != nil || assignee.UserType == consts.User {
+				return &InvalidRuleError{Reason: "assign requires a merchant or admin assignee"}
+			}
+		}
+	case consts.RuleActionAddTag:
+		if action.Tag == "" || utf8.RuneCountInString(action.Tag) > maxRuleTagLength {
+			return &InvalidRuleError{Reason: fmt.Sprintf("add_tag requires a tag of at most %d characters", maxRuleTagLength)}
+		}
+	case consts.RuleActionReply:
+		if action.Content == "" || utf8.RuneCountInString(action.Content) > maxRuleContentLen {
+			return &InvalidRuleError{Reason: fmt.Sprintf("reply requires content of at most %d characters", maxRuleContentLen)}
+		}
+	case consts.RuleActionNotify:
+		switch action.Recipients {
+		case consts.RuleNotifyTarget, consts.RuleNotifyAssignee, consts.RuleNotifyCreator, consts.RuleNotifyAdmins:
+		default:
+			return &InvalidRuleError{Reason: "notify requires recipients target, assignee, creator or admins"}
+		}
+		if action.Content == "" || utf8.RuneCountInString(action.Content) > maxRuleContentLen {
+			return &InvalidRuleError{Reason: fmt.Sprintf("notify requires content of at most %d characters", maxRuleContentLen)}
+		}
+	case consts.RuleActionWebhook:
+		u, err := url.Parse(action.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+			return &InvalidRuleError{Reason: "webhook requires an http or https url"}
+		}
+		// 域名解析后的地址在连接时检查
+		if ip := net.ParseIP(u.Hostname()); (ip != nil && !isPublicIP(ip)) || strings.EqualFold(u.Hostname(), "localhost") {
+			return &InvalidRuleError{Reason: "webhook url must be a public address"}
+		}
+	default:
+		return &InvalidRuleError{Reason: fmt.Sprintf("unknown action type %q", action.Type)}
+	}
+	return nil
+}
+
+// ruleDetail 审计日志中记录的规则信息
+func ruleDetail(rule *models.AutomationRule) map[string]interface{} {
+	actions := make([]string, 0, len(rule.Actions))
+	for _, action := range rule.Actions {
+		actions = append(actions, action.Type)
+	}
+	return map[string]interface{}{
+		"name":    rule.Name,
+		"trigger": rule.Trigger,
+		"enabled": rule.Enabled,
+		"actions": actions,
+	}
+}
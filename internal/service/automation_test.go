@@ -0,0 +1,389 @@
+package service
+
+import (
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeRuleRepo 内存中的自动化规则仓库，返回记录的副本
+type fakeRuleRepo struct {
+	repository.AutomationRuleRepository
+	rules  []*models.AutomationRule
+	nextID uint64
+}
+
+func copyRule(rule *models.AutomationRule) *models.AutomationRule {
+	c := *rule
+	c.Actions = append([]models.RuleAction(nil), rule.Actions...)
+	c.Conditions = append([]models.RuleCondition(nil), rule.Conditions...)
+	return &c
+}
+
+func (r *fakeRuleRepo) Create(rule *models.AutomationRule) error {
+	r.nextID++
+	rule.ID = r.nextID
+	r.rules = append(r.rules, copyRule(rule))
+	return nil
+}
+
+func (r *fakeRuleRepo) GetByID(id uint64) (*models.AutomationRule, error) {
+	for _, rule := range r.rules {
+		if rule.ID == id {
+			return copyRule(rule), nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *fakeRuleRepo) List() ([]*models.AutomationRule, error) {
+	rules := make([]*models.AutomationRule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		rules = append(rules, copyRule(rule))
+	}
+	return rules, nil
+}
+
+func (r *fakeRuleRepo) ListEnabled(trigger string) ([]*models.AutomationRule, error) {
+	var rules []*models.AutomationRule
+	for _, rule := range r.rules {
+		if rule.Enabled && rule.Trigger == trigger {
+			rules = append(rules, copyRule(rule))
+		}
+	}
+	return rules, nil
+}
+
+func (r *fakeRuleRepo) Update(rule *models.AutomationRule) error {
+	for i := range r.rules {
+		if r.rules[i].ID == rule.ID {
+			r.rules[i] = copyRule(rule)
+			return nil
+		}
+	}
+	return errors.New("record not found")
+}
+
+// fakeRuleFeedbackRepo 只实现 FindByID，返回反馈的副本
+type fakeRuleFeedbackRepo struct {
+	repository.FeedbackRepository
+	feedback *models.Feedback
+}
+
+func (r *fakeRuleFeedbackRepo) FindByID(id uint64) (*models.Feedback, error) {
+	if r.feedback == nil || r.feedback.ID != id {
+		return nil, errors.New("record not found")
+	}
+	c := *r.feedback
+	c.Tags = append([]string(nil), r.feedback.Tags...)
+	return &c, nil
+}
+
+func newTestAutomationService(feedback *models.Feedback, rules ...*models.AutomationRule) (*automationService, *fakeRuleRepo) {
+	ruleRepo := &fakeRuleRepo{}
+	for _, rule := range rules {
+		ruleRepo.Create(rule)
+	}
+	s := NewAutomationService(ruleRepo, &fakeRuleFeedbackRepo{feedback: feedback}, nil, nil, nil, nil, nil, nil, nil, nil, time.Second).(*automationService)
+	s.now = func() time.Time { return time.Date(2026, 10, 19, 10, 30, 0, 0, time.UTC) }
+	return s, ruleRepo
+}
+
+func TestCompareRuleValue(t *testing.T) {
+	list := func(values ...interface{}) []interface{} { return values }
+	tests := []struct {
+		op       string
+		actual   interface{}
+		expected interface{}
+		want     bool
+	}{
+		{consts.RuleOpEq, "Refund", "refund", true},
+		{consts.RuleOpEq, "refund", "refunds", false},
+		{consts.RuleOpEq, float64(2), float64(2), true},
+		{consts.RuleOpEq, true, true, true},
+		{consts.RuleOpEq, true, "true", false}, // 类型不同不相等
+		{consts.RuleOpEq, float64(1), "1", false},
+		{consts.RuleOpNe, float64(1), float64(2), true},
+		{consts.RuleOpNe, "a", "A", false},
+		{consts.RuleOpIn, float64(2), list(float64(1), float64(2)), true},
+		{consts.RuleOpIn, "BILLING", list("refund", "billing"), true},
+		{consts.RuleOpIn, float64(3), list(float64(1), float64(2)), false},
+		{consts.RuleOpIn, float64(3), float64(3), false}, // 值不是数组
+		{consts.RuleOpNotIn, float64(3), list(float64(1), float64(2)), true},
+		{consts.RuleOpNotIn, float64(1), list(float64(1), float64(2)), false},
+		{consts.RuleOpGt, float64(3), float64(2), true},
+		{consts.RuleOpGt, float64(2), float64(2), false},
+		{consts.RuleOpGte, float64(2), float64(2), true},
+		{consts.RuleOpLt, float64(1), float64(2), true},
+		{consts.RuleOpLte, float64(3), float64(2), false},
+		{consts.RuleOpGt, "3", float64(2), false}, // 只比较数值
+		{consts.RuleOpContains, "Please REFUND me", "refund", true},
+		{consts.RuleOpContains, "hello", "", false}, // 空关键词不匹配
+		{consts.RuleOpContains, []string{"VIP", "urgent"}, "vip", true},
+		{consts.RuleOpContains, []string{"vip-plus"}, "vip", false}, // 标签整体匹配
+		{consts.RuleOpNotContains, "hello", "refund", true},
+		{consts.RuleOpNotContains, []string{"vip"}, "VIP", false},
+		{consts.RuleOpContainsAny, "my order is late", list("refund", "LATE"), true},
+		{consts.RuleOpContainsAny, []string{"vip"}, list("urgent", "spam"), false},
+		{consts.RuleOpContainsAny, "late", "late", false}, // 值不是数组
+		{"unknown", "a", "a", false},
+	}
+	for _, tt := range tests {
+		if got := compareRuleValue(tt.op, tt.actual, tt.expected); got != tt.want {
+			t.Errorf("compareRuleValue(%q, %#v, %#v) = %v, want %v", tt.op, tt.actual, tt.expected, got, tt.want)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	s, _ := newTestAutomationService(nil)
+	feedback := &models.Feedback{ID: 1, Title: "Refund please", Content: "order 42", Status: consts.Open, CreatorID: 7, CreatorType: consts.User, Tags: []string{"vip"},
+		CreatedAt: time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)}
+	cond := func(field, op string, value interface{}) models.RuleCondition {
+		return models.RuleCondition{Field: field, Operator: op, Value: value}
+	}
+	statusOpen := cond(consts.RuleFieldStatus, consts.RuleOpEq, float64(consts.Open))
+	keyword := cond(consts.RuleFieldText, consts.RuleOpContains, "refund")
+	notVIP := cond(consts.RuleFieldTags, consts.RuleOpNotContains, "vip")
+	fromCreator := cond(consts.RuleFieldMessageFromCreator, consts.RuleOpEq, true)
+
+	tests := []struct {
+		name       string
+		trigger    string
+		message    *models.FeedbackMessage
+		matchAny   bool
+		conditions []models.RuleCondition
+		want       bool
+		results    []bool
+	}{
+		{"no conditions", consts.RuleTriggerFeedbackCreated, nil, false, nil, true, nil},
+		{"all match", consts.RuleTriggerFeedbackCreated, nil, false, []models.RuleCondition{statusOpen, keyword}, true, []bool{true, true}},
+		{"all with one failing", consts.RuleTriggerFeedbackCreated, nil, false, []models.RuleCondition{statusOpen, notVIP, keyword}, false, []bool{true, false, true}},
+		{"any with one matching", consts.RuleTriggerFeedbackCreated, nil, true, []models.RuleCondition{notVIP, keyword}, true, []bool{false, true}},
+		{"any with none matching", consts.RuleTriggerFeedbackCreated, nil, true, []models.RuleCondition{notVIP}, false, []bool{false}},
+		{"age in minutes", consts.RuleTriggerFeedbackCreated, nil, false, []models.RuleCondition{cond(consts.RuleFieldAgeMinutes, consts.RuleOpGte, float64(30))}, true, []bool{true}},
+		// 触发消息的字段只适用于 message_received
+		{"message field without message", consts.RuleTriggerFeedbackCreated, nil, false, []models.RuleCondition{fromCreator}, false, []bool{false}},
+		{"message from creator", consts.RuleTriggerMessageReceived, &models.FeedbackMessage{SenderID: 7, SenderType: consts.User, Content: "any news?"}, false,
+			[]models.RuleCondition{fromCreator, cond(consts.RuleFieldText, consts.RuleOpContains, "news")}, true, []bool{true, true}},
+		{"message from merchant", consts.RuleTriggerMessageReceived, &models.FeedbackMessage{SenderID: 7, SenderType: consts.Merchant}, false,
+			[]models.RuleCondition{fromCreator}, false, []bool{false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &ruleContext{trigger: tt.trigger, feedback: feedback, message: tt.message, now: s.now()}
+			rule := &models.AutomationRule{MatchAny: tt.matchAny, Conditions: tt.conditions}
+
+			if got := s.matches(ctx, rule, nil); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+			// 记录结果时计算所有条件，匹配结果相同
+			results := []models.RuleConditionResult{}
+			if got := s.matches(ctx, rule, &results); got != tt.want {
+				t.Errorf("matches() with results = %v, want %v", got, tt.want)
+			}
+			if len(results) != len(tt.results) {
+				t.Fatalf("results = %+v, want %d entries", results, len(tt.results))
+			}
+			for i, result := range results {
+				if result.Matched != tt.results[i] || result.Field != tt.conditions[i].Field {
+					t.Errorf("results[%d] = %+v, want matched %v", i, result, tt.results[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAutomationTestSimulatesActions(t *testing.T) {
+	feedback := &models.Feedback{ID: 1, Title: "Refund please", Status: consts.Open, CreatorID: 7, CreatorType: consts.User}
+	tagRefund := &models.AutomationRule{Name: "tag refunds", Trigger: consts.RuleTriggerFeedbackCreated, Enabled: true,
+		Conditions: []models.RuleCondition{{Field: consts.RuleFieldText, Operator: consts.RuleOpContains, Value: "refund"}},
+		Actions: []models.RuleAction{
+			{Type: consts.RuleActionAddTag, Tag: "refund"},
+			{Type: consts.RuleActionWebhook, URL: "https://hooks.example.com/refund", Secret: "s3cret"},
+		}}
+	// 依赖前一条规则添加的标签
+	escalate := &models.AutomationRule{Name: "refund in progress", Trigger: consts.RuleTriggerFeedbackCreated, Enabled: true, StopProcessing: true,
+		Conditions: []models.RuleCondition{{Field: consts.RuleFieldTags, Operator: consts.RuleOpContains, Value: "refund"}},
+		Actions:    []models.RuleAction{{Type: consts.RuleActionSetStatus, Status: consts.InProgress}}}
+	afterStop := &models.AutomationRule{Name: "after stop", Trigger: consts.RuleTriggerFeedbackCreated, Enabled: true,
+		Actions: []models.RuleAction{{Type: consts.RuleActionAddTag, Tag: "late"}}}
+	other := &models.AutomationRule{Name: "other trigger", Trigger: consts.RuleTriggerSLABreached, Enabled: true,
+		Actions: []models.RuleAction{{Type: consts.RuleActionAddTag, Tag: "sla"}}}
+	s, _ := newTestAutomationService(feedback, tagRefund, escalate, afterStop, other)
+
+	results, err := s.Test(&models.AutomationTestRequest{FeedbackID: 1, Trigger: consts.RuleTriggerFeedbackCreated})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].RuleID != tagRefund.ID || results[1].RuleID != escalate.ID {
+		t.Fatalf("results = %+v, want the first two rules", results)
+	}
+	if !results[0].Matched || !results[1].Matched {
+		t.Errorf("matched = %v, %v, want both", results[0].Matched, results[1].Matched)
+	}
+	if secret := results[0].Actions[1].Secret; secret != ruleSecretMask {
+		t.Errorf("webhook secret in result = %q, want mask", secret)
+	}
+	// 模拟不修改数据
+	if feedback.Status != consts.Open || len(feedback.Tags) != 0 {
+		t.Errorf("feedback modified: %+v", feedback)
+	}
+
+	// 指定其他触发事件的规则时不匹配
+	results, err = s.Test(&models.AutomationTestRequest{FeedbackID: 1, Trigger: consts.RuleTriggerFeedbackCreated, RuleID: other.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Matched || results[0].Actions != nil {
+		t.Errorf("results = %+v, want one unmatched rule", results)
+	}
+
+	// 未保存的规则
+	results, err = s.Test(&models.AutomationTestRequest{FeedbackID: 1, Trigger: consts.RuleTriggerStatusChanged, OldStatus: consts.Open,
+		Rule: &models.AutomationRuleRequest{Name: "reopened", Trigger: consts.RuleTriggerStatusChanged,
+			Conditions: []models.RuleCondition{{Field: consts.RuleFieldNewStatus, Operator: consts.RuleOpEq, Value: float64(consts.Open)}},
+			Actions:    []models.RuleAction{{Type: consts.RuleActionReply, Content: "We are on it"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !results[0].Matched || results[0].Conditions[0].Actual != float64(consts.Open) {
+		t.Errorf("results = %+v, want new_status to default to the current status", results)
+	}
+}
+
+func TestAutomationTestErrors(t *testing.T) {
+	s, _ := newTestAutomationService(&models.Feedback{ID: 1})
+	var invalid *InvalidRuleError
+
+	if _, err := s.Test(&models.AutomationTestRequest{FeedbackID: 1, Trigger: "deleted"}); !errors.As(err, &invalid) {
+		t.Errorf("unknown trigger: err = %v", err)
+	}
+	if _, err := s.Test(&models.AutomationTestRequest{FeedbackID: 2, Trigger: consts.RuleTriggerFeedbackCreated}); !errors.Is(err, ErrFeedbackNotFound) {
+		t.Errorf("missing feedback: err = %v", err)
+	}
+	if _, err := s.Test(&models.AutomationTestRequest{FeedbackID: 1, Trigger: consts.RuleTriggerFeedbackCreated, RuleID: 9}); !errors.Is(err, ErrAutomationRuleNotFound) {
+		t.Errorf("missing rule: err = %v", err)
+	}
+	_, err := s.Test(&models.AutomationTestRequest{FeedbackID: 1, Trigger: consts.RuleTriggerFeedbackCreated,
+		Rule: &models.AutomationRuleRequest{Name: "bad", Trigger: consts.RuleTriggerFeedbackCreated,
+			Conditions: []models.RuleCondition{{Field: consts.RuleFieldSLAKind, Operator: consts.RuleOpEq, Value: "resolution"}},
+			Actions:    []models.RuleAction{{Type: consts.RuleActionAddTag, Tag: "x"}}}})
+	if !errors.As(err, &invalid) {
+		t.Errorf("field of another trigger: err = %v", err)
+	}
+}
+
+func TestWebhookSecretIsWriteOnly(t *testing.T) {
+	s, repo := newTestAutomationService(nil)
+	webhook := func(url, secret string) *models.AutomationRuleRequest {
+		return &models.AutomationRuleRequest{Name: "hook", Trigger: consts.RuleTriggerFeedbackCreated,
+			Actions: []models.RuleAction{{Type: consts.RuleActionWebhook, URL: url, Secret: secret}}}
+	}
+	stored := func() string { return repo.rules[0].Actions[0].Secret }
+
+	rule, err := s.Create(nil, webhook("https://hooks.example.com/a", "s3cret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.Actions[0].Secret != ruleSecretMask || stored() != "s3cret" {
+		t.Fatalf("create: response secret %q, stored %q", rule.Actions[0].Secret, stored())
+	}
+	rules, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules[0].Actions[0].Secret != ruleSecretMask {
+		t.Errorf("list: secret = %q", rules[0].Actions[0].Secret)
+	}
+
+	// 提交占位值保留原密钥
+	if rule, err = s.Update(nil, rule.ID, webhook("https://hooks.example.com/a", ruleSecretMask)); err != nil {
+		t.Fatal(err)
+	}
+	if rule.Actions[0].Secret != ruleSecretMask || stored() != "s3cret" {
+		t.Errorf("update with mask: response secret %q, stored %q", rule.Actions[0].Secret, stored())
+	}
+	// 地址改变时需要重新填写密钥
+	var invalid *InvalidRuleError
+	if _, err := s.Update(nil, rule.ID, webhook("https://evil.example.com/a", ruleSecretMask)); !errors.As(err, &invalid) {
+		t.Errorf("update url with mask: err = %v", err)
+	}
+	if _, err := s.Update(nil, rule.ID, webhook("https://hooks.example.com/b", "n3w")); err != nil || stored() != "n3w" {
+		t.Errorf("update secret: err = %v, stored %q", err, stored())
+	}
+	// 空密钥表示不签名
+	if _, err := s.Update(nil, rule.ID, webhook("https://hooks.example.com/b", "")); err != nil || stored() != "" {
+		t.Errorf("clear secret: err = %v, stored %q", err, stored())
+	}
+}
+
+func TestWebhookRejectsNonPublicAddresses(t *testing.T) {
+	s, _ := newTestAutomationService(nil)
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.1/hook",
+		"http://[::1]/hook",
+		"http://[fd00::1]/hook",
+		"http://0.0.0.0/hook",
+		"ftp://hooks.example.com/hook",
+	} {
+		if err := s.checkRuleAction(models.RuleAction{Type: consts.RuleActionWebhook, URL: url}); err == nil {
+			t.Errorf("checkRuleAction(%q) accepted", url)
+		}
+	}
+	for _, url := range []string{"https://hooks.example.com/hook", "http://93.184.216.34/hook"} {
+		if err := s.checkRuleAction(models.RuleAction{Type: consts.RuleActionWebhook, URL: url}); err != nil {
+			t.Errorf("checkRuleAction(%q): %v", url, err)
+		}
+	}
+
+	// 域名解析到内网地址时在连接时拒绝
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	defer server.Close()
+	resp, err := s.httpClient.Post(server.URL, "application/json", nil)
+	if err == nil {
+		resp.Body.Close()
+	}
+	if !errors.Is(err, ErrWebhookAddress) || called {
+		t.Errorf("request to loopback server: err = %v, called = %v", err, called)
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1::1", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.0.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+	}
+	for _, tt := range tests {
+		if got := isPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
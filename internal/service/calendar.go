@@ -0,0 +1,273 @@
+package service
+
+import (
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/calendar"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCalendarNotFound 工作日历不存在
+	ErrCalendarNotFound = errors.New("business calendar not found")
+	// ErrInvalidCalendarTarget 工作日历的团队无效：管理员团队的 TargetID 必须为 0，商家的 TargetID 必须为 0 或已存在的商家组织
+	ErrInvalidCalendarTarget = errors.New("invalid calendar target")
+)
+
+// InvalidCalendarError 工作日历的时区、营业时间或节假日格式无效
+type InvalidCalendarError struct {
+	Reason string
+}
+
+func (e *InvalidCalendarError) Error() string {
+	return "invalid business calendar: " + e.Reason
+}
+
+// auditTargetCalendar 审计日志中工作日历对象的类型
+const auditTargetCalendar = "calendar"
+
+// calendarWeekdays 工作日历中营业时间的星期名称
+var calendarWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// CalendarService 工作日历服务接口
+// 团队（商家组织、所有商家的默认设置、管理员团队）可以指定一个工作日历，商家组织的日历优先，其次为所有商家的默认日历
+// 没有指定日历的团队按全天营业计算；SLA 截止时间等按团队的营业时间计算
+type CalendarService interface {
+	// 按团队或反馈的目标方获取工作日历，没有指定时返回 nil（全天营业）
+	ForTeam(targetType uint8, targetID uint64) *calendar.Calendar
+	ForFeedback(feedback *models.Feedback) *calendar.Calendar
+	TeamCalendar(user *models.User) (*models.TeamCalendarResponse, error)
+
+	// 工作日历管理
+	List() ([]*models.BusinessCalendar, error)
+	Create(actor *AuditActor, req *models.BusinessCalendarRequest) (*models.BusinessCalendar, error)
+	Update(actor *AuditActor, id uint64, req *models.BusinessCalendarRequest) (*models.BusinessCalendar, error)
+	Delete(actor *AuditActor, id uint64) error
+
+	// 团队使用的工作日历
+	ListAssignments() ([]*models.CalendarAssignment, error)
+	Assign(actor *AuditActor, req *models.AssignCalendarRequest) error
+}
+
+// calendarService 工作日历服务实现
+type calendarService struct {
+	calendarRepo repository.BusinessCalendarRepository
+	orgRepo      repository.OrganizationRepository
+	auditRepo    repository.AuditLogRepository
+}
+
+// NewCalendarService 创建工作日历服务
+func NewCalendarService(calendarRepo repository.BusinessCalendarRepository, orgRepo repository.OrganizationRepository, auditRepo repository.AuditLogRepository) CalendarService {
+	return &calendarService{
+		calendarRepo: calendarRepo,
+		orgRepo:      orgRepo,
+		auditRepo:    auditRepo,
+	}
+}
+
+// ForTeam 获取团队使用的工作日历
+func (s *calendarService) ForTeam(targetType uint8, targetID uint64) *calendar.Calendar {
+	record := s.assigned(targetType, targetID)
+	if record == nil {
+		return nil
+	}
+	cal, err := buildCalendar(record.Timezone, record.Hours, record.Holidays)
+	if err != nil {
+		log.Printf("工作日历 %d 无效，按全天营业计算: %v", record.ID, err)
+		return nil
+	}
+	return cal
+}
+
+// assigned 获取团队指定的工作日历记录，商家组织没有指定时使用所有商家的默认日历，都没有时返回 nil
+func (s *calendarService) assigned(targetType uint8, targetID uint64) *models.BusinessCalendar {
+	assignment, err := s.calendarRepo.GetAssignment(targetType, targetID)
+	if err != nil && targetType == consts.TargetMerchant && targetID != 0 {
+		assignment, err = s.calendarRepo.GetAssignment(consts.TargetMerchant, 0)
+	}
+	if err != nil {
+		return nil
+	}
+	record, err := s.calendarRepo.GetByID(assignment.CalendarID)
+	if err != nil {
+		return nil
+	}
+	return record
+}
+
+// ForFeedback 获取反馈目标方使用的工作日历
+func (s *calendarService) ForFeedback(feedback *models.Feedback) *calendar.Calendar {
+	if feedback.TargetType == consts.TargetAdmin {
+		return s.ForTeam(consts.TargetAdmin, 0)
+	}
+	return s.ForTeam(feedback.TargetType, feedback.TargetID)
+}
+
+// TeamCalendar 获取当前用户所在团队使用的工作日历和当前的营业状态
+func (s *calendarService) TeamCalendar(user *models.User) (*models.TeamCalendarResponse, error) {
+	targetType, targetID, err := assignmentTeam(user)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.TeamCalendarResponse{Calendar: s.assigned(targetType, targetID)}
+	now := time.Now()
+	cal := s.ForTeam(targetType, targetID)
+	resp.Open = cal.IsOpen(now)
+	resp.NextOpen = cal.NextOpen(now)
+	return resp, nil
+}
+
+// List 获取所有工作日历
+func (s *calendarService) List() ([]*models.BusinessCalendar, error) {
+	return s.calendarRepo.List()
+}
+
+// Create 创建工作日历
+func (s *calendarService) Create(actor *AuditActor, req *models.BusinessCalendarRequest) (*models.BusinessCalendar, error) {
+	record := &models.BusinessCalendar{}
+	if err := applyCalendarRequest(record, req); err != nil {
+		return nil, err
+	}
+	if err := s.calendarRepo.Create(record); err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditCalendarCreate, auditTargetCalendar, strconv.FormatUint(record.ID, 10), calendarDetail(record))
+	return record, nil
+}
+
+// Update 修改工作日历，已创建的反馈的截止时间不变
+func (s *calendarService) Update(actor *AuditActor, id uint64, req *models.BusinessCalendarRequest) (*models.BusinessCalendar, error) {
+	record, err := s.calendarRepo.GetByID(id)
+	if err != nil {
+		return nil, ErrCalendarNotFound
+	}
+	if err := applyCalendarRequest(record, req); err != nil {
+		return nil, err
+	}
+	if err := s.calendarRepo.Update(record); err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditCalendarUpdate, auditTargetCalendar, strconv.FormatUint(record.ID, 10), calendarDetail(record))
+	return record, nil
+}
+
+// Delete 删除工作日历，使用该日历的团队改为全天营业
+func (s *calendarService) Delete(actor *AuditActor, id uint64) error {
+	record, err := s.calendarRepo.GetByID(id)
+	if err != nil {
+		return ErrCalendarNotFound
+	}
+	if err := s.calendarRepo.Delete(id); err != nil {
+		return err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditCalendarDelete, auditTargetCalendar, strconv.FormatUint(record.ID, 10), calendarDetail(record))
+	return nil
+}
+
+// ListAssignments 获取所有团队使用的工作日历
+func (s *calendarService) ListAssignments() ([]*models.CalendarAssignment, error) {
+	return s.calendarRepo.ListAssignments()
+}
+
+// Assign 为团队指定工作日历，CalendarID 为 0 时取消指定
+func (s *calendarService) Assign(actor *AuditActor, req *models.AssignCalendarRequest) error {
+	switch req.TargetType {
+	case consts.TargetAdmin:
+		if req.TargetID != 0 {
+			return ErrInvalidCalendarTarget
+		}
+	case consts.TargetMerchant:
+		if req.TargetID != 0 {
+			if _, err := s.orgRepo.GetByID(req.TargetID); err != nil {
+				return ErrInvalidCalendarTarget
+			}
+		}
+	default:
+		return ErrInvalidCalendarTarget
+	}
+
+	if req.CalendarID == 0 {
+		if err := s.calendarRepo.Unassign(req.TargetType, req.TargetID); err != nil {
+			return err
+		}
+	} else {
+		if _, err := s.calendarRepo.GetByID(req.CalendarID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCalendarNotFound
+			}
+			return err
+		}
+		if err := s.calendarRepo.Assign(&models.CalendarAssignment{
+			TargetType: req.TargetType,
+			TargetID:   req.TargetID,
+			CalendarID: req.CalendarID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	writeAudit(s.auditRepo, actor, consts.AuditCalendarAssign, auditTargetCalendar, strconv.FormatUint(req.CalendarID, 10), map[string]interface{}{
+		"target_type": req.TargetType,
+		"target_id":   req.TargetID,
+		"calendar_id": req.CalendarID,
+	})
+	return nil
+}
+
+// applyCalendarRequest 校验请求中的日历并写入记录
+func applyCalendarRequest(record *models.BusinessCalendar, req *models.BusinessCalendarRequest) error {
+	timezone := strings.TrimSpace(req.Timezone)
+	hours := make(map[string][]string, len(req.Hours))
+	for day, ranges := range req.Hours {
+		hours[strings.ToLower(strings.TrimSpace(day))] = ranges
+	}
+	if _, err := buildCalendar(timezone, hours, req.Holidays); err != nil {
+		return &InvalidCalendarError{Reason: err.Error()}
+	}
+
+	record.Name = strings.TrimSpace(req.Name)
+	record.Timezone = timezone
+	record.Hours = hours
+	record.Holidays = req.Holidays
+	return nil
+}
+
+// buildCalendar 将存储的营业时间转换为 calendar.Calendar
+func buildCalendar(timezone string, hours map[string][]string, holidays []string) (*calendar.Calendar, error) {
+	week := make(map[time.Weekday][]string, len(hours))
+	for day, ranges := range hours {
+		weekday, ok := calendarWeekdays[day]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", day)
+		}
+		week[weekday] = ranges
+	}
+	return calendar.New(timezone, week, holidays)
+}
+
+// calendarDetail 审计日志中记录的日历内容
+func calendarDetail(record *models.BusinessCalendar) map[string]interface{} {
+	return map[string]interface{}{
+		"name":     record.Name,
+		"timezone": record.Timezone,
+		"hours":    record.Hours,
+		"holidays": record.Holidays,
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/ws"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrEscalationForbidden 当前用户不能升级该反馈
+	ErrEscalationForbidden = errors.New("not allowed to escalate this feedback")
+	// ErrEscalationUnavailable 只有发给商家且未解决、未关闭的反馈可以升级
+	ErrEscalationUnavailable = errors.New("feedback cannot be escalated")
+	// ErrAlreadyEscalated 反馈已经升级
+	ErrAlreadyEscalated = errors.New("feedback has already been escalated")
+)
+
+// EscalationTooEarlyError 商家回复的等待时间未到，反馈创建者暂时不能升级
+type EscalationTooEarlyError struct {
+	AvailableAt time.Time // 可以升级的时间
+}
+
+func (e *EscalationTooEarlyError) Error() string {
+	return fmt.Sprintf("feedback can be escalated after %s", e.AvailableAt.Format(time.RFC3339))
+}
+
+// EscalationService 反馈升级服务接口
+// 发给商家的反馈可以升级到平台管理员：反馈创建者在商家超过等待时间未回复后可以升级，商家组织中可以处理反馈的成员随时可以升级
+// 升级后管理员团队成为反馈的参与方，接收反馈的消息通知，商家仍然留在会话中
+type EscalationService interface {
+	Escalate(actor *models.User, feedbackID uint64, reason string) (*models.Feedback, error)
+}
+
+// escalationService 反馈升级服务实现
+type escalationService struct {
+	feedbackRepo repository.FeedbackRepository
+	messageRepo  repository.FeedbackMessageRepository
+	eventRepo    repository.FeedbackEventRepository
+	userRepo     repository.UserRepository
+	wsHandler    *ws.WSHandler
+	wait         time.Duration
+}
+
+// NewEscalationService 创建反馈升级服务，wait 为反馈创建者可以升级前商家的回复等待时间
+func NewEscalationService(feedbackRepo repository.FeedbackRepository, messageRepo repository.FeedbackMessageRepository, eventRepo repository.FeedbackEventRepository, userRepo repository.UserRepository, wsHandler *ws.WSHandler, wait time.Duration) EscalationService {
+	return &escalationService{
+		feedbackRepo: feedbackRepo,
+		messageRepo:  messageRepo,
+		eventRepo:    eventRepo,
+		userRepo:     userRepo,
+		wsHandler:    wsHandler,
+		wait:         wait,
+	}
+}
+
+// Escalate 将反馈升级到平台管理员，写入处理记录并通知反馈的所有参与方
+func (s *escalationService) Escalate(actor *models.User, feedbackID uint64, reason string) (*models.Feedback, error) {
+	feedback, err := s.feedbackRepo.FindByID(feedbackID)
+	if err != nil {
+		return nil, ErrFeedbackNotFound
+	}
+	if feedback.TargetType != consts.TargetMerchant || feedback.Status == consts.Resolved || feedback.Status == consts.Closed {
+		return nil, ErrEscalationUnavailable
+	}
+	if feedback.EscalatedAt != nil {
+		return nil, ErrAlreadyEscalated
+	}
+
+	by := "merchant"
+	switch {
+	case actor.UserType == consts.Merchant && actor.OrgID == feedback.TargetID && CanHandleFeedback(actor):
+	case feedback.CreatorID == actor.ID && feedback.CreatorType == actor.UserType:
+		by = "creator"
+		if err := s.checkWaited(feedback); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrEscalationForbidden
+	}
+
+	now := time.Now()
+	ok, err := s.feedbackRepo.MarkEscalated(feedback.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrAlreadyEscalated
+	}
+	feedback.EscalatedAt = &now
+
+	recordFeedbackEvent(s.eventRepo, feedback.ID, actor, consts.FeedbackEventEscalate, map[string]interface{}{
+		"by":     by,
+		"reason": reason,
+	})
+	s.notify(actor, feedback, reason)
+	return feedback, nil
+}
+
+// checkWaited 检查反馈创建者最早一条未得到商家回复的消息是否已等待足够长的时间
+func (s *escalationService) checkWaited(feedback *models.Feedback) error {
+	messages, err := s.messageRepo.FindAllByFeedbackID(feedback.ID)
+	if err != nil {
+		return err
+	}
+
+	var lastReply time.Time
+	for _, message := range messages {
+		if message.SenderType == consts.Merchant && message.CreatedAt.After(lastReply) {
+			lastReply = message.CreatedAt
+		}
+	}
+	var waitingSince time.Time
+	for _, message := range messages {
+		if message.SenderID != feedback.CreatorID || message.SenderType != feedback.CreatorType || !message.CreatedAt.After(lastReply) {
+			continue
+		}
+		if waitingSince.IsZero() || message.CreatedAt.Before(waitingSince) {
+			waitingSince = message.CreatedAt
+		}
+	}
+
+	// 商家已经回复了创建者的所有消息，需要创建者再次发送消息后重新等待
+	if waitingSince.IsZero() {
+		return &EscalationTooEarlyError{AvailableAt: time.Now().Add(s.wait)}
+	}
+	if availableAt := waitingSince.Add(s.wait); time.Now().Before(availableAt) {
+		return &EscalationTooEarlyError{AvailableAt: availableAt}
+	}
+	return nil
+}
+
+// notify 发送反馈升级事件给反馈创建者、商家组织的所有员工和所有管理员
+func (s *escalationService) notify(actor *models.User, feedback *models.Feedback, reason string) {
+	if s.wsHandler == nil {
+		return
+	}
+
+	message := models.WSMessage{
+		Event:     consts.EventEscalated,
+		Timestamp: time.Now(),
+		Sender:    &models.Sender{ID: actor.ID, Type: actor.UserType, Name: actor.Name()},
+		Data: &models.EscalationData{
+			FeedbackID:  feedback.ID,
+			Title:       feedback.Title,
+			Reason:      reason,
+			EscalatedAt: *feedback.EscalatedAt,
+		},
+	}
+	jsonMessage, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+
+	s.wsHandler.SendMessageToUser(feedback.CreatorID, feedback.CreatorType, jsonMessage)
+	sendToTarget(s.wsHandler, s.userRepo, feedback, jsonMessage, 0)
+	sendToAdmins(s.wsHandler, s.userRepo, jsonMessage, 0)
+}
@@ -2,36 +2,55 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"feedback-system/internal/consts"
 	"feedback-system/internal/models"
 	"feedback-system/internal/repository"
 	"feedback-system/pkg/ws"
 	"fmt"
+	"strings"
 	"time"
 )
 
+var (
+	// ErrFeedbackNotFound 反馈不存在
+	ErrFeedbackNotFound = errors.New("feedback not found")
+	// ErrFeedbackClosed 反馈已关闭，不能再修改
+	ErrFeedbackClosed = errors.New("feedback is closed")
+	// ErrFeedbackReadOnly 商家组织的只读员工不能处理反馈
+	ErrFeedbackReadOnly = errors.New("read-only members cannot handle feedback")
+	// ErrFeedbackForbidden 当前用户不能获取该范围的反馈列表或删除该反馈
+	ErrFeedbackForbidden = errors.New("not allowed to access these feedbacks")
+)
+
 // FeedbackService 反馈服务接口
 type FeedbackService interface {
 	// 创建反馈
 	Create(feedback *models.Feedback) error
 
-	// 获取反馈详情
-	GetByID(id uint64) (*models.Feedback, error)
+	// 获取反馈详情，只有反馈的参与方可以获取
+	GetByID(actor *models.User, id uint64) (*models.Feedback, error)
 
-	// 获取角色创建的反馈列表
-	GetByCreator(creatorID uint64, creatorType uint8) ([]*models.Feedback, error)
+	// 获取角色创建的反馈列表，只有创建者本人和管理员可以获取
+	GetByCreator(actor *models.User, creatorID uint64, creatorType uint8) ([]*models.Feedback, error)
 
-	// 获取目标接收的反馈列表
-	GetByTarget(targetID uint64, targetType uint8) ([]*models.Feedback, error)
+	// 获取目标接收的反馈列表，只有目标组织的员工和管理员可以获取；assignee 不为 nil 时只获取指定处理人的反馈（0 表示未分配）
+	GetByTarget(actor *models.User, targetID uint64, targetType uint8, filter *models.FeedbackFilter) ([]*models.Feedback, error)
 
-	// 获取所有反馈
-	GetAll() ([]*models.Feedback, error)
+	// 获取所有反馈，只有管理员可以获取，assignee 含义同上
+	GetAll(actor *models.User, filter *models.FeedbackFilter) ([]*models.Feedback, error)
+
+	// 更新反馈状态，只有反馈的参与方中可以处理反馈的用户可以修改
+	UpdateStatus(actor *models.User, id uint64, status uint8) error
 
-	// 更新反馈状态
-	UpdateStatus(id uint64, status uint8, userID uint64, userType uint8) error
+	// 删除反馈，只有反馈的创建者和管理员可以删除
+	Delete(actor *models.User, id uint64) error
 
-	// 删除反馈
-	Delete(id uint64, userID uint64, userType uint8) error
+	// 判断用户是否为反馈的参与方
+	IsParticipant(id uint64, user *models.User) bool
+
+	// 获取反馈的处理记录
+	History(id uint64) ([]*models.FeedbackEvent, error)
 }
 
 // feedbackService 反馈服务实现
@@ -39,27 +58,62 @@ type feedbackService struct {
 	feedbackRepo repository.FeedbackRepository
 	messageRepo  repository.FeedbackMessageRepository
 	userRepo     repository.UserRepository
+	orgRepo      repository.OrganizationRepository
+	eventRepo    repository.FeedbackEventRepository
 	wsHandler    *ws.WSHandler
+
+	attachmentService AttachmentService
+	assignmentService AssignmentService
+	slaService        SLAService
+	automationService AutomationService
 }
 
 // NewFeedbackService 创建反馈服务
-func NewFeedbackService(repo repository.FeedbackRepository, messageRepo repository.FeedbackMessageRepository, userRepo repository.UserRepository, wsHandler *ws.WSHandler) FeedbackService {
+func NewFeedbackService(repo repository.FeedbackRepository, messageRepo repository.FeedbackMessageRepository, userRepo repository.UserRepository, orgRepo repository.OrganizationRepository, eventRepo repository.FeedbackEventRepository, wsHandler *ws.WSHandler, attachmentService AttachmentService, assignmentService AssignmentService, slaService SLAService, automationService AutomationService) FeedbackService {
 	return &feedbackService{
-		feedbackRepo: repo,
-		messageRepo:  messageRepo,
-		userRepo:     userRepo,
-		wsHandler:    wsHandler,
+		feedbackRepo:      repo,
+		messageRepo:       messageRepo,
+		userRepo:          userRepo,
+		orgRepo:           orgRepo,
+		eventRepo:         eventRepo,
+		wsHandler:         wsHandler,
+		attachmentService: attachmentService,
+		assignmentService: assignmentService,
+		slaService:        slaService,
+		automationService: automationService,
 	}
 }
 
 // Create 创建反馈
 func (s *feedbackService) Create(feedback *models.Feedback) error {
+	// 图片地址去掉签名参数后再保存
+	images, attachmentIDs := s.attachmentService.NormalizeURLs(feedback.Images)
+	feedback.Images = images
+	feedback.Category = truncate(strings.TrimSpace(feedback.Category), 50)
+	feedback.AssigneeID, feedback.EscalatedAt, feedback.Tags = 0, nil, nil
+
+	// 只能引用本人上传的未使用附件
+	if err := s.attachmentService.CheckBindable(0, attachmentIDs, feedback.CreatorID, feedback.CreatorType); err != nil {
+		return err
+	}
+
+	// 按目标方适用的 SLA 策略计算截止时间
+	if s.slaService != nil {
+		s.slaService.Apply(feedback)
+	}
+
 	// 创建反馈
 	err := s.feedbackRepo.Create(feedback)
 	if err != nil {
 		return err
 	}
 
+	// 将引用的附件绑定到反馈，之后反馈的参与方才能访问这些附件
+	if err := s.attachmentService.BindToFeedback(feedback.ID, consts.AttachmentRefFeedback, feedback.ID, attachmentIDs, feedback.CreatorID, feedback.CreatorType); err != nil {
+		return err
+	}
+	feedback.Images = s.attachmentService.SignURLs(feedback.ID, nil, feedback.Images)
+
 	// 将反馈内容作为第一条消息保存
 	if s.messageRepo != nil {
 		initialMessage := &models.FeedbackMessage{
@@ -75,21 +129,22 @@ func (s *feedbackService) Create(feedback *models.Feedback) error {
 		s.messageRepo.Create(initialMessage)
 	}
 
+	// 按目标团队的自动分配设置选择处理人
+	if s.assignmentService != nil {
+		s.assignmentService.Route(feedback)
+	}
+
 	// 如果有WebSocket处理程序，发送通知
 	if s.wsHandler != nil {
 		// 获取创建者用户名
 		var creatorName string
 		creator, err := s.userRepo.GetByID(feedback.CreatorID)
 		if err == nil && creator != nil {
-			creatorName = creator.Username
+			creatorName = creator.Name()
 		}
 
-		// 获取目标用户名
-		var targetName string
-		target, err := s.userRepo.GetByID(feedback.TargetID)
-		if err == nil && target != nil {
-			targetName = target.Username
-		}
+		// 获取目标名称（商家组织或管理员）
+		targetName := s.targetName(feedback)
 
 		// 创建新反馈通知消息
 		newFeedbackMessage := models.WSMessage{
@@ -116,6 +171,8 @@ func (s *feedbackService) Create(feedback *models.Feedback) error {
 				"target_type":  feedback.TargetType,
 				"target_name":  targetName,
 				"status":       feedback.Status,
+				"category":     feedback.Category,
+				"assignee_id":  feedback.AssigneeID,
 				"created_at":   feedback.CreatedAt,
 			},
 		}
@@ -126,38 +183,27 @@ func (s *feedbackService) Create(feedback *models.Feedback) error {
 			return err
 		}
 
-		// 将目标类型转换为用户类型
-		var targetUserType uint8
-		switch feedback.TargetType {
-		case 1: // TARGET_TYPE.MERCHANT = 1
-			targetUserType = consts.Merchant // USER_TYPE.MERCHANT = 2
-		case 2: // TARGET_TYPE.ADMIN = 2
-			targetUserType = consts.Admin // USER_TYPE.ADMIN = 3
-		default:
-			targetUserType = feedback.TargetType
-		}
-
-		// 发送消息给目标用户
-		s.wsHandler.SendMessageToUser(feedback.TargetID, targetUserType, jsonMessage)
+		// 发送消息给目标：商家组织的所有员工或目标管理员
+		sendToTarget(s.wsHandler, s.userRepo, feedback, jsonMessage, 0)
 
 		// 同时发送给所有管理员（如果目标不是管理员）
-		if feedback.TargetType != 2 { // TARGET_TYPE.ADMIN = 2
-			admins, err := s.userRepo.GetAdmins()
-			if err == nil {
-				for _, admin := range admins {
-					s.wsHandler.SendMessageToUser(admin.ID, consts.Admin, jsonMessage)
-				}
-			}
+		if feedback.TargetType != consts.TargetAdmin {
+			sendToAdmins(s.wsHandler, s.userRepo, jsonMessage, 0)
 		}
 	}
 
+	// 执行创建反馈的自动化规则
+	if s.automationService != nil {
+		s.automationService.Fire(&AutomationEvent{Trigger: consts.RuleTriggerFeedbackCreated, FeedbackID: feedback.ID})
+	}
+
 	return nil
 }
 
-// GetByID 获取反馈详情
-func (s *feedbackService) GetByID(id uint64) (*models.Feedback, error) {
+// GetByID 获取反馈详情，反馈不存在或用户不是参与方时返回 ErrFeedbackNotFound
+func (s *feedbackService) GetByID(actor *models.User, id uint64) (*models.Feedback, error) {
 	// 获取反馈基本信息
-	feedback, err := s.feedbackRepo.FindByID(id)
+	feedback, err := participantFeedback(s.feedbackRepo, id, actor)
 	if err != nil {
 		return nil, err
 	}
@@ -169,24 +215,26 @@ func (s *feedbackService) GetByID(id uint64) (*models.Feedback, error) {
 		creator, err := s.userRepo.GetByID(feedback.CreatorID)
 		if err == nil && creator != nil {
 			// 添加创建者名称到返回结构中
-			feedback.CreatorName = creator.Username
+			feedback.CreatorName = creator.Name()
 		}
 	}
 
-	// 获取目标用户信息
-	if feedback.TargetType == consts.Merchant || feedback.TargetType == consts.Admin {
-		target, err := s.userRepo.GetByID(feedback.TargetID)
-		if err == nil && target != nil {
-			// 添加目标用户名称到返回结构中
-			feedback.TargetName = target.Username
-		}
-	}
+	// 获取目标名称（商家组织或管理员）
+	feedback.TargetName = s.targetName(feedback)
+	feedback.AssigneeName = s.assigneeName(feedback)
+
+	// 为图片地址加上签名，供前端直接展示
+	feedback.Images = s.attachmentService.SignURLs(feedback.ID, actor, feedback.Images)
 
 	return feedback, nil
 }
 
-// GetByCreator 获取用户创建的反馈列表
-func (s *feedbackService) GetByCreator(creatorID uint64, creatorType uint8) ([]*models.Feedback, error) {
+// GetByCreator 获取用户创建的反馈列表，不是创建者本人或管理员时返回 ErrFeedbackForbidden
+func (s *feedbackService) GetByCreator(actor *models.User, creatorID uint64, creatorType uint8) ([]*models.Feedback, error) {
+	if actor.UserType != consts.Admin && (actor.ID != creatorID || actor.UserType != creatorType) {
+		return nil, ErrFeedbackForbidden
+	}
+
 	// 获取反馈列表
 	feedbacks, err := s.feedbackRepo.FindByCreator(creatorID, creatorType)
 	if err != nil {
@@ -200,27 +248,31 @@ func (s *feedbackService) GetByCreator(creatorID uint64, creatorType uint8) ([]*
 			creator, err := s.userRepo.GetByID(creatorID)
 			if err == nil && creator != nil {
 				// 添加创建者名称到返回结构中
-				feedback.CreatorName = creator.Username
+				feedback.CreatorName = creator.Name()
 			}
 		}
 
-		// 获取目标用户信息
-		if feedback.TargetType == consts.Merchant || feedback.TargetType == consts.Admin {
-			target, err := s.userRepo.GetByID(feedback.TargetID)
-			if err == nil && target != nil {
-				// 添加目标用户名称到返回结构中
-				feedback.TargetName = target.Username
-			}
-		}
+		// 获取目标名称（商家组织或管理员）
+		feedback.TargetName = s.targetName(feedback)
+		feedback.AssigneeName = s.assigneeName(feedback)
+
+		// 为图片地址加上签名，供前端直接展示
+		feedback.Images = s.attachmentService.SignURLs(feedback.ID, actor, feedback.Images)
 	}
 
 	return feedbacks, nil
 }
 
 // GetByTarget 获取目标接收的反馈列表
-func (s *feedbackService) GetByTarget(targetID uint64, targetType uint8) ([]*models.Feedback, error) {
+// 商家组织收到的反馈只有组织的员工和管理员可以获取，发给管理员的反馈只有管理员可以获取，否则返回 ErrFeedbackForbidden
+func (s *feedbackService) GetByTarget(actor *models.User, targetID uint64, targetType uint8, filter *models.FeedbackFilter) ([]*models.Feedback, error) {
+	orgStaff := targetType == consts.TargetMerchant && actor.UserType == consts.Merchant && actor.OrgID != 0 && actor.OrgID == targetID
+	if actor.UserType != consts.Admin && !orgStaff {
+		return nil, ErrFeedbackForbidden
+	}
+
 	// 获取反馈列表
-	feedbacks, err := s.feedbackRepo.FindByTarget(targetID, targetType)
+	feedbacks, err := s.feedbackRepo.FindByFilter(targetID, targetType, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -232,27 +284,29 @@ func (s *feedbackService) GetByTarget(targetID uint64, targetType uint8) ([]*mod
 			creator, err := s.userRepo.GetByID(feedback.CreatorID)
 			if err == nil && creator != nil {
 				// 添加创建者名称到返回结构中
-				feedback.CreatorName = creator.Username
+				feedback.CreatorName = creator.Name()
 			}
 		}
 
-		// 目标用户信息已知
-		if targetType == consts.Merchant || targetType == consts.Admin {
-			target, err := s.userRepo.GetByID(targetID)
-			if err == nil && target != nil {
-				// 添加目标用户名称到返回结构中
-				feedback.TargetName = target.Username
-			}
-		}
+		// 获取目标名称（商家组织或管理员）
+		feedback.TargetName = s.targetName(feedback)
+		feedback.AssigneeName = s.assigneeName(feedback)
+
+		// 为图片地址加上签名，供前端直接展示
+		feedback.Images = s.attachmentService.SignURLs(feedback.ID, actor, feedback.Images)
 	}
 
 	return feedbacks, nil
 }
 
-// GetAll 获取所有反馈
-func (s *feedbackService) GetAll() ([]*models.Feedback, error) {
+// GetAll 获取所有反馈，不是管理员时返回 ErrFeedbackForbidden
+func (s *feedbackService) GetAll(actor *models.User, filter *models.FeedbackFilter) ([]*models.Feedback, error) {
+	if actor.UserType != consts.Admin {
+		return nil, ErrFeedbackForbidden
+	}
+
 	// 获取所有反馈
-	feedbacks, err := s.feedbackRepo.FindAll()
+	feedbacks, err := s.feedbackRepo.FindByFilter(0, 0, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -264,55 +318,65 @@ func (s *feedbackService) GetAll() ([]*models.Feedback, error) {
 			creator, err := s.userRepo.GetByID(feedback.CreatorID)
 			if err == nil && creator != nil {
 				// 添加创建者名称到返回结构中
-				feedback.CreatorName = creator.Username
+				feedback.CreatorName = creator.Name()
 			}
 		}
 
-		// 获取目标用户信息
-		if feedback.TargetType == consts.Merchant || feedback.TargetType == consts.Admin {
-			target, err := s.userRepo.GetByID(feedback.TargetID)
-			if err == nil && target != nil {
-				// 添加目标用户名称到返回结构中
-				feedback.TargetName = target.Username
-			}
-		}
+		// 获取目标名称（商家组织或管理员）
+		feedback.TargetName = s.targetName(feedback)
+		feedback.AssigneeName = s.assigneeName(feedback)
+
+		// 为图片地址加上签名，供前端直接展示
+		feedback.Images = s.attachmentService.SignURLs(feedback.ID, actor, feedback.Images)
 	}
 
 	return feedbacks, nil
 }
 
 // UpdateStatus 更新反馈状态
-func (s *feedbackService) UpdateStatus(id uint64, status uint8, userID uint64, userType uint8) error {
+// 反馈不存在或用户不是参与方时返回 ErrFeedbackNotFound，商家组织的只读员工返回 ErrFeedbackReadOnly
+func (s *feedbackService) UpdateStatus(actor *models.User, id uint64, status uint8) error {
 	// 获取反馈
-	feedback, err := s.feedbackRepo.FindByID(id)
+	feedback, err := participantFeedback(s.feedbackRepo, id, actor)
 	if err != nil {
 		return err
 	}
+	if !CanHandleFeedback(actor) {
+		return ErrFeedbackReadOnly
+	}
+
+	// 已关闭的反馈不能再修改状态
+	if feedback.Status == consts.Closed {
+		return ErrFeedbackClosed
+	}
 
-	// 更新状态
+	// 更新状态，读取之后被关闭的反馈同样不修改
 	oldStatus := feedback.Status
-	err = s.feedbackRepo.UpdateStatus(id, status)
+	ok, err := s.feedbackRepo.UpdateStatus(id, status)
 	if err != nil {
 		return err
 	}
+	if !ok {
+		return ErrFeedbackClosed
+	}
+	recordFeedbackEvent(s.eventRepo, id, actor, consts.FeedbackEventStatusChange, map[string]interface{}{
+		"old_status": oldStatus,
+		"new_status": status,
+	})
+	if s.slaService != nil {
+		s.slaService.OnStatusChange(feedback, status)
+	}
 
 	// 如果有WebSocket处理程序，发送通知
 	if s.wsHandler != nil {
-		// 获取用户名
-		var userName string
-		user, err := s.userRepo.GetByID(userID)
-		if err == nil && user != nil {
-			userName = user.Username
-		}
-
 		// 创建状态变更消息
 		message := models.WSMessage{
 			Event:     consts.EventStatusChange,
 			Timestamp: time.Now(),
 			Sender: &models.Sender{
-				ID:   userID,
-				Type: userType,
-				Name: userName,
+				ID:   actor.ID,
+				Type: actor.UserType,
+				Name: actor.Name(),
 			},
 			Data: &models.StatusChangeData{
 				FeedbackID: id,
@@ -331,13 +395,28 @@ func (s *feedbackService) UpdateStatus(id uint64, status uint8, userID uint64, u
 		s.wsHandler.BroadcastMessage(jsonMessage)
 	}
 
+	// 执行修改状态的自动化规则
+	if s.automationService != nil && oldStatus != status {
+		s.automationService.Fire(&AutomationEvent{Trigger: consts.RuleTriggerStatusChanged, FeedbackID: id, OldStatus: oldStatus, NewStatus: status})
+	}
+
 	return nil
 }
 
 // Delete 删除反馈（级联删除相关消息）
-func (s *feedbackService) Delete(id uint64, userID uint64, userType uint8) error {
+// 反馈不存在或用户不是参与方时返回 ErrFeedbackNotFound，目标方的员工不能删除，返回 ErrFeedbackForbidden
+func (s *feedbackService) Delete(actor *models.User, id uint64) error {
+	feedback, err := participantFeedback(s.feedbackRepo, id, actor)
+	if err != nil {
+		return err
+	}
+	if actor.UserType != consts.Admin && (feedback.CreatorID != actor.ID || feedback.CreatorType != actor.UserType) {
+		return ErrFeedbackForbidden
+	}
+	userID, userType := actor.ID, actor.UserType
+
 	// 首先删除该反馈的所有消息
-	err := s.messageRepo.DeleteByFeedbackID(id)
+	err = s.messageRepo.DeleteByFeedbackID(id)
 	if err != nil {
 		return fmt.Errorf("删除反馈消息失败: %v", err)
 	}
@@ -348,6 +427,15 @@ func (s *feedbackService) Delete(id uint64, userID uint64, userType uint8) error
 		return fmt.Errorf("删除反馈失败: %v", err)
 	}
 
+	if err := s.eventRepo.DeleteByFeedback(id); err != nil {
+		return fmt.Errorf("删除反馈处理记录失败: %v", err)
+	}
+
+	// 释放反馈及其消息对附件的引用，附件由定时清理删除
+	if err := s.attachmentService.ReleaseFeedback(id); err != nil {
+		return fmt.Errorf("释放反馈附件失败: %v", err)
+	}
+
 	// 如果有WebSocket处理程序，发送删除通知
 	if s.wsHandler != nil {
 		fmt.Printf("=== 发送反馈删除通知 ===\n")
@@ -357,7 +445,7 @@ func (s *feedbackService) Delete(id uint64, userID uint64, userType uint8) error
 		var userName string
 		user, err := s.userRepo.GetByID(userID)
 		if err == nil && user != nil {
-			userName = user.Username
+			userName = user.Name()
 		}
 
 		// 创建反馈删除消息
@@ -389,3 +477,109 @@ func (s *feedbackService) Delete(id uint64, userID uint64, userType uint8) error
 
 	return nil
 }
+
+// IsParticipant 判断用户是否为反馈的参与方，反馈不存在时返回 false
+func (s *feedbackService) IsParticipant(id uint64, user *models.User) bool {
+	_, err := participantFeedback(s.feedbackRepo, id, user)
+	return err == nil
+}
+
+// History 获取反馈的处理记录
+func (s *feedbackService) History(id uint64) ([]*models.FeedbackEvent, error) {
+	events, err := s.eventRepo.ListByFeedback(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 添加操作者名称，系统自动操作没有操作者
+	names := map[uint64]string{}
+	for _, event := range events {
+		if event.ActorID == 0 {
+			continue
+		}
+		name, ok := names[event.ActorID]
+		if !ok {
+			if actor, err := s.userRepo.GetByID(event.ActorID); err == nil {
+				name = actor.Name()
+			}
+			names[event.ActorID] = name
+		}
+		event.ActorName = name
+	}
+	return events, nil
+}
+
+// assigneeName 获取反馈处理人的名称，未分配时为空
+func (s *feedbackService) assigneeName(feedback *models.Feedback) string {
+	if feedback.AssigneeID == 0 {
+		return ""
+	}
+	if assignee, err := s.userRepo.GetByID(feedback.AssigneeID); err == nil {
+		return assignee.Name()
+	}
+	return ""
+}
+
+// targetName 获取反馈目标的名称：发给商家的反馈为商家组织名称，发给管理员的反馈为管理员名称
+func (s *feedbackService) targetName(feedback *models.Feedback) string {
+	switch feedback.TargetType {
+	case consts.TargetMerchant:
+		if org, err := s.orgRepo.GetByID(feedback.TargetID); err == nil {
+			return org.Name
+		}
+	case consts.TargetAdmin:
+		if target, err := s.userRepo.GetByID(feedback.TargetID); err == nil {
+			return target.Name()
+		}
+	}
+	return ""
+}
+
+// sendToTarget 发送 WebSocket 消息给反馈的目标方
+// 发给商家的反馈发送给商家组织的所有员工，发给管理员的反馈发送给目标管理员；skipUserID 为不需要接收的用户（如发送者本人）
+func sendToTarget(wsHandler *ws.WSHandler, userRepo repository.UserRepository, feedback *models.Feedback, message []byte, skipUserID uint64) {
+	switch feedback.TargetType {
+	case consts.TargetMerchant:
+		for _, member := range orgMembers(userRepo, feedback.TargetID) {
+			if member.ID != skipUserID {
+				wsHandler.SendMessageToUser(member.ID, consts.Merchant, message)
+			}
+		}
+	case consts.TargetAdmin:
+		if feedback.TargetID != skipUserID {
+			wsHandler.SendMessageToUser(feedback.TargetID, consts.Admin, message)
+		}
+	}
+}
+
+// sendToAdmins 发送消息给所有管理员，skipUserID 为不需要发送的管理员（通常是发送者本人）
+func sendToAdmins(wsHandler *ws.WSHandler, userRepo repository.UserRepository, message []byte, skipUserID uint64) {
+	admins, err := userRepo.GetAdmins()
+	if err != nil {
+		return
+	}
+	for _, admin := range admins {
+		if admin.ID != skipUserID {
+			wsHandler.SendMessageToUser(admin.ID, consts.Admin, message)
+		}
+	}
+}
+
+// sendToParticipants 发送消息给反馈的所有参与方：创建者、目标方，已升级的反馈同时发送给所有管理员
+func sendToParticipants(wsHandler *ws.WSHandler, userRepo repository.UserRepository, feedback *models.Feedback, message []byte) {
+	wsHandler.SendMessageToUser(feedback.CreatorID, feedback.CreatorType, message)
+	sendToTarget(wsHandler, userRepo, feedback, message, 0)
+	if feedback.TargetType == consts.TargetMerchant && feedback.EscalatedAt != nil {
+		sendToAdmins(wsHandler, userRepo, message, 0)
+	}
+}
+
+// participantFeedback 获取用户参与的反馈
+// 反馈不存在或用户不是参与方时都返回 ErrFeedbackNotFound，不暴露反馈是否存在
+func participantFeedback(feedbackRepo repository.FeedbackRepository, id uint64, user *models.User) (*models.Feedback, error) {
+	feedback, err := feedbackRepo.FindByID(id)
+	if err != nil || feedback == nil || user == nil || !isFeedbackParticipant(feedback, user) {
+		return nil, ErrFeedbackNotFound
+	}
+	return feedback, nil
+}
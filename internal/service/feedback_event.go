@@ -0,0 +1,31 @@
+package service
+
+import (
+	"encoding/json"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"log"
+)
+
+// recordFeedbackEvent 写入反馈处理记录，actor 为 nil 表示系统自动操作；写入失败只记录日志，不影响操作本身
+func recordFeedbackEvent(repo repository.FeedbackEventRepository, feedbackID uint64, actor *models.User, eventType string, detail map[string]interface{}) {
+	if repo == nil {
+		return
+	}
+
+	event := &models.FeedbackEvent{
+		FeedbackID: feedbackID,
+		Type:       eventType,
+	}
+	if actor != nil {
+		event.ActorID = actor.ID
+		event.ActorType = actor.UserType
+	}
+	if len(detail) > 0 {
+		data, _ := json.Marshal(detail)
+		event.Detail = string(data)
+	}
+	if err := repo.Create(event); err != nil {
+		log.Printf("写入反馈处理记录失败: feedback=%d type=%s err=%v", feedbackID, eventType, err)
+	}
+}
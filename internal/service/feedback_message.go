@@ -11,19 +11,25 @@ import (
 	"time"
 )
 
+// systemSenderName 系统消息的发送者名称
+const systemSenderName = "系统"
+
 // FeedbackMessageService 反馈消息服务接口
 type FeedbackMessageService interface {
-	// 创建反馈消息
-	Create(message *models.FeedbackMessage) error
+	// 创建反馈消息，只有反馈的参与方中可以处理反馈的用户可以发送
+	Create(actor *models.User, message *models.FeedbackMessage) error
 
-	// 获取反馈的所有消息
-	GetByFeedbackID(feedbackID uint64) ([]*models.FeedbackMessage, error)
+	// 获取反馈的所有消息，只有反馈的参与方可以获取
+	GetByFeedbackID(actor *models.User, feedbackID uint64) ([]*models.FeedbackMessage, error)
 
 	// 标记消息为已读
 	MarkAsRead(id uint64)
 
 	// 删除消息
 	Delete(id uint64) error
+
+	// 以系统身份发送消息，用于自动关闭、跟进提醒等自动操作
+	CreateSystemMessage(feedback *models.Feedback, content string) (*models.FeedbackMessage, error)
 }
 
 // feedbackMessageService 反馈消息服务实现
@@ -31,31 +37,64 @@ type feedbackMessageService struct {
 	messageRepo  repository.FeedbackMessageRepository
 	feedbackRepo repository.FeedbackRepository
 	userRepo     repository.UserRepository
+	eventRepo    repository.FeedbackEventRepository
 	wsHandler    *ws.WSHandler
+
+	attachmentService AttachmentService
+	assignmentService AssignmentService
+	slaService        SLAService
+	automationService AutomationService
 }
 
 // NewFeedbackMessageService 创建反馈消息服务
-func NewFeedbackMessageService(repo repository.FeedbackMessageRepository, feedbackRepo repository.FeedbackRepository, userRepo repository.UserRepository, wsHandler *ws.WSHandler) FeedbackMessageService {
+func NewFeedbackMessageService(repo repository.FeedbackMessageRepository, feedbackRepo repository.FeedbackRepository, userRepo repository.UserRepository, eventRepo repository.FeedbackEventRepository, wsHandler *ws.WSHandler, attachmentService AttachmentService, assignmentService AssignmentService, slaService SLAService, automationService AutomationService) FeedbackMessageService {
 	return &feedbackMessageService{
-		messageRepo:  repo,
-		feedbackRepo: feedbackRepo,
-		userRepo:     userRepo,
-		wsHandler:    wsHandler,
+		messageRepo:       repo,
+		feedbackRepo:      feedbackRepo,
+		userRepo:          userRepo,
+		eventRepo:         eventRepo,
+		wsHandler:         wsHandler,
+		attachmentService: attachmentService,
+		assignmentService: assignmentService,
+		slaService:        slaService,
+		automationService: automationService,
 	}
 }
 
-// Create 创建反馈消息
-func (s *feedbackMessageService) Create(message *models.FeedbackMessage) error {
-	// 检查反馈状态，如果已解决则不允许发送消息
-	feedback, err := s.feedbackRepo.FindByID(message.FeedbackID)
+// Create 以 actor 的身份创建反馈消息
+// 反馈不存在或用户不是参与方时返回 ErrFeedbackNotFound，商家组织的只读员工返回 ErrFeedbackReadOnly
+func (s *feedbackMessageService) Create(actor *models.User, message *models.FeedbackMessage) error {
+	feedback, err := participantFeedback(s.feedbackRepo, message.FeedbackID, actor)
 	if err != nil {
 		return err
 	}
+	if !CanHandleFeedback(actor) {
+		return ErrFeedbackReadOnly
+	}
+	message.SenderID = actor.ID
+	message.SenderType = actor.UserType
+
+	// 检查反馈状态，如果已解决则不允许发送消息
 
 	// 状态为3表示已解决
 	if feedback.Status == 3 {
 		return errors.New("反馈已解决，无法发送新消息")
 	}
+	if feedback.Status == consts.Closed {
+		return errors.New("反馈已关闭，无法发送新消息")
+	}
+	if message.ContentType == consts.SystemMessage {
+		return errors.New("不能发送系统消息")
+	}
+
+	// 图片地址去掉签名参数后再保存
+	content, attachmentIDs := s.attachmentService.NormalizeMessageContent(message.ContentType, message.Content)
+	message.Content = content
+
+	// 只能引用本人上传的未使用附件或该反馈中的附件
+	if err := s.attachmentService.CheckBindable(message.FeedbackID, attachmentIDs, message.SenderID, message.SenderType); err != nil {
+		return err
+	}
 
 	// 创建消息
 	err = s.messageRepo.Create(message)
@@ -63,10 +102,28 @@ func (s *feedbackMessageService) Create(message *models.FeedbackMessage) error {
 		return err
 	}
 
+	// 将引用的附件绑定到反馈，之后反馈的参与方才能访问这些附件
+	if err := s.attachmentService.BindToFeedback(message.FeedbackID, consts.AttachmentRefMessage, message.ID, attachmentIDs, message.SenderID, message.SenderType); err != nil {
+		return err
+	}
+	message.Content = s.attachmentService.SignMessageContent(message.FeedbackID, actor, message.ContentType, message.Content)
+
+	// 记录双方最近一次回复的时间，用于跟进提醒
+	s.recordReply(feedback, message)
+
+	// 更新 SLA 计时：目标方回复时记录首次回复并暂停解决计时，客户回复时恢复
+	if s.slaService != nil {
+		s.slaService.OnMessage(feedback, message)
+	}
+
 	// 检查是否需要自动更新反馈状态
-	// 如果是目标方（商家或管理员）首次回复，将状态更新为"处理中"
+	// 如果是目标方（商家或管理员）首次回复，将状态更新为"处理中"，未分配的反馈同时分配给回复人
+	statusChanged := false
 	if s.shouldUpdateFeedbackStatus(message) {
-		s.updateFeedbackStatusToInProgress(message.FeedbackID)
+		responder, _ := s.userRepo.GetByID(message.SenderID)
+		s.updateFeedbackStatusToInProgress(message.FeedbackID, responder)
+		s.assignmentService.AutoAssign(feedback, responder)
+		statusChanged = true
 	}
 
 	// 如果有WebSocket处理程序，发送通知
@@ -82,79 +139,129 @@ func (s *feedbackMessageService) Create(message *models.FeedbackMessage) error {
 		if s.userRepo != nil {
 			sender, err := s.userRepo.GetByID(message.SenderID)
 			if err == nil && sender != nil {
-				senderName = sender.Username
+				senderName = sender.Name()
 			}
 		}
 
-		// 创建WebSocket消息（使用前端期望的字段格式）
-		wsMessage := models.WSMessage{
-			Event:     consts.EventMessage,
-			Timestamp: time.Now(),
-			Sender: &models.Sender{
-				ID:   message.SenderID,
-				Type: message.SenderType,
-				Name: senderName,
-			},
-			Data: map[string]interface{}{
-				"feedbackId":  message.FeedbackID, // 使用驼峰格式
-				"messageId":   message.ID,
-				"content":     message.Content,
-				"messageType": message.ContentType,
-				"createdAt":   message.CreatedAt,
-			},
+		sender := &models.Sender{
+			ID:   message.SenderID,
+			Type: message.SenderType,
+			Name: senderName,
 		}
-
-		// 序列化消息
-		jsonMessage, err := json.Marshal(wsMessage)
+		jsonMessage, err := broadcastMessage(s.wsHandler, s.userRepo, feedback, message, sender)
 		if err != nil {
 			return err
 		}
 
-		// 发送给反馈的创建者（如果不是发送者本人）
-		if feedback.CreatorID != message.SenderID {
-			log.Printf("发送消息给创建者: UserID=%d, UserType=%d", feedback.CreatorID, feedback.CreatorType)
-			s.wsHandler.SendMessageToUser(feedback.CreatorID, feedback.CreatorType, jsonMessage)
-		}
+		// 同时发送给发送者本人，这样发送者也能看到自己的消息
+		s.wsHandler.SendMessageToUser(message.SenderID, message.SenderType, jsonMessage)
+	}
 
-		// 发送给反馈的目标用户（如果不是发送者本人）
-		if feedback.TargetID != message.SenderID {
-			// 将目标类型转换为用户类型
-			var targetUserType uint8
-			switch feedback.TargetType {
-			case 1: // TARGET_TYPE.MERCHANT = 1
-				targetUserType = consts.Merchant // USER_TYPE.MERCHANT = 2
-			case 2: // TARGET_TYPE.ADMIN = 2
-				targetUserType = consts.Admin // USER_TYPE.ADMIN = 3
-			default:
-				targetUserType = feedback.TargetType
-			}
-			log.Printf("发送消息给目标用户: UserID=%d, TargetType=%d, UserType=%d", feedback.TargetID, feedback.TargetType, targetUserType)
-			s.wsHandler.SendMessageToUser(feedback.TargetID, targetUserType, jsonMessage)
+	// 执行自动化规则：目标方首次回复自动改为处理中时先执行修改状态的规则，再执行收到新消息的规则
+	if s.automationService != nil {
+		if statusChanged {
+			s.automationService.Fire(&AutomationEvent{Trigger: consts.RuleTriggerStatusChanged, FeedbackID: feedback.ID, OldStatus: consts.Open, NewStatus: consts.InProgress})
 		}
+		s.automationService.Fire(&AutomationEvent{Trigger: consts.RuleTriggerMessageReceived, FeedbackID: feedback.ID, Message: message})
+	}
 
-		// 发送给所有管理员（如果发送者不是管理员且目标不是管理员）
-		// 修复重复消息问题：只有当反馈目标不是管理员时，才广播给所有管理员
-		if message.SenderType != consts.Admin && feedback.TargetType != 2 {
-			admins, err := s.userRepo.GetAdmins()
-			if err == nil {
-				for _, admin := range admins {
-					// 避免重复发送给已经作为目标用户接收消息的管理员
-					if admin.ID != feedback.TargetID {
-						s.wsHandler.SendMessageToUser(admin.ID, consts.Admin, jsonMessage)
-					}
-				}
-			}
+	return nil
+}
+
+// broadcastMessage 将新消息发送给反馈的所有参与方（发送者本人除外），返回发送的 WebSocket 消息
+func broadcastMessage(wsHandler *ws.WSHandler, userRepo repository.UserRepository, feedback *models.Feedback, message *models.FeedbackMessage, sender *models.Sender) ([]byte, error) {
+	// 创建WebSocket消息（使用前端期望的字段格式）
+	wsMessage := models.WSMessage{
+		Event:     consts.EventMessage,
+		Timestamp: time.Now(),
+		Sender:    sender,
+		Data: map[string]interface{}{
+			"feedbackId":  message.FeedbackID, // 使用驼峰格式
+			"messageId":   message.ID,
+			"content":     message.Content,
+			"messageType": message.ContentType,
+			"createdAt":   message.CreatedAt,
+		},
+	}
+
+	// 序列化消息
+	jsonMessage, err := json.Marshal(wsMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	// 发送给反馈的创建者（如果不是发送者本人）
+	if feedback.CreatorID != message.SenderID {
+		log.Printf("发送消息给创建者: UserID=%d, UserType=%d", feedback.CreatorID, feedback.CreatorType)
+		wsHandler.SendMessageToUser(feedback.CreatorID, feedback.CreatorType, jsonMessage)
+	}
+
+	// 发送给反馈的目标方（商家组织的所有员工或目标管理员），发送者本人在最后单独发送
+	sendToTarget(wsHandler, userRepo, feedback, jsonMessage, message.SenderID)
+
+	// 发给商家的反馈中创建者和商家的消息同时发送给所有管理员，供管理员了解会话；
+	// 升级后管理员团队成为参与方，管理员发送的消息也发送给其他管理员（发送者本人在最后单独发送）
+	// 发给管理员的反馈已经发送给目标管理员，不再广播
+	if feedback.TargetType == consts.TargetMerchant && (message.SenderType != consts.Admin || feedback.EscalatedAt != nil) {
+		skipUserID := uint64(0)
+		if message.SenderType == consts.Admin {
+			skipUserID = message.SenderID
 		}
+		sendToAdmins(wsHandler, userRepo, jsonMessage, skipUserID)
+	}
 
-		// 同时发送给发送者本人，这样发送者也能看到自己的消息
-		s.wsHandler.SendMessageToUser(message.SenderID, message.SenderType, jsonMessage)
+	return jsonMessage, nil
+}
+
+// CreateSystemMessage 以系统身份发送文本消息并通知反馈的所有参与方；不影响反馈状态、SLA 计时和双方的回复时间
+func (s *feedbackMessageService) CreateSystemMessage(feedback *models.Feedback, content string) (*models.FeedbackMessage, error) {
+	return createSystemMessage(s.messageRepo, s.wsHandler, s.userRepo, feedback, content)
+}
+
+// createSystemMessage 保存系统消息并发送给反馈的所有参与方，自动化规则的预设回复同样使用系统消息
+func createSystemMessage(messageRepo repository.FeedbackMessageRepository, wsHandler *ws.WSHandler, userRepo repository.UserRepository, feedback *models.Feedback, content string) (*models.FeedbackMessage, error) {
+	message := &models.FeedbackMessage{
+		FeedbackID:  feedback.ID,
+		ContentType: consts.SystemMessage,
+		Content:     content,
+	}
+	if err := messageRepo.Create(message); err != nil {
+		return nil, err
 	}
+	message.SenderName = systemSenderName
 
-	return nil
+	if wsHandler != nil {
+		if _, err := broadcastMessage(wsHandler, userRepo, feedback, message, &models.Sender{Name: systemSenderName}); err != nil {
+			return nil, err
+		}
+	}
+	return message, nil
 }
 
-// GetByFeedbackID 获取反馈的所有消息
-func (s *feedbackMessageService) GetByFeedbackID(feedbackID uint64) ([]*models.FeedbackMessage, error) {
+// recordReply 记录目标方或反馈创建者最近一次回复的时间，失败时只记录日志
+// 升级后管理员团队成为目标方，管理员的回复同样记为目标方回复
+func (s *feedbackMessageService) recordReply(feedback *models.Feedback, message *models.FeedbackMessage) {
+	var column string
+	switch {
+	case message.SenderType == targetUserType(feedback.TargetType),
+		message.SenderType == consts.Admin && feedback.TargetType == consts.TargetMerchant && feedback.EscalatedAt != nil:
+		column = "last_target_reply_at"
+	case message.SenderID == feedback.CreatorID && message.SenderType == feedback.CreatorType:
+		column = "last_creator_reply_at"
+	default:
+		return
+	}
+	if err := s.feedbackRepo.UpdateFields(feedback.ID, map[string]interface{}{column: message.CreatedAt}); err != nil {
+		log.Printf("记录反馈 %d 的回复时间失败: %v", feedback.ID, err)
+	}
+}
+
+// GetByFeedbackID 获取反馈的所有消息，反馈不存在或用户不是参与方时返回 ErrFeedbackNotFound
+func (s *feedbackMessageService) GetByFeedbackID(actor *models.User, feedbackID uint64) ([]*models.FeedbackMessage, error) {
+	if _, err := participantFeedback(s.feedbackRepo, feedbackID, actor); err != nil {
+		return nil, err
+	}
+
 	messages, err := s.messageRepo.FindAllByFeedbackID(feedbackID)
 	if err != nil {
 		return nil, err
@@ -162,13 +269,18 @@ func (s *feedbackMessageService) GetByFeedbackID(feedbackID uint64) ([]*models.F
 
 	// 为每条消息添加发送者姓名
 	for _, message := range messages {
-		if s.userRepo != nil {
+		if message.ContentType == consts.SystemMessage {
+			message.SenderName = systemSenderName
+		} else if s.userRepo != nil {
 			sender, err := s.userRepo.GetByID(message.SenderID)
 			if err == nil && sender != nil {
 				// 添加发送者姓名到消息中（需要在模型中添加这个字段）
-				message.SenderName = sender.Username
+				message.SenderName = sender.Name()
 			}
 		}
+
+		// 为图片地址加上签名，供前端直接展示
+		message.Content = s.attachmentService.SignMessageContent(feedbackID, actor, message.ContentType, message.Content)
 	}
 
 	return messages, nil
@@ -231,15 +343,19 @@ func (s *feedbackMessageService) shouldUpdateFeedbackStatus(message *models.Feed
 	return false
 }
 
-// updateFeedbackStatusToInProgress 将反馈状态更新为处理中
-func (s *feedbackMessageService) updateFeedbackStatusToInProgress(feedbackID uint64) {
+// updateFeedbackStatusToInProgress 将反馈状态更新为处理中，responder 为回复人
+func (s *feedbackMessageService) updateFeedbackStatusToInProgress(feedbackID uint64, responder *models.User) {
 	// 将反馈状态更新为处理中(2)
-	err := s.feedbackRepo.UpdateStatus(feedbackID, 2)
-	if err != nil {
+	ok, err := s.feedbackRepo.UpdateStatus(feedbackID, 2)
+	if err != nil || !ok {
 		// 记录错误但不影响消息创建
 		// 在实际项目中应该使用日志记录
 		return
 	}
+	recordFeedbackEvent(s.eventRepo, feedbackID, responder, consts.FeedbackEventStatusChange, map[string]interface{}{
+		"old_status": consts.Open,
+		"new_status": consts.InProgress,
+	})
 
 	// 发送状态变更通知
 	if s.wsHandler != nil {
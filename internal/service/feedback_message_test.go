@@ -0,0 +1,65 @@
+package service
+
+import (
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"testing"
+	"time"
+)
+
+// fakeReplyFeedbackRepo 记录 UpdateFields 更新的字段
+type fakeReplyFeedbackRepo struct {
+	repository.FeedbackRepository
+	updated map[string]interface{}
+}
+
+func (r *fakeReplyFeedbackRepo) UpdateFields(id uint64, fields map[string]interface{}) error {
+	r.updated = fields
+	return nil
+}
+
+func TestRecordReply(t *testing.T) {
+	escalated := time.Now().Add(-time.Hour)
+	merchantFeedback := models.Feedback{ID: 5, CreatorID: 7, CreatorType: consts.User, TargetID: 3, TargetType: consts.TargetMerchant}
+	escalatedFeedback := merchantFeedback
+	escalatedFeedback.EscalatedAt = &escalated
+	adminFeedback := models.Feedback{ID: 6, CreatorID: 7, CreatorType: consts.User, TargetID: 1, TargetType: consts.TargetAdmin}
+
+	tests := []struct {
+		name     string
+		feedback models.Feedback
+		sender   *models.User
+		want     string
+	}{
+		{"merchant reply", merchantFeedback, testAgent, "last_target_reply_at"},
+		{"creator reply", merchantFeedback, testCustomer, "last_creator_reply_at"},
+		// 未升级时管理员只是旁观者
+		{"admin reply before escalation", merchantFeedback, testAdmin, ""},
+		// 升级后管理员团队成为目标方
+		{"admin reply after escalation", escalatedFeedback, testAdmin, "last_target_reply_at"},
+		{"merchant reply after escalation", escalatedFeedback, testAgent, "last_target_reply_at"},
+		{"admin target reply", adminFeedback, testAdmin, "last_target_reply_at"},
+		{"other user", merchantFeedback, &models.User{ID: 8, UserType: consts.User}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeReplyFeedbackRepo{}
+			s := &feedbackMessageService{feedbackRepo: repo}
+			feedback := tt.feedback
+			s.recordReply(&feedback, &models.FeedbackMessage{
+				FeedbackID: feedback.ID,
+				SenderID:   tt.sender.ID,
+				SenderType: tt.sender.UserType,
+				CreatedAt:  time.Now(),
+			})
+			var got string
+			for column := range repo.updated {
+				got = column
+			}
+			if got != tt.want {
+				t.Errorf("updated column = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"testing"
+)
+
+// fakeListFeedbackRepo 记录列表查询，返回空列表；FindByID 返回固定的反馈
+type fakeListFeedbackRepo struct {
+	repository.FeedbackRepository
+	feedback *models.Feedback
+	queried  bool
+}
+
+func (r *fakeListFeedbackRepo) FindByID(id uint64) (*models.Feedback, error) {
+	if r.feedback == nil || r.feedback.ID != id {
+		return nil, errors.New("record not found")
+	}
+	return r.feedback, nil
+}
+
+func (r *fakeListFeedbackRepo) FindByCreator(creatorID uint64, creatorType uint8) ([]*models.Feedback, error) {
+	r.queried = true
+	return nil, nil
+}
+
+func (r *fakeListFeedbackRepo) FindByFilter(targetID uint64, targetType uint8, filter *models.FeedbackFilter) ([]*models.Feedback, error) {
+	r.queried = true
+	return nil, nil
+}
+
+var (
+	testAdmin    = &models.User{ID: 1, UserType: consts.Admin}
+	testCustomer = &models.User{ID: 7, UserType: consts.User}
+	testAgent    = &models.User{ID: 20, UserType: consts.Merchant, OrgID: 3, OrgRole: consts.OrgRoleAgent}
+	testOutsider = &models.User{ID: 21, UserType: consts.Merchant, OrgID: 4, OrgRole: consts.OrgRoleOwner}
+	testNoOrg    = &models.User{ID: 22, UserType: consts.Merchant}
+)
+
+func TestFeedbackListVisibility(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    func(s FeedbackService, actor *models.User) error
+		actor   *models.User
+		allowed bool
+	}{
+		{"all as admin", listAll, testAdmin, true},
+		{"all as customer", listAll, testCustomer, false},
+		{"all as merchant", listAll, testAgent, false},
+		{"own created", listCreatedBy(7, consts.User), testCustomer, true},
+		{"created by another user", listCreatedBy(8, consts.User), testCustomer, false},
+		{"created by same ID of another type", listCreatedBy(7, consts.Merchant), testCustomer, false},
+		{"created by anyone as admin", listCreatedBy(8, consts.User), testAdmin, true},
+		{"own organization", listTarget(3, consts.TargetMerchant), testAgent, true},
+		{"another organization", listTarget(3, consts.TargetMerchant), testOutsider, false},
+		{"merchant without organization", listTarget(0, consts.TargetMerchant), testNoOrg, false},
+		{"organization as customer", listTarget(3, consts.TargetMerchant), testCustomer, false},
+		{"admin target as merchant", listTarget(20, consts.TargetAdmin), testAgent, false},
+		{"any target as admin", listTarget(3, consts.TargetMerchant), testAdmin, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeListFeedbackRepo{}
+			s := NewFeedbackService(repo, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+			err := tt.list(s, tt.actor)
+			if tt.allowed && (err != nil || !repo.queried) {
+				t.Errorf("err = %v, queried = %v, want allowed", err, repo.queried)
+			}
+			if !tt.allowed && (!errors.Is(err, ErrFeedbackForbidden) || repo.queried) {
+				t.Errorf("err = %v, queried = %v, want ErrFeedbackForbidden", err, repo.queried)
+			}
+		})
+	}
+}
+
+func listAll(s FeedbackService, actor *models.User) error {
+	_, err := s.GetAll(actor, &models.FeedbackFilter{})
+	return err
+}
+
+func listCreatedBy(creatorID uint64, creatorType uint8) func(FeedbackService, *models.User) error {
+	return func(s FeedbackService, actor *models.User) error {
+		_, err := s.GetByCreator(actor, creatorID, creatorType)
+		return err
+	}
+}
+
+func listTarget(targetID uint64, targetType uint8) func(FeedbackService, *models.User) error {
+	return func(s FeedbackService, actor *models.User) error {
+		_, err := s.GetByTarget(actor, targetID, targetType, &models.FeedbackFilter{})
+		return err
+	}
+}
+
+func TestFeedbackDeleteRequiresCreatorOrAdmin(t *testing.T) {
+	feedback := &models.Feedback{ID: 5, CreatorID: 7, CreatorType: consts.User, TargetID: 3, TargetType: consts.TargetMerchant}
+	tests := []struct {
+		name  string
+		actor *models.User
+		want  error
+	}{
+		// 不是参与方时不暴露反馈是否存在
+		{"outsider", testOutsider, ErrFeedbackNotFound},
+		{"another customer", &models.User{ID: 8, UserType: consts.User}, ErrFeedbackNotFound},
+		// 目标组织的员工是参与方，但不能删除
+		{"target staff", testAgent, ErrFeedbackForbidden},
+		{"target viewer", &models.User{ID: 23, UserType: consts.Merchant, OrgID: 3, OrgRole: consts.OrgRoleViewer}, ErrFeedbackForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewFeedbackService(&fakeListFeedbackRepo{feedback: feedback}, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+			if err := s.Delete(tt.actor, feedback.ID); !errors.Is(err, tt.want) {
+				t.Errorf("Delete() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
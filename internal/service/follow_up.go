@@ -0,0 +1,243 @@
+package service
+
+import (
+	"encoding/json"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/ws"
+	"fmt"
+	"log"
+	"time"
+)
+
+// FollowUpService 反馈跟进服务接口，由后台定时任务调用
+// 已解决超过一定时间且未重新打开的反馈自动关闭；处理中的反馈目标方长时间未回复时提醒目标方；
+// 目标方回复后反馈创建者长时间未回复时询问是否仍需要帮助。每个操作都在会话中发送系统消息、写入处理记录并推送 WebSocket 事件
+// 各时长不大于 0 时不执行对应的操作
+type FollowUpService interface {
+	AutoClose() (int64, error)
+	FollowUpTargets() (int64, error)
+	FollowUpCreators() (int64, error)
+}
+
+// followUpService 反馈跟进服务实现
+type followUpService struct {
+	feedbackRepo   repository.FeedbackRepository
+	eventRepo      repository.FeedbackEventRepository
+	userRepo       repository.UserRepository
+	messageService FeedbackMessageService
+	slaService     SLAService
+	wsHandler      *ws.WSHandler
+
+	autoCloseAfter       time.Duration
+	targetFollowUpAfter  time.Duration
+	creatorFollowUpAfter time.Duration
+	now                  func() time.Time
+}
+
+// NewFollowUpService 创建反馈跟进服务
+// autoCloseAfter 为已解决多久后自动关闭，targetFollowUpAfter 为目标方多久未回复后提醒，creatorFollowUpAfter 为反馈创建者多久未回复后询问
+func NewFollowUpService(feedbackRepo repository.FeedbackRepository, eventRepo repository.FeedbackEventRepository, userRepo repository.UserRepository, messageService FeedbackMessageService, slaService SLAService, wsHandler *ws.WSHandler, autoCloseAfter, targetFollowUpAfter, creatorFollowUpAfter time.Duration) FollowUpService {
+	return &followUpService{
+		feedbackRepo:         feedbackRepo,
+		eventRepo:            eventRepo,
+		userRepo:             userRepo,
+		messageService:       messageService,
+		slaService:           slaService,
+		wsHandler:            wsHandler,
+		autoCloseAfter:       autoCloseAfter,
+		targetFollowUpAfter:  targetFollowUpAfter,
+		creatorFollowUpAfter: creatorFollowUpAfter,
+		now:                  time.Now,
+	}
+}
+
+// AutoClose 关闭已解决超过 autoCloseAfter 且未重新打开的反馈，返回关闭的数量
+func (s *followUpService) AutoClose() (int64, error) {
+	if s.autoCloseAfter <= 0 {
+		return 0, nil
+	}
+
+	now := s.now()
+	feedbacks, err := s.feedbackRepo.FindResolvedBefore(now.Add(-s.autoCloseAfter))
+	if err != nil {
+		return 0, err
+	}
+
+	var closed int64
+	for _, feedback := range feedbacks {
+		ok, err := s.feedbackRepo.CloseResolved(feedback.ID, now)
+		if err != nil {
+			return closed, err
+		}
+		// 反馈在查询之后被重新打开
+		if !ok {
+			continue
+		}
+		closed++
+
+		recordFeedbackEvent(s.eventRepo, feedback.ID, nil, consts.FeedbackEventAutoClose, map[string]interface{}{
+			"old_status": consts.Resolved,
+			"new_status": consts.Closed,
+		})
+		if s.slaService != nil {
+			s.slaService.OnStatusChange(feedback, consts.Closed)
+		}
+		feedback.Status, feedback.StatusChangedAt, feedback.ClosedAt = consts.Closed, &now, &now
+
+		s.systemMessage(feedback, fmt.Sprintf("反馈已解决超过%s，已自动关闭。如果问题再次出现，请提交新的反馈。", describeDuration(s.autoCloseAfter)))
+		s.notifyClosed(feedback)
+	}
+	return closed, nil
+}
+
+// FollowUpTargets 提醒目标方回复处理中且超过 targetFollowUpAfter 未回复的反馈，每段未回复期间只提醒一次，返回提醒的数量
+func (s *followUpService) FollowUpTargets() (int64, error) {
+	if s.targetFollowUpAfter <= 0 {
+		return 0, nil
+	}
+
+	now := s.now()
+	feedbacks, err := s.feedbackRepo.FindAwaitingTarget(now.Add(-s.targetFollowUpAfter))
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, feedback := range feedbacks {
+		if err := s.feedbackRepo.UpdateFields(feedback.ID, map[string]interface{}{"target_followed_up_at": now}); err != nil {
+			return count, err
+		}
+		count++
+
+		idleSince := targetIdleSince(feedback)
+		recordFeedbackEvent(s.eventRepo, feedback.ID, nil, consts.FeedbackEventFollowUp, map[string]interface{}{
+			"kind":       consts.FollowUpTarget,
+			"idle_since": idleSince,
+		})
+		s.systemMessage(feedback, fmt.Sprintf("该反馈已超过%s没有得到回复，已提醒处理人员尽快处理。", describeDuration(s.targetFollowUpAfter)))
+		s.notifyFollowUp(feedback, consts.FollowUpTarget, idleSince)
+	}
+	return count, nil
+}
+
+// FollowUpCreators 询问目标方回复后超过 creatorFollowUpAfter 未回复的反馈创建者是否仍需要帮助，每次目标方回复后只询问一次，返回询问的数量
+func (s *followUpService) FollowUpCreators() (int64, error) {
+	if s.creatorFollowUpAfter <= 0 {
+		return 0, nil
+	}
+
+	now := s.now()
+	feedbacks, err := s.feedbackRepo.FindAwaitingCreator(now.Add(-s.creatorFollowUpAfter))
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, feedback := range feedbacks {
+		if err := s.feedbackRepo.UpdateFields(feedback.ID, map[string]interface{}{"creator_followed_up_at": now}); err != nil {
+			return count, err
+		}
+		count++
+
+		idleSince := *feedback.LastTargetReplyAt
+		recordFeedbackEvent(s.eventRepo, feedback.ID, nil, consts.FeedbackEventFollowUp, map[string]interface{}{
+			"kind":       consts.FollowUpCreator,
+			"idle_since": idleSince,
+		})
+		s.systemMessage(feedback, "请问您的问题解决了吗？如果仍然需要帮助，请直接回复这条反馈。")
+		s.notifyFollowUp(feedback, consts.FollowUpCreator, idleSince)
+	}
+	return count, nil
+}
+
+// systemMessage 在反馈会话中发送系统消息，失败时只记录日志
+func (s *followUpService) systemMessage(feedback *models.Feedback, content string) {
+	if _, err := s.messageService.CreateSystemMessage(feedback, content); err != nil {
+		log.Printf("发送反馈 %d 的系统消息失败: %v", feedback.ID, err)
+	}
+}
+
+// notifyClosed 向反馈创建者、目标方和已升级反馈的管理员推送状态变更事件
+func (s *followUpService) notifyClosed(feedback *models.Feedback) {
+	if s.wsHandler == nil {
+		return
+	}
+
+	message := models.WSMessage{
+		Event:     consts.EventStatusChange,
+		Timestamp: s.now(),
+		Sender:    &models.Sender{Name: systemSenderName},
+		Data: &models.StatusChangeData{
+			FeedbackID: feedback.ID,
+			OldStatus:  consts.Resolved,
+			NewStatus:  consts.Closed,
+		},
+	}
+	jsonMessage, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+
+	sendToParticipants(s.wsHandler, s.userRepo, feedback, jsonMessage)
+}
+
+// notifyFollowUp 推送跟进提醒事件：提醒目标方时发送给目标方（已升级的反馈同时发送给所有管理员），询问创建者时发送给反馈创建者
+func (s *followUpService) notifyFollowUp(feedback *models.Feedback, kind string, idleSince time.Time) {
+	if s.wsHandler == nil {
+		return
+	}
+
+	message := models.WSMessage{
+		Event:     consts.EventFollowUp,
+		Timestamp: s.now(),
+		Data: &models.FollowUpData{
+			FeedbackID: feedback.ID,
+			Title:      feedback.Title,
+			Kind:       kind,
+			IdleSince:  idleSince,
+		},
+	}
+	jsonMessage, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+
+	if kind == consts.FollowUpCreator {
+		s.wsHandler.SendMessageToUser(feedback.CreatorID, feedback.CreatorType, jsonMessage)
+		return
+	}
+	sendToTarget(s.wsHandler, s.userRepo, feedback, jsonMessage, 0)
+	if feedback.TargetType == consts.TargetMerchant && feedback.EscalatedAt != nil {
+		sendToAdmins(s.wsHandler, s.userRepo, jsonMessage, 0)
+	}
+}
+
+// targetIdleSince 目标方开始未回复的时间：最近一次状态变更和目标方最近一次回复中较晚的一个，与仓库查询条件一致
+func targetIdleSince(feedback *models.Feedback) time.Time {
+	since := feedback.UpdatedAt
+	if feedback.StatusChangedAt != nil {
+		since = *feedback.StatusChangedAt
+	}
+	replied := feedback.CreatedAt
+	if feedback.LastTargetReplyAt != nil {
+		replied = *feedback.LastTargetReplyAt
+	}
+	if replied.After(since) {
+		return replied
+	}
+	return since
+}
+
+// describeDuration 将时长描述为“ N 天”或“ N 小时”，用于系统消息
+func describeDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return fmt.Sprintf(" %d 天", d/(24*time.Hour))
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf(" %d 小时", d/time.Hour)
+	default:
+		return " " + d.String()
+	}
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/scheduler"
+	"time"
+)
+
+var (
+	// ErrJobNotFound 定时任务不存在
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobRunning 定时任务正在运行
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// auditTargetJob 审计日志中定时任务对象的类型
+const auditTargetJob = "job"
+
+// jobRunPageSize 任务运行记录默认每页数量
+const jobRunPageSize = 20
+
+// JobService 后台定时任务服务接口
+// 任务按 cron 表达式在进程内调度，多实例部署时通过数据库锁保证每次执行只在一个实例上运行，每次运行都写入运行记录
+type JobService interface {
+	Register(name, description, spec string, fn scheduler.JobFunc) error
+	Start()
+	Stop()
+
+	// 任务管理
+	List() ([]*models.JobStatus, error)
+	Trigger(actor *AuditActor, name string) (*models.JobRun, error)
+	Runs(name string, query *models.JobRunQuery) (*models.JobRunListResponse, error)
+
+	// CleanupRuns 删除超过保留时间的运行记录
+	CleanupRuns(retention time.Duration) (int64, error)
+}
+
+// jobService 定时任务服务实现
+type jobService struct {
+	jobRepo   repository.JobRepository
+	auditRepo repository.AuditLogRepository
+	scheduler *scheduler.Scheduler
+	clock     scheduler.Clock
+}
+
+// NewJobService 创建定时任务服务
+// instance 为本实例的标识；lockTTL 为任务锁的有效期，也是单次运行的最长时间
+func NewJobService(jobRepo repository.JobRepository, auditRepo repository.AuditLogRepository, clock scheduler.Clock, instance string, lockTTL time.Duration) JobService {
+	return &jobService{
+		jobRepo:   jobRepo,
+		auditRepo: auditRepo,
+		scheduler: scheduler.New(clock, &jobStore{jobRepo: jobRepo}, instance, lockTTL),
+		clock:     clock,
+	}
+}
+
+// Register 注册任务，spec 为空表示只能手动运行
+func (s *jobService) Register(name, description, spec string, fn scheduler.JobFunc) error {
+	return s.scheduler.Register(name, description, spec, fn)
+}
+
+// Start 开始按执行计划运行任务
+func (s *jobService) Start() {
+	s.scheduler.Start()
+}
+
+// Stop 停止调度并等待正在运行的任务结束
+func (s *jobService) Stop() {
+	s.scheduler.Stop()
+}
+
+// List 获取所有任务的执行计划和最近一次运行记录
+func (s *jobService) List() ([]*models.JobStatus, error) {
+	jobs := s.scheduler.Jobs()
+	names := make([]string, len(jobs))
+	for i, job := range jobs {
+		names[i] = job.Name
+	}
+	lastRuns, err := s.jobRepo.LastRuns(names)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*models.JobStatus, 0, len(jobs))
+	for _, job := range jobs {
+		status := &models.JobStatus{
+			Name:        job.Name,
+			Description: job.Description,
+			Schedule:    job.Spec,
+			LastRun:     lastRuns[job.Name],
+		}
+		if !job.NextRun.IsZero() {
+			next := job.NextRun
+			status.NextRunAt = &next
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Trigger 立即在后台运行任务，返回新的运行记录
+func (s *jobService) Trigger(actor *AuditActor, name string) (*models.JobRun, error) {
+	runID, err := s.scheduler.Trigger(name)
+	switch {
+	case errors.Is(err, scheduler.ErrJobNotFound):
+		return nil, ErrJobNotFound
+	case errors.Is(err, scheduler.ErrJobRunning):
+		return nil, ErrJobRunning
+	case err != nil:
+		return nil, err
+	}
+
+	writeAudit(s.auditRepo, actor, consts.AuditJobTrigger, auditTargetJob, name, map[string]interface{}{
+		"run_id": runID,
+	})
+	return s.jobRepo.GetRun(runID)
+}
+
+// Runs 分页获取任务的运行记录
+func (s *jobService) Runs(name string, query *models.JobRunQuery) (*models.JobRunListResponse, error) {
+	if !s.registered(name) {
+		return nil, ErrJobNotFound
+	}
+
+	page, pageSize := query.Page, query.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = jobRunPageSize
+	}
+	runs, total, err := s.jobRepo.ListRuns(name, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &models.JobRunListResponse{
+		Items:    runs,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// registered 任务是否已注册
+func (s *jobService) registered(name string) bool {
+	for _, job := range s.scheduler.Jobs() {
+		if job.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanupRuns 删除超过保留时间的运行记录，retention 不大于 0 时不删除
+func (s *jobService) CleanupRuns(retention time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+	return s.jobRepo.DeleteRunsBefore(s.clock.Now().Add(-retention))
+}
+
+// jobStore 基于数据库的任务锁和运行记录存储
+type jobStore struct {
+	jobRepo repository.JobRepository
+}
+
+// Lock 获取任务锁
+func (st *jobStore) Lock(_ context.Context, job, owner string, slot, now, until time.Time) (bool, error) {
+	var slotPtr *time.Time
+	if !slot.IsZero() {
+		slotPtr = &slot
+	}
+	return st.jobRepo.Lock(job, owner, slotPtr, now, until)
+}
+
+// Unlock 释放任务锁
+func (st *jobStore) Unlock(_ context.Context, job, owner string, now time.Time) error {
+	return st.jobRepo.Unlock(job, owner, now)
+}
+
+// RunStarted 创建运行中的运行记录
+func (st *jobStore) RunStarted(_ context.Context, job, trigger, owner string, at time.Time) (uint64, error) {
+	run := &models.JobRun{
+		Job:       job,
+		Trigger:   trigger,
+		Instance:  owner,
+		Status:    consts.JobRunRunning,
+		StartedAt: at,
+	}
+	if err := st.jobRepo.CreateRun(run); err != nil {
+		return 0, err
+	}
+	return run.ID, nil
+}
+
+// RunFinished 记录运行结果
+func (st *jobStore) RunFinished(_ context.Context, runID uint64, at time.Time, processed int64, runErr error) error {
+	message := ""
+	if runErr != nil {
+		message = truncate(runErr.Error(), 2000)
+	}
+	return st.jobRepo.FinishRun(runID, at, processed, message)
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/captcha"
+	"feedback-system/pkg/ratelimit"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrCaptchaRequired 登录失败次数较多，需要提供验证码
+	ErrCaptchaRequired = errors.New("captcha required")
+	// ErrCaptchaInvalid 验证码校验未通过
+	ErrCaptchaInvalid = errors.New("invalid captcha")
+)
+
+// LoginLockedError 账号或IP因连续登录失败被临时锁定
+type LoginLockedError struct {
+	RetryAfter time.Duration // 距离解锁的时间
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+// 登录失败原因，记录在审计日志中
+const (
+	loginFailUnknownUser    = "unknown_user"
+	loginFailBadPassword    = "bad_password"
+	loginFailLocked         = "locked"
+	loginFailCaptchaMissing = "captcha_required"
+	loginFailCaptchaInvalid = "captcha_invalid"
+)
+
+// LoginPolicy 登录防暴力破解策略
+type LoginPolicy struct {
+	// 同一账号（用户名+用户类型）连续失败多少次后锁定，0 表示不锁定
+	UserLockThreshold int64
+	// 同一IP连续失败多少次后锁定，0 表示不锁定
+	IPLockThreshold int64
+	// 账号或IP失败多少次后要求验证码，0 表示不要求
+	CaptchaThreshold int64
+	// 失败计数在最后一次失败后保留多久
+	FailureWindow time.Duration
+	// 首次锁定时长，之后每多失败一次锁定时长翻倍
+	LockBase time.Duration
+	// 最长锁定时长
+	LockMax time.Duration
+}
+
+// LoginGuard 登录防暴力破解
+// 分别按账号和IP统计登录失败次数：超过验证码阈值后要求验证码，超过锁定阈值后按指数退避锁定，
+// 所有失败的登录尝试都写入审计日志。为 nil 时不做任何限制
+type LoginGuard struct {
+	store     ratelimit.Store
+	auditRepo repository.AuditLogRepository
+	verifier  captcha.Verifier
+	policy    LoginPolicy
+}
+
+// NewLoginGuard 创建登录防暴力破解实例，verifier 为 nil 时不要求验证码
+func NewLoginGuard(store ratelimit.Store, auditRepo repository.AuditLogRepository, verifier captcha.Verifier, policy LoginPolicy) *LoginGuard {
+	return &LoginGuard{
+		store:     store,
+		auditRepo: auditRepo,
+		verifier:  verifier,
+		policy:    policy,
+	}
+}
+
+// loginAttempt 一次登录尝试
+type loginAttempt struct {
+	username  string
+	userType  uint8
+	ip        string
+	userAgent string
+	captcha   string
+}
+
+// loginSubject 登录失败的计数对象（账号或IP）
+type loginSubject struct {
+	name      string
+	key       string
+	threshold int64
+}
+
+// subjects 登录尝试对应的计数对象
+func (g *LoginGuard) subjects(a *loginAttempt) []loginSubject {
+	return []loginSubject{
+		{
+			name:      "user",
+			key:       fmt.Sprintf("user:%d:%s", a.userType, strings.ToLower(a.username)),
+			threshold: g.policy.UserLockThreshold,
+		},
+		{
+			name:      "ip",
+			key:       "ip:" + a.ip,
+			threshold: g.policy.IPLockThreshold,
+		},
+	}
+}
+
+// Check 登录前检查账号或IP是否被锁定、是否需要验证码
+// 计数存储不可用时放行，避免影响正常登录
+func (g *LoginGuard) Check(ctx context.Context, a *loginAttempt) error {
+	if g == nil {
+		return nil
+	}
+
+	var retryAfter time.Duration
+	captchaRequired := false
+	for _, subject := range g.subjects(a) {
+		_, lockTTL, err := g.store.Get(ctx, "login:lock:"+subject.key)
+		if err != nil {
+			log.Printf("读取登录锁定状态失败: %v", err)
+			return nil
+		}
+		if lockTTL > retryAfter {
+			retryAfter = lockTTL
+		}
+
+		failures, _, err := g.store.Get(ctx, "login:fail:"+subject.key)
+		if err != nil {
+			log.Printf("读取登录失败次数失败: %v", err)
+			return nil
+		}
+		if g.policy.CaptchaThreshold > 0 && failures >= g.policy.CaptchaThreshold {
+			captchaRequired = true
+		}
+	}
+
+	if retryAfter > 0 {
+		g.audit(consts.AuditLoginFailed, a, loginFailLocked, nil)
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+
+	if captchaRequired && g.verifier != nil {
+		if err := g.verifier.Verify(ctx, a.captcha, a.ip); err != nil {
+			switch {
+			case errors.Is(err, captcha.ErrMissing):
+				g.audit(consts.AuditLoginFailed, a, loginFailCaptchaMissing, nil)
+				return ErrCaptchaRequired
+			case errors.Is(err, captcha.ErrInvalid):
+				g.audit(consts.AuditLoginFailed, a, loginFailCaptchaInvalid, nil)
+				return ErrCaptchaInvalid
+			default:
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Fail 记录一次用户名或密码错误，失败次数达到阈值时锁定账号或IP
+func (g *LoginGuard) Fail(ctx context.Context, a *loginAttempt, reason string) {
+	if g == nil {
+		return
+	}
+	g.audit(consts.AuditLoginFailed, a, reason, nil)
+
+	for _, subject := range g.subjects(a) {
+		failKey := "login:fail:" + subject.key
+		failures, _, err := g.store.Incr(ctx, failKey, g.policy.FailureWindow)
+		if err != nil {
+			log.Printf("记录登录失败次数失败: %v", err)
+			continue
+		}
+		// 每次失败都重新计时，持续失败时计数不会因窗口到期而清零
+		if err := g.store.Expire(ctx, failKey, g.policy.FailureWindow); err != nil {
+			log.Printf("记录登录失败次数失败: %v", err)
+		}
+
+		if subject.threshold <= 0 || failures < subject.threshold {
+			continue
+		}
+		lock := g.lockDuration(failures - subject.threshold)
+		if err := g.store.Set(ctx, "login:lock:"+subject.key, 1, lock); err != nil {
+			log.Printf("锁定登录失败: %v", err)
+			continue
+		}
+		g.audit(consts.AuditLoginLocked, a, reason, map[string]interface{}{
+			"subject":  subject.name,
+			"failures": failures,
+			"seconds":  int64(lock / time.Second),
+		})
+	}
+}
+
+// Succeed 登录成功后清除账号的失败计数
+// IP的失败计数保留，避免攻击者用自己的账号登录来重置IP计数
+func (g *LoginGuard) Succeed(ctx context.Context, a *loginAttempt) {
+	if g == nil {
+		return
+	}
+	if err := g.store.Delete(ctx, "login:fail:"+g.subjects(a)[0].key); err != nil {
+		log.Printf("清除登录失败次数失败: %v", err)
+	}
+}
+
+// lockDuration 第 n 次超过阈值后的锁定时长：LockBase × 2^n，不超过 LockMax
+func (g *LoginGuard) lockDuration(n int64) time.Duration {
+	lock := g.policy.LockBase
+	for i := int64(0); i < n && lock < g.policy.LockMax; i++ {
+		lock *= 2
+	}
+	if g.policy.LockMax > 0 && lock > g.policy.LockMax {
+		lock = g.policy.LockMax
+	}
+	return lock
+}
+
+// audit 写入登录审计日志
+func (g *LoginGuard) audit(action string, a *loginAttempt, reason string, extra map[string]interface{}) {
+	detail := map[string]interface{}{
+		"reason":    reason,
+		"user_type": a.userType,
+	}
+	for k, v := range extra {
+		detail[k] = v
+	}
+	writeAudit(g.auditRepo, &AuditActor{IP: a.ip, UserAgent: a.userAgent}, action, "user", a.username, detail)
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"feedback-system/pkg/captcha"
+	"feedback-system/pkg/ratelimit"
+	"testing"
+	"time"
+)
+
+// fakeVerifier 验证码为 "ok" 时通过
+type fakeVerifier struct{}
+
+func (fakeVerifier) Verify(ctx context.Context, response, remoteIP string) error {
+	switch response {
+	case "":
+		return captcha.ErrMissing
+	case "ok":
+		return nil
+	default:
+		return captcha.ErrInvalid
+	}
+}
+
+func newTestLoginGuard() *LoginGuard {
+	return NewLoginGuard(ratelimit.NewMemoryStore(0), nil, fakeVerifier{}, LoginPolicy{
+		UserLockThreshold: 3,
+		IPLockThreshold:   5,
+		CaptchaThreshold:  2,
+		FailureWindow:     time.Hour,
+		LockBase:          time.Minute,
+		LockMax:           4 * time.Minute,
+	})
+}
+
+func TestLoginGuardLocksAccount(t *testing.T) {
+	ctx := context.Background()
+	g := newTestLoginGuard()
+	alice := &loginAttempt{username: "alice", userType: 1, ip: "10.0.0.1", captcha: "ok"}
+
+	tests := []struct {
+		name     string
+		wantLock time.Duration // 0 表示未锁定
+	}{
+		{"1 failure", 0},
+		{"2 failures", 0},
+		// 达到阈值后按 LockBase 锁定，之后每次失败锁定时长翻倍，不超过 LockMax
+		{"3 failures", time.Minute},
+		{"4 failures", 2 * time.Minute},
+		{"5 failures", 4 * time.Minute},
+		{"6 failures", 4 * time.Minute},
+	}
+	for _, tt := range tests {
+		g.Fail(ctx, alice, loginFailBadPassword)
+		err := g.Check(ctx, alice)
+		var locked *LoginLockedError
+		if tt.wantLock == 0 {
+			if err != nil {
+				t.Errorf("%s: Check() = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+		if !errors.As(err, &locked) {
+			t.Errorf("%s: Check() = %v, want LoginLockedError", tt.name, err)
+			continue
+		}
+		if locked.RetryAfter > tt.wantLock || locked.RetryAfter < tt.wantLock-time.Second {
+			t.Errorf("%s: RetryAfter = %s, want %s", tt.name, locked.RetryAfter, tt.wantLock)
+		}
+	}
+
+	// 用户名不区分大小写
+	if err := g.Check(ctx, &loginAttempt{username: "ALICE", userType: 1, ip: "10.0.0.2"}); err == nil {
+		t.Error("Check() with another case = nil, want locked")
+	}
+	// 其他用户类型的同名账号不受影响
+	if err := g.Check(ctx, &loginAttempt{username: "alice", userType: 2, ip: "10.0.0.2"}); err != nil {
+		t.Errorf("Check() for another user type = %v", err)
+	}
+}
+
+func TestLoginGuardLocksIP(t *testing.T) {
+	ctx := context.Background()
+	g := newTestLoginGuard()
+
+	// 每个账号只失败一次，但同一IP累计达到阈值
+	for _, username := range []string{"a", "b", "c", "d", "e"} {
+		g.Fail(ctx, &loginAttempt{username: username, userType: 1, ip: "10.0.0.1"}, loginFailUnknownUser)
+	}
+	var locked *LoginLockedError
+	if err := g.Check(ctx, &loginAttempt{username: "f", userType: 1, ip: "10.0.0.1", captcha: "ok"}); !errors.As(err, &locked) {
+		t.Errorf("Check() = %v, want LoginLockedError", err)
+	}
+	if err := g.Check(ctx, &loginAttempt{username: "f", userType: 1, ip: "10.0.0.2"}); err != nil {
+		t.Errorf("Check() from another IP = %v", err)
+	}
+}
+
+func TestLoginGuardCaptchaAndSucceed(t *testing.T) {
+	ctx := context.Background()
+	g := newTestLoginGuard()
+	attempt := func(captcha string) *loginAttempt {
+		return &loginAttempt{username: "alice", userType: 1, ip: "10.0.0.1", captcha: captcha}
+	}
+
+	g.Fail(ctx, attempt(""), loginFailBadPassword)
+	if err := g.Check(ctx, attempt("")); err != nil {
+		t.Fatalf("Check() below captcha threshold = %v", err)
+	}
+	g.Fail(ctx, attempt(""), loginFailBadPassword)
+
+	tests := []struct {
+		captcha string
+		want    error
+	}{
+		{"", ErrCaptchaRequired},
+		{"wrong", ErrCaptchaInvalid},
+		{"ok", nil},
+	}
+	for _, tt := range tests {
+		if err := g.Check(ctx, attempt(tt.captcha)); !errors.Is(err, tt.want) {
+			t.Errorf("Check(captcha %q) = %v, want %v", tt.captcha, err, tt.want)
+		}
+	}
+
+	// 登录成功清除账号的失败计数，但保留IP的失败计数
+	g.Succeed(ctx, attempt("ok"))
+	if err := g.Check(ctx, &loginAttempt{username: "alice", userType: 1, ip: "10.0.0.2"}); err != nil {
+		t.Errorf("Check() after Succeed = %v", err)
+	}
+	if err := g.Check(ctx, &loginAttempt{username: "bob", userType: 1, ip: "10.0.0.1"}); !errors.Is(err, ErrCaptchaRequired) {
+		t.Errorf("Check() from the same IP after Succeed = %v, want ErrCaptchaRequired", err)
+	}
+}
+
+func TestNilLoginGuard(t *testing.T) {
+	var g *LoginGuard
+	a := &loginAttempt{username: "alice"}
+	g.Fail(context.Background(), a, loginFailBadPassword)
+	g.Succeed(context.Background(), a)
+	if err := g.Check(context.Background(), a); err != nil {
+		t.Errorf("Check() = %v", err)
+	}
+}
@@ -0,0 +1,638 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/ratelimit"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/dgrijalva/jwt-go"
+	"golang.org/x/oauth2"
+)
+
+var (
+	// ErrSSODisabled 未配置单点登录
+	ErrSSODisabled = errors.New("single sign-on is not configured")
+	// ErrInvalidSSOState 单点登录的 state 无效或已过期（超时或在其他浏览器中完成了登录）
+	ErrInvalidSSOState = errors.New("invalid or expired sso state")
+	// ErrSSOUserTypeNotAllowed 该用户类型不允许单点登录，或 IdP 账号不在允许的分组中
+	ErrSSOUserTypeNotAllowed = errors.New("single sign-on is not allowed for this user type")
+	// ErrSSOAccountNotFound IdP 账号没有绑定本地账号且未开启自动创建账号
+	ErrSSOAccountNotFound = errors.New("no account is linked to this identity")
+	// ErrIdentityLinked IdP 账号已绑定其他本地账号
+	ErrIdentityLinked = errors.New("identity is already linked to another account")
+	// ErrIdentityNotFound 外部身份不存在或不属于当前用户
+	ErrIdentityNotFound = errors.New("identity not found")
+	// ErrInvalidSSOToken 单点登录令牌无效、已过期或已被使用
+	ErrInvalidSSOToken = errors.New("invalid or expired sso token")
+)
+
+const (
+	// oidcStateTTL 从跳转到 IdP 到回调的最长时间
+	oidcStateTTL = 10 * time.Minute
+	// ssoTokenTTL 回调后换取登录令牌的有效期
+	ssoTokenTTL = time.Minute
+	// ssoTokenKeyPrefix 已签发的单点登录令牌在计数存储中的键前缀
+	ssoTokenKeyPrefix = "sso-token:"
+	// auditTargetIdentity 审计日志中外部身份对象的类型
+	auditTargetIdentity = "identity"
+)
+
+// usernameInvalidChars 自动创建账号时用户名中不允许的字符
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9._@-]+`)
+
+// OIDCPolicy 单点登录策略
+type OIDCPolicy struct {
+	// IdP 的 issuer 地址，为空时不启用单点登录
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	ProviderName string
+
+	// 用户名和分组的声明名称
+	UsernameClaim string
+	GroupsClaim   string
+
+	// 允许以商家身份登录的分组，为空时不限制
+	MerchantGroups []string
+	// 允许以管理员身份登录的分组，为空时不允许管理员单点登录
+	AdminGroups []string
+
+	// 没有绑定本地账号时自动创建账号
+	AutoProvision bool
+	// 没有绑定本地账号时按已验证的邮箱绑定已有账号
+	LinkByEmail bool
+}
+
+// Enabled 是否启用单点登录
+func (p *OIDCPolicy) Enabled() bool {
+	return p.Issuer != "" && p.ClientID != ""
+}
+
+// allowsType 该用户类型是否可以单点登录
+func (p *OIDCPolicy) allowsType(userType uint8) bool {
+	switch userType {
+	case consts.Merchant:
+		return true
+	case consts.Admin:
+		return len(p.AdminGroups) > 0
+	}
+	return false
+}
+
+// allows IdP 账号所属的分组是否允许以该用户类型登录
+func (p *OIDCPolicy) allows(userType uint8, groups []string) bool {
+	switch userType {
+	case consts.Merchant:
+		return len(p.MerchantGroups) == 0 || intersects(p.MerchantGroups, groups)
+	case consts.Admin:
+		return intersects(p.AdminGroups, groups)
+	}
+	return false
+}
+
+// OIDCAuthRequest 跳转到 IdP 的授权请求
+type OIDCAuthRequest struct {
+	// IdP 授权地址
+	URL string
+	// 签名后的登录状态，由处理程序保存在 Cookie 中，回调时原样传回
+	State string
+}
+
+// OIDCCallbackResult 单点登录回调结果
+type OIDCCallbackResult struct {
+	// 完成后返回的页面
+	ReturnPath string
+	// 用于换取登录令牌的一次性令牌，绑定外部账号时为空
+	Token string
+	// 是否为绑定外部账号
+	Linked bool
+}
+
+// OIDCService 单点登录服务接口
+// 使用授权码模式和 PKCE；回调时验证 ID Token 后按外部身份、邮箱查找本地账号，必要时自动创建账号
+type OIDCService interface {
+	Config() *models.OIDCConfigResponse
+
+	// 登录或绑定外部账号，linkUser 不为 nil 时为绑定
+	Begin(ctx context.Context, userType uint8, returnPath string, linkUser *models.User) (*OIDCAuthRequest, error)
+	// 处理 IdP 回调，state 为 Begin 返回的签名状态；出错时结果中仍包含返回页面（状态无效时除外）
+	Callback(ctx context.Context, state, stateParam, code string, actor *AuditActor) (*OIDCCallbackResult, error)
+	// 使用回调签发的一次性令牌换取登录令牌
+	Exchange(token, deviceName, ip, userAgent string) (*models.UserLoginResponse, error)
+
+	// 外部身份管理
+	ListIdentities(userID uint64) ([]*models.UserIdentity, error)
+	Unlink(actor *AuditActor, id uint64) error
+}
+
+// oidcService 单点登录服务实现
+type oidcService struct {
+	userRepo     repository.UserRepository
+	identityRepo repository.UserIdentityRepository
+	orgRepo      repository.OrganizationRepository
+	auditRepo    repository.AuditLogRepository
+	userService  UserService
+	store        ratelimit.Store
+	policy       OIDCPolicy
+	jwtSecret    []byte
+
+	// IdP 配置在首次使用时获取，获取失败时下次重试
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+// NewOIDCService 创建单点登录服务，store 用于保证单点登录令牌只能使用一次
+func NewOIDCService(userRepo repository.UserRepository, identityRepo repository.UserIdentityRepository, orgRepo repository.OrganizationRepository, auditRepo repository.AuditLogRepository, userService UserService, store ratelimit.Store, policy OIDCPolicy, jwtSecret string) OIDCService {
+	return &oidcService{
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		orgRepo:      orgRepo,
+		auditRepo:    auditRepo,
+		userService:  userService,
+		store:        store,
+		policy:       policy,
+		jwtSecret:    []byte(jwtSecret),
+	}
+}
+
+// Config 单点登录配置
+func (s *oidcService) Config() *models.OIDCConfigResponse {
+	if !s.policy.Enabled() {
+		return &models.OIDCConfigResponse{}
+	}
+	return &models.OIDCConfigResponse{
+		Enabled:      true,
+		ProviderName: s.policy.ProviderName,
+		Merchant:     s.policy.allowsType(consts.Merchant),
+		Admin:        s.policy.allowsType(consts.Admin),
+	}
+}
+
+// Begin 生成跳转到 IdP 的授权地址
+// state、nonce 和 PKCE 校验码签名后保存在登录状态中，不在服务端存储
+func (s *oidcService) Begin(ctx context.Context, userType uint8, returnPath string, linkUser *models.User) (*OIDCAuthRequest, error) {
+	if !s.policy.Enabled() {
+		return nil, ErrSSODisabled
+	}
+	var linkID uint64
+	if linkUser != nil {
+		userType, linkID = linkUser.UserType, linkUser.ID
+	}
+	if !s.policy.allowsType(userType) {
+		return nil, ErrSSOUserTypeNotAllowed
+	}
+
+	config, _, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stateParam, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	state, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"typ":      "oidc_state",
+		"state":    stateParam,
+		"nonce":    nonce,
+		"verifier": verifier,
+		"ut":       userType,
+		"ret":      SafeReturnPath(returnPath, userType),
+		"link":     linkID,
+		"exp":      time.Now().Add(oidcStateTTL).Unix(),
+	}).SignedString(s.jwtSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OIDCAuthRequest{
+		URL:   config.AuthCodeURL(stateParam, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)),
+		State: state,
+	}, nil
+}
+
+// oidcState 解析后的登录状态
+type oidcState struct {
+	state      string
+	nonce      string
+	verifier   string
+	userType   uint8
+	returnPath string
+	linkID     uint64
+}
+
+// parseState 校验并解析登录状态
+func (s *oidcService) parseState(tokenString string) (*oidcState, error) {
+	claims, err := s.parseClaims(tokenString, "oidc_state")
+	if err != nil {
+		return nil, ErrInvalidSSOState
+	}
+	state := &oidcState{}
+	state.state, _ = claims["state"].(string)
+	state.nonce, _ = claims["nonce"].(string)
+	state.verifier, _ = claims["verifier"].(string)
+	state.returnPath, _ = claims["ret"].(string)
+	userType, _ := claims["ut"].(float64)
+	linkID, _ := claims["link"].(float64)
+	state.userType, state.linkID = uint8(userType), uint64(linkID)
+	if state.state == "" || state.nonce == "" || state.verifier == "" {
+		return nil, ErrInvalidSSOState
+	}
+	return state, nil
+}
+
+// Callback 处理 IdP 回调
+// 使用授权码和 PKCE 校验码换取令牌并验证 ID Token，然后绑定外部账号或签发一次性令牌
+func (s *oidcService) Callback(ctx context.Context, stateToken, stateParam, code string, actor *AuditActor) (*OIDCCallbackResult, error) {
+	if !s.policy.Enabled() {
+		return nil, ErrSSODisabled
+	}
+	state, err := s.parseState(stateToken)
+	if err != nil {
+		return nil, err
+	}
+	result := &OIDCCallbackResult{ReturnPath: state.returnPath, Linked: state.linkID != 0}
+	if subtle.ConstantTimeCompare([]byte(state.state), []byte(stateParam)) != 1 {
+		return result, ErrInvalidSSOState
+	}
+
+	claims, err := s.verify(ctx, state, code)
+	if err != nil {
+		return result, err
+	}
+	if !s.policy.allows(state.userType, claims.groups) {
+		return result, ErrSSOUserTypeNotAllowed
+	}
+
+	identity, err := s.identityRepo.FindBySubject(s.policy.Issuer, claims.subject, state.userType)
+	if err != nil {
+		identity = nil
+	}
+
+	// 已登录用户绑定外部账号
+	if state.linkID != 0 {
+		if identity != nil {
+			if identity.UserID != state.linkID {
+				return result, ErrIdentityLinked
+			}
+			return result, nil
+		}
+		user, err := s.userRepo.GetByID(state.linkID)
+		if err != nil {
+			return result, ErrUserNotFound
+		}
+		_, err = s.link(actor.as(user), user, claims, false)
+		return result, err
+	}
+
+	// 登录：依次按外部身份、已验证的邮箱查找本地账号，都没有时自动创建账号
+	var user *models.User
+	if identity != nil {
+		if user, err = s.userRepo.GetByID(identity.UserID); err != nil {
+			return result, ErrSSOAccountNotFound
+		}
+	} else if user = s.findByEmail(claims, state.userType); user != nil {
+		if identity, err = s.link(actor.as(user), user, claims, true); err != nil {
+			return result, err
+		}
+	} else if s.policy.AutoProvision {
+		if user, identity, err = s.provision(actor, claims, state.userType); err != nil {
+			return result, err
+		}
+	} else {
+		return result, ErrSSOAccountNotFound
+	}
+	if err := checkAccountStatus(user); err != nil {
+		return result, err
+	}
+
+	s.identityRepo.Touch(identity.ID, time.Now())
+	writeAudit(s.auditRepo, actor.as(user), consts.AuditSSOLogin, auditTargetUser, userTarget(user), map[string]interface{}{
+		"issuer":  s.policy.Issuer,
+		"subject": claims.subject,
+	})
+
+	if result.Token, err = s.generateSSOToken(user); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// Exchange 使用一次性令牌换取登录令牌，账号启用了两步验证时返回两步验证的临时令牌
+func (s *oidcService) Exchange(tokenString, deviceName, ip, userAgent string) (*models.UserLoginResponse, error) {
+	claims, err := s.parseClaims(tokenString, "sso")
+	if err != nil {
+		return nil, ErrInvalidSSOToken
+	}
+	userID, _ := claims["id"].(float64)
+	jti, _ := claims["jti"].(string)
+	if userID == 0 || jti == "" {
+		return nil, ErrInvalidSSOToken
+	}
+
+	// 只接受服务端签发的令牌，且只能使用一次
+	consumed, err := consumeOneTimeToken(context.Background(), s.store, ssoTokenKeyPrefix+jti, ssoTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidSSOToken
+	}
+
+	user, err := s.userRepo.GetByID(uint64(userID))
+	if err != nil {
+		return nil, ErrInvalidSSOToken
+	}
+	return s.userService.LoginVerified(user, deviceName, ip, userAgent)
+}
+
+// ListIdentities 获取用户绑定的外部身份
+func (s *oidcService) ListIdentities(userID uint64) ([]*models.UserIdentity, error) {
+	return s.identityRepo.ListByUser(userID)
+}
+
+// Unlink 解除绑定外部身份
+// 自动创建的账号没有可用的密码，解除全部绑定后需要通过找回密码设置密码才能登录
+func (s *oidcService) Unlink(actor *AuditActor, id uint64) error {
+	ok, err := s.identityRepo.Delete(id, actor.User.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrIdentityNotFound
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditSSOUnlink, auditTargetIdentity, strconv.FormatUint(id, 10), nil)
+	return nil
+}
+
+// client 获取 IdP 配置和 OAuth2 客户端配置
+func (s *oidcService) client(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.provider == nil {
+		provider, err := oidc.NewProvider(ctx, s.policy.Issuer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("获取 IdP 配置失败: %w", err)
+		}
+		s.provider = provider
+	}
+
+	scopes := s.policy.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID}
+	}
+	config := &oauth2.Config{
+		ClientID:     s.policy.ClientID,
+		ClientSecret: s.policy.ClientSecret,
+		RedirectURL:  s.policy.RedirectURL,
+		Endpoint:     s.provider.Endpoint(),
+		Scopes:       scopes,
+	}
+	return config, s.provider.Verifier(&oidc.Config{ClientID: s.policy.ClientID}), nil
+}
+
+// oidcClaims 从 ID Token 中读取的用户信息
+type oidcClaims struct {
+	subject       string
+	username      string
+	email         string
+	emailVerified bool
+	name          string
+	groups        []string
+}
+
+// verify 使用授权码换取令牌并验证 ID Token 的签名、受众、有效期和 nonce
+func (s *oidcService) verify(ctx context.Context, state *oidcState, code string) (*oidcClaims, error) {
+	config, verifier, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(state.verifier))
+	if err != nil {
+		return nil, fmt.Errorf("换取令牌失败: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("IdP 未返回 id_token")
+	}
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("验证 id_token 失败: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(state.nonce)) != 1 {
+		return nil, errors.New("id_token 的 nonce 不匹配")
+	}
+
+	raw := map[string]interface{}{}
+	if err := idToken.Claims(&raw); err != nil {
+		return nil, err
+	}
+	claims := &oidcClaims{subject: idToken.Subject}
+	claims.username, _ = raw[s.policy.UsernameClaim].(string)
+	claims.email, _ = raw["email"].(string)
+	claims.name, _ = raw["name"].(string)
+	switch verified := raw["email_verified"].(type) {
+	case bool:
+		claims.emailVerified = verified
+	case string:
+		claims.emailVerified = verified == "true"
+	}
+	claims.groups = claimStrings(raw[s.policy.GroupsClaim])
+	return claims, nil
+}
+
+// findByEmail 按已验证的邮箱查找同类型的本地账号，未开启按邮箱绑定时返回 nil
+func (s *oidcService) findByEmail(claims *oidcClaims, userType uint8) *models.User {
+	if !s.policy.LinkByEmail || !claims.emailVerified || claims.email == "" {
+		return nil
+	}
+	user, err := s.userRepo.GetByEmail(claims.email, userType)
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
+// link 为本地账号绑定外部身份
+func (s *oidcService) link(actor *AuditActor, user *models.User, claims *oidcClaims, byEmail bool) (*models.UserIdentity, error) {
+	identity := &models.UserIdentity{
+		UserID:      user.ID,
+		UserType:    user.UserType,
+		Issuer:      s.policy.Issuer,
+		Subject:     claims.subject,
+		Email:       truncate(claims.email, 255),
+		LastLoginAt: time.Now(),
+	}
+	if err := s.identityRepo.Create(identity); err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditSSOLink, auditTargetUser, userTarget(user), map[string]interface{}{
+		"issuer":   s.policy.Issuer,
+		"subject":  claims.subject,
+		"by_email": byEmail,
+	})
+	return identity, nil
+}
+
+// provision 自动创建账号并绑定外部身份
+// 账号使用随机密码，只能通过单点登录登录；IdP 已经确认了身份，商家账号不需要审核
+func (s *oidcService) provision(actor *AuditActor, claims *oidcClaims, userType uint8) (*models.User, *models.UserIdentity, error) {
+	username, err := s.availableUsername(claims, userType)
+	if err != nil {
+		return nil, nil, err
+	}
+	randomPassword, err := generateRefreshToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user := &models.User{
+		Username:    username,
+		Password:    hashPassword(randomPassword),
+		DisplayName: truncate(strings.TrimSpace(claims.name), 50),
+		UserType:    userType,
+		Status:      consts.AccountActive,
+	}
+	if claims.emailVerified {
+		user.Email = truncate(claims.email, 255)
+	}
+	// 商家账号和其组织在同一事务中创建
+	if userType == consts.Merchant {
+		if err := s.orgRepo.CreateWithOwner(ownOrganization(user), user); err != nil {
+			return nil, nil, err
+		}
+	} else if err := s.userRepo.Create(user); err != nil {
+		return nil, nil, err
+	}
+	writeAudit(s.auditRepo, actor.as(user), consts.AuditSSOProvision, auditTargetUser, userTarget(user), map[string]interface{}{
+		"issuer":   s.policy.Issuer,
+		"subject":  claims.subject,
+		"username": username,
+	})
+
+	identity, err := s.link(actor.as(user), user, claims, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, identity, nil
+}
+
+// availableUsername 自动创建账号的用户名
+// 优先使用用户名声明，其次是邮箱的本地部分；与已有账号重名时追加随机后缀
+func (s *oidcService) availableUsername(claims *oidcClaims, userType uint8) (string, error) {
+	base := claims.username
+	if base == "" {
+		base, _, _ = strings.Cut(claims.email, "@")
+	}
+	base = truncate(strings.Trim(usernameInvalidChars.ReplaceAllString(base, ""), ".-"), 80)
+	if base == "" {
+		base = "sso"
+	}
+
+	username := base
+	for i := 0; i < 5; i++ {
+		if _, err := s.userRepo.GetByUsername(username, userType); err != nil {
+			return username, nil
+		}
+		suffix, err := generateRecoveryCode()
+		if err != nil {
+			return "", err
+		}
+		username = base + "-" + suffix[:5]
+	}
+	return "", ErrUsernameTaken
+}
+
+// generateSSOToken 签发用于换取登录令牌的一次性令牌，jti 登记在计数存储中
+func (s *oidcService) generateSSOToken(user *models.User) (string, error) {
+	jti, err := generateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	if err := issueOneTimeToken(context.Background(), s.store, ssoTokenKeyPrefix+jti, ssoTokenTTL); err != nil {
+		return "", err
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"typ": "sso",
+		"id":  user.ID,
+		"jti": jti,
+		"exp": time.Now().Add(ssoTokenTTL).Unix(),
+	}).SignedString(s.jwtSecret)
+}
+
+// parseClaims 校验指定类型的 JWT 并返回声明
+func (s *oidcService) parseClaims(tokenString, typ string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return s.jwtSecret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claims["typ"] != typ {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// SafeReturnPath 单点登录完成后返回的页面，只允许本站的相对路径，防止开放重定向
+func SafeReturnPath(path string, userType uint8) string {
+	if strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "//") && !strings.ContainsAny(path, "\\\r\n") {
+		return path
+	}
+	if userType == consts.Admin {
+		return "/admin"
+	}
+	return "/merchant"
+}
+
+// claimStrings 读取字符串或字符串数组类型的声明
+func claimStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(strings.ReplaceAll(v, ",", " "))
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				list = append(list, str)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// intersects 两个列表是否有相同的元素
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
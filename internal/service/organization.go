@@ -0,0 +1,251 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/password"
+	"log"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrOrganizationNotFound 商家组织不存在，或当前账号不属于任何组织
+	ErrOrganizationNotFound = errors.New("organization not found")
+	// ErrNotOrgOwner 只有组织所有者可以管理组织
+	ErrNotOrgOwner = errors.New("only the organization owner can manage the organization")
+	// ErrOrgMemberNotFound 员工不存在或不属于当前组织
+	ErrOrgMemberNotFound = errors.New("organization member not found")
+	// ErrOrganizationHasStaff 组织还有其他员工，不能删除所有者
+	ErrOrganizationHasStaff = errors.New("organization still has other staff accounts")
+)
+
+// auditTargetOrg 审计日志中商家组织对象的类型
+const auditTargetOrg = "organization"
+
+// OrganizationService 商家组织服务接口
+// 商家注册时自动创建以其为所有者的组织，所有者可以为组织创建客服和只读员工账号
+type OrganizationService interface {
+	// 用户创建反馈时可选择的商家组织
+	ListActive() ([]*models.Organization, error)
+
+	// 当前员工所属组织
+	Get(user *models.User) (*models.OrganizationResponse, error)
+	Update(actor *AuditActor, req *models.UpdateOrganizationRequest) (*models.Organization, error)
+
+	// 员工管理，只有所有者可以操作
+	CreateMember(actor *AuditActor, req *models.CreateOrgMemberRequest) (*models.User, error)
+	UpdateMember(actor *AuditActor, id uint64, req *models.UpdateOrgMemberRequest) (*models.User, error)
+	RemoveMember(actor *AuditActor, id uint64) error
+}
+
+// organizationService 商家组织服务实现
+type organizationService struct {
+	orgRepo          repository.OrganizationRepository
+	userRepo         repository.UserRepository
+	auditRepo        repository.AuditLogRepository
+	adminUserService AdminUserService
+	passwords        *password.Policy
+}
+
+// NewOrganizationService 创建商家组织服务
+func NewOrganizationService(orgRepo repository.OrganizationRepository, userRepo repository.UserRepository, auditRepo repository.AuditLogRepository, adminUserService AdminUserService, passwords *password.Policy) OrganizationService {
+	return &organizationService{
+		orgRepo:          orgRepo,
+		userRepo:         userRepo,
+		auditRepo:        auditRepo,
+		adminUserService: adminUserService,
+		passwords:        passwords,
+	}
+}
+
+// ListActive 获取所有者账号正常的商家组织
+func (s *organizationService) ListActive() ([]*models.Organization, error) {
+	return s.orgRepo.ListActive()
+}
+
+// Get 获取员工所属组织及其所有员工
+func (s *organizationService) Get(user *models.User) (*models.OrganizationResponse, error) {
+	if user.OrgID == 0 {
+		return nil, ErrOrganizationNotFound
+	}
+	org, err := s.orgRepo.GetByID(user.OrgID)
+	if err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+	members, err := s.userRepo.ListByOrg(org.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		member.Password = ""
+	}
+	return &models.OrganizationResponse{Organization: org, Members: members}, nil
+}
+
+// Update 修改组织名称
+func (s *organizationService) Update(actor *AuditActor, req *models.UpdateOrganizationRequest) (*models.Organization, error) {
+	if err := checkOrgOwner(actor.User); err != nil {
+		return nil, err
+	}
+	org, err := s.orgRepo.GetByID(actor.User.OrgID)
+	if err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if err := s.orgRepo.UpdateName(org.ID, name); err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditOrgUpdate, auditTargetOrg, orgTarget(org.ID), map[string]interface{}{
+		"old_name": org.Name,
+		"new_name": name,
+	})
+	org.Name = name
+	return org, nil
+}
+
+// CreateMember 创建员工账号，员工是与所有者同属一个组织的商家账号，首次登录后必须修改密码
+func (s *organizationService) CreateMember(actor *AuditActor, req *models.CreateOrgMemberRequest) (*models.User, error) {
+	if err := checkOrgOwner(actor.User); err != nil {
+		return nil, err
+	}
+	if _, err := s.userRepo.GetByUsername(req.Username, consts.Merchant); err == nil {
+		return nil, ErrUsernameTaken
+	}
+	if err := s.passwords.Validate(req.Password, req.Username); err != nil {
+		return nil, err
+	}
+
+	member := &models.User{
+		Username:           req.Username,
+		Password:           hashPassword(req.Password),
+		Email:              req.Email,
+		DisplayName:        strings.TrimSpace(req.DisplayName),
+		UserType:           consts.Merchant,
+		Status:             consts.AccountActive,
+		MustChangePassword: true,
+		OrgID:              actor.User.OrgID,
+		OrgRole:            req.Role,
+	}
+	if err := s.userRepo.Create(member); err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditOrgMemberCreate, auditTargetUser, userTarget(member), map[string]interface{}{
+		"org_id":   member.OrgID,
+		"username": member.Username,
+		"role":     member.OrgRole,
+	})
+	member.Password = ""
+	return member, nil
+}
+
+// UpdateMember 修改员工角色和技能，立即生效；所有者的角色不能修改，但可以修改自己的技能
+func (s *organizationService) UpdateMember(actor *AuditActor, id uint64, req *models.UpdateOrgMemberRequest) (*models.User, error) {
+	member, err := s.orgMember(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Role != "" && member.OrgRole == consts.OrgRoleOwner {
+		return nil, ErrOrgMemberNotFound
+	}
+
+	fields := map[string]interface{}{}
+	detail := map[string]interface{}{"org_id": member.OrgID}
+	if req.Role != "" && req.Role != member.OrgRole {
+		fields["org_role"] = req.Role
+		detail["old_role"], detail["new_role"] = member.OrgRole, req.Role
+		member.OrgRole = req.Role
+	}
+	if req.Skills != nil {
+		skills := normalizeSkills(*req.Skills)
+		encoded, err := json.Marshal(skills)
+		if err != nil {
+			return nil, err
+		}
+		fields["skills"] = string(encoded)
+		detail["skills"] = skills
+		member.Skills = skills
+	}
+	member.Password = ""
+	if len(fields) == 0 {
+		return member, nil
+	}
+
+	if err := s.userRepo.UpdateFields(member.ID, fields); err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditOrgMemberUpdate, auditTargetUser, userTarget(member), detail)
+	return member, nil
+}
+
+// RemoveMember 删除员工账号，员工处理过的反馈和消息保留
+func (s *organizationService) RemoveMember(actor *AuditActor, id uint64) error {
+	member, err := s.member(actor, id)
+	if err != nil {
+		return err
+	}
+	return s.adminUserService.Delete(actor, member.ID, FeedbackPolicyKeep)
+}
+
+// member 获取当前所有者组织中的其他员工
+func (s *organizationService) member(actor *AuditActor, id uint64) (*models.User, error) {
+	member, err := s.orgMember(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if member.OrgRole == consts.OrgRoleOwner {
+		return nil, ErrOrgMemberNotFound
+	}
+	return member, nil
+}
+
+// orgMember 获取当前所有者组织中的成员，包括所有者自己
+func (s *organizationService) orgMember(actor *AuditActor, id uint64) (*models.User, error) {
+	if err := checkOrgOwner(actor.User); err != nil {
+		return nil, err
+	}
+	member, err := s.userRepo.GetByID(id)
+	if err != nil || member.UserType != consts.Merchant || member.OrgID != actor.User.OrgID {
+		return nil, ErrOrgMemberNotFound
+	}
+	return member, nil
+}
+
+// checkOrgOwner 检查用户是否为组织所有者
+func checkOrgOwner(user *models.User) error {
+	if user == nil || user.UserType != consts.Merchant || user.OrgID == 0 {
+		return ErrOrganizationNotFound
+	}
+	if user.OrgRole != consts.OrgRoleOwner {
+		return ErrNotOrgOwner
+	}
+	return nil
+}
+
+// ownOrganization 新开通的商家自己的组织，组织名称默认为商家名称，与商家账号在同一事务中创建
+func ownOrganization(user *models.User) *models.Organization {
+	return &models.Organization{Name: truncate(user.Name(), 100)}
+}
+
+// CanHandleFeedback 用户能否处理反馈（回复消息、修改状态），商家组织的只读员工不能处理
+func CanHandleFeedback(user *models.User) bool {
+	return user.UserType != consts.Merchant || user.OrgRole != consts.OrgRoleViewer
+}
+
+// orgMembers 获取商家组织的所有员工，用于 WebSocket 通知，查询失败时只记录日志
+func orgMembers(userRepo repository.UserRepository, orgID uint64) []*models.User {
+	members, err := userRepo.ListByOrg(orgID)
+	if err != nil {
+		log.Printf("获取组织 %d 的员工失败: %v", orgID, err)
+	}
+	return members
+}
+
+// orgTarget 审计日志中组织对象的标识
+func orgTarget(id uint64) string {
+	return strconv.FormatUint(id, 10)
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/mailer"
+	"feedback-system/pkg/password"
+	"feedback-system/pkg/ratelimit"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidResetToken 重置令牌无效、已过期或已被使用
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+	// ErrTooManyResetRequests 申请重置密码过于频繁
+	ErrTooManyResetRequests = errors.New("too many password reset requests")
+)
+
+// PasswordResetService 密码重置服务接口
+type PasswordResetService interface {
+	// 申请重置密码，账号存在且有邮箱时发送重置链接
+	Forgot(req *models.ForgotPasswordRequest, ip string) error
+
+	// 使用重置令牌设置新密码
+	Reset(req *models.ResetPasswordRequest) error
+}
+
+// passwordResetService 密码重置服务实现
+type passwordResetService struct {
+	userRepo    repository.UserRepository
+	resetRepo   repository.PasswordResetRepository
+	userService UserService
+	mailer      mailer.Mailer
+	passwords   *password.Policy
+	limiter     *ratelimit.Limiter
+	baseURL     string
+	ttl         time.Duration
+}
+
+// NewPasswordResetService 创建密码重置服务
+// baseURL 为邮件中重置链接的站点地址；limiter 限制同一IP和同一账号的申请频率，为 nil 时不限制
+func NewPasswordResetService(userRepo repository.UserRepository, resetRepo repository.PasswordResetRepository, userService UserService, mailer mailer.Mailer, passwords *password.Policy, limiter *ratelimit.Limiter, baseURL string, ttl time.Duration) PasswordResetService {
+	return &passwordResetService{
+		userRepo:    userRepo,
+		resetRepo:   resetRepo,
+		userService: userService,
+		mailer:      mailer,
+		passwords:   passwords,
+		limiter:     limiter,
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		ttl:         ttl,
+	}
+}
+
+// Forgot 申请重置密码
+// 无论账号是否存在都返回成功，避免通过该接口探测用户名；邮件在后台发送，不会因邮件服务缓慢阻塞请求
+func (s *passwordResetService) Forgot(req *models.ForgotPasswordRequest, ip string) error {
+	if !s.allow("ip:" + ip) {
+		return ErrTooManyResetRequests
+	}
+
+	user, err := s.userRepo.GetByUsername(req.Username, req.UserType)
+	if err != nil {
+		return nil
+	}
+	email := resetEmail(user)
+	if email == "" {
+		log.Printf("用户 %d 未设置邮箱，无法发送重置密码邮件", user.ID)
+		return nil
+	}
+	// 同一账号的申请超出频率时静默忽略，避免向用户邮箱发送大量邮件
+	if !s.allow(fmt.Sprintf("user:%d", user.ID)) {
+		return nil
+	}
+
+	token, err := generateRefreshToken()
+	if err != nil {
+		return err
+	}
+	// 重新申请时之前的令牌全部失效
+	if err := s.resetRepo.DeleteByUser(user.ID); err != nil {
+		return err
+	}
+	if err := s.resetRepo.Create(&models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		IP:        truncate(ip, 45),
+		ExpiresAt: time.Now().Add(s.ttl),
+	}); err != nil {
+		return err
+	}
+
+	link := s.baseURL + "/reset-password.html?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("%s，您好：\n\n我们收到了重置您账号密码的申请。请在 %d 分钟内打开以下链接设置新密码：\n\n%s\n\n如果这不是您本人的操作，请忽略这封邮件，您的密码不会被修改。\n",
+		user.Username, int(s.ttl/time.Minute), link)
+	go func() {
+		if err := s.mailer.Send(email, "重置密码", body); err != nil {
+			log.Printf("发送重置密码邮件失败: user=%d err=%v", user.ID, err)
+		}
+	}()
+	return nil
+}
+
+// Reset 使用重置令牌设置新密码
+// 令牌使用后立即失效，密码修改后用户的所有登录会话被注销
+func (s *passwordResetService) Reset(req *models.ResetPasswordRequest) error {
+	token, err := s.resetRepo.FindByHash(hashToken(req.Token))
+	if err != nil || token.UsedAt != nil || !time.Now().Before(token.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+	user, err := s.userRepo.GetByID(token.UserID)
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+	if err := s.passwords.Validate(req.NewPassword, user.Username); err != nil {
+		return err
+	}
+
+	// 令牌失效和修改密码在同一事务中完成，并发使用同一令牌时只有一个请求能成功
+	used, err := s.resetRepo.ResetPassword(token.ID, user.ID, hashPassword(req.NewPassword))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidResetToken
+	}
+	return s.userService.LogoutAll(user.ID, user.UserType)
+}
+
+// allow 检查申请频率，计数存储不可用时放行
+func (s *passwordResetService) allow(key string) bool {
+	if s.limiter == nil {
+		return true
+	}
+	result, err := s.limiter.Allow(context.Background(), key)
+	if err != nil {
+		log.Printf("重置密码限流计数失败: %v", err)
+		return true
+	}
+	return result.Allowed
+}
+
+// resetEmail 接收重置邮件的邮箱，未设置邮箱时使用邮箱格式的联系方式
+func resetEmail(user *models.User) string {
+	if user.Email != "" {
+		return user.Email
+	}
+	if contact := strings.TrimSpace(user.Contact); strings.Contains(contact, "@") {
+		return contact
+	}
+	return ""
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"errors"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/ws"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	// 内置时区数据库，运行环境没有安装 tzdata 时也能校验时区
+	_ "time/tzdata"
+)
+
+var (
+	// ErrInvalidContact 联系方式不是有效的手机号或邮箱
+	ErrInvalidContact = errors.New("contact must be a phone number or email")
+	// ErrInvalidLanguage 语言代码格式错误
+	ErrInvalidLanguage = errors.New("invalid language tag")
+	// ErrInvalidTimezone 时区不存在
+	ErrInvalidTimezone = errors.New("invalid timezone")
+)
+
+var (
+	// mobilePattern 中国大陆手机号
+	mobilePattern = regexp.MustCompile(`^1[3-9]\d{9}$`)
+	// e164Pattern 国际格式电话号码（E.164）
+	e164Pattern = regexp.MustCompile(`^\+[1-9]\d{6,14}$`)
+	// languagePattern 语言代码（BCP 47 的常用子集），如 zh、zh-CN、en-US
+	languagePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+)
+
+// ProfileService 个人资料服务接口
+type ProfileService interface {
+	// 修改个人资料，只修改请求中出现的字段
+	UpdateProfile(user *models.User, req *models.UpdateProfileRequest) (*models.User, error)
+
+	// 填充头像签名链接
+	SignAvatar(user *models.User)
+}
+
+// profileService 个人资料服务实现
+type profileService struct {
+	userRepo          repository.UserRepository
+	attachmentService AttachmentService
+	wsHandler         *ws.WSHandler
+}
+
+// NewProfileService 创建个人资料服务
+func NewProfileService(userRepo repository.UserRepository, attachmentService AttachmentService, wsHandler *ws.WSHandler) ProfileService {
+	return &profileService{
+		userRepo:          userRepo,
+		attachmentService: attachmentService,
+		wsHandler:         wsHandler,
+	}
+}
+
+// UpdateProfile 修改个人资料
+// 显示名称修改后，已建立的WebSocket连接随即使用新名称，之后的事件中发送者名称均为新名称
+func (s *profileService) UpdateProfile(user *models.User, req *models.UpdateProfileRequest) (*models.User, error) {
+	fields := map[string]interface{}{}
+
+	if req.DisplayName != nil {
+		user.DisplayName = strings.TrimSpace(*req.DisplayName)
+		fields["display_name"] = user.DisplayName
+	}
+	if req.Contact != nil {
+		contact, err := normalizeContact(*req.Contact)
+		if err != nil {
+			return nil, err
+		}
+		user.Contact = contact
+		fields["contact"] = contact
+	}
+	if req.Language != nil {
+		language := strings.TrimSpace(*req.Language)
+		if language != "" && !languagePattern.MatchString(language) {
+			return nil, ErrInvalidLanguage
+		}
+		user.Language = language
+		fields["language"] = language
+	}
+	if req.Timezone != nil {
+		timezone := strings.TrimSpace(*req.Timezone)
+		if timezone != "" {
+			if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+				return nil, ErrInvalidTimezone
+			}
+		}
+		user.Timezone = timezone
+		fields["timezone"] = timezone
+	}
+	if req.AvatarID != nil {
+		if err := s.attachmentService.BindAvatar(user, *req.AvatarID); err != nil {
+			return nil, err
+		}
+		if *req.AvatarID == 0 {
+			user.AvatarID = nil
+		} else {
+			user.AvatarID = req.AvatarID
+		}
+		fields["avatar_id"] = user.AvatarID
+	}
+
+	if len(fields) > 0 {
+		if err := s.userRepo.UpdateFields(user.ID, fields); err != nil {
+			return nil, err
+		}
+	}
+	if req.DisplayName != nil && s.wsHandler != nil {
+		s.wsHandler.RenameUser(user.ID, user.UserType, user.Name())
+	}
+
+	s.SignAvatar(user)
+	return user, nil
+}
+
+// SignAvatar 填充头像签名链接，头像可能出现在其他用户的页面中，因此使用无需令牌的签名链接
+func (s *profileService) SignAvatar(user *models.User) {
+	if user.AvatarID == nil {
+		user.AvatarURL = ""
+		return
+	}
+	user.AvatarURL = s.attachmentService.SignedURL(*user.AvatarID)
+}
+
+// normalizeContact 校验联系方式并去除空格和连字符，只接受手机号或邮箱，空字符串表示清除
+func normalizeContact(contact string) (string, error) {
+	contact = strings.TrimSpace(contact)
+	if contact == "" {
+		return "", nil
+	}
+	if strings.Contains(contact, "@") {
+		addr, err := mail.ParseAddress(contact)
+		if err != nil || addr.Address != contact {
+			return "", ErrInvalidContact
+		}
+		return contact, nil
+	}
+
+	phone := strings.NewReplacer(" ", "", "-", "").Replace(contact)
+	if mobilePattern.MatchString(phone) || e164Pattern.MatchString(phone) {
+		return phone, nil
+	}
+	return "", ErrInvalidContact
+}
@@ -0,0 +1,404 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/calendar"
+	"feedback-system/pkg/ws"
+	"log"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSLAPolicyNotFound SLA 策略不存在
+	ErrSLAPolicyNotFound = errors.New("sla policy not found")
+	// ErrSLAPolicyExists 该目标已有 SLA 策略
+	ErrSLAPolicyExists = errors.New("sla policy for this target already exists")
+	// ErrInvalidSLATarget SLA 策略的目标无效：管理员团队的 TargetID 必须为 0，商家的 TargetID 必须为 0 或已存在的商家组织
+	ErrInvalidSLATarget = errors.New("invalid sla policy target")
+)
+
+// auditTargetSLAPolicy 审计日志中 SLA 策略对象的类型
+const auditTargetSLAPolicy = "sla_policy"
+
+// SLAService SLA 服务接口
+// 反馈创建时按目标方适用的策略（商家组织的策略优先，其次为所有商家的默认策略；管理员团队只有一个策略）计算首次回复和解决的截止时间
+// 截止时间按目标方团队的工作日历计算，营业时间以外和节假日不计时
+// 目标方回复后进入等待客户回复状态，解决计时暂停，客户回复后按剩余时间顺延；已解决期间同样暂停
+type SLAService interface {
+	// 反馈生命周期中更新 SLA 计时
+	Apply(feedback *models.Feedback)
+	OnMessage(feedback *models.Feedback, message *models.FeedbackMessage)
+	OnStatusChange(feedback *models.Feedback, newStatus uint8)
+
+	// 检查即将超时和已超时的反馈并发送通知，返回发送的通知数量
+	Check() (int, error)
+
+	// 设置超时处理函数，每个时限超时时调用一次，用于触发自动化规则
+	SetBreachHandler(handler func(feedback *models.Feedback, kind string))
+
+	// SLA 策略管理
+	ListPolicies() ([]*models.SLAPolicy, error)
+	CreatePolicy(actor *AuditActor, req *models.SLAPolicyRequest) (*models.SLAPolicy, error)
+	UpdatePolicy(actor *AuditActor, id uint64, req *models.SLAPolicyRequest) (*models.SLAPolicy, error)
+	DeletePolicy(actor *AuditActor, id uint64) error
+}
+
+// slaService SLA 服务实现
+type slaService struct {
+	policyRepo   repository.SLAPolicyRepository
+	feedbackRepo repository.FeedbackRepository
+	eventRepo    repository.FeedbackEventRepository
+	orgRepo      repository.OrganizationRepository
+	userRepo     repository.UserRepository
+	auditRepo    repository.AuditLogRepository
+	wsHandler    *ws.WSHandler
+
+	calendarService CalendarService
+	breachHandler   func(feedback *models.Feedback, kind string)
+	now             func() time.Time
+}
+
+// NewSLAService 创建 SLA 服务
+func NewSLAService(policyRepo repository.SLAPolicyRepository, feedbackRepo repository.FeedbackRepository, eventRepo repository.FeedbackEventRepository, orgRepo repository.OrganizationRepository, userRepo repository.UserRepository, auditRepo repository.AuditLogRepository, wsHandler *ws.WSHandler, calendarService CalendarService) SLAService {
+	return &slaService{
+		policyRepo:   policyRepo,
+		feedbackRepo: feedbackRepo,
+		eventRepo:    eventRepo,
+		orgRepo:      orgRepo,
+		userRepo:     userRepo,
+		auditRepo:    auditRepo,
+		wsHandler:    wsHandler,
+
+		calendarService: calendarService,
+		now:             time.Now,
+	}
+}
+
+// Apply 为即将创建的反馈计算截止时间，没有适用的策略时不设置；请求中提交的 SLA 字段一律忽略
+func (s *slaService) Apply(feedback *models.Feedback) {
+	feedback.SLAPolicyID, feedback.FirstResponseDue, feedback.FirstRespondedAt, feedback.ResolutionDue, feedback.SLAPausedAt = 0, nil, nil, nil, nil
+	feedback.FirstResponseWarned, feedback.FirstResponseBreached, feedback.ResolutionWarned, feedback.ResolutionBreached = false, false, false, false
+
+	policy := s.policyFor(feedback)
+	if policy == nil {
+		return
+	}
+
+	start := feedback.CreatedAt
+	if start.IsZero() {
+		start = s.now()
+	}
+	feedback.SLAPolicyID = policy.ID
+	if policy.FirstResponseMinutes > 0 {
+		due := s.deadline(feedback, start, time.Duration(policy.FirstResponseMinutes)*time.Minute)
+		feedback.FirstResponseDue = &due
+	}
+	if policy.ResolutionMinutes > 0 {
+		due := s.deadline(feedback, start, time.Duration(policy.ResolutionMinutes)*time.Minute)
+		feedback.ResolutionDue = &due
+	}
+}
+
+// OnMessage 新消息保存后更新计时：目标方回复时记录首次回复并暂停解决计时，反馈创建者回复时恢复计时
+func (s *slaService) OnMessage(feedback *models.Feedback, message *models.FeedbackMessage) {
+	if feedback.SLAPolicyID == 0 {
+		return
+	}
+
+	now := s.now()
+	fields := map[string]interface{}{}
+	switch {
+	case message.SenderType == targetUserType(feedback.TargetType):
+		if feedback.FirstRespondedAt == nil {
+			fields["first_responded_at"] = now
+			feedback.FirstRespondedAt = &now
+		}
+		if feedback.ResolutionDue != nil && feedback.SLAPausedAt == nil {
+			fields["sla_paused_at"] = now
+			feedback.SLAPausedAt = &now
+		}
+	case message.SenderID == feedback.CreatorID && message.SenderType == feedback.CreatorType:
+		s.resume(feedback, now, fields)
+	}
+	s.update(feedback, fields)
+}
+
+// OnStatusChange 修改状态后更新计时：解决或关闭时暂停解决计时，重新打开时恢复
+func (s *slaService) OnStatusChange(feedback *models.Feedback, newStatus uint8) {
+	if feedback.SLAPolicyID == 0 || feedback.Status == newStatus {
+		return
+	}
+
+	now := s.now()
+	fields := map[string]interface{}{}
+	switch {
+	case newStatus == consts.Resolved || newStatus == consts.Closed:
+		if feedback.ResolutionDue != nil && feedback.SLAPausedAt == nil {
+			fields["sla_paused_at"] = now
+			feedback.SLAPausedAt = &now
+		}
+	case feedback.Status == consts.Resolved:
+		s.resume(feedback, now, fields)
+	}
+	s.update(feedback, fields)
+}
+
+// resume 恢复暂停的解决计时，按暂停时剩余的营业时长从现在起重新计算截止时间
+func (s *slaService) resume(feedback *models.Feedback, now time.Time, fields map[string]interface{}) {
+	if feedback.SLAPausedAt == nil || feedback.ResolutionDue == nil {
+		return
+	}
+	cal := s.calendar(feedback)
+	remaining := cal.Between(*feedback.SLAPausedAt, *feedback.ResolutionDue)
+	due := cal.Add(now, remaining)
+	fields["resolution_due"] = due
+	fields["sla_paused_at"] = nil
+	feedback.ResolutionDue, feedback.SLAPausedAt = &due, nil
+}
+
+// update 保存计时字段，失败时只记录日志
+func (s *slaService) update(feedback *models.Feedback, fields map[string]interface{}) {
+	if len(fields) == 0 {
+		return
+	}
+	if err := s.feedbackRepo.UpdateFields(feedback.ID, fields); err != nil {
+		log.Printf("更新反馈 %d 的 SLA 计时失败: %v", feedback.ID, err)
+	}
+}
+
+// deadline 计算从 start 开始经过 d 的营业时长之后的截止时间
+func (s *slaService) deadline(feedback *models.Feedback, start time.Time, d time.Duration) time.Time {
+	return s.calendar(feedback).Add(start, d)
+}
+
+// calendar 反馈目标方的工作日历，没有时返回 nil（全天营业）
+func (s *slaService) calendar(feedback *models.Feedback) *calendar.Calendar {
+	if s.calendarService == nil {
+		return nil
+	}
+	return s.calendarService.ForFeedback(feedback)
+}
+
+// policyFor 获取反馈适用的策略，没有时返回 nil
+func (s *slaService) policyFor(feedback *models.Feedback) *models.SLAPolicy {
+	targets := []uint64{0}
+	if feedback.TargetType == consts.TargetMerchant {
+		targets = []uint64{feedback.TargetID, 0}
+	}
+	for _, targetID := range targets {
+		policy, err := s.policyRepo.GetByTarget(feedback.TargetType, targetID)
+		if err == nil {
+			return policy
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("获取 SLA 策略失败: %v", err)
+			return nil
+		}
+	}
+	return nil
+}
+
+// Check 检查仍在计时的反馈，到达预警时间时发送 sla_warning 事件，超过截止时间时发送 sla_breached 事件并写入处理记录
+// 每个时限的预警和超时各只通知一次
+func (s *slaService) Check() (int, error) {
+	feedbacks, err := s.feedbackRepo.FindSLAActive()
+	if err != nil {
+		return 0, err
+	}
+
+	now := s.now()
+	policies := map[uint64]*models.SLAPolicy{}
+	sent := 0
+	for _, feedback := range feedbacks {
+		policy, ok := policies[feedback.SLAPolicyID]
+		if !ok {
+			// 策略已删除时仍检查超时，但不再预警
+			policy, _ = s.policyRepo.GetByID(feedback.SLAPolicyID)
+			policies[feedback.SLAPolicyID] = policy
+		}
+		var warning time.Duration
+		if policy != nil {
+			warning = time.Duration(policy.WarningMinutes) * time.Minute
+		}
+
+		if feedback.FirstResponseDue != nil && feedback.FirstRespondedAt == nil && !feedback.FirstResponseBreached {
+			sent += s.checkDeadline(feedback, consts.SLAFirstResponse, *feedback.FirstResponseDue, feedback.FirstResponseWarned, warning, now)
+		}
+		if feedback.ResolutionDue != nil && feedback.SLAPausedAt == nil && !feedback.ResolutionBreached {
+			sent += s.checkDeadline(feedback, consts.SLAResolution, *feedback.ResolutionDue, feedback.ResolutionWarned, warning, now)
+		}
+	}
+	return sent, nil
+}
+
+// checkDeadline 检查一个时限，kind 同时是标记字段的前缀（first_response_warned、resolution_breached 等），返回发送的通知数量
+func (s *slaService) checkDeadline(feedback *models.Feedback, kind string, due time.Time, warned bool, warning time.Duration, now time.Time) int {
+	event, column := "", ""
+	switch {
+	case !now.Before(due):
+		event, column = consts.EventSLABreached, kind+"_breached"
+	case !warned && warning > 0 && !now.Before(due.Add(-warning)):
+		event, column = consts.EventSLAWarning, kind+"_warned"
+	default:
+		return 0
+	}
+
+	ok, err := s.feedbackRepo.MarkSLAFlag(feedback.ID, column)
+	if err != nil {
+		log.Printf("标记反馈 %d 的 SLA 状态失败: %v", feedback.ID, err)
+		return 0
+	}
+	if !ok {
+		return 0
+	}
+	if event == consts.EventSLABreached {
+		recordFeedbackEvent(s.eventRepo, feedback.ID, nil, consts.FeedbackEventSLABreach, map[string]interface{}{
+			"kind":   kind,
+			"due_at": due,
+		})
+	}
+	s.notify(feedback, event, kind, due)
+	if event == consts.EventSLABreached && s.breachHandler != nil {
+		s.breachHandler(feedback, kind)
+	}
+	return 1
+}
+
+// SetBreachHandler 设置超时处理函数；SLA 服务创建时自动化规则服务尚未创建，因此在创建后设置
+func (s *slaService) SetBreachHandler(handler func(feedback *models.Feedback, kind string)) {
+	s.breachHandler = handler
+}
+
+// notify 发送 SLA 事件给反馈的目标方，已升级的反馈同时发送给所有管理员
+func (s *slaService) notify(feedback *models.Feedback, event, kind string, due time.Time) {
+	if s.wsHandler == nil {
+		return
+	}
+
+	message := models.WSMessage{
+		Event:     event,
+		Timestamp: s.now(),
+		Data: &models.SLAEventData{
+			FeedbackID: feedback.ID,
+			Title:      feedback.Title,
+			Kind:       kind,
+			DueAt:      due,
+		},
+	}
+	jsonMessage, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+
+	sendToTarget(s.wsHandler, s.userRepo, feedback, jsonMessage, 0)
+	if feedback.TargetType == consts.TargetMerchant && feedback.EscalatedAt != nil {
+		sendToAdmins(s.wsHandler, s.userRepo, jsonMessage, 0)
+	}
+}
+
+// ListPolicies 获取所有 SLA 策略
+func (s *slaService) ListPolicies() ([]*models.SLAPolicy, error) {
+	return s.policyRepo.List()
+}
+
+// CreatePolicy 创建 SLA 策略，只影响之后创建的反馈
+func (s *slaService) CreatePolicy(actor *AuditActor, req *models.SLAPolicyRequest) (*models.SLAPolicy, error) {
+	if err := s.checkTarget(req, 0); err != nil {
+		return nil, err
+	}
+
+	policy := &models.SLAPolicy{}
+	applySLAPolicyRequest(policy, req)
+	if err := s.policyRepo.Create(policy); err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditSLAPolicyCreate, auditTargetSLAPolicy, strconv.FormatUint(policy.ID, 10), slaPolicyDetail(policy))
+	return policy, nil
+}
+
+// UpdatePolicy 修改 SLA 策略，已创建的反馈的截止时间不变
+func (s *slaService) UpdatePolicy(actor *AuditActor, id uint64, req *models.SLAPolicyRequest) (*models.SLAPolicy, error) {
+	policy, err := s.policyRepo.GetByID(id)
+	if err != nil {
+		return nil, ErrSLAPolicyNotFound
+	}
+	if err := s.checkTarget(req, id); err != nil {
+		return nil, err
+	}
+
+	applySLAPolicyRequest(policy, req)
+	if err := s.policyRepo.Update(policy); err != nil {
+		return nil, err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditSLAPolicyUpdate, auditTargetSLAPolicy, strconv.FormatUint(policy.ID, 10), slaPolicyDetail(policy))
+	return policy, nil
+}
+
+// DeletePolicy 删除 SLA 策略，已创建的反馈仍按原截止时间检查超时
+func (s *slaService) DeletePolicy(actor *AuditActor, id uint64) error {
+	policy, err := s.policyRepo.GetByID(id)
+	if err != nil {
+		return ErrSLAPolicyNotFound
+	}
+	if err := s.policyRepo.Delete(id); err != nil {
+		return err
+	}
+	writeAudit(s.auditRepo, actor, consts.AuditSLAPolicyDelete, auditTargetSLAPolicy, strconv.FormatUint(policy.ID, 10), slaPolicyDetail(policy))
+	return nil
+}
+
+// checkTarget 检查策略目标是否有效，且没有其他策略（ID 不为 exceptID）使用同一目标
+func (s *slaService) checkTarget(req *models.SLAPolicyRequest, exceptID uint64) error {
+	switch req.TargetType {
+	case consts.TargetAdmin:
+		if req.TargetID != 0 {
+			return ErrInvalidSLATarget
+		}
+	case consts.TargetMerchant:
+		if req.TargetID != 0 {
+			if _, err := s.orgRepo.GetByID(req.TargetID); err != nil {
+				return ErrInvalidSLATarget
+			}
+		}
+	default:
+		return ErrInvalidSLATarget
+	}
+
+	existing, err := s.policyRepo.GetByTarget(req.TargetType, req.TargetID)
+	if err == nil && existing.ID != exceptID {
+		return ErrSLAPolicyExists
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// applySLAPolicyRequest 将请求中的字段写入策略
+func applySLAPolicyRequest(policy *models.SLAPolicy, req *models.SLAPolicyRequest) {
+	policy.Name = req.Name
+	policy.TargetType = req.TargetType
+	policy.TargetID = req.TargetID
+	policy.FirstResponseMinutes = req.FirstResponseMinutes
+	policy.ResolutionMinutes = req.ResolutionMinutes
+	policy.WarningMinutes = req.WarningMinutes
+}
+
+// slaPolicyDetail 审计日志中记录的策略内容
+func slaPolicyDetail(policy *models.SLAPolicy) map[string]interface{} {
+	return map[string]interface{}{
+		"name":                   policy.Name,
+		"target_type":            policy.TargetType,
+		"target_id":              policy.TargetID,
+		"first_response_minutes": policy.FirstResponseMinutes,
+		"resolution_minutes":     policy.ResolutionMinutes,
+		"warning_minutes":        policy.WarningMinutes,
+	}
+}
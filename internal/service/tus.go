@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/imaging"
+	"feedback-system/pkg/storage"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUploadNotFound 上传会话不存在或不属于当前用户
+	ErrUploadNotFound = errors.New("upload not found")
+	// ErrUploadTooLarge 文件大小超过当前用户类型的上限
+	ErrUploadTooLarge = errors.New("upload exceeds maximum size")
+	// ErrUploadOffsetMismatch 续传偏移量与服务端记录不一致
+	ErrUploadOffsetMismatch = errors.New("upload offset mismatch")
+	// ErrUploadCompleted 上传已完成，不能继续写入
+	ErrUploadCompleted = errors.New("upload already completed")
+)
+
+// TusService 断点续传上传服务接口（tus 1.0.0 协议）
+type TusService interface {
+	// 创建上传会话
+	Create(owner *models.User, length int64, filename, metadata string) (*models.UploadSession, error)
+
+	// 获取上传会话
+	Get(id string, owner *models.User) (*models.UploadSession, error)
+
+	// 从指定偏移量续传数据，全部数据接收完成后转交附件存储
+	Append(ctx context.Context, id string, owner *models.User, offset int64, r io.Reader) (*models.UploadSession, error)
+
+	// 终止上传并删除已接收的数据
+	Terminate(id string, owner *models.User) error
+
+	// 当前用户类型的单文件大小上限
+	MaxSize(userType uint8) int64
+
+	// 清理过期的上传会话
+	CleanupExpired() (int, error)
+}
+
+// tusService 断点续传上传服务实现
+// 每次续传的数据先写入本地临时文件，再作为一个分片写入存储驱动，
+// 分片列表和偏移量记录在数据库中并按偏移量做条件更新，因此不依赖本地磁盘和进程内锁，支持多副本部署
+type tusService struct {
+	sessionRepo       repository.UploadSessionRepository
+	attachmentService AttachmentService
+	storage           storage.Storage
+	dir               string
+	expiry            time.Duration
+	maxSize           map[uint8]int64
+}
+
+// NewTusService 创建断点续传上传服务
+// dir 为单次请求接收数据时使用的本地临时目录，请求结束后即删除
+func NewTusService(sessionRepo repository.UploadSessionRepository, attachmentService AttachmentService, fileStorage storage.Storage, dir string, expiry time.Duration, maxSize map[uint8]int64) (TusService, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &tusService{
+		sessionRepo:       sessionRepo,
+		attachmentService: attachmentService,
+		storage:           fileStorage,
+		dir:               dir,
+		expiry:            expiry,
+		maxSize:           maxSize,
+	}, nil
+}
+
+// Create 创建上传会话
+func (s *tusService) Create(owner *models.User, length int64, filename, metadata string) (*models.UploadSession, error) {
+	if length <= 0 || length > s.MaxSize(owner.UserType) {
+		return nil, ErrUploadTooLarge
+	}
+	// 提前检查存储配额，避免上传完成后才发现空间不足
+	if err := s.attachmentService.CheckQuota(owner, length); err != nil {
+		return nil, err
+	}
+
+	session := &models.UploadSession{
+		ID:        uuid.New().String(),
+		OwnerID:   owner.ID,
+		OwnerType: owner.UserType,
+		Length:    length,
+		Filename:  filename,
+		Metadata:  metadata,
+		ExpiresAt: time.Now().Add(s.expiry),
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Get 获取上传会话
+func (s *tusService) Get(id string, owner *models.User) (*models.UploadSession, error) {
+	session, err := s.sessionRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUploadNotFound
+		}
+		return nil, err
+	}
+	if session.OwnerID != owner.ID || session.OwnerType != owner.UserType {
+		return nil, ErrUploadNotFound
+	}
+	return session, nil
+}
+
+// Append 续传数据
+// 连接中断时已接收的数据同样保存为分片并计入偏移量，客户端可通过 HEAD 请求获取偏移量后继续上传
+func (s *tusService) Append(ctx context.Context, id string, owner *models.User, offset int64, r io.Reader) (*models.UploadSession, error) {
+	session, err := s.Get(id, owner)
+	if err != nil {
+		return nil, err
+	}
+	if session.CompletedAt != nil {
+		return nil, ErrUploadCompleted
+	}
+	if session.Offset != offset {
+		return nil, ErrUploadOffsetMismatch
+	}
+
+	tmp, err := os.CreateTemp(s.dir, id+"-*.part")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	remaining := session.Length - offset
+	n, copyErr := io.Copy(tmp, io.LimitReader(r, remaining))
+
+	// 数据超出声明的文件大小时拒绝本次写入
+	if copyErr == nil && n == remaining {
+		var extra [1]byte
+		if m, _ := r.Read(extra[:]); m > 0 {
+			return nil, ErrUploadTooLarge
+		}
+	}
+
+	if n > 0 {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		// 客户端断开时请求上下文已取消，已接收的数据仍需保存
+		putCtx := context.WithoutCancel(ctx)
+		// 分片键带随机后缀，并发续传时失败的一方不会覆盖成功一方的分片
+		part := fmt.Sprintf("tus/%s/%020d-%s", id, offset, uuid.New().String())
+		if err := s.storage.Put(putCtx, part, tmp, n, "application/octet-stream"); err != nil {
+			return nil, err
+		}
+
+		expiresAt := time.Now().Add(s.expiry)
+		ok, err := s.sessionRepo.UpdateOffset(id, offset, offset+n, part, expiresAt)
+		if err != nil || !ok {
+			s.storage.Delete(putCtx, part)
+			if err != nil {
+				return nil, err
+			}
+			return nil, ErrUploadOffsetMismatch
+		}
+		session.Offset = offset + n
+		session.Parts += part + "\n"
+		session.ExpiresAt = expiresAt
+	}
+	if copyErr != nil {
+		return session, copyErr
+	}
+
+	if session.Offset == session.Length {
+		if err := s.complete(ctx, session, owner); err != nil {
+			return session, err
+		}
+	}
+	return session, nil
+}
+
+// complete 上传完成后按顺序合并分片转交附件存储
+func (s *tusService) complete(ctx context.Context, session *models.UploadSession, owner *models.User) error {
+	r := &partsReader{ctx: ctx, storage: s.storage, keys: session.PartKeys()}
+	defer r.Close()
+
+	attachment, err := s.attachmentService.UploadFile(ctx, owner, session.Filename, session.Length, r)
+	if err != nil {
+		// 文件内容校验失败时无法通过重试恢复，直接删除上传会话释放存储空间
+		if errors.Is(err, ErrFileTypeNotAllowed) || errors.Is(err, ErrQuotaExceeded) || errors.Is(err, imaging.ErrUnsupportedFormat) || errors.Is(err, imaging.ErrTooLarge) {
+			s.sessionRepo.Delete(session.ID)
+			s.deleteParts(session)
+		}
+		return err
+	}
+	if err := s.sessionRepo.Complete(session.ID, attachment.ID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	session.AttachmentID = &attachment.ID
+	session.CompletedAt = &now
+	s.deleteParts(session)
+	return nil
+}
+
+// Terminate 终止上传
+func (s *tusService) Terminate(id string, owner *models.User) error {
+	session, err := s.Get(id, owner)
+	if err != nil {
+		return err
+	}
+	if err := s.sessionRepo.Delete(id); err != nil {
+		return err
+	}
+	return s.deleteParts(session)
+}
+
+// MaxSize 当前用户类型的单文件大小上限
+func (s *tusService) MaxSize(userType uint8) int64 {
+	return s.maxSize[userType]
+}
+
+// CleanupExpired 清理过期的上传会话
+// 未完成的会话删除分片和记录；已完成的会话只删除记录，附件本身不受影响
+func (s *tusService) CleanupExpired() (int, error) {
+	total := 0
+	for {
+		sessions, err := s.sessionRepo.FindExpired(time.Now(), 100)
+		if err != nil {
+			return total, err
+		}
+		if len(sessions) == 0 {
+			return total, nil
+		}
+		for _, session := range sessions {
+			if err := s.sessionRepo.Delete(session.ID); err != nil {
+				return total, err
+			}
+			s.deleteParts(session)
+			total++
+		}
+	}
+}
+
+// deleteParts 删除上传会话的全部分片
+func (s *tusService) deleteParts(session *models.UploadSession) error {
+	var firstErr error
+	for _, key := range session.PartKeys() {
+		if err := s.storage.Delete(context.Background(), key); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// partsReader 按顺序依次读取存储中的分片，同一时间只打开一个分片
+type partsReader struct {
+	ctx     context.Context
+	storage storage.Storage
+	keys    []string
+	current io.ReadCloser
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.keys) == 0 {
+				return 0, io.EOF
+			}
+			rc, _, err := r.storage.Get(r.ctx, r.keys[0])
+			if err != nil {
+				return 0, err
+			}
+			r.current = rc
+			r.keys = r.keys[1:]
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *partsReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
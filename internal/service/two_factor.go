@@ -0,0 +1,378 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/pkg/ratelimit"
+	"feedback-system/pkg/totp"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/skip2/go-qrcode"
+)
+
+var (
+	// ErrInvalidMFAToken 两步验证临时令牌无效或已过期
+	ErrInvalidMFAToken = errors.New("invalid mfa token")
+	// ErrInvalidTwoFactorCode 两步验证码或恢复码错误
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	// ErrTwoFactorNotAllowed 当前用户类型不支持两步验证
+	ErrTwoFactorNotAllowed = errors.New("two-factor authentication is not available for this user type")
+	// ErrTwoFactorEnabled 已启用两步验证
+	ErrTwoFactorEnabled = errors.New("two-factor authentication already enabled")
+	// ErrTwoFactorNotEnabled 未启用两步验证
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication not enabled")
+	// ErrTwoFactorNotSetup 尚未生成绑定密钥
+	ErrTwoFactorNotSetup = errors.New("two-factor authentication not set up")
+	// ErrTwoFactorMandatory 当前用户类型强制两步验证，不能关闭
+	ErrTwoFactorMandatory = errors.New("two-factor authentication is mandatory")
+)
+
+const (
+	// mfaTokenTTL 两步验证临时令牌有效期
+	mfaTokenTTL = 5 * time.Minute
+	// mfaTokenKeyPrefix 已签发的两步验证临时令牌在计数存储中的键前缀
+	mfaTokenKeyPrefix = "mfa-token:"
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+	// loginFailTwoFactor 两步验证码错误，记录在审计日志中
+	loginFailTwoFactor = "bad_two_factor_code"
+)
+
+// TwoFactorPolicy 两步验证策略
+type TwoFactorPolicy struct {
+	// 验证器应用中显示的发行方名称
+	Issuer string
+	// 强制启用两步验证的用户类型，未绑定的账号登录时需要先完成绑定
+	Required map[uint8]bool
+}
+
+// twoFactorAllowed 两步验证只对商家和管理员开放
+func twoFactorAllowed(userType uint8) bool {
+	return userType == consts.Merchant || userType == consts.Admin
+}
+
+// SetupTwoFactor 为已登录用户生成待确认的TOTP密钥，调用 EnableTwoFactor 确认后生效
+func (s *userService) SetupTwoFactor(user *models.User) (*models.TwoFactorSetupResponse, error) {
+	if !twoFactorAllowed(user.UserType) {
+		return nil, ErrTwoFactorNotAllowed
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	return s.setupTwoFactor(user)
+}
+
+// EnableTwoFactor 校验验证码后启用两步验证，返回新生成的恢复码
+func (s *userService) EnableTwoFactor(user *models.User, code string) ([]string, error) {
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotSetup
+	}
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	if err := s.userRepo.UpdateTwoFactor(user.ID, true, user.TOTPSecret); err != nil {
+		return nil, err
+	}
+	if _, err := s.userRepo.UseTOTPStep(user.ID, step); err != nil {
+		return nil, err
+	}
+	user.TwoFactorEnabled = true
+	return s.generateRecoveryCodes(user.ID)
+}
+
+// DisableTwoFactor 校验密码和验证码后关闭两步验证
+func (s *userService) DisableTwoFactor(user *models.User, password, code string) error {
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if s.twoFactor.Required[user.UserType] {
+		return ErrTwoFactorMandatory
+	}
+	if user.Password != hashPassword(password) {
+		return ErrInvalidCredentials
+	}
+	if err := s.verifySecondFactor(user, code); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdateTwoFactor(user.ID, false, ""); err != nil {
+		return err
+	}
+	user.TwoFactorEnabled = false
+	return s.recoveryRepo.DeleteByUser(user.ID)
+}
+
+// RegenerateRecoveryCodes 校验验证码后重新生成恢复码，原有恢复码全部失效
+func (s *userService) RegenerateRecoveryCodes(user *models.User, code string) ([]string, error) {
+	if !user.TwoFactorEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := s.verifySecondFactor(user, code); err != nil {
+		return nil, err
+	}
+	return s.generateRecoveryCodes(user.ID)
+}
+
+// SetupTwoFactorForLogin 强制两步验证的账号首次登录时，凭临时令牌生成待确认的TOTP密钥
+// 临时令牌在此步骤不失效，之后仍用于 LoginTwoFactor 确认绑定
+func (s *userService) SetupTwoFactorForLogin(mfaToken string) (*models.TwoFactorSetupResponse, error) {
+	user, _, err := s.parseMFAToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled || !s.twoFactor.Required[user.UserType] {
+		return nil, ErrInvalidMFAToken
+	}
+	return s.setupTwoFactor(user)
+}
+
+// LoginTwoFactor 两步验证登录的第二步：校验验证码或恢复码后创建会话
+// 强制两步验证且尚未绑定的账号，在此步骤确认绑定，响应中返回恢复码
+// 验证码错误时临时令牌仍然有效（失败次数由 loginGuard 限制），登录成功后令牌失效
+func (s *userService) LoginTwoFactor(req *models.TwoFactorLoginRequest, ip, userAgent string) (*models.UserLoginResponse, error) {
+	user, jti, err := s.parseMFAToken(req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	attempt := &loginAttempt{
+		username:  user.Username,
+		userType:  user.UserType,
+		ip:        ip,
+		userAgent: userAgent,
+	}
+	if err := s.loginGuard.Check(ctx, attempt); err != nil {
+		return nil, err
+	}
+
+	response := &models.UserLoginResponse{}
+	if user.TwoFactorEnabled {
+		err = s.verifySecondFactor(user, req.Code)
+	} else if s.twoFactor.Required[user.UserType] {
+		response.RecoveryCodes, err = s.EnableTwoFactor(user, req.Code)
+	} else {
+		return nil, ErrInvalidMFAToken
+	}
+	if err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			s.loginGuard.Fail(ctx, attempt, loginFailTwoFactor)
+		}
+		return nil, err
+	}
+
+	// 并发使用同一临时令牌时只有一个请求能创建会话
+	consumed, err := consumeOneTimeToken(ctx, s.tokenStore, mfaTokenKeyPrefix+jti, mfaTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidMFAToken
+	}
+	s.loginGuard.Succeed(ctx, attempt)
+
+	tokens, err := s.createSession(user, s.deviceName(req.DeviceName, userAgent), truncate(ip, 45), truncate(userAgent, 255))
+	if err != nil {
+		return nil, err
+	}
+	response.User = *user
+	response.Token = tokens.Token
+	response.RefreshToken = tokens.RefreshToken
+	response.ExpiresIn = tokens.ExpiresIn
+	return response, nil
+}
+
+// twoFactorChallenge 密码校验通过后，需要两步验证时返回临时令牌代替访问令牌
+func (s *userService) twoFactorChallenge(user *models.User) (*models.UserLoginResponse, bool, error) {
+	required := s.twoFactor.Required[user.UserType]
+	if !user.TwoFactorEnabled && !required {
+		return nil, false, nil
+	}
+
+	token, err := s.generateMFAToken(user)
+	if err != nil {
+		return nil, true, err
+	}
+	return &models.UserLoginResponse{
+		User:                   *user,
+		TwoFactorRequired:      true,
+		TwoFactorSetupRequired: !user.TwoFactorEnabled,
+		MFAToken:               token,
+	}, true, nil
+}
+
+// setupTwoFactor 生成并保存待确认的TOTP密钥
+func (s *userService) setupTwoFactor(user *models.User) (*models.TwoFactorSetupResponse, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateTwoFactor(user.ID, false, secret); err != nil {
+		return nil, err
+	}
+	user.TOTPSecret = secret
+
+	uri := totp.URI(s.twoFactor.Issuer, user.Username, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TwoFactorSetupResponse{
+		Secret:     secret,
+		OtpauthURL: uri,
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// verifySecondFactor 校验TOTP验证码或恢复码
+// 同一时间步的验证码只能使用一次；恢复码使用后立即失效
+func (s *userService) verifySecondFactor(user *models.User, code string) error {
+	if step, ok := totp.Validate(user.TOTPSecret, code, time.Now()); ok {
+		used, err := s.userRepo.UseTOTPStep(user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := s.recoveryRepo.Use(user.ID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// generateRecoveryCodes 生成恢复码，数据库中只保存哈希值
+func (s *userService) generateRecoveryCodes(userID uint64) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+	if err := s.recoveryRepo.Replace(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateMFAToken 生成两步验证临时令牌，只能用于两步验证登录接口
+// 只在密码或单点登录校验通过后签发，令牌的 jti 登记在计数存储中，未登记的令牌即使签名正确也无效
+func (s *userService) generateMFAToken(user *models.User) (string, error) {
+	jti, err := generateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	if err := issueOneTimeToken(context.Background(), s.tokenStore, mfaTokenKeyPrefix+jti, mfaTokenTTL); err != nil {
+		return "", err
+	}
+	claims := jwt.MapClaims{
+		"id":  user.ID,
+		"typ": "mfa",
+		"jti": jti,
+		"exp": time.Now().Add(mfaTokenTTL).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.jwtSecret)
+}
+
+// parseMFAToken 解析两步验证临时令牌并获取用户和令牌的 jti
+// 令牌未登记、已过期或已使用时返回 ErrInvalidMFAToken
+func (s *userService) parseMFAToken(tokenString string) (*models.User, string, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return s.jwtSecret, nil
+	})
+	if err != nil {
+		return nil, "", ErrInvalidMFAToken
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claims["typ"] != "mfa" {
+		return nil, "", ErrInvalidMFAToken
+	}
+	userID, ok := claims["id"].(float64)
+	jti, _ := claims["jti"].(string)
+	if !ok || jti == "" {
+		return nil, "", ErrInvalidMFAToken
+	}
+	issued, err := oneTimeTokenIssued(context.Background(), s.tokenStore, mfaTokenKeyPrefix+jti)
+	if err != nil {
+		return nil, "", err
+	}
+	if !issued {
+		return nil, "", ErrInvalidMFAToken
+	}
+	user, err := s.userRepo.GetByID(uint64(userID))
+	if err != nil || checkAccountStatus(user) != nil {
+		return nil, "", ErrInvalidMFAToken
+	}
+	return user, jti, nil
+}
+
+// 一次性令牌在计数存储中的状态：签发时计数为 1，使用时加一，只有计数从 1 变为 2 的请求使用成功；
+// 未签发的令牌加一后为 1，已使用的令牌加一后大于 2，都视为无效
+
+// issueOneTimeToken 登记新签发的一次性令牌
+func issueOneTimeToken(ctx context.Context, store ratelimit.Store, key string, ttl time.Duration) error {
+	return store.Set(ctx, key, 1, ttl)
+}
+
+// oneTimeTokenIssued 一次性令牌是否已签发且尚未使用
+func oneTimeTokenIssued(ctx context.Context, store ratelimit.Store, key string) (bool, error) {
+	count, _, err := store.Get(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	return count == 1, nil
+}
+
+// consumeOneTimeToken 使用一次性令牌，令牌未签发、已过期或已使用时返回 false
+func consumeOneTimeToken(ctx context.Context, store ratelimit.Store, key string, ttl time.Duration) (bool, error) {
+	count, _, err := store.Incr(ctx, key, ttl)
+	if err != nil {
+		return false, err
+	}
+	return count == 2, nil
+}
+
+// generateRecoveryCode 生成恢复码，格式为 xxxxx-xxxxx（小写字母和数字）
+func generateRecoveryCode() (string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i := range buf {
+		buf[i] = alphabet[int(buf[i])%len(alphabet)]
+	}
+	return string(buf[:5]) + "-" + string(buf[5:]), nil
+}
+
+// normalizeRecoveryCode 统一恢复码格式，忽略大小写、空格和连字符
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
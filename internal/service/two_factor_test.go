@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/ratelimit"
+	"feedback-system/pkg/totp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// fakeMFAUserRepo 只实现 GetByID 和 UseTOTPStep
+type fakeMFAUserRepo struct {
+	repository.UserRepository
+	user     *models.User
+	lastStep int64
+}
+
+func (r *fakeMFAUserRepo) GetByID(id uint64) (*models.User, error) {
+	if r.user == nil || r.user.ID != id {
+		return nil, errors.New("record not found")
+	}
+	return r.user, nil
+}
+
+func (r *fakeMFAUserRepo) UseTOTPStep(id uint64, step int64) (bool, error) {
+	if step <= r.lastStep {
+		return false, nil
+	}
+	r.lastStep = step
+	return true, nil
+}
+
+// fakeRecoveryRepo 在内存中保存恢复码哈希
+type fakeRecoveryRepo struct {
+	repository.RecoveryCodeRepository
+	hashes map[string]bool
+}
+
+func (r *fakeRecoveryRepo) Replace(userID uint64, hashes []string) error {
+	r.hashes = make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		r.hashes[hash] = true
+	}
+	return nil
+}
+
+func (r *fakeRecoveryRepo) Use(userID uint64, hash string) (bool, error) {
+	if !r.hashes[hash] {
+		return false, nil
+	}
+	delete(r.hashes, hash)
+	return true, nil
+}
+
+func TestVerifySecondFactorRejectsReuse(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{ID: 1, UserType: consts.Admin, TOTPSecret: secret}
+	recovery := &fakeRecoveryRepo{}
+	s := &userService{userRepo: &fakeMFAUserRepo{user: user}, recoveryRepo: recovery}
+	codes, err := s.generateRecoveryCodes(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, _ := totp.Code(secret, totp.Step(time.Now()))
+	previous, _ := totp.Code(secret, totp.Step(time.Now())-1)
+
+	// 按顺序执行，后面的步骤依赖前面已使用的验证码
+	steps := []struct {
+		name string
+		code string
+		ok   bool
+	}{
+		{"current code", current, true},
+		{"same code again", current, false},
+		// 已使用较新的时间步后，较早的验证码同样无效
+		{"earlier code", previous, false},
+		{"recovery code", codes[0], true},
+		{"recovery code again", codes[0], false},
+		{"recovery code in another format", strings.ToUpper(strings.ReplaceAll(codes[1], "-", " ")), true},
+		{"unknown code", "aaaaa-bbbbb", false},
+	}
+	for _, step := range steps {
+		err := s.verifySecondFactor(user, step.code)
+		if step.ok && err != nil {
+			t.Errorf("%s: err = %v", step.name, err)
+		}
+		if !step.ok && !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Errorf("%s: err = %v, want ErrInvalidTwoFactorCode", step.name, err)
+		}
+	}
+	if len(recovery.hashes) != recoveryCodeCount-2 {
+		t.Errorf("%d recovery codes left, want %d", len(recovery.hashes), recoveryCodeCount-2)
+	}
+}
+
+func TestMFATokenIsSingleUse(t *testing.T) {
+	user := &models.User{ID: 1, Username: "admin", UserType: consts.Admin}
+	s := &userService{
+		userRepo:   &fakeMFAUserRepo{user: user},
+		tokenStore: ratelimit.NewMemoryStore(time.Minute),
+		jwtSecret:  []byte("secret"),
+	}
+	ctx := context.Background()
+
+	token, err := s.generateMFAToken(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 消费前可以多次解析，如先获取绑定信息再确认绑定
+	for i := 0; i < 2; i++ {
+		if _, _, err := s.parseMFAToken(token); err != nil {
+			t.Fatalf("parseMFAToken() = %v", err)
+		}
+	}
+	_, jti, _ := s.parseMFAToken(token)
+	if ok, err := consumeOneTimeToken(ctx, s.tokenStore, mfaTokenKeyPrefix+jti, mfaTokenTTL); err != nil || !ok {
+		t.Fatalf("first consume = %v, %v", ok, err)
+	}
+	if ok, _ := consumeOneTimeToken(ctx, s.tokenStore, mfaTokenKeyPrefix+jti, mfaTokenTTL); ok {
+		t.Error("second consume succeeded")
+	}
+	if _, _, err := s.parseMFAToken(token); !errors.Is(err, ErrInvalidMFAToken) {
+		t.Errorf("parseMFAToken() after use = %v, want ErrInvalidMFAToken", err)
+	}
+}
+
+func TestMFATokenRequiresIssuedJTI(t *testing.T) {
+	user := &models.User{ID: 1, Username: "admin", UserType: consts.Admin}
+	s := &userService{
+		userRepo:   &fakeMFAUserRepo{user: user},
+		tokenStore: ratelimit.NewMemoryStore(time.Minute),
+		jwtSecret:  []byte("secret"),
+	}
+	sign := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.jwtSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	exp := time.Now().Add(time.Minute).Unix()
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		// 签名正确但不是服务端签发的令牌同样无效
+		{"without jti", sign(jwt.MapClaims{"id": 1, "typ": "mfa", "exp": exp})},
+		{"unknown jti", sign(jwt.MapClaims{"id": 1, "typ": "mfa", "jti": "forged", "exp": exp})},
+		{"wrong type", sign(jwt.MapClaims{"id": 1, "typ": "sso", "jti": "forged", "exp": exp})},
+		{"malformed", "not-a-token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := s.parseMFAToken(tt.token); !errors.Is(err, ErrInvalidMFAToken) {
+				t.Errorf("parseMFAToken() = %v, want ErrInvalidMFAToken", err)
+			}
+		})
+	}
+}
@@ -1,154 +1,460 @@
 package service
 
 import (
+	"context"
 	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"feedback-system/internal/consts"
 	"feedback-system/internal/models"
 	"feedback-system/internal/repository"
+	"feedback-system/pkg/password"
+	"feedback-system/pkg/ratelimit"
+	"feedback-system/pkg/ws"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
 )
 
-// 定义JWT密钥，实际项目中应该从配置文件或环境变量中获取
-const jwtSecret = "feedback-system-secret-key"
+var (
+	// ErrInvalidToken 访问令牌无效、已过期或所属会话已撤销
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrInvalidRefreshToken 刷新令牌无效、已过期或已被使用
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrSessionNotFound 会话不存在或不属于当前用户
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrInvalidCredentials 用户名或密码错误
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrPasswordUnchanged 新密码与原密码相同
+	ErrPasswordUnchanged = errors.New("new password must differ from the old one")
+	// ErrAccountPending 商家账号等待管理员审核
+	ErrAccountPending = errors.New("account is pending approval")
+	// ErrAccountSuspended 账号已被停用
+	ErrAccountSuspended = errors.New("account is suspended")
+)
+
+// sessionTouchInterval 会话最后活跃时间的更新间隔，避免每个请求都写数据库
+const sessionTouchInterval = time.Minute
+
+// legacyAdminPassword 旧版本自动创建的默认管理员的公开密码
+const legacyAdminPassword = "admin123"
 
 // UserService 用户服务接口
 type UserService interface {
-	Register(req *models.UserRegisterRequest) (*models.User, error)
-	Login(req *models.UserLoginRequest) (*models.UserLoginResponse, error)
+	Login(req *models.UserLoginRequest, ip, userAgent string) (*models.UserLoginResponse, error)
+	LoginVerified(user *models.User, deviceName, ip, userAgent string) (*models.UserLoginResponse, error)
+	Refresh(refreshToken string) (*models.TokenResponse, error)
+	Logout(sessionID string) error
+	LogoutAll(userID uint64, userType uint8) error
+	ChangePassword(user *models.User, sessionID, oldPassword, newPassword string) error
+	ListSessions(userID uint64, userType uint8) ([]*models.UserSession, error)
+	RevokeSession(userID uint64, userType uint8, sessionID string) error
 	GetUserByID(id uint64) (*models.User, error)
-	ValidateToken(token string) (*models.User, error)
+	ValidateToken(token string) (*models.User, *models.UserSession, error)
 	GetMerchants() ([]*models.User, error)
+	CleanupSessions() (int64, error)
+
+	// 两步验证
+	LoginTwoFactor(req *models.TwoFactorLoginRequest, ip, userAgent string) (*models.UserLoginResponse, error)
+	SetupTwoFactorForLogin(mfaToken string) (*models.TwoFactorSetupResponse, error)
+	SetupTwoFactor(user *models.User) (*models.TwoFactorSetupResponse, error)
+	EnableTwoFactor(user *models.User, code string) ([]string, error)
+	DisableTwoFactor(user *models.User, password, code string) error
+	RegenerateRecoveryCodes(user *models.User, code string) ([]string, error)
 }
 
 // userService 用户服务实现
 type userService struct {
-	userRepo repository.UserRepository
+	userRepo     repository.UserRepository
+	sessionRepo  repository.UserSessionRepository
+	recoveryRepo repository.RecoveryCodeRepository
+	wsHandler    *ws.WSHandler
+	loginGuard   *LoginGuard
+	tokenStore   ratelimit.Store
+	twoFactor    TwoFactorPolicy
+	passwords    *password.Policy
+
+	jwtSecret  []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
 }
 
-// NewUserService 创建用户服务实例
-func NewUserService(userRepo repository.UserRepository) UserService {
+// NewUserService 创建用户服务实例，loginGuard 为 nil 时不限制登录失败次数
+// tokenStore 记录已签发的两步验证临时令牌，保证令牌只能使用一次
+func NewUserService(userRepo repository.UserRepository, sessionRepo repository.UserSessionRepository, recoveryRepo repository.RecoveryCodeRepository, wsHandler *ws.WSHandler, loginGuard *LoginGuard, tokenStore ratelimit.Store, twoFactor TwoFactorPolicy, passwords *password.Policy, jwtSecret string, accessTTL, refreshTTL time.Duration) UserService {
 	return &userService{
-		userRepo: userRepo,
+		userRepo:     userRepo,
+		sessionRepo:  sessionRepo,
+		recoveryRepo: recoveryRepo,
+		wsHandler:    wsHandler,
+		loginGuard:   loginGuard,
+		tokenStore:   tokenStore,
+		twoFactor:    twoFactor,
+		passwords:    passwords,
+		jwtSecret:    []byte(jwtSecret),
+		accessTTL:    accessTTL,
+		refreshTTL:   refreshTTL,
 	}
 }
 
-// Register 用户注册
-func (s *userService) Register(req *models.UserRegisterRequest) (*models.User, error) {
-	// 检查用户名是否已存在
-	_, err := s.userRepo.GetByUsername(req.Username, req.UserType)
-	if err == nil {
-		return nil, errors.New("username already exists for this user type")
-	}
-
-	// 创建新用户
-	user := &models.User{
-		Username: req.Username,
-		Password: hashPassword(req.Password), // 对密码进行加密
-		Contact:  req.Contact,
-		UserType: req.UserType,
+// Login 用户登录
+// ip 和 userAgent 记录到登录会话中，用于会话列表展示
+// 连续失败时可能返回 *LoginLockedError、ErrCaptchaRequired 或 ErrCaptchaInvalid；
+// 需要两步验证时不创建会话，返回临时令牌，由 LoginTwoFactor 完成登录
+func (s *userService) Login(req *models.UserLoginRequest, ip, userAgent string) (*models.UserLoginResponse, error) {
+	ctx := context.Background()
+	attempt := &loginAttempt{
+		username:  req.Username,
+		userType:  req.UserType,
+		ip:        ip,
+		userAgent: userAgent,
+		captcha:   req.Captcha,
 	}
 
-	// 保存用户
-	err = s.userRepo.Create(user)
-	if err != nil {
+	// 检查账号或IP是否被锁定、是否需要验证码
+	if err := s.loginGuard.Check(ctx, attempt); err != nil {
 		return nil, err
 	}
 
-	return user, nil
-}
-
-// Login 用户登录
-func (s *userService) Login(req *models.UserLoginRequest) (*models.UserLoginResponse, error) {
 	// 根据用户名和用户类型查找用户
 	user, err := s.userRepo.GetByUsername(req.Username, req.UserType)
 	if err != nil {
-		return nil, errors.New("invalid username or password")
+		s.loginGuard.Fail(ctx, attempt, loginFailUnknownUser)
+		return nil, ErrInvalidCredentials
 	}
 
 	// 验证密码
 	if user.Password != hashPassword(req.Password) { // 比较加密后的密码
-		return nil, errors.New("invalid username or password")
+		s.loginGuard.Fail(ctx, attempt, loginFailBadPassword)
+		return nil, ErrInvalidCredentials
+	}
+	s.loginGuard.Succeed(ctx, attempt)
+
+	// 密码正确后再检查账号状态，避免通过状态提示探测用户名
+	if err := checkAccountStatus(user); err != nil {
+		return nil, err
+	}
+
+	// 仍在使用旧默认密码或密码不再符合密码策略时，要求修改密码后才能访问其他接口
+	if err := s.flagWeakPassword(user, req.Password); err != nil {
+		return nil, err
 	}
 
-	// 生成JWT令牌
-	token, err := generateToken(user)
+	// 启用了两步验证或当前用户类型强制两步验证时，先返回临时令牌
+	if challenge, ok, err := s.twoFactorChallenge(user); ok {
+		return challenge, err
+	}
+
+	// 创建登录会话并签发令牌
+	tokens, err := s.createSession(user, s.deviceName(req.DeviceName, userAgent), truncate(ip, 45), truncate(userAgent, 255))
 	if err != nil {
 		return nil, err
 	}
 
 	// 创建登录响应
 	response := &models.UserLoginResponse{
-		User:  *user,
-		Token: token,
+		User:         *user,
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
 	}
 
 	return response, nil
 }
 
+// flagWeakPassword 登录时发现密码是旧版本默认管理员的公开密码或不符合当前密码策略时，为账号加上 must_change_password 标记
+// 升级前创建的账号（包括仍在使用 admin123 的默认管理员）在下次登录时即被要求修改密码
+func (s *userService) flagWeakPassword(user *models.User, plain string) error {
+	if user.MustChangePassword {
+		return nil
+	}
+	weak := user.UserType == consts.Admin && plain == legacyAdminPassword
+	if !weak && s.passwords != nil {
+		weak = s.passwords.Validate(plain, user.Username) != nil
+	}
+	if !weak {
+		return nil
+	}
+	if err := s.userRepo.UpdateFields(user.ID, map[string]interface{}{"must_change_password": true}); err != nil {
+		return err
+	}
+	user.MustChangePassword = true
+	return nil
+}
+
+// LoginVerified 为已在外部完成身份验证的用户（如单点登录）创建登录会话
+// 不校验密码，但仍检查账号状态和两步验证
+func (s *userService) LoginVerified(user *models.User, deviceName, ip, userAgent string) (*models.UserLoginResponse, error) {
+	if err := checkAccountStatus(user); err != nil {
+		return nil, err
+	}
+	if challenge, ok, err := s.twoFactorChallenge(user); ok {
+		return challenge, err
+	}
+
+	tokens, err := s.createSession(user, s.deviceName(deviceName, userAgent), truncate(ip, 45), truncate(userAgent, 255))
+	if err != nil {
+		return nil, err
+	}
+	return &models.UserLoginResponse{
+		User:         *user,
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+	}, nil
+}
+
+// Refresh 使用刷新令牌换取新的访问令牌
+// 刷新令牌每次使用后轮换；已轮换的旧令牌再次出现说明令牌可能被窃取，撤销整个会话
+func (s *userService) Refresh(refreshToken string) (*models.TokenResponse, error) {
+	hash := hashToken(refreshToken)
+
+	session, err := s.sessionRepo.FindByRefreshTokenHash(hash)
+	if err != nil {
+		if reused, err := s.sessionRepo.FindByPrevRefreshTokenHash(hash); err == nil {
+			log.Printf("检测到刷新令牌重放，撤销会话: session=%s user=%d/%d", reused.ID, reused.UserID, reused.UserType)
+			s.Logout(reused.ID)
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if !session.Active(time.Now()) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetByID(session.UserID)
+	if err != nil || checkAccountStatus(user) != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	newRefreshToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.sessionRepo.Rotate(session.ID, hash, hashToken(newRefreshToken), time.Now().Add(s.refreshTTL))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// 并发刷新时其他请求已轮换了令牌
+		return nil, ErrInvalidRefreshToken
+	}
+
+	token, err := s.generateToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TokenResponse{
+		Token:        token,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    int64(s.accessTTL / time.Second),
+	}, nil
+}
+
+// Logout 撤销会话并断开该会话的WebSocket连接
+func (s *userService) Logout(sessionID string) error {
+	if err := s.sessionRepo.Revoke(sessionID); err != nil {
+		return err
+	}
+	if s.wsHandler != nil {
+		s.wsHandler.DisconnectSession(sessionID)
+	}
+	return nil
+}
+
+// LogoutAll 撤销用户的所有会话（所有设备退出登录）
+func (s *userService) LogoutAll(userID uint64, userType uint8) error {
+	sessionIDs, err := s.sessionRepo.RevokeByUser(userID, userType)
+	if err != nil {
+		return err
+	}
+	s.disconnectSessions(sessionIDs)
+	return nil
+}
+
+// ChangePassword 修改密码
+// 新密码需符合密码策略；修改后清除“需要修改密码”标记，并注销当前会话以外的所有会话
+func (s *userService) ChangePassword(user *models.User, sessionID, oldPassword, newPassword string) error {
+	if user.Password != hashPassword(oldPassword) {
+		return ErrInvalidCredentials
+	}
+	if oldPassword == newPassword {
+		return ErrPasswordUnchanged
+	}
+	if err := s.passwords.Validate(newPassword, user.Username); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdatePassword(user.ID, hashPassword(newPassword), false); err != nil {
+		return err
+	}
+	user.Password = hashPassword(newPassword)
+	user.MustChangePassword = false
+
+	sessionIDs, err := s.sessionRepo.RevokeOthers(user.ID, user.UserType, sessionID)
+	if err != nil {
+		return err
+	}
+	s.disconnectSessions(sessionIDs)
+	return nil
+}
+
+// disconnectSessions 断开已撤销会话的WebSocket连接
+func (s *userService) disconnectSessions(sessionIDs []string) {
+	if s.wsHandler == nil {
+		return
+	}
+	for _, id := range sessionIDs {
+		s.wsHandler.DisconnectSession(id)
+	}
+}
+
 // GetUserByID 根据ID获取用户
 func (s *userService) GetUserByID(id uint64) (*models.User, error) {
 	return s.userRepo.GetByID(id)
 }
 
+// ListSessions 获取用户的有效会话列表
+func (s *userService) ListSessions(userID uint64, userType uint8) ([]*models.UserSession, error) {
+	return s.sessionRepo.FindActiveByUser(userID, userType, time.Now())
+}
+
+// RevokeSession 撤销用户的指定会话，并断开该会话的WebSocket连接
+func (s *userService) RevokeSession(userID uint64, userType uint8, sessionID string) error {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil || session.UserID != userID || session.UserType != userType {
+		return ErrSessionNotFound
+	}
+	return s.Logout(session.ID)
+}
+
 // ValidateToken 验证JWT令牌
-func (s *userService) ValidateToken(tokenString string) (*models.User, error) {
+// 除签名和有效期外，还会检查令牌所属的会话是否已被撤销
+func (s *userService) ValidateToken(tokenString string) (*models.User, *models.UserSession, error) {
 	// 解析JWT令牌
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		// 验证签名算法
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
-		return []byte(jwtSecret), nil
+		return s.jwtSecret, nil
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// 验证令牌有效性
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		// 获取用户ID
-		userIDFloat, ok := claims["id"].(float64)
-		if !ok {
-			return nil, errors.New("invalid token claims")
-		}
-		userID := uint64(userIDFloat)
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, nil, ErrInvalidToken
+	}
 
-		// 获取用户信息
-		user, err := s.userRepo.GetByID(userID)
-		if err != nil {
-			return nil, err
-		}
+	// 获取用户ID和会话ID，旧版本签发的令牌没有会话ID，需要重新登录
+	userIDFloat, ok := claims["id"].(float64)
+	if !ok {
+		return nil, nil, ErrInvalidToken
+	}
+	userID := uint64(userIDFloat)
+	sessionID, ok := claims["sid"].(string)
+	if !ok || sessionID == "" {
+		return nil, nil, ErrInvalidToken
+	}
 
-		return user, nil
+	// 检查会话是否有效
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	now := time.Now()
+	if !session.Active(now) || session.UserID != userID {
+		return nil, nil, ErrInvalidToken
+	}
+	if now.Sub(session.LastUsedAt) > sessionTouchInterval {
+		s.sessionRepo.Touch(session.ID, now)
+		session.LastUsedAt = now
 	}
 
-	return nil, errors.New("invalid token")
+	// 获取用户信息
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if checkAccountStatus(user) != nil {
+		return nil, nil, ErrInvalidToken
+	}
+
+	return user, session, nil
+}
+
+// deviceName 会话的设备名称，客户端未提供时根据User-Agent推断
+func (s *userService) deviceName(name, userAgent string) string {
+	device := strings.TrimSpace(name)
+	if device == "" {
+		device = describeDevice(userAgent)
+	}
+	return truncate(device, 100)
 }
 
-// generateToken 生成JWT令牌
-func generateToken(user *models.User) (string, error) {
+// createSession 创建登录会话并签发访问令牌和刷新令牌
+func (s *userService) createSession(user *models.User, device, ip, userAgent string) (*models.TokenResponse, error) {
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.UserSession{
+		ID:               uuid.New().String(),
+		UserID:           user.ID,
+		UserType:         user.UserType,
+		RefreshTokenHash: hashToken(refreshToken),
+		Device:           device,
+		IP:               ip,
+		UserAgent:        userAgent,
+		ExpiresAt:        now.Add(s.refreshTTL),
+		LastUsedAt:       now,
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	token, err := s.generateToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TokenResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.accessTTL / time.Second),
+	}, nil
+}
+
+// generateToken 生成JWT访问令牌
+func (s *userService) generateToken(user *models.User, sessionID string) (string, error) {
 	// 创建JWT声明
 	claims := jwt.MapClaims{
 		"id":        user.ID,
 		"username":  user.Username,
 		"user_type": user.UserType,
-		"exp":       time.Now().Add(time.Hour * 24).Unix(), // 令牌有效期为24小时
+		"sid":       sessionID,
+		"exp":       time.Now().Add(s.accessTTL).Unix(),
 	}
 
 	// 创建令牌
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// 签名令牌
-	tokenString, err := token.SignedString([]byte(jwtSecret))
-	if err != nil {
-		return "", err
-	}
+	return token.SignedString(s.jwtSecret)
+}
 
-	return tokenString, nil
+// CleanupSessions 删除刷新令牌已过期的会话
+func (s *userService) CleanupSessions() (int64, error) {
+	return s.sessionRepo.DeleteExpired(time.Now())
 }
 
 // GetMerchants 获取所有商家用户
@@ -156,6 +462,83 @@ func (s *userService) GetMerchants() ([]*models.User, error) {
 	return s.userRepo.GetMerchants()
 }
 
+// checkAccountStatus 只有已启用的账号可以登录和使用令牌
+func checkAccountStatus(user *models.User) error {
+	switch user.Status {
+	case consts.AccountPending:
+		return ErrAccountPending
+	case consts.AccountSuspended:
+		return ErrAccountSuspended
+	}
+	return nil
+}
+
+// generateRefreshToken 生成随机刷新令牌
+func generateRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken 计算令牌哈希，数据库中只保存哈希值
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// describeDevice 根据User-Agent推断设备名称，例如 "Chrome / Windows"
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "未知设备"
+	}
+
+	// 按匹配优先级排列：Edge 和 Opera 的 User-Agent 中同时包含 Chrome，Chrome 的同时包含 Safari
+	browsers := []struct{ token, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"MicroMessenger", "微信"}, {"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"}, {"Safari/", "Safari"},
+	}
+	systems := []struct{ token, name string }{
+		{"Android", "Android"}, {"iPhone", "iPhone"}, {"iPad", "iPad"}, {"Windows", "Windows"},
+		{"Mac OS X", "macOS"}, {"Linux", "Linux"},
+	}
+
+	browser, system := "", ""
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, sys := range systems {
+		if strings.Contains(userAgent, sys.token) {
+			system = sys.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " / " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	default:
+		return truncate(userAgent, 100)
+	}
+}
+
+// truncate 按字符数截断字符串，避免超出数据库字段长度
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
 // hashPassword 对密码进行MD5加密
 func hashPassword(password string) string {
 	hash := md5.Sum([]byte(password))
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"feedback-system/internal/consts"
+	"feedback-system/internal/models"
+	"feedback-system/internal/repository"
+	"feedback-system/pkg/password"
+	"testing"
+)
+
+// fakeFlagUserRepo 记录 UpdateFields 的调用
+type fakeFlagUserRepo struct {
+	repository.UserRepository
+	updated map[string]interface{}
+}
+
+func (r *fakeFlagUserRepo) UpdateFields(id uint64, fields map[string]interface{}) error {
+	r.updated = fields
+	return nil
+}
+
+func TestFlagWeakPassword(t *testing.T) {
+	policy, err := password.NewPolicy(8, 72, 2, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		user     models.User
+		password string
+		policy   *password.Policy
+		want     bool
+	}{
+		{"legacy default admin", models.User{ID: 1, Username: "admin", UserType: consts.Admin}, "admin123", policy, true},
+		// 未配置密码策略时同样识别旧默认密码
+		{"legacy default admin without policy", models.User{ID: 1, Username: "root", UserType: consts.Admin}, "admin123", nil, true},
+		{"breached password", models.User{ID: 2, Username: "alice", UserType: consts.User}, "password1", policy, true},
+		{"too short", models.User{ID: 2, Username: "alice", UserType: consts.User}, "a1b2", policy, true},
+		{"strong password", models.User{ID: 2, Username: "alice", UserType: consts.User}, "correct-Horse-42", policy, false},
+		{"admin123 as a merchant without policy", models.User{ID: 3, Username: "shop", UserType: consts.Merchant}, "admin123", nil, false},
+		{"already flagged", models.User{ID: 1, Username: "admin", UserType: consts.Admin, MustChangePassword: true}, "admin123", policy, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeFlagUserRepo{}
+			s := &userService{userRepo: repo, passwords: tt.policy}
+			user := tt.user
+			if err := s.flagWeakPassword(&user, tt.password); err != nil {
+				t.Fatal(err)
+			}
+			if user.MustChangePassword != tt.want {
+				t.Errorf("MustChangePassword = %v, want %v", user.MustChangePassword, tt.want)
+			}
+			// 已带标记的账号不重复更新
+			stored := repo.updated != nil
+			if stored != (tt.want && !tt.user.MustChangePassword) {
+				t.Errorf("updated = %v", repo.updated)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+// Package calendar 工作日历：按时区的每周营业时间和节假日，计算营业时间内的时长
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // 内置时区数据，容器中没有系统时区数据时也能加载时区
+)
+
+// maxSearchDays 查找营业时间时最多向后查找的天数，避免节假日覆盖全部日期时死循环
+const maxSearchDays = 3 * 366
+
+// dateLayout 节假日日期格式
+const dateLayout = "2006-01-02"
+
+// ErrNoBusinessHours 日历在一周内没有任何营业时间
+var ErrNoBusinessHours = errors.New("calendar has no business hours")
+
+// Interval 一天中的营业时段，以当天零点起的分钟数表示，End 最大为 1440（24:00）
+type Interval struct {
+	Start int
+	End   int
+}
+
+// Calendar 工作日历，所有时间按 Location 所在时区计算
+// nil 日历表示全天营业（7×24 小时），所有方法按自然时间计算
+type Calendar struct {
+	location *time.Location
+	week     [7][]Interval // 按 time.Weekday 索引，时段按开始时间排序且不重叠
+	holidays map[string]bool
+}
+
+// New 创建工作日历
+// timezone 为 IANA 时区名称；hours 按 time.Weekday 索引，每个时段格式为 "09:00-18:00"；holidays 为 "2006-01-02" 格式的日期
+func New(timezone string, hours map[time.Weekday][]string, holidays []string) (*Calendar, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q", timezone)
+	}
+
+	c := &Calendar{location: location, holidays: make(map[string]bool, len(holidays))}
+	open := false
+	for weekday, ranges := range hours {
+		if weekday < time.Sunday || weekday > time.Saturday {
+			return nil, fmt.Errorf("invalid weekday %d", weekday)
+		}
+		for _, value := range ranges {
+			interval, err := ParseInterval(value)
+			if err != nil {
+				return nil, err
+			}
+			c.week[weekday] = append(c.week[weekday], interval)
+			open = true
+		}
+		day := c.week[weekday]
+		sort.Slice(day, func(i, j int) bool { return day[i].Start < day[j].Start })
+		for i := 1; i < len(day); i++ {
+			if day[i].Start < day[i-1].End {
+				return nil, fmt.Errorf("overlapping business hours on %s", weekday)
+			}
+		}
+	}
+	if !open {
+		return nil, ErrNoBusinessHours
+	}
+
+	for _, date := range holidays {
+		if _, err := time.Parse(dateLayout, date); err != nil {
+			return nil, fmt.Errorf("invalid holiday %q", date)
+		}
+		c.holidays[date] = true
+	}
+	return c, nil
+}
+
+// ParseInterval 解析 "09:00-18:00" 格式的营业时段，结束时间可以为 24:00
+func ParseInterval(value string) (Interval, error) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return Interval{}, fmt.Errorf("invalid business hours %q", value)
+	}
+	start, err1 := parseClock(parts[0])
+	end, err2 := parseClock(parts[1])
+	if err1 != nil || err2 != nil || start >= end {
+		return Interval{}, fmt.Errorf("invalid business hours %q", value)
+	}
+	return Interval{Start: start, End: end}, nil
+}
+
+// parseClock 解析 "HH:MM"，返回当天零点起的分钟数
+func parseClock(value string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) != 2 {
+		return 0, errors.New("invalid clock")
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, err
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, err
+	}
+	if hour < 0 || minute < 0 || minute > 59 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, errors.New("invalid clock")
+	}
+	return hour*60 + minute, nil
+}
+
+// Location 日历的时区
+func (c *Calendar) Location() *time.Location {
+	if c == nil {
+		return time.Local
+	}
+	return c.location
+}
+
+// IsOpen t 是否在营业时间内
+func (c *Calendar) IsOpen(t time.Time) bool {
+	if c == nil {
+		return true
+	}
+	open := false
+	c.eachWindow(t, 1, func(start, end time.Time) bool {
+		open = !t.Before(start) && t.Before(end)
+		return !open
+	})
+	return open
+}
+
+// NextOpen 返回 t 之后（含 t）最近的营业时间，t 在营业时间内时返回 t
+func (c *Calendar) NextOpen(t time.Time) time.Time {
+	if c == nil {
+		return t
+	}
+	next := t
+	c.eachWindow(t, maxSearchDays, func(start, end time.Time) bool {
+		if !t.Before(end) {
+			return true
+		}
+		if t.Before(start) {
+			next = start
+		}
+		return false
+	})
+	return next
+}
+
+// Add 从 start 开始累计 d 的营业时长，返回到达的时间；营业时间以外不计时
+// 例如周五 17:00 加 2 小时、营业时间为工作日 9:00-18:00 时，结果为下周一 10:00
+func (c *Calendar) Add(start time.Time, d time.Duration) time.Time {
+	if c == nil {
+		return start.Add(d)
+	}
+	if d <= 0 {
+		return start
+	}
+
+	// 日历在查找范围内没有足够的营业时间时按自然时间计算
+	result := start.Add(d)
+	remaining := d
+	c.eachWindow(start, maxSearchDays, func(from, end time.Time) bool {
+		if from.Before(start) {
+			from = start
+		}
+		if !from.Before(end) {
+			return true
+		}
+		if available := end.Sub(from); remaining > available {
+			remaining -= available
+			return true
+		}
+		result = from.Add(remaining)
+		return false
+	})
+	return result
+}
+
+// Between 返回 from 到 to 之间的营业时长，to 早于 from 时返回 0
+func (c *Calendar) Between(from, to time.Time) time.Duration {
+	if !from.Before(to) {
+		return 0
+	}
+	if c == nil {
+		return to.Sub(from)
+	}
+
+	var total time.Duration
+	c.eachWindow(from, maxSearchDays, func(start, end time.Time) bool {
+		if !start.Before(to) {
+			return false
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if start.Before(end) {
+			total += end.Sub(start)
+		}
+		return true
+	})
+	return total
+}
+
+// eachWindow 从 t 所在日期起按时间顺序遍历 days 天内的营业时段 [start, end)，跳过节假日；fn 返回 false 时停止
+func (c *Calendar) eachWindow(t time.Time, days int, fn func(start, end time.Time) bool) {
+	year, month, day := t.In(c.location).Date()
+	for i := 0; i < days; i++ {
+		date := time.Date(year, month, day+i, 0, 0, 0, 0, c.location)
+		if c.holidays[date.Format(dateLayout)] {
+			continue
+		}
+		y, m, d := date.Date()
+		for _, interval := range c.week[date.Weekday()] {
+			start := time.Date(y, m, d, 0, interval.Start, 0, 0, c.location)
+			end := time.Date(y, m, d, 0, interval.End, 0, 0, c.location)
+			if !fn(start, end) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package calendar
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// officeHours 工作日 9:00-18:00 的日历
+func officeHours(t *testing.T, timezone string, holidays ...string) *Calendar {
+	t.Helper()
+	hours := map[time.Weekday][]string{}
+	for _, day := range weekdays {
+		hours[day] = []string{"09:00-18:00"}
+	}
+	c, err := New(timezone, hours, holidays)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// at 返回日历时区中的时间
+func at(c *Calendar, year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, c.Location())
+}
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Interval
+		ok    bool
+	}{
+		{"09:00-18:00", Interval{540, 1080}, true},
+		{" 09:30 - 12:15 ", Interval{570, 735}, true},
+		{"18:00-24:00", Interval{1080, 1440}, true},
+		{"00:00-24:00", Interval{0, 1440}, true},
+		{"18:00-09:00", Interval{}, false},
+		{"09:00-09:00", Interval{}, false},
+		{"09:00-24:30", Interval{}, false},
+		{"09:00-25:00", Interval{}, false},
+		{"09:60-10:00", Interval{}, false},
+		{"9-10", Interval{}, false},
+		{"09:00", Interval{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseInterval(tt.value)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseInterval(%q) = %v, %v; want %v, ok=%v", tt.value, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNewRejectsInvalidCalendars(t *testing.T) {
+	if _, err := New("Asia/Shanghai", nil, nil); !errors.Is(err, ErrNoBusinessHours) {
+		t.Errorf("no hours: err = %v, want ErrNoBusinessHours", err)
+	}
+	hours := map[time.Weekday][]string{time.Monday: {"09:00-18:00"}}
+	if _, err := New("Mars/Olympus", hours, nil); err == nil {
+		t.Error("invalid timezone accepted")
+	}
+	if _, err := New("Asia/Shanghai", hours, []string{"2026/10/01"}); err == nil {
+		t.Error("invalid holiday accepted")
+	}
+	if _, err := New("Asia/Shanghai", map[time.Weekday][]string{time.Monday: {"13:00-18:00", "09:00-13:30"}}, nil); err == nil {
+		t.Error("overlapping hours accepted")
+	}
+	if _, err := New("Asia/Shanghai", map[time.Weekday][]string{time.Monday: {"13:00-18:00", "09:00-13:00"}}, nil); err != nil {
+		t.Errorf("adjacent hours rejected: %v", err)
+	}
+}
+
+func TestIsOpenAndNextOpen(t *testing.T) {
+	c := officeHours(t, "Asia/Shanghai", "2026-10-01")
+	tests := []struct {
+		name string
+		t    time.Time
+		open bool
+		next time.Time
+	}{
+		{"before opening", at(c, 2026, 10, 14, 8, 59), false, at(c, 2026, 10, 14, 9, 0)},
+		{"at opening", at(c, 2026, 10, 14, 9, 0), true, at(c, 2026, 10, 14, 9, 0)},
+		{"last minute", at(c, 2026, 10, 14, 17, 59), true, at(c, 2026, 10, 14, 17, 59)},
+		{"closing time is closed", at(c, 2026, 10, 14, 18, 0), false, at(c, 2026, 10, 15, 9, 0)},
+		{"friday evening", at(c, 2026, 10, 16, 19, 0), false, at(c, 2026, 10, 19, 9, 0)},
+		{"saturday", at(c, 2026, 10, 17, 11, 0), false, at(c, 2026, 10, 19, 9, 0)},
+		{"holiday", at(c, 2026, 10, 1, 10, 0), false, at(c, 2026, 10, 2, 9, 0)},
+		{"other timezone", time.Date(2026, 10, 14, 2, 0, 0, 0, time.UTC), true, time.Date(2026, 10, 14, 2, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.IsOpen(tt.t); got != tt.open {
+				t.Errorf("IsOpen(%v) = %v, want %v", tt.t, got, tt.open)
+			}
+			if got := c.NextOpen(tt.t); !got.Equal(tt.next) {
+				t.Errorf("NextOpen(%v) = %v, want %v", tt.t, got, tt.next)
+			}
+		})
+	}
+}
+
+func TestAdd(t *testing.T) {
+	c := officeHours(t, "Asia/Shanghai", "2026-10-19")
+	tests := []struct {
+		name  string
+		start time.Time
+		d     time.Duration
+		want  time.Time
+	}{
+		{"within the day", at(c, 2026, 10, 14, 10, 0), 2 * time.Hour, at(c, 2026, 10, 14, 12, 0)},
+		{"reaches closing time exactly", at(c, 2026, 10, 14, 16, 0), 2 * time.Hour, at(c, 2026, 10, 14, 18, 0)},
+		{"overnight", at(c, 2026, 10, 14, 17, 0), 2 * time.Hour, at(c, 2026, 10, 15, 10, 0)},
+		{"before opening", at(c, 2026, 10, 14, 7, 0), time.Hour, at(c, 2026, 10, 14, 10, 0)},
+		{"friday afternoon rolls over the weekend and holiday", at(c, 2026, 10, 16, 17, 0), 2 * time.Hour, at(c, 2026, 10, 20, 10, 0)},
+		{"friday evening", at(c, 2026, 10, 16, 19, 30), 30 * time.Minute, at(c, 2026, 10, 20, 9, 30)},
+		{"several business days end at closing time", at(c, 2026, 10, 13, 9, 0), 27 * time.Hour, at(c, 2026, 10, 15, 18, 0)},
+		{"zero duration", at(c, 2026, 10, 17, 12, 0), 0, at(c, 2026, 10, 17, 12, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Add(tt.start, tt.d); !got.Equal(tt.want) {
+				t.Errorf("Add(%v, %v) = %v, want %v", tt.start, tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	c := officeHours(t, "Asia/Shanghai", "2026-10-19")
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     time.Duration
+	}{
+		{"within the day", at(c, 2026, 10, 14, 10, 0), at(c, 2026, 10, 14, 12, 30), 150 * time.Minute},
+		{"outside business hours", at(c, 2026, 10, 14, 18, 0), at(c, 2026, 10, 15, 9, 0), 0},
+		{"friday to tuesday over holiday", at(c, 2026, 10, 16, 17, 0), at(c, 2026, 10, 20, 10, 0), 2 * time.Hour},
+		{"full week", at(c, 2026, 10, 5, 0, 0), at(c, 2026, 10, 12, 0, 0), 45 * time.Hour},
+		{"reversed", at(c, 2026, 10, 14, 12, 0), at(c, 2026, 10, 14, 10, 0), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Between(tt.from, tt.to); got != tt.want {
+				t.Errorf("Between(%v, %v) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntervalEndingAtMidnight(t *testing.T) {
+	// 晚班 18:00-24:00 与次日 00:00-06:00 相连
+	hours := map[time.Weekday][]string{}
+	for _, day := range []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday} {
+		hours[day] = []string{"00:00-06:00", "18:00-24:00"}
+	}
+	c, err := New("Asia/Shanghai", hours, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.IsOpen(at(c, 2026, 10, 14, 23, 59)) || !c.IsOpen(at(c, 2026, 10, 15, 0, 0)) {
+		t.Error("expected open around midnight")
+	}
+	if got, want := c.Add(at(c, 2026, 10, 14, 23, 0), 2*time.Hour), at(c, 2026, 10, 15, 1, 0); !got.Equal(want) {
+		t.Errorf("Add across midnight = %v, want %v", got, want)
+	}
+	if got, want := c.Add(at(c, 2026, 10, 14, 17, 0), 6*time.Hour), at(c, 2026, 10, 15, 0, 0); !got.Equal(want) {
+		t.Errorf("Add to midnight = %v, want %v", got, want)
+	}
+	if got := c.Between(at(c, 2026, 10, 14, 12, 0), at(c, 2026, 10, 15, 12, 0)); got != 12*time.Hour {
+		t.Errorf("Between over midnight = %v, want 12h", got)
+	}
+}
+
+func TestDaylightSavingTransitions(t *testing.T) {
+	// 纽约 2026-03-08 02:00 夏令时开始（02:00-03:00 不存在），2026-11-01 02:00 夏令时结束（01:00-02:00 出现两次）
+	hours := map[time.Weekday][]string{}
+	for _, day := range []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday} {
+		hours[day] = []string{"01:00-04:00"}
+	}
+	c, err := New("America/New_York", hours, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		day  time.Time
+		want time.Duration
+	}{
+		{"ordinary day", at(c, 2026, 3, 7, 0, 0), 3 * time.Hour},
+		{"spring forward", at(c, 2026, 3, 8, 0, 0), 2 * time.Hour},
+		{"fall back", at(c, 2026, 11, 1, 0, 0), 4 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Between(tt.day, tt.day.Add(12*time.Hour)); got != tt.want {
+				t.Errorf("Between = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// 夏令时开始当天 01:30 起 2 小时：当天只剩 1.5 小时营业时间，次日 01:30 到达
+	if got, want := c.Add(at(c, 2026, 3, 8, 1, 30), 2*time.Hour), at(c, 2026, 3, 9, 1, 30); !got.Equal(want) {
+		t.Errorf("Add on spring forward = %v, want %v", got, want)
+	}
+	// 夏令时结束当天 01:30（第一次）起 2 小时：营业时间按实际经过的时间计算
+	first := time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC)
+	if got, want := c.Add(first, 2*time.Hour), time.Date(2026, 11, 1, 7, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Add on fall back = %v, want %v", got, want)
+	}
+
+	// 全天营业的日历在夏令时开始当天只有 23 小时
+	allDay, err := New("America/New_York", map[time.Weekday][]string{time.Sunday: {"00:00-24:00"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := allDay.Between(at(allDay, 2026, 3, 8, 0, 0), at(allDay, 2026, 3, 9, 0, 0)); got != 23*time.Hour {
+		t.Errorf("24:00 interval on spring forward = %v, want 23h", got)
+	}
+}
+
+func TestBetweenInvertsAdd(t *testing.T) {
+	shanghai := officeHours(t, "Asia/Shanghai", "2026-10-01", "2026-10-02", "2026-10-19")
+	newYork := officeHours(t, "America/New_York")
+	lunch, err := New("Europe/Berlin", map[time.Weekday][]string{
+		time.Monday:    {"08:00-12:00", "13:00-17:30"},
+		time.Wednesday: {"08:00-12:00", "13:00-17:30"},
+		time.Saturday:  {"20:00-24:00"},
+	}, []string{"2026-10-03"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calendars := map[string]*Calendar{"shanghai": shanghai, "new york": newYork, "berlin": lunch, "nil": nil}
+	durations := []time.Duration{time.Minute, 45 * time.Minute, 4 * time.Hour, 9 * time.Hour, 30 * time.Hour, 100 * time.Hour}
+	for name, c := range calendars {
+		// 覆盖节假日和纽约 2026-11-01 的夏令时结束
+		start := time.Date(2026, 9, 28, 0, 0, 0, 0, time.UTC)
+		for t0 := start; t0.Before(start.Add(40 * 24 * time.Hour)); t0 = t0.Add(97 * time.Minute) {
+			for _, d := range durations {
+				end := c.Add(t0, d)
+				if got := c.Between(t0, end); got != d {
+					t.Fatalf("%s: Between(%v, Add(%v, %v)=%v) = %v", name, t0, t0, d, end, got)
+				}
+				if c != nil && d > 0 && !c.IsOpen(end.Add(-time.Nanosecond)) {
+					t.Fatalf("%s: Add(%v, %v) = %v ends outside business hours", name, t0, d, end)
+				}
+			}
+		}
+	}
+}
+
+func TestNilCalendarIsAlwaysOpen(t *testing.T) {
+	var c *Calendar
+	now := time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC)
+	if !c.IsOpen(now) || !c.NextOpen(now).Equal(now) {
+		t.Error("nil calendar should always be open")
+	}
+	if got := c.Add(now, 5*time.Hour); !got.Equal(now.Add(5 * time.Hour)) {
+		t.Errorf("Add = %v", got)
+	}
+	if got := c.Between(now, now.Add(5*time.Hour)); got != 5*time.Hour {
+		t.Errorf("Between = %v", got)
+	}
+}
@@ -0,0 +1,78 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMissing 未提供验证码
+	ErrMissing = errors.New("captcha: missing response")
+	// ErrInvalid 验证码校验未通过
+	ErrInvalid = errors.New("captcha: invalid response")
+)
+
+// Verifier 验证码校验接口
+type Verifier interface {
+	// Verify 校验客户端提交的验证码响应，remoteIP 为客户端IP
+	Verify(ctx context.Context, response, remoteIP string) error
+}
+
+// SiteVerifier 通过 siteverify 接口校验验证码
+// hCaptcha、reCAPTCHA、Cloudflare Turnstile 的服务端校验接口格式相同，只需配置不同的地址和密钥
+type SiteVerifier struct {
+	verifyURL string
+	secret    string
+	client    *http.Client
+}
+
+// NewSiteVerifier 创建 siteverify 校验器
+func NewSiteVerifier(verifyURL, secret string) *SiteVerifier {
+	return &SiteVerifier{
+		verifyURL: verifyURL,
+		secret:    secret,
+		client:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Verify 校验验证码
+func (v *SiteVerifier) Verify(ctx context.Context, response, remoteIP string) error {
+	if strings.TrimSpace(response) == "" {
+		return ErrMissing
+	}
+
+	form := url.Values{}
+	form.Set("secret", v.secret)
+	form.Set("response", response)
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if !result.Success {
+		return ErrInvalid
+	}
+	return nil
+}
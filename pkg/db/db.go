@@ -1,7 +1,9 @@
 package db
 
 import (
+	"feedback-system/internal/consts"
 	"feedback-system/internal/models"
+	"log"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -13,11 +15,106 @@ func NewDB(dsn string) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	if err := dropLegacyIndexes(db); err != nil {
+		return nil, err
+	}
+
 	err = db.AutoMigrate(
 		&models.User{},
 		&models.Feedback{},
 		&models.FeedbackMessage{},
+		&models.Attachment{},
+		&models.AttachmentVariant{},
+		&models.AttachmentReference{},
+		&models.UploadSession{},
+		&models.UserSession{},
+		&models.AuditLog{},
+		&models.RecoveryCode{},
+		&models.PasswordResetToken{},
+		&models.MerchantInvite{},
+		&models.UserIdentity{},
+		&models.APIKey{},
+		&models.Organization{},
+		&models.FeedbackEvent{},
+		&models.AssignmentSetting{},
+		&models.SLAPolicy{},
+		&models.BusinessCalendar{},
+		&models.CalendarAssignment{},
+		&models.JobLock{},
+		&models.JobRun{},
+		&models.AutomationRule{},
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := backfillOrganizations(db); err != nil {
+		return nil, err
+	}
 
-	return db, err
+	return db, nil
+}
+
+// dropLegacyIndexes 删除旧版本的唯一索引
+// 附件去重后内容相同的附件共用存储对象，存储对象键不再唯一，AutoMigrate 不会删除已有索引
+func dropLegacyIndexes(db *gorm.DB) error {
+	legacy := []struct {
+		model interface{}
+		index string
+	}{
+		{&models.Attachment{}, "idx_attachments_storage_key"},
+		{&models.AttachmentVariant{}, "idx_attachment_variants_storage_key"},
+	}
+	for _, l := range legacy {
+		if db.Migrator().HasIndex(l.model, l.index) {
+			if err := db.Migrator().DropIndex(l.model, l.index); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// backfillOrganizations 为不属于任何组织的商家创建以其为所有者的组织
+// 旧版本中发给商家的反馈以商家用户ID为目标，组织ID优先使用商家用户ID，已有反馈无需迁移；
+// 该ID已被其他组织占用时组织使用新ID，并在同一事务中将该商家已收到的反馈迁移到新组织
+func backfillOrganizations(db *gorm.DB) error {
+	var merchants []*models.User
+	if err := db.Where("user_type = ? AND org_id = 0", consts.Merchant).Find(&merchants).Error; err != nil {
+		return err
+	}
+
+	for _, merchant := range merchants {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			org := &models.Organization{Name: merchant.Name()}
+			var occupied []*models.Organization
+			if err := tx.Where("id = ?", merchant.ID).Limit(1).Find(&occupied).Error; err != nil {
+				return err
+			}
+			if len(occupied) == 0 {
+				org.ID = merchant.ID
+			}
+			if err := tx.Create(org).Error; err != nil {
+				return err
+			}
+			if len(occupied) > 0 {
+				// 占用该ID的组织创建之前以该ID为目标的反馈只能是发给这个商家的旧反馈
+				result := tx.Model(&models.Feedback{}).
+					Where("target_type = ? AND target_id = ? AND created_at < ?", consts.TargetMerchant, merchant.ID, occupied[0].CreatedAt).
+					Update("target_id", org.ID)
+				if result.Error != nil {
+					return result.Error
+				}
+				log.Printf("组织ID %d 已被占用，商家 %s 的组织使用新ID %d，已迁移 %d 条反馈", merchant.ID, merchant.Username, org.ID, result.RowsAffected)
+			}
+			return tx.Model(&models.User{}).Where("id = ?", merchant.ID).Updates(map[string]interface{}{
+				"org_id":   org.ID,
+				"org_role": consts.OrgRoleOwner,
+			}).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,315 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+)
+
+var (
+	// ErrUnsupportedFormat 不支持的图片格式（按文件内容识别，而不是扩展名或请求头）
+	ErrUnsupportedFormat = errors.New("imaging: unsupported image format")
+
+	// ErrTooLarge 图片文件大小或像素数超过限制，GIF 的像素数按帧数累计
+	ErrTooLarge = errors.New("imaging: image dimensions too large")
+)
+
+// 支持的图片格式（按魔数识别）
+const (
+	FormatJPEG = "image/jpeg"
+	FormatPNG  = "image/png"
+	FormatGIF  = "image/gif"
+)
+
+// VariantSpec 缩略图规格：按最长边等比缩放
+type VariantSpec struct {
+	Name    string
+	MaxSide int
+}
+
+// Options 处理选项
+type Options struct {
+	// 图片文件最大字节数，超过时不再读取，0 表示不限制
+	MaxBytes int64
+	// 最大像素数（宽×高，GIF 为宽×高×帧数），防止解码超大图片耗尽内存
+	MaxPixels int
+	// JPEG 编码质量
+	JPEGQuality int
+	// 需要生成的缩略图规格
+	Variants []VariantSpec
+}
+
+// DefaultOptions 默认处理选项
+var DefaultOptions = Options{
+	MaxBytes:    20 << 20,
+	MaxPixels:   40 * 1000 * 1000,
+	JPEGQuality: 85,
+	Variants: []VariantSpec{
+		{Name: "thumb", MaxSide: 160},
+		{Name: "small", MaxSide: 480},
+		{Name: "medium", MaxSide: 1024},
+	},
+}
+
+// Image 处理后的图片
+type Image struct {
+	Name        string // 规格名称，原图为空
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// Result 处理结果
+type Result struct {
+	Original *Image
+	// 缩略图，只生成比原图小的规格
+	Variants []*Image
+}
+
+// Sniff 根据文件头识别图片格式
+func Sniff(head []byte) (string, error) {
+	switch contentType := http.DetectContentType(head); contentType {
+	case FormatJPEG, FormatPNG, FormatGIF:
+		return contentType, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// ReadAll 读取图片文件，超过 opts.MaxBytes 时返回 ErrTooLarge，最多只多读一个字节
+func ReadAll(r io.Reader, opts Options) ([]byte, error) {
+	if opts.MaxBytes <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, opts.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > opts.MaxBytes {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// Process 校验并重新编码图片，同时生成缩略图
+// 重新编码会丢弃 EXIF（含GPS定位）等全部元数据，JPEG 的方向信息会先应用到像素上
+func Process(r io.Reader, opts Options) (*Result, error) {
+	data, err := ReadAll(r, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType, err := Sniff(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先只解析文件头中的尺寸，避免解码超大图片
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	pixels := cfg.Width * cfg.Height
+	if contentType == FormatGIF {
+		// 每一帧都会解码为完整的图片，帧数很多的小尺寸 GIF 同样会耗尽内存
+		frames, err := gifFrameCount(data)
+		if err != nil {
+			return nil, err
+		}
+		pixels *= frames
+	}
+	if opts.MaxPixels > 0 && pixels > opts.MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	var (
+		original *Image
+		src      image.Image
+	)
+	switch contentType {
+	case FormatGIF:
+		// GIF 保留动画，逐帧重新编码时不会写入注释和应用扩展等元数据
+		g, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+		}
+		var buf bytes.Buffer
+		if err := gif.EncodeAll(&buf, &gif.GIF{Image: g.Image, Delay: g.Delay, LoopCount: g.LoopCount, Disposal: g.Disposal, Config: g.Config, BackgroundIndex: g.BackgroundIndex}); err != nil {
+			return nil, err
+		}
+		src = g.Image[0]
+		original = &Image{ContentType: FormatGIF, Ext: ".gif", Width: g.Config.Width, Height: g.Config.Height, Data: buf.Bytes()}
+
+	default:
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+		}
+		if contentType == FormatJPEG {
+			img = applyOrientation(img, jpegOrientation(data))
+		}
+		src = img
+		original, err = encode(img, contentType, opts.JPEGQuality)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := &Result{Original: original}
+	bounds := src.Bounds()
+	for _, spec := range opts.Variants {
+		if bounds.Dx() <= spec.MaxSide && bounds.Dy() <= spec.MaxSide {
+			continue
+		}
+		// 缩略图统一输出静态图片：带透明通道的格式使用 PNG，其余使用 JPEG
+		variantType := FormatJPEG
+		if contentType != FormatJPEG {
+			variantType = FormatPNG
+		}
+		variant, err := encode(Fit(src, spec.MaxSide), variantType, opts.JPEGQuality)
+		if err != nil {
+			return nil, err
+		}
+		variant.Name = spec.Name
+		result.Variants = append(result.Variants, variant)
+	}
+
+	return result, nil
+}
+
+// gifFrameCount 只遍历 GIF 的块结构统计帧数，不解码像素
+// 文件截断时返回已统计的帧数，由解码时报错
+func gifFrameCount(data []byte) (int, error) {
+	// 文件头 6 字节，逻辑屏幕描述符 7 字节，其后是可选的全局颜色表
+	if len(data) < 13 {
+		return 0, ErrUnsupportedFormat
+	}
+	pos := 13 + colorTableSize(data[10])
+	frames := 0
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x21: // 扩展：标签 1 字节，其后是数据子块
+			pos = skipSubBlocks(data, pos+2)
+		case 0x2C: // 图像描述符 10 字节，可选的局部颜色表，LZW 最小码长 1 字节，其后是数据子块
+			if pos+10 > len(data) {
+				return frames, nil
+			}
+			pos = skipSubBlocks(data, pos+10+colorTableSize(data[pos+9])+1)
+			frames++
+		case 0x3B: // 结束标记
+			return frames, nil
+		default:
+			return 0, fmt.Errorf("%w: gif: unknown block type %#x", ErrUnsupportedFormat, data[pos])
+		}
+	}
+	return frames, nil
+}
+
+// colorTableSize 根据描述符中的标志位计算颜色表的字节数
+func colorTableSize(flags byte) int {
+	if flags&0x80 == 0 {
+		return 0
+	}
+	return 3 << (flags&0x07 + 1)
+}
+
+// skipSubBlocks 跳过以长度为 0 的子块结尾的数据子块序列，返回其后的位置
+func skipSubBlocks(data []byte, pos int) int {
+	for pos < len(data) {
+		n := int(data[pos])
+		pos++
+		if n == 0 {
+			break
+		}
+		pos += n
+	}
+	return pos
+}
+
+// encode 编码图片
+func encode(img image.Image, contentType string, quality int) (*Image, error) {
+	var (
+		buf bytes.Buffer
+		ext string
+		err error
+	)
+	switch contentType {
+	case FormatJPEG:
+		ext = ".jpg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	case FormatPNG:
+		ext = ".png"
+		err = png.Encode(&buf, img)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Image{
+		ContentType: contentType,
+		Ext:         ext,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		Data:        buf.Bytes(),
+	}, nil
+}
+
+// Fit 等比缩放图片，使最长边不超过 maxSide，不会放大
+// 使用区域平均算法，缩小倍数较大时也能保持清晰且无锯齿
+func Fit(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return src
+	}
+	dw, dh := maxSide, maxSide
+	if w >= h {
+		dh = max(1, h*maxSide/w)
+	} else {
+		dw = max(1, w*maxSide/h)
+	}
+
+	// 统一转换为 NRGBA 以便按像素读取
+	rgba := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		sy0, sy1 := y*h/dh, max((y+1)*h/dh, y*h/dh+1)
+		for x := 0; x < dw; x++ {
+			sx0, sx1 := x*w/dw, max((x+1)*w/dw, x*w/dw+1)
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				off := sy*rgba.Stride + sx0*4
+				for sx := sx0; sx < sx1; sx++ {
+					pa := uint64(rgba.Pix[off+3])
+					// 按透明度加权，避免透明像素的颜色渗入边缘
+					r += uint64(rgba.Pix[off]) * pa
+					g += uint64(rgba.Pix[off+1]) * pa
+					bl += uint64(rgba.Pix[off+2]) * pa
+					a += pa
+					n++
+					off += 4
+				}
+			}
+			i := y*dst.Stride + x*4
+			if a > 0 {
+				dst.Pix[i] = uint8(r / a)
+				dst.Pix[i+1] = uint8(g / a)
+				dst.Pix[i+2] = uint8(bl / a)
+			}
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,95 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+)
+
+// testGIF 生成指定尺寸和帧数的 GIF
+func testGIF(t *testing.T, width, height, frames int) []byte {
+	t.Helper()
+	palette := color.Palette{color.Black, color.White}
+	g := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+		frame.SetColorIndex(i%width, 0, 1)
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGIFFrameCount(t *testing.T) {
+	for _, frames := range []int{1, 3, 40} {
+		n, err := gifFrameCount(testGIF(t, 16, 8, frames))
+		if err != nil || n != frames {
+			t.Errorf("gifFrameCount() = %d, %v, want %d", n, err, frames)
+		}
+	}
+	if _, err := gifFrameCount([]byte("GIF89a")); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("truncated header: err = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestProcessLimits(t *testing.T) {
+	opts := Options{MaxBytes: 64 << 10, MaxPixels: 100 * 100 * 10}
+	tests := []struct {
+		name    string
+		data    []byte
+		opts    Options
+		wantErr error
+	}{
+		{"small png", testPNG(t, 100, 100), opts, nil},
+		{"png over pixel limit", testPNG(t, 400, 400), opts, ErrTooLarge},
+		{"gif within frame budget", testGIF(t, 100, 100, 10), opts, nil},
+		// 每帧都不大，但帧数×尺寸超过限制
+		{"gif over frame budget", testGIF(t, 100, 100, 11), opts, ErrTooLarge},
+		// 超过文件大小限制时不解析内容
+		{"file over byte limit", append(testPNG(t, 10, 10), make([]byte, 64<<10)...), opts, ErrTooLarge},
+		{"no byte limit", append(testPNG(t, 10, 10), make([]byte, 64<<10)...), Options{}, nil},
+		{"not an image", []byte("hello, world"), opts, ErrUnsupportedFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Process(bytes.NewReader(tt.data), tt.opts)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("Process() = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Process() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadAllStopsAtLimit(t *testing.T) {
+	r := bytes.NewReader(make([]byte, 1000))
+	if _, err := ReadAll(r, Options{MaxBytes: 100}); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("ReadAll() = %v, want ErrTooLarge", err)
+	}
+	// 只多读一个字节用于判断是否超限
+	if read := 1000 - r.Len(); read != 101 {
+		t.Errorf("read %d bytes, want 101", read)
+	}
+	if data, err := ReadAll(bytes.NewReader(make([]byte, 100)), Options{MaxBytes: 100}); err != nil || len(data) != 100 {
+		t.Errorf("ReadAll() = %d bytes, %v", len(data), err)
+	}
+}
@@ -0,0 +1,82 @@
+package mailer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	// Send 发送纯文本邮件
+	Send(to, subject, body string) error
+}
+
+// New 根据配置创建邮件发送器，host 为空时只把邮件内容输出到日志，便于本地开发
+func New(host string, port int, username, password, from string) Mailer {
+	if host == "" {
+		return LogMailer{}
+	}
+	return NewSMTPMailer(host, port, username, password, from)
+}
+
+// SMTPMailer 通过SMTP服务器发送邮件
+// 本地调试可使用 MailHog 等SMTP测试服务（默认监听 1025 端口，无需认证）
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPMailer 创建SMTP邮件发送器，username 为空时不进行认证
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	m := &SMTPMailer{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: from,
+	}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+// Send 发送邮件，服务器支持时自动启用 STARTTLS
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{to}, buildMessage(m.from, to, subject, body))
+}
+
+// LogMailer 将邮件输出到日志，未配置SMTP服务器时使用
+type LogMailer struct{}
+
+// Send 输出邮件内容到日志
+func (LogMailer) Send(to, subject, body string) error {
+	log.Printf("未配置SMTP服务器，邮件未发送: to=%s subject=%s\n%s", to, subject, body)
+	return nil
+}
+
+// buildMessage 构造UTF-8编码的纯文本邮件
+func buildMessage(from, to, subject, body string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	// 正文按每行 76 个字符分行
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return buf.Bytes()
+}
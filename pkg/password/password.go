@@ -0,0 +1,122 @@
+package password
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// breachedList 内置的常见弱密码列表
+//
+//go:embed breached.txt
+var breachedList []byte
+
+// ValidationError 密码不符合策略，Message 可直接展示给用户
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return "password: " + e.Message
+}
+
+// Policy 密码策略
+type Policy struct {
+	minLength  int
+	maxLength  int
+	minClasses int
+	breached   map[string]struct{}
+}
+
+// NewPolicy 创建密码策略
+// minClasses 为小写字母、大写字母、数字、符号四类中至少包含的类别数；
+// checkBreached 为 true 时拒绝内置弱密码列表中的密码，extraFile 可追加自定义列表（每行一个，# 开头为注释）
+func NewPolicy(minLength, maxLength, minClasses int, checkBreached bool, extraFile string) (*Policy, error) {
+	p := &Policy{
+		minLength:  minLength,
+		maxLength:  maxLength,
+		minClasses: minClasses,
+	}
+	if !checkBreached {
+		return p, nil
+	}
+
+	p.breached = make(map[string]struct{})
+	p.load(bytes.NewReader(breachedList))
+	if extraFile != "" {
+		f, err := os.Open(extraFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if err := p.load(f); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Validate 校验密码是否符合策略，不符合时返回 *ValidationError
+func (p *Policy) Validate(password, username string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.minLength {
+		return &ValidationError{Message: fmt.Sprintf("密码长度不能少于%d个字符", p.minLength)}
+	}
+	if p.maxLength > 0 && length > p.maxLength {
+		return &ValidationError{Message: fmt.Sprintf("密码长度不能超过%d个字符", p.maxLength)}
+	}
+	if classes := characterClasses(password); classes < p.minClasses {
+		return &ValidationError{Message: fmt.Sprintf("密码至少需要包含小写字母、大写字母、数字、符号中的%d类", p.minClasses)}
+	}
+	if username != "" && strings.EqualFold(password, username) {
+		return &ValidationError{Message: "密码不能与用户名相同"}
+	}
+	if _, ok := p.breached[strings.ToLower(password)]; ok {
+		return &ValidationError{Message: "密码过于常见，容易被猜到，请更换"}
+	}
+	return nil
+}
+
+// load 读取弱密码列表
+func (p *Policy) load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.breached[strings.ToLower(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// characterClasses 统计密码包含的字符类别数
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, ok := range []bool{lower, upper, digit, symbol} {
+		if ok {
+			count++
+		}
+	}
+	return count
+}
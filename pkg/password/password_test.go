@@ -0,0 +1,81 @@
+package password
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	policy, err := NewPolicy(8, 20, 3, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lenient, err := NewPolicy(4, 0, 1, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		policy   *Policy
+		password string
+		username string
+		wantErr  bool
+	}{
+		{"valid", policy, "Blue-Horse-42", "alice", false},
+		{"too short", policy, "Ab1-", "alice", true},
+		// 长度按字符计算而不是字节
+		{"multibyte at minimum length", policy, "密码Ab1-密码", "alice", false},
+		{"too long", policy, "Blue-Horse-42-Blue-Horse", "alice", true},
+		{"two classes", policy, "bluehorse42", "alice", true},
+		{"three classes", policy, "Bluehorse42", "alice", false},
+		{"same as username", policy, "Alice-2024", "alice-2024", true},
+		{"breached password with a suffix", policy, "Password1!", "alice", false},
+		// 弱密码列表不区分大小写
+		{"breached in another case", policy, "PassWord1", "alice", true},
+		{"breached list disabled", lenient, "admin123", "", false},
+		{"no maximum length", lenient, "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.password, tt.username)
+			var validationErr *ValidationError
+			if tt.wantErr && !errors.As(err, &validationErr) {
+				t.Errorf("Validate(%q) = %v, want *ValidationError", tt.password, err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Validate(%q) = %v", tt.password, err)
+			}
+		})
+	}
+}
+
+func TestBreachedList(t *testing.T) {
+	extra := filepath.Join(t.TempDir(), "extra.txt")
+	if err := os.WriteFile(extra, []byte("# 公司内部常见密码\n\nCompany-2024\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := NewPolicy(1, 0, 1, true, extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, password := range []string{"admin123", "password1", "company-2024"} {
+		if err := policy.Validate(password, ""); err == nil {
+			t.Errorf("Validate(%q) = nil, want breached", password)
+		}
+	}
+	// 注释行不是密码
+	if err := policy.Validate("# 公司内部常见密码", ""); err != nil {
+		t.Errorf("comment line: %v", err)
+	}
+
+	if _, err := NewPolicy(1, 0, 1, true, filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("missing extra file: want error")
+	}
+	// 不检查弱密码时忽略自定义列表
+	if _, err := NewPolicy(1, 0, 1, false, filepath.Join(t.TempDir(), "missing.txt")); err != nil {
+		t.Errorf("missing extra file without breached check: %v", err)
+	}
+}
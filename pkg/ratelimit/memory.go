@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore 进程内存计数存储，适用于单实例部署
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+// memoryEntry 内存计数
+type memoryEntry struct {
+	value     int64
+	expiresAt time.Time
+}
+
+// NewMemoryStore 创建内存计数存储，并按 cleanupInterval 定时清理过期计数
+func NewMemoryStore(cleanupInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{entries: make(map[string]*memoryEntry)}
+	if cleanupInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(cleanupInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				s.cleanup()
+			}
+		}()
+	}
+	return s
+}
+
+// Incr 计数加一
+func (s *MemoryStore) Incr(_ context.Context, key string, ttl time.Duration) (int64, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	e := s.get(key, now)
+	if e == nil {
+		e = &memoryEntry{expiresAt: now.Add(ttl)}
+		s.entries[key] = e
+	}
+	e.value++
+	return e.value, e.expiresAt.Sub(now), nil
+}
+
+// Get 获取计数
+func (s *MemoryStore) Get(_ context.Context, key string) (int64, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if e := s.get(key, now); e != nil {
+		return e.value, e.expiresAt.Sub(now), nil
+	}
+	return 0, 0, nil
+}
+
+// Set 设置计数
+func (s *MemoryStore) Set(_ context.Context, key string, value int64, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = &memoryEntry{value: value, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// Expire 重新设置有效期
+func (s *MemoryStore) Expire(_ context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if e := s.get(key, now); e != nil {
+		e.expiresAt = now.Add(ttl)
+	}
+	return nil
+}
+
+// Delete 删除计数
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// get 获取未过期的计数，调用方需持有锁
+func (s *MemoryStore) get(key string, now time.Time) *memoryEntry {
+	e, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if !now.Before(e.expiresAt) {
+		delete(s.entries, key)
+		return nil
+	}
+	return e
+}
+
+// cleanup 清理过期计数
+func (s *MemoryStore) cleanup() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Store 计数存储
+// 默认使用进程内存，多副本部署时使用 Redis 在各实例间共享计数
+type Store interface {
+	// Incr 计数加一并返回当前计数和剩余有效期，计数首次创建时设置有效期
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, time.Duration, error)
+
+	// Get 获取计数和剩余有效期，不存在时返回 0
+	Get(ctx context.Context, key string) (int64, time.Duration, error)
+
+	// Set 设置计数和有效期
+	Set(ctx context.Context, key string, value int64, ttl time.Duration) error
+
+	// Expire 重新设置计数的有效期，不存在时忽略
+	Expire(ctx context.Context, key string, ttl time.Duration) error
+
+	// Delete 删除计数
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStore 根据配置创建计数存储，redisURL 为空时使用内存存储
+// redisURL 格式如 redis://:password@localhost:6379/0
+func NewStore(redisURL string) (Store, error) {
+	if redisURL == "" {
+		return NewMemoryStore(time.Minute), nil
+	}
+	return NewRedisStore(redisURL)
+}
+
+// Result 限流检查结果
+type Result struct {
+	Allowed   bool          // 是否放行
+	Limit     int64         // 窗口内允许的请求数
+	Remaining int64         // 窗口内剩余的请求数
+	Reset     time.Duration // 距离窗口重置的时间
+}
+
+// Limiter 固定窗口限流器
+// 每个键在窗口内最多放行 limit 次，窗口从第一次请求开始计时
+type Limiter struct {
+	store  Store
+	prefix string
+	limit  int64
+	window time.Duration
+}
+
+// NewLimiter 创建限流器，prefix 用于区分不同用途的计数
+func NewLimiter(store Store, prefix string, limit int64, window time.Duration) *Limiter {
+	return &Limiter{store: store, prefix: prefix, limit: limit, window: window}
+}
+
+// Allow 记录一次请求并返回是否放行
+func (l *Limiter) Allow(ctx context.Context, key string) (*Result, error) {
+	count, ttl, err := l.store.Incr(ctx, l.prefix+key, l.window)
+	if err != nil {
+		return nil, err
+	}
+	remaining := l.limit - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &Result{
+		Allowed:   count <= l.limit,
+		Limit:     l.limit,
+		Remaining: remaining,
+		Reset:     ttl,
+	}, nil
+}
+
+// Reset 清除键的计数
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	return l.store.Delete(ctx, l.prefix+key)
+}
@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreWindow(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(0)
+
+	for want := int64(1); want <= 3; want++ {
+		count, ttl, err := s.Incr(ctx, "k", 50*time.Millisecond)
+		if err != nil || count != want {
+			t.Fatalf("Incr() = %d, %v, want %d", count, err, want)
+		}
+		if ttl <= 0 || ttl > 50*time.Millisecond {
+			t.Errorf("ttl = %s", ttl)
+		}
+	}
+	// 有效期从第一次计数开始，之后的计数不会延长窗口
+	if _, ttl, _ := s.Incr(ctx, "k", time.Hour); ttl > 50*time.Millisecond {
+		t.Errorf("ttl after later Incr = %s, want the original window", ttl)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if count, ttl, _ := s.Get(ctx, "k"); count != 0 || ttl != 0 {
+		t.Errorf("Get() after window = %d, %s, want 0", count, ttl)
+	}
+	if count, _, _ := s.Incr(ctx, "k", time.Minute); count != 1 {
+		t.Errorf("Incr() after window = %d, want a new window", count)
+	}
+}
+
+func TestMemoryStoreSetExpireDelete(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(0)
+
+	if err := s.Set(ctx, "k", 5, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if count, ttl, _ := s.Get(ctx, "k"); count != 5 || ttl <= 0 {
+		t.Errorf("Get() = %d, %s", count, ttl)
+	}
+
+	// Expire 重新计时
+	s.Expire(ctx, "k", 20*time.Millisecond)
+	if _, ttl, _ := s.Get(ctx, "k"); ttl > 20*time.Millisecond {
+		t.Errorf("ttl after Expire = %s", ttl)
+	}
+	// 不存在的键忽略 Expire，不会创建计数
+	s.Expire(ctx, "missing", time.Minute)
+	if count, _, _ := s.Get(ctx, "missing"); count != 0 {
+		t.Errorf("Expire created key with count %d", count)
+	}
+
+	s.Delete(ctx, "k")
+	if count, _, _ := s.Get(ctx, "k"); count != 0 {
+		t.Errorf("Get() after Delete = %d", count)
+	}
+}
+
+func TestMemoryStoreCleanup(t *testing.T) {
+	s := NewMemoryStore(0)
+	s.Set(context.Background(), "expired", 1, time.Millisecond)
+	s.Set(context.Background(), "live", 1, time.Minute)
+	time.Sleep(5 * time.Millisecond)
+	s.cleanup()
+	if _, ok := s.entries["expired"]; ok {
+		t.Error("expired entry not cleaned up")
+	}
+	if _, ok := s.entries["live"]; !ok {
+		t.Error("live entry cleaned up")
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(0)
+	limiter := NewLimiter(store, "api:", 3, 50*time.Millisecond)
+
+	tests := []struct {
+		key       string
+		allowed   bool
+		remaining int64
+	}{
+		{"a", true, 2},
+		{"a", true, 1},
+		{"a", true, 0},
+		{"a", false, 0},
+		{"a", false, 0},
+		// 不同的键分别计数
+		{"b", true, 2},
+	}
+	for i, tt := range tests {
+		result, err := limiter.Allow(ctx, tt.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Allowed != tt.allowed || result.Remaining != tt.remaining || result.Limit != 3 {
+			t.Errorf("request %d: %+v, want allowed %v remaining %d", i, result, tt.allowed, tt.remaining)
+		}
+	}
+	// 计数带有前缀，不同用途的限流器互不影响
+	if count, _, _ := store.Get(ctx, "api:a"); count != 5 {
+		t.Errorf("stored count = %d, want 5", count)
+	}
+
+	if err := limiter.Reset(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := limiter.Allow(ctx, "a"); !result.Allowed || result.Remaining != 2 {
+		t.Errorf("after Reset: %+v", result)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if result, _ := limiter.Allow(ctx, "b"); !result.Allowed || result.Remaining != 2 {
+		t.Errorf("after window: %+v", result)
+	}
+}
@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// incrScript 计数加一，首次创建时设置有效期，返回计数和剩余有效期（毫秒）
+var incrScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return {n, redis.call('PTTL', KEYS[1])}
+`)
+
+// RedisStore Redis 计数存储，多副本部署时各实例共享计数
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore 连接 Redis 并创建计数存储
+func NewRedisStore(redisURL string) (*RedisStore, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &RedisStore{client: client}, nil
+}
+
+// Incr 计数加一
+func (s *RedisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, time.Duration, error) {
+	res, err := incrScript.Run(ctx, s.client, []string{key}, ttl.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	return res[0], time.Duration(res[1]) * time.Millisecond, nil
+}
+
+// Get 获取计数
+func (s *RedisStore) Get(ctx context.Context, key string) (int64, time.Duration, error) {
+	pipe := s.client.Pipeline()
+	get := pipe.Get(ctx, key)
+	pttl := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return 0, 0, err
+	}
+
+	value, err := get.Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, 0, nil
+		}
+		return 0, 0, err
+	}
+	ttl := pttl.Val()
+	if ttl < 0 {
+		ttl = 0
+	}
+	return value, ttl, nil
+}
+
+// Set 设置计数
+func (s *RedisStore) Set(ctx context.Context, key string, value int64, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Expire 重新设置有效期
+func (s *RedisStore) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	return s.client.PExpire(ctx, key, ttl).Err()
+}
+
+// Delete 删除计数
+func (s *RedisStore) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key).Err()
+}
@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 时钟，测试中可以使用 FakeClock 控制时间
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer 定时器
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// SystemClock 系统时钟
+var SystemClock Clock = systemClock{}
+
+// systemClock 使用 time 包的系统时钟
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTimer(d time.Duration) Timer { return systemTimer{time.NewTimer(d)} }
+
+// systemTimer 包装 time.Timer
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time { return t.timer.C }
+
+func (t systemTimer) Stop() bool { return t.timer.Stop() }
+
+// FakeClock 手动推进的时钟，用于测试；时间只在调用 Advance 或 Set 时变化，到期的定时器随之触发
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock 创建从 now 开始的手动时钟
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now 当前时间
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer 创建在 d 之后触发的定时器，d 不大于 0 时立即触发
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, at: c.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.ch <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance 将时间推进 d，并触发到期的定时器
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	now := c.now.Add(d)
+	c.mu.Unlock()
+	c.Set(now)
+}
+
+// Set 将时间设置为 t，并触发到期的定时器
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = t
+	pending := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.at.After(t) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.ch <- t
+	}
+	c.timers = pending
+}
+
+// Timers 尚未触发的定时器数量，测试中可以据此等待调度器进入等待状态
+func (c *FakeClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// fakeTimer FakeClock 的定时器
+type fakeTimer struct {
+	clock *FakeClock
+	at    time.Time
+	ch    chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+// Stop 停止定时器，定时器尚未触发时返回 true
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	for i, timer := range t.clock.timers {
+		if timer == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
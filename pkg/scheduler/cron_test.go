@@ -0,0 +1,160 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRejectsInvalidSpecs(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@every 500ms",
+		"@every soon",
+		"@fortnightly",
+	}
+	for _, spec := range specs {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	utc := func(year int, month time.Month, day, hour, minute, sec int) time.Time {
+		return time.Date(year, month, day, hour, minute, sec, 0, time.UTC)
+	}
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		// 步长与严格晚于 t
+		{"*/15 * * * *", utc(2026, 10, 19, 10, 7, 0), utc(2026, 10, 19, 10, 15, 0)},
+		{"*/15 * * * *", utc(2026, 10, 19, 10, 15, 0), utc(2026, 10, 19, 10, 30, 0)},
+		{"*/15 * * * *", utc(2026, 10, 19, 10, 14, 59), utc(2026, 10, 19, 10, 15, 0)},
+		{"*/15 * * * *", utc(2026, 10, 19, 23, 50, 0), utc(2026, 10, 20, 0, 0, 0)},
+		{"5-20/5 * * * *", utc(2026, 10, 19, 10, 0, 0), utc(2026, 10, 19, 10, 5, 0)},
+		{"5-20/5 * * * *", utc(2026, 10, 19, 10, 20, 0), utc(2026, 10, 19, 11, 5, 0)},
+		{"10/20 * * * *", utc(2026, 10, 19, 10, 31, 0), utc(2026, 10, 19, 10, 50, 0)},
+		{"0,30 8-9 * * *", utc(2026, 10, 19, 9, 30, 0), utc(2026, 10, 20, 8, 0, 0)},
+		// 工作日
+		{"0 9 * * 1-5", utc(2026, 10, 16, 10, 0, 0), utc(2026, 10, 19, 9, 0, 0)},
+		{"0 9 * * mon-fri", utc(2026, 10, 16, 8, 0, 0), utc(2026, 10, 16, 9, 0, 0)},
+		// 周日可以写作 0 或 7
+		{"0 0 * * 7", utc(2026, 10, 19, 0, 0, 0), utc(2026, 10, 25, 0, 0, 0)},
+		{"0 0 * * 0", utc(2026, 10, 19, 0, 0, 0), utc(2026, 10, 25, 0, 0, 0)},
+		// 日和周都有限制时满足其一即可
+		{"0 0 13 * fri", utc(2026, 10, 1, 0, 0, 0), utc(2026, 10, 2, 0, 0, 0)},
+		{"0 0 13 * fri", utc(2026, 10, 9, 0, 0, 0), utc(2026, 10, 13, 0, 0, 0)},
+		// 月份和日期的跨度
+		{"0 0 1 * *", utc(2026, 12, 15, 0, 0, 0), utc(2027, 1, 1, 0, 0, 0)},
+		{"0 0 31 * *", utc(2026, 4, 1, 0, 0, 0), utc(2026, 5, 31, 0, 0, 0)},
+		{"0 12 * jan,jul *", utc(2026, 10, 19, 0, 0, 0), utc(2027, 1, 1, 12, 0, 0)},
+		{"0 0 29 2 *", utc(2026, 3, 1, 0, 0, 0), utc(2028, 2, 29, 0, 0, 0)},
+		// 永不执行
+		{"0 0 30 2 *", utc(2026, 1, 1, 0, 0, 0), time.Time{}},
+		// 预定义的执行计划
+		{"@hourly", utc(2026, 10, 19, 10, 30, 0), utc(2026, 10, 19, 11, 0, 0)},
+		{"@daily", utc(2026, 10, 19, 10, 30, 0), utc(2026, 10, 20, 0, 0, 0)},
+		{"@weekly", utc(2026, 10, 19, 10, 30, 0), utc(2026, 10, 25, 0, 0, 0)},
+		{"@monthly", utc(2026, 10, 19, 10, 30, 0), utc(2026, 11, 1, 0, 0, 0)},
+		{"@yearly", utc(2026, 10, 19, 10, 30, 0), utc(2027, 1, 1, 0, 0, 0)},
+		// @every 对齐到间隔的整数倍
+		{"@every 15m", utc(2026, 10, 19, 10, 7, 30), utc(2026, 10, 19, 10, 15, 0)},
+		{"@every 15m", utc(2026, 10, 19, 10, 15, 0), utc(2026, 10, 19, 10, 30, 0)},
+		{"@every 1h", utc(2026, 10, 19, 10, 59, 59), utc(2026, 10, 19, 11, 0, 0)},
+	}
+	for _, tt := range tests {
+		schedule, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%v) = %v, want %v", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestNextUsesLocationOfT(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedule, err := Parse("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// UTC 2026-10-19 02:00 为上海 10:00，下一次为上海次日 9:00
+	got := schedule.Next(time.Date(2026, 10, 19, 2, 0, 0, 0, time.UTC).In(shanghai))
+	if want := time.Date(2026, 10, 20, 9, 0, 0, 0, shanghai); !got.Equal(want) {
+		t.Errorf("Next = %v, want %v", got, want)
+	}
+}
+
+func TestNextAcrossDaylightSaving(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		// 夏令时开始当天 02:30 不存在，跳过当天
+		{"30 2 * * *", time.Date(2026, 3, 8, 0, 0, 0, 0, newYork), time.Date(2026, 3, 9, 2, 30, 0, 0, newYork)},
+		// 当天的其他时间不受影响，且按当地时间计算
+		{"0 9 * * *", time.Date(2026, 3, 7, 12, 0, 0, 0, newYork), time.Date(2026, 3, 8, 9, 0, 0, 0, newYork)},
+		{"0 9 * * *", time.Date(2026, 10, 31, 12, 0, 0, 0, newYork), time.Date(2026, 11, 1, 9, 0, 0, 0, newYork)},
+		// 夏令时结束当天 01:30 出现两次，只执行第一次
+		{"30 1 * * *", time.Date(2026, 11, 1, 0, 0, 0, 0, newYork), time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		schedule, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%v) = %v, want %v", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestNextAcrossMidnightDaylightSaving(t *testing.T) {
+	// 圣地亚哥 2026-09-06 00:00 夏令时开始，当天 00:00-01:00 不存在
+	santiago, err := time.LoadLocation("America/Santiago")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"0 12 * * *", time.Date(2026, 9, 5, 13, 0, 0, 0, santiago), time.Date(2026, 9, 6, 12, 0, 0, 0, santiago)},
+		{"0 0 * * *", time.Date(2026, 9, 5, 13, 0, 0, 0, santiago), time.Date(2026, 9, 7, 0, 0, 0, 0, santiago)},
+		{"0 12 * oct *", time.Date(2026, 9, 5, 13, 0, 0, 0, santiago), time.Date(2026, 10, 1, 12, 0, 0, 0, santiago)},
+	}
+	for _, tt := range tests {
+		schedule, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%v) = %v, want %v", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore 进程内存的任务锁和运行记录存储，适用于单实例部署和测试
+type MemoryStore struct {
+	mu    sync.Mutex
+	locks map[string]*memoryLock
+	runs  []*MemoryRun
+}
+
+// memoryLock 内存任务锁
+type memoryLock struct {
+	owner    string
+	until    time.Time
+	lastSlot time.Time
+}
+
+// MemoryRun 内存中的运行记录
+type MemoryRun struct {
+	ID         uint64
+	Job        string
+	Trigger    string
+	Owner      string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Processed  int64
+	Err        error
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{locks: make(map[string]*memoryLock)}
+}
+
+// Lock 获取任务锁
+func (m *MemoryStore) Lock(_ context.Context, job, owner string, slot, now, until time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l, ok := m.locks[job]
+	if !ok {
+		l = &memoryLock{}
+		m.locks[job] = l
+	}
+	if l.until.After(now) {
+		return false, nil
+	}
+	if !slot.IsZero() {
+		if !slot.After(l.lastSlot) {
+			return false, nil
+		}
+		l.lastSlot = slot
+	}
+	l.owner, l.until = owner, until
+	return true, nil
+}
+
+// Unlock 释放任务锁
+func (m *MemoryStore) Unlock(_ context.Context, job, owner string, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if l, ok := m.locks[job]; ok && l.owner == owner {
+		l.until = now
+	}
+	return nil
+}
+
+// RunStarted 记录任务开始运行
+func (m *MemoryStore) RunStarted(_ context.Context, job, trigger, owner string, at time.Time) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	run := &MemoryRun{ID: uint64(len(m.runs) + 1), Job: job, Trigger: trigger, Owner: owner, StartedAt: at}
+	m.runs = append(m.runs, run)
+	return run.ID, nil
+}
+
+// RunFinished 记录任务运行结束
+func (m *MemoryStore) RunFinished(_ context.Context, runID uint64, at time.Time, processed int64, runErr error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if runID == 0 || runID > uint64(len(m.runs)) {
+		return nil
+	}
+	run := m.runs[runID-1]
+	run.FinishedAt, run.Processed, run.Err = at, processed, runErr
+	return nil
+}
+
+// Runs 返回所有运行记录的副本，按开始顺序排列
+func (m *MemoryStore) Runs() []MemoryRun {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	runs := make([]MemoryRun, len(m.runs))
+	for i, run := range m.runs {
+		runs[i] = *run
+	}
+	return runs
+}
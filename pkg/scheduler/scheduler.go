@@ -0,0 +1,290 @@
+// Package scheduler 进程内定时任务调度：按 cron 表达式执行任务，通过 Store 加锁保证多实例部署时每次执行只在一个实例上运行，并记录运行历史
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrJobNotFound 任务不存在
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobExists 同名任务已注册
+	ErrJobExists = errors.New("job already registered")
+	// ErrJobRunning 任务正在本实例或其他实例上运行
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// 任务的触发方式
+const (
+	TriggerSchedule = "schedule" // 按执行计划触发
+	TriggerManual   = "manual"   // 手动触发
+)
+
+// JobFunc 任务函数，返回处理的数量（如清理的记录数）；ctx 在锁到期前或调度器停止时取消
+type JobFunc func(ctx context.Context) (int64, error)
+
+// Store 任务锁和运行记录的存储，多实例部署时需要使用共享存储（如数据库）
+type Store interface {
+	// Lock 获取任务锁直到 until，锁被其他实例持有且未到期时返回 false；
+	// slot 不为零值时为按计划执行的计划时间，同一计划时间只有一个实例可以获取锁（即使先获取的实例已经运行完成）
+	Lock(ctx context.Context, job, owner string, slot, now, until time.Time) (bool, error)
+	// Unlock 释放本实例持有的任务锁
+	Unlock(ctx context.Context, job, owner string, now time.Time) error
+	// RunStarted 记录任务开始运行，返回运行记录ID
+	RunStarted(ctx context.Context, job, trigger, owner string, at time.Time) (uint64, error)
+	// RunFinished 记录任务运行结束，runErr 为空表示成功
+	RunFinished(ctx context.Context, runID uint64, at time.Time, processed int64, runErr error) error
+}
+
+// JobInfo 已注册任务的信息
+type JobInfo struct {
+	Name        string
+	Description string
+	Spec        string    // 执行计划，为空表示只能手动触发
+	NextRun     time.Time // 下一次计划执行时间，没有执行计划或调度器未启动时为零值
+	Running     bool      // 是否正在本实例上运行
+}
+
+// job 已注册的任务
+type job struct {
+	name        string
+	description string
+	spec        string
+	schedule    Schedule
+	fn          JobFunc
+	next        time.Time
+	running     bool
+}
+
+// Scheduler 定时任务调度器
+type Scheduler struct {
+	clock   Clock
+	store   Store
+	owner   string
+	lockTTL time.Duration
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	started bool
+	wake    chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New 创建调度器
+// owner 为本实例的标识，记录在锁和运行记录中；lockTTL 为任务锁的有效期，也是单次运行的最长时间，实例在运行中退出时锁到期后其他实例可以接管
+func New(clock Clock, store Store, owner string, lockTTL time.Duration) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		clock:   clock,
+		store:   store,
+		owner:   owner,
+		lockTTL: lockTTL,
+		jobs:    make(map[string]*job),
+		wake:    make(chan struct{}, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Register 注册任务，spec 为执行计划（格式见 Parse），为空表示只能手动触发
+func (s *Scheduler) Register(name, description, spec string, fn JobFunc) error {
+	var schedule Schedule
+	if spec != "" {
+		var err error
+		if schedule, err = Parse(spec); err != nil {
+			return fmt.Errorf("job %s: %w", name, err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("job %s: %w", name, ErrJobExists)
+	}
+	s.jobs[name] = &job{name: name, description: description, spec: spec, schedule: schedule, fn: fn}
+	s.notify()
+	return nil
+}
+
+// Start 启动调度，按执行计划运行任务；未启动时任务仍然可以手动触发
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	s.wg.Add(1)
+	go s.loop()
+}
+
+// Stop 停止调度，取消正在运行的任务的 ctx 并等待其结束
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// Jobs 返回所有已注册的任务，按名称排序
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]JobInfo, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		infos = append(infos, JobInfo{
+			Name:        j.name,
+			Description: j.description,
+			Spec:        j.spec,
+			NextRun:     j.next,
+			Running:     j.running,
+		})
+	}
+	sort.Slice(infos, func(a, b int) bool { return infos[a].Name < infos[b].Name })
+	return infos
+}
+
+// Trigger 立即在后台运行任务，返回运行记录ID；任务正在运行时返回 ErrJobRunning
+func (s *Scheduler) Trigger(name string) (uint64, error) {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return 0, ErrJobNotFound
+	}
+	return s.start(j, TriggerManual, time.Time{})
+}
+
+// notify 唤醒调度循环重新计算下一次执行时间，调用方需持有 s.mu
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// loop 调度循环：运行到期的任务，然后等待到最近的下一次执行时间
+func (s *Scheduler) loop() {
+	defer s.wg.Done()
+	for {
+		now := s.clock.Now()
+		var next time.Time
+		s.mu.Lock()
+		for _, j := range s.jobs {
+			if j.schedule == nil {
+				continue
+			}
+			if j.next.IsZero() {
+				j.next = j.schedule.Next(now)
+			} else if !j.next.After(now) {
+				s.wg.Add(1)
+				go func(j *job, slot time.Time) {
+					defer s.wg.Done()
+					if _, err := s.start(j, TriggerSchedule, slot); err != nil && !errors.Is(err, ErrJobRunning) {
+						log.Printf("启动定时任务 %s 失败: %v", j.name, err)
+					}
+				}(j, j.next)
+				j.next = j.schedule.Next(now)
+			}
+			if !j.next.IsZero() && (next.IsZero() || j.next.Before(next)) {
+				next = j.next
+			}
+		}
+		s.mu.Unlock()
+
+		var timer Timer
+		var fire <-chan time.Time
+		if !next.IsZero() {
+			timer = s.clock.NewTimer(next.Sub(now))
+			fire = timer.C()
+		}
+		select {
+		case <-fire:
+		case <-s.wake:
+		case <-s.ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// start 获取任务锁并在后台运行任务，返回运行记录ID
+func (s *Scheduler) start(j *job, trigger string, slot time.Time) (uint64, error) {
+	s.mu.Lock()
+	if j.running {
+		s.mu.Unlock()
+		return 0, ErrJobRunning
+	}
+	j.running = true
+	s.mu.Unlock()
+	release := func() {
+		s.mu.Lock()
+		j.running = false
+		s.mu.Unlock()
+	}
+
+	now := s.clock.Now()
+	locked, err := s.store.Lock(s.ctx, j.name, s.owner, slot, now, now.Add(s.lockTTL))
+	if err != nil || !locked {
+		release()
+		if err == nil {
+			err = ErrJobRunning
+		}
+		return 0, err
+	}
+	runID, err := s.store.RunStarted(s.ctx, j.name, trigger, s.owner, now)
+	if err != nil {
+		s.unlock(j.name)
+		release()
+		return 0, err
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer release()
+
+		processed, runErr := s.run(j)
+		if runErr != nil {
+			log.Printf("定时任务 %s 运行失败: %v", j.name, runErr)
+		}
+		// 调度器停止时 s.ctx 已取消，运行结果仍需写入
+		if err := s.store.RunFinished(context.Background(), runID, s.clock.Now(), processed, runErr); err != nil {
+			log.Printf("记录定时任务 %s 的运行结果失败: %v", j.name, err)
+		}
+		s.unlock(j.name)
+	}()
+	return runID, nil
+}
+
+// run 运行任务函数，任务 panic 时作为失败处理
+func (s *Scheduler) run(j *job) (processed int64, err error) {
+	ctx, cancel := context.WithTimeout(s.ctx, s.lockTTL)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return j.fn(ctx)
+}
+
+// unlock 释放任务锁，失败时只记录日志，锁到期后自动失效
+func (s *Scheduler) unlock(name string) {
+	if err := s.store.Unlock(context.Background(), name, s.owner, s.clock.Now()); err != nil {
+		log.Printf("释放定时任务 %s 的锁失败: %v", name, err)
+	}
+}
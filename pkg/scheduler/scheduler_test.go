@@ -0,0 +1,251 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor 等待条件成立，调度在后台 goroutine 中进行，只能轮询
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// finishedRuns 已结束的运行记录数量
+func finishedRuns(store *MemoryStore) int {
+	n := 0
+	for _, run := range store.Runs() {
+		if !run.FinishedAt.IsZero() {
+			n++
+		}
+	}
+	return n
+}
+
+// countingStore 记录 Lock 的调用次数
+type countingStore struct {
+	*MemoryStore
+	locks atomic.Int64
+}
+
+func (s *countingStore) Lock(ctx context.Context, job, owner string, slot, now, until time.Time) (bool, error) {
+	defer s.locks.Add(1)
+	return s.MemoryStore.Lock(ctx, job, owner, slot, now, until)
+}
+
+var baseTime = time.Date(2026, 10, 19, 9, 59, 0, 0, time.UTC)
+
+func TestScheduledJobRunsOncePerSlot(t *testing.T) {
+	clock := NewFakeClock(baseTime)
+	store := NewMemoryStore()
+	s := New(clock, store, "a", time.Minute)
+	defer s.Stop()
+
+	var calls atomic.Int64
+	if err := s.Register("cleanup", "", "@hourly", func(context.Context) (int64, error) {
+		calls.Add(1)
+		return 3, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	waitFor(t, "scheduler to wait for the first slot", func() bool { return clock.Timers() == 1 })
+	if next := s.Jobs()[0].NextRun; !next.Equal(baseTime.Add(time.Minute)) {
+		t.Fatalf("NextRun = %v", next)
+	}
+
+	clock.Advance(time.Minute)
+	waitFor(t, "first run", func() bool { return finishedRuns(store) == 1 })
+	waitFor(t, "scheduler to wait for the next slot", func() bool { return clock.Timers() == 1 })
+
+	// 同一小时内时间推进不会再次执行
+	clock.Advance(30 * time.Minute)
+	waitFor(t, "scheduler to wait for the next slot", func() bool { return clock.Timers() == 1 })
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("calls = %d, want 1", n)
+	}
+	if next := s.Jobs()[0].NextRun; !next.Equal(baseTime.Add(61 * time.Minute)) {
+		t.Fatalf("NextRun = %v", next)
+	}
+
+	clock.Advance(30 * time.Minute)
+	waitFor(t, "second run", func() bool { return finishedRuns(store) == 2 })
+
+	runs := store.Runs()
+	for _, run := range runs {
+		if run.Job != "cleanup" || run.Trigger != TriggerSchedule || run.Owner != "a" || run.Processed != 3 || run.Err != nil {
+			t.Errorf("run = %+v", run)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("calls = %d, want 2", n)
+	}
+}
+
+func TestSharedStoreRunsSlotOnce(t *testing.T) {
+	clock := NewFakeClock(baseTime)
+	store := &countingStore{MemoryStore: NewMemoryStore()}
+
+	var calls atomic.Int64
+	job := func(context.Context) (int64, error) {
+		calls.Add(1)
+		return 0, nil
+	}
+	for _, owner := range []string{"a", "b"} {
+		s := New(clock, store, owner, time.Minute)
+		if err := s.Register("cleanup", "", "*/5 * * * *", job); err != nil {
+			t.Fatal(err)
+		}
+		s.Start()
+		defer s.Stop()
+	}
+	waitFor(t, "both schedulers to wait", func() bool { return clock.Timers() == 2 })
+
+	for slot := 1; slot <= 3; slot++ {
+		clock.Advance(5 * time.Minute)
+		waitFor(t, "both schedulers to try the slot", func() bool { return store.locks.Load() == int64(2*slot) })
+		waitFor(t, "the run to finish", func() bool { return finishedRuns(store.MemoryStore) == slot })
+		waitFor(t, "both schedulers to wait", func() bool { return clock.Timers() == 2 })
+	}
+
+	if n := calls.Load(); n != 3 {
+		t.Errorf("calls = %d, want 3 (one per slot)", n)
+	}
+	if runs := store.Runs(); len(runs) != 3 {
+		t.Errorf("runs = %d, want 3", len(runs))
+	}
+}
+
+func TestTriggerWhileRunning(t *testing.T) {
+	clock := NewFakeClock(baseTime)
+	store := NewMemoryStore()
+	a := New(clock, store, "a", time.Minute)
+	b := New(clock, store, "b", time.Minute)
+	defer a.Stop()
+	defer b.Stop()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	job := func(ctx context.Context) (int64, error) {
+		started <- struct{}{}
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return 0, nil
+	}
+	for _, s := range []*Scheduler{a, b} {
+		if err := s.Register("export", "", "", job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := a.Trigger("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Trigger unknown job: err = %v, want ErrJobNotFound", err)
+	}
+
+	runID, err := a.Trigger("export")
+	if err != nil || runID == 0 {
+		t.Fatalf("Trigger = %d, %v", runID, err)
+	}
+	<-started
+	if !a.Jobs()[0].Running {
+		t.Error("job not reported as running")
+	}
+	if _, err := a.Trigger("export"); !errors.Is(err, ErrJobRunning) {
+		t.Errorf("Trigger on the same instance: err = %v, want ErrJobRunning", err)
+	}
+	if _, err := b.Trigger("export"); !errors.Is(err, ErrJobRunning) {
+		t.Errorf("Trigger on another instance: err = %v, want ErrJobRunning", err)
+	}
+
+	close(release)
+	waitFor(t, "run to finish", func() bool { return finishedRuns(store) == 1 && !a.Jobs()[0].Running })
+
+	// 运行结束后可以再次触发，包括其他实例
+	go func() { <-started }()
+	if _, err := b.Trigger("export"); err != nil {
+		t.Errorf("Trigger after finish: %v", err)
+	}
+	waitFor(t, "second run to finish", func() bool { return finishedRuns(store) == 2 })
+	if runs := store.Runs(); runs[0].Trigger != TriggerManual || runs[1].Owner != "b" {
+		t.Errorf("runs = %+v", runs)
+	}
+}
+
+func TestPanicIsRecordedAsFailedRun(t *testing.T) {
+	clock := NewFakeClock(baseTime)
+	store := NewMemoryStore()
+	s := New(clock, store, "a", time.Minute)
+	defer s.Stop()
+
+	var mu sync.Mutex
+	fail := true
+	if err := s.Register("flaky", "", "", func(context.Context) (int64, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			panic("boom")
+		}
+		return 1, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Trigger("flaky"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "run to finish", func() bool { return finishedRuns(store) == 1 })
+	run := store.Runs()[0]
+	if run.Err == nil || !strings.Contains(run.Err.Error(), "panic: boom") {
+		t.Errorf("run error = %v, want panic", run.Err)
+	}
+
+	// panic 后任务锁和运行状态已释放，可以再次运行
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	waitFor(t, "job to be released", func() bool { return !s.Jobs()[0].Running })
+	if _, err := s.Trigger("flaky"); err != nil {
+		t.Fatalf("Trigger after panic: %v", err)
+	}
+	waitFor(t, "second run to finish", func() bool { return finishedRuns(store) == 2 })
+	if run := store.Runs()[1]; run.Err != nil || run.Processed != 1 {
+		t.Errorf("second run = %+v", run)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	s := New(NewFakeClock(baseTime), NewMemoryStore(), "a", time.Minute)
+	defer s.Stop()
+	noop := func(context.Context) (int64, error) { return 0, nil }
+
+	if err := s.Register("b", "second", "@daily", noop); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Register("a", "first", "", noop); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Register("a", "", "", noop); !errors.Is(err, ErrJobExists) {
+		t.Errorf("duplicate: err = %v, want ErrJobExists", err)
+	}
+	if err := s.Register("c", "", "61 * * * *", noop); err == nil {
+		t.Error("invalid spec accepted")
+	}
+
+	jobs := s.Jobs()
+	if len(jobs) != 2 || jobs[0].Name != "a" || jobs[1].Name != "b" || jobs[1].Spec != "@daily" || !jobs[1].NextRun.IsZero() {
+		t.Errorf("Jobs() = %+v", jobs)
+	}
+}
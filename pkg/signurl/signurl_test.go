@@ -0,0 +1,85 @@
+package signurl
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parse 拆出签名链接的路径、附加参数、过期时间和签名
+func parse(t *testing.T, signed string) (string, url.Values, string, string) {
+	t.Helper()
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	expires, signature := query.Get(ExpiresParam), query.Get(SignatureParam)
+	query.Del(ExpiresParam)
+	query.Del(SignatureParam)
+	return u.Path, query, expires, signature
+}
+
+func TestVerify(t *testing.T) {
+	signer := NewSigner("secret", time.Hour)
+	extra := url.Values{"variant": {"small"}}
+	path, query, expires, signature := parse(t, signer.Sign("/api/attachments/1", extra))
+	later := strconv.FormatInt(time.Now().Add(24*time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name      string
+		signer    *Signer
+		path      string
+		extra     url.Values
+		expires   string
+		signature string
+		want      error
+	}{
+		{"valid", signer, path, query, expires, signature, nil},
+		{"other path", signer, "/api/attachments/2", query, expires, signature, ErrInvalidSignature},
+		{"path prefix", signer, "/api/attachments/10", query, expires, signature, ErrInvalidSignature},
+		{"changed extra", signer, path, url.Values{"variant": {"medium"}}, expires, signature, ErrInvalidSignature},
+		{"dropped extra", signer, path, nil, expires, signature, ErrInvalidSignature},
+		// 延长有效期会使签名失效
+		{"extended expiry", signer, path, query, later, signature, ErrInvalidSignature},
+		{"malformed expiry", signer, path, query, "soon", signature, ErrInvalidSignature},
+		{"changed signature", signer, path, query, expires, strings.Repeat("0", len(signature)), ErrInvalidSignature},
+		{"empty signature", signer, path, query, expires, "", ErrInvalidSignature},
+		{"other secret", NewSigner("other", time.Hour), path, query, expires, signature, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.signer.Verify(tt.path, tt.extra, tt.expires, tt.signature); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyExpired(t *testing.T) {
+	signer := NewSigner("secret", time.Minute)
+	path, query, expires, signature := parse(t, signer.SignAt("/api/attachments/1", nil, time.Now().Add(-2*time.Minute)))
+	if err := signer.Verify(path, query, expires, signature); !errors.Is(err, ErrExpired) {
+		t.Errorf("Verify() = %v, want ErrExpired", err)
+	}
+	// 签名错误优先于过期，不暴露链接是否曾经有效
+	if err := signer.Verify(path, query, expires, "bad"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestSignKeepsExtraParams(t *testing.T) {
+	signer := NewSigner("secret", time.Hour)
+	now := time.Unix(1700000000, 0)
+	signed := signer.SignAt("/a", url.Values{"variant": {"thumb"}}, now)
+	_, query, expires, _ := parse(t, signed)
+	if query.Get("variant") != "thumb" {
+		t.Errorf("variant = %q", query.Get("variant"))
+	}
+	if want := strconv.FormatInt(now.Add(time.Hour).Unix(), 10); expires != want {
+		t.Errorf("expires = %s, want %s", expires, want)
+	}
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// LocalStorage 本地磁盘存储驱动
+// 适合单机部署和开发环境，多副本部署时请使用 S3Storage
+type LocalStorage struct {
+	baseDir string
+}
+
+// NewLocalStorage 创建本地磁盘存储，根目录不存在时自动创建
+func NewLocalStorage(baseDir string) (*LocalStorage, error) {
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{baseDir: baseDir}, nil
+}
+
+// fullPath 将对象键转换为磁盘路径
+func (s *LocalStorage) fullPath(key string) (string, string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	return key, filepath.Join(s.baseDir, filepath.FromSlash(key)), nil
+}
+
+// Put 写入对象
+// 先写入同目录下的临时文件再重命名，避免读取到写了一半的文件
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, p, err := s.fullPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// Get 读取对象
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	key, p, err := s.fullPath(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, localObjectInfo(key, fi), nil
+}
+
+// Stat 获取对象元信息
+func (s *LocalStorage) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	key, p, err := s.fullPath(key)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return localObjectInfo(key, fi), nil
+}
+
+// Delete 删除对象
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	_, p, err := s.fullPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// PresignGet 本地磁盘不支持预签名链接，由下载接口直接读取文件
+func (s *LocalStorage) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return "", ErrPresignNotSupported
+}
+
+// localObjectInfo 根据文件信息构造对象元信息，内容类型按扩展名推断
+func localObjectInfo(key string, fi os.FileInfo) *ObjectInfo {
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &ObjectInfo{
+		Key:         key,
+		Size:        fi.Size(),
+		ContentType: contentType,
+		ModTime:     fi.ModTime(),
+	}
+}
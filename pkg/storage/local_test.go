@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalStoragePutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Put(ctx, "images/a.png", strings.NewReader("hello"), -1, "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	rc, info, err := s.Get(ctx, "/images/a.png")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hello" {
+		t.Errorf("content = %q, want %q", data, "hello")
+	}
+	if info.Key != "images/a.png" || info.Size != 5 || info.ContentType != "image/png" {
+		t.Errorf("info = %+v", info)
+	}
+
+	if err := s.Delete(ctx, "images/a.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Stat(ctx, "images/a.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat after delete: err = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, "images/a.png"); err != nil {
+		t.Errorf("Delete missing object: %v", err)
+	}
+}
+
+func TestLocalStorageRejectsTraversal(t *testing.T) {
+	s, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Put(context.Background(), "../escape.txt", strings.NewReader("x"), 1, "")
+	if !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("err = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestLocalStoragePresignNotSupported(t *testing.T) {
+	s, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.PresignGet(context.Background(), "a.txt", 0); !errors.Is(err, ErrPresignNotSupported) {
+		t.Errorf("err = %v, want ErrPresignNotSupported", err)
+	}
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound 对象不存在
+	ErrNotFound = errors.New("storage: object not found")
+
+	// ErrPresignNotSupported 当前驱动不支持预签名链接，调用方应改为直接读取对象内容
+	ErrPresignNotSupported = errors.New("storage: presigned url not supported")
+
+	// ErrInvalidKey 对象键非法（为空或包含 .. 等路径穿越片段）
+	ErrInvalidKey = errors.New("storage: invalid object key")
+)
+
+// ObjectInfo 对象元信息
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage 上传文件存储接口
+// 对象键统一使用 "/" 分隔的相对路径，例如 images/xxx.png
+type Storage interface {
+	// Put 写入对象，size 未知时传 -1
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Get 读取对象，调用方负责关闭返回的 ReadCloser
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+
+	// Stat 获取对象元信息
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+
+	// Delete 删除对象，对象不存在时不报错
+	Delete(ctx context.Context, key string) error
+
+	// PresignGet 生成限时下载链接，不支持时返回 ErrPresignNotSupported
+	PresignGet(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// New 根据驱动名称创建存储
+// driver 取值：local-本地磁盘 s3-S3兼容对象存储
+func New(driver, localDir string, s3Config S3Config) (Storage, error) {
+	switch driver {
+	case "local":
+		return NewLocalStorage(localDir)
+	case "s3":
+		return NewS3Storage(s3Config)
+	default:
+		return nil, fmt.Errorf("storage: unknown driver %q", driver)
+	}
+}
+
+// CleanKey 规范化对象键，拒绝空键和路径穿越
+func CleanKey(key string) (string, error) {
+	key = strings.TrimLeft(strings.ReplaceAll(key, "\\", "/"), "/")
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == "" {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCleanKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+		err  error
+	}{
+		{"images/a.png", "images/a.png", nil},
+		{"/images//a.png", "images/a.png", nil},
+		{"images\\a.png", "images/a.png", nil},
+		{"images/./a.png", "images/a.png", nil},
+		{"", "", ErrInvalidKey},
+		{"/", "", ErrInvalidKey},
+		{"../a.png", "", ErrInvalidKey},
+		{"images/../../a.png", "", ErrInvalidKey},
+		{"images\\..\\a.png", "", ErrInvalidKey},
+	}
+	for _, tt := range tests {
+		got, err := CleanKey(tt.key)
+		if !errors.Is(err, tt.err) || got != tt.want {
+			t.Errorf("CleanKey(%q) = %q, %v; want %q, %v", tt.key, got, err, tt.want, tt.err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret RFC 6238 附录 B 中 SHA1 测试用的密钥 "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	// RFC 给出的是 8 位验证码，这里取后 6 位
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeAcceptsLowercaseAndPaddedSecret(t *testing.T) {
+	want, _ := Code(rfcSecret, 1)
+	for _, secret := range []string{strings.ToLower(rfcSecret), rfcSecret + "===="} {
+		if got, err := Code(secret, 1); err != nil || got != want {
+			t.Errorf("Code(%q) = %s, %v, want %s", secret, got, err, want)
+		}
+	}
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("invalid secret: want error")
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+	code := func(step int64) string {
+		c, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", code(current), current, true},
+		{"previous step", code(current - 1), current - 1, true},
+		{"next step", code(current + 1), current + 1, true},
+		// 超出允许的时钟误差
+		{"two steps ago", code(current - 2), 0, false},
+		{"two steps ahead", code(current + 2), 0, false},
+		{"with spaces", " " + code(current)[:3] + " " + code(current)[3:] + " ", current, true},
+		{"too short", code(current)[:5], 0, false},
+		{"too long", code(current) + "0", 0, false},
+		{"empty", "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, tt.code, now)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("Validate() = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateSecret()
+	if len(a) != 32 || a == b {
+		t.Errorf("GenerateSecret() = %q, %q", a, b)
+	}
+	if _, err := Code(a, 0); err != nil {
+		t.Errorf("generated secret is not valid base32: %v", err)
+	}
+}
@@ -74,7 +74,7 @@ func (c *WSClient) ReadPump(hub *Hub) {
 			wsMessage.Sender = &models.Sender{
 				ID:   c.UserID,
 				Type: c.UserType,
-				Name: c.UserName,
+				Name: c.Name(),
 			}
 		}
 
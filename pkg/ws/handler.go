@@ -3,7 +3,6 @@ package ws
 import (
 	"log"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -34,37 +33,13 @@ func NewWSHandler() *WSHandler {
 }
 
 // HandleConnection 处理WebSocket连接请求
-// 该函数负责验证用户身份信息，升级HTTP连接为WebSocket连接，
-// 并启动客户端的读写协程
+// 该函数负责升级HTTP连接为WebSocket连接，并启动客户端的读写协程。
+// 用户身份由调用方通过访问令牌验证后传入，不再信任查询参数中的用户信息
 // 参数:
 //   - c: gin框架的上下文对象，包含HTTP请求和响应信息
-func (h *WSHandler) HandleConnection(c *gin.Context) {
-	// 获取并验证用户基本信息
-	userIDStr := c.Query("user_id")
-	if userIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user ID"})
-		return
-	}
-
-	// 将字符串ID转换为uint64
-	userID, err := strconv.ParseUint(userIDStr, 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
-
-	userTypeStr := c.Query("user_type")
-	userType, err := strconv.ParseUint(userTypeStr, 10, 8)
-	if err != nil || userType < 1 || userType > 3 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user type"})
-		return
-	}
-
-	userName := c.Query("user_name")
-	if userName == "" {
-		userName = "User-" + strconv.FormatUint(userID, 10)
-	}
-
+//   - userID, userType, userName: 已认证的用户信息
+//   - sessionID: 访问令牌所属的登录会话ID，会话撤销时据此断开连接
+func (h *WSHandler) HandleConnection(c *gin.Context, userID uint64, userType uint8, userName, sessionID string) {
 	// 升级HTTP连接为WebSocket连接
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -73,7 +48,7 @@ func (h *WSHandler) HandleConnection(c *gin.Context) {
 	}
 
 	// 创建客户端
-	client := NewWSClient(conn, userID, uint8(userType), userName)
+	client := NewWSClient(conn, userID, userType, userName, sessionID)
 
 	// 注册客户端到Hub中进行统一管理
 	h.hub.register <- client
@@ -83,6 +58,16 @@ func (h *WSHandler) HandleConnection(c *gin.Context) {
 	go client.ReadPump(h.hub)
 }
 
+// DisconnectSession 断开属于指定登录会话的连接
+func (h *WSHandler) DisconnectSession(sessionID string) int {
+	return h.hub.DisconnectSession(sessionID)
+}
+
+// RenameUser 用户修改显示名称后，更新其已建立连接的名称
+func (h *WSHandler) RenameUser(userID uint64, userType uint8, name string) int {
+	return h.hub.RenameUser(userID, userType, name)
+}
+
 // SendMessageToUser 发送消息给特定用户（通过数字ID）
 func (h *WSHandler) SendMessageToUser(userID uint64, userType uint8, message []byte) bool {
 	return h.hub.SendToUser(userID, userType, message)
@@ -93,6 +78,11 @@ func (h *WSHandler) SendMessageToUserByStr(userIDStr string, userType uint8, mes
 	return h.hub.SendToUserByStr(userIDStr, userType, message)
 }
 
+// IsOnline 用户当前是否在线（有活跃的 WebSocket 连接）
+func (h *WSHandler) IsOnline(userID uint64, userType uint8) bool {
+	return h.hub.IsOnline(userID, userType)
+}
+
 // BroadcastMessage 广播消息给所有用户
 func (h *WSHandler) BroadcastMessage(message []byte) {
 	h.hub.broadcast <- message
@@ -8,6 +8,8 @@ import (
 	"log"
 	"sync"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 // Hub WebSocket连接管理中心
@@ -182,7 +184,7 @@ func (h *Hub) sendConnectEvent(client *WSClient) {
 		Sender: &models.Sender{
 			ID:   client.UserID,
 			Type: client.UserType,
-			Name: client.UserName,
+			Name: client.Name(),
 		},
 	}
 
@@ -227,3 +229,56 @@ func (h *Hub) SendToUser(userID uint64, userType uint8, message []byte) bool {
 	}
 	return false
 }
+
+// IsOnline 用户是否有活跃的连接
+func (h *Hub) IsOnline(userID uint64, userType uint8) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	_, exists := h.userClients[getUserKeyByID(userID, userType)]
+	return exists
+}
+
+// RenameUser 更新指定用户所有连接的显示名称，返回更新的连接数
+func (h *Hub) RenameUser(userID uint64, userType uint8, name string) int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	count := 0
+	for client := range h.clients {
+		if client.UserID == userID && client.UserType == userType {
+			client.SetName(name)
+			count++
+		}
+	}
+	return count
+}
+
+// DisconnectSession 断开属于指定登录会话的连接，返回断开的连接数
+func (h *Hub) DisconnectSession(sessionID string) int {
+	if sessionID == "" {
+		return 0
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	count := 0
+	for client := range h.clients {
+		if client.SessionID != sessionID {
+			continue
+		}
+		delete(h.clients, client)
+		userKey := getUserKeyByID(client.UserID, client.UserType)
+		if c, exists := h.userClients[userKey]; exists && c == client {
+			delete(h.userClients, userKey)
+		}
+		client.CloseWithReason(websocket.ClosePolicyViolation, "session revoked")
+		count++
+	}
+
+	if count > 0 {
+		log.Printf("Session disconnected: SessionID=%s, Clients=%d", sessionID, count)
+	}
+	return count
+}
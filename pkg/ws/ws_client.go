@@ -2,6 +2,7 @@ package ws
 
 import (
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -11,23 +12,39 @@ type WSClient struct {
 	Conn      *websocket.Conn // WebSocket连接
 	UserID    uint64          // 用户ID（数字形式）
 	UserType  uint8           // 用户类型：1-用户 2-商家 3-管理员
-	UserName  string          // 用户名称
+	UserName  string          // 用户显示名称，修改个人资料后通过 SetName 更新，读取时使用 Name
+	SessionID string          // 登录会话ID，会话撤销时据此断开连接
 	Send      chan []byte     // 发送消息的通道
 	mutex     sync.Mutex      // 互斥锁，保证并发安全
 	IsClosing bool            // 是否正在关闭
 }
 
 // NewWSClient 创建新的WebSocket客户端
-func NewWSClient(conn *websocket.Conn, userID uint64, userType uint8, userName string) *WSClient {
+func NewWSClient(conn *websocket.Conn, userID uint64, userType uint8, userName, sessionID string) *WSClient {
 	return &WSClient{
-		Conn:     conn,
-		UserID:   userID,
-		UserType: userType,
-		UserName: userName,
-		Send:     make(chan []byte, 256), // 缓冲区大小为256
+		Conn:      conn,
+		UserID:    userID,
+		UserType:  userType,
+		UserName:  userName,
+		SessionID: sessionID,
+		Send:      make(chan []byte, 256), // 缓冲区大小为256
 	}
 }
 
+// Name 获取用户显示名称
+func (c *WSClient) Name() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.UserName
+}
+
+// SetName 更新用户显示名称，之后该连接发出的事件使用新名称
+func (c *WSClient) SetName(name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.UserName = name
+}
+
 // Close 关闭WebSocket连接
 func (c *WSClient) Close() {
 	c.mutex.Lock()
@@ -41,3 +58,10 @@ func (c *WSClient) Close() {
 	c.Conn.Close()
 	close(c.Send)
 }
+
+// CloseWithReason 发送关闭帧后关闭WebSocket连接
+// 前端收到 1008 (ClosePolicyViolation) 关闭码时会退出登录
+func (c *WSClient) CloseWithReason(code int, reason string) {
+	c.Conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeWait))
+	c.Close()
+}
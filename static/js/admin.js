@@ -105,7 +105,8 @@ class AdminApp {
             loginForm: document.getElementById('loginForm'),
             loginUsername: document.getElementById('loginUsername'),
             loginPassword: document.getElementById('loginPassword'),
-            loginSubmitBtn: document.getElementById('loginSubmitBtn')
+            loginSubmitBtn: document.getElementById('loginSubmitBtn'),
+            ssoLoginBtn: document.getElementById('ssoLoginBtn')
         };
     }
 
@@ -272,34 +273,69 @@ class AdminApp {
             // 响应数据：{code, message, data: {user, token}}
             const response = await HttpUtils.post(CONFIG.ENDPOINTS.USER.LOGIN, loginData);
 
-            // 保存用户信息和令牌（使用管理员专用存储键）
-            this.state.currentUser = {
-                ...response.data.user
-            };
-            StorageUtils.setToken(response.data.token);
-            StorageUtils.setUserData(response.data.user);
-            StorageUtils.setUserType(CONFIG.USER_TYPE.ADMIN);
+            // 启用了两步验证时，输入验证码后才能拿到令牌
+            response.data = await TwoFactorUtils.complete(response.data);
 
-            // 更新UI
-            this.updateUIAfterLogin();
+            await this.completeLogin(response.data, password);
+        } catch (error) {
+            console.error('登录失败:', error);
+            this.showAlert('登录失败: ' + error.message, 'danger');
+        }
+    }
 
-            // 关闭登录模态框
-            this.elements.loginModal.hide();
+    /**
+     * 保存登录结果并进入工作台，密码登录和单点登录共用
+     * @param {Object} data 已完成两步验证的登录响应数据
+     * @param {string|null} password 登录时输入的密码，单点登录时为 null
+     */
+    async completeLogin(data, password) {
+        // 保存用户信息和令牌（使用管理员专用存储键）
+        this.state.currentUser = {
+            ...data.user
+        };
+        StorageUtils.setToken(data.token);
+        StorageUtils.setRefreshToken(data.refresh_token);
+        StorageUtils.setUserData(data.user);
+        StorageUtils.setUserType(CONFIG.USER_TYPE.ADMIN);
+
+        // 需要修改密码时（如默认管理员首次登录），先完成修改；单点登录不使用本地密码
+        if (password !== null) {
+            await PasswordUtils.ensureChanged(this.state.currentUser, password);
+        }
+
+        // 更新UI
+        this.updateUIAfterLogin();
 
-            // 重置表单
-            this.elements.loginForm.reset();
+        // 关闭登录模态框
+        this.elements.loginModal.hide();
 
-            // 加载数据
-            await this.loadFeedbacks();
-            await this.loadStatistics();
+        // 重置表单
+        this.elements.loginForm.reset();
 
-            // 连接WebSocket
-            this.connectWebSocket();
+        // 加载数据
+        await this.loadFeedbacks();
+        await this.loadStatistics();
+
+        // 连接WebSocket
+        this.connectWebSocket();
+
+        this.showAlert('登录成功', 'success');
+    }
 
-            this.showAlert('登录成功', 'success');
+    /**
+     * 初始化单点登录：启用时显示单点登录按钮，从 IdP 返回时使用 sso_token 完成登录
+     * 前后端对接：GET /api/user/oidc/config、POST /api/user/oidc/exchange → internal/handler/oidc.go
+     */
+    async initSSO() {
+        try {
+            await SSOUtils.setupButton(this.elements.ssoLoginBtn, CONFIG.USER_TYPE_NUMBERS.ADMIN);
+            const data = await SSOUtils.consume();
+            if (data) {
+                await this.completeLogin(data, null);
+            }
         } catch (error) {
-            console.error('登录失败:', error);
-            this.showAlert('登录失败: ' + error.message, 'danger');
+            console.error('单点登录失败:', error);
+            this.showAlert('单点登录失败: ' + error.message, 'danger');
         }
     }
 
@@ -307,6 +343,9 @@ class AdminApp {
      * 处理管理员登出
      */
     handleLogout() {
+        // 通知后端注销当前会话
+        HttpUtils.logout();
+
         // 关闭WebSocket连接
         if (this.state.wsConnection) {
             this.state.wsConnection.close();
@@ -415,10 +454,11 @@ class AdminApp {
     /**
      * 连接WebSocket
      */
-    connectWebSocket() {
+    async connectWebSocket() {
         if (!this.state.currentUser) return;
 
-        const token = StorageUtils.getToken();
+        // 访问令牌有效期较短，连接前确保令牌未过期
+        const token = await HttpUtils.getFreshToken();
         if (!token) {
             this.showAlert('登录已过期，请重新登录', 'warning');
             this.handleLogout();
@@ -503,6 +543,27 @@ class AdminApp {
                     this.handleNewFeedbackEvent(message);
                     break;
 
+                case CONFIG.WS_EVENT_TYPE.ASSIGNED:
+                    this.handleAssignedEvent(message);
+                    break;
+
+                case CONFIG.WS_EVENT_TYPE.ESCALATED:
+                    this.handleEscalatedEvent(message);
+                    break;
+
+                case CONFIG.WS_EVENT_TYPE.SLA_WARNING:
+                case CONFIG.WS_EVENT_TYPE.SLA_BREACHED:
+                    this.handleSLAEvent(message);
+                    break;
+
+                case CONFIG.WS_EVENT_TYPE.FOLLOW_UP:
+                    this.handleFollowUpEvent(message);
+                    break;
+
+                case CONFIG.WS_EVENT_TYPE.NOTIFICATION:
+                    this.showAlert(`${message.data.content}: ${message.data.title}`, 'info');
+                    break;
+
                 default:
                     console.warn('未知的WebSocket事件类型:', message.event);
             }
@@ -523,7 +584,7 @@ class AdminApp {
                 return;
             }
 
-            this.appendMessage(message);
+            this.appendMessage(message, Number(message.data.messageType) === CONFIG.MESSAGE_TYPE.SYSTEM);
             this.scrollChatToBottom();
 
             // 发送已读回执
@@ -577,6 +638,61 @@ class AdminApp {
         this.loadStatistics();
     }
 
+    /**
+     * 处理处理人变更事件
+     * 后端只推送给新处理人和原处理人
+     * @param {Object} message - 消息对象，data: {feedback_id, title, action, assignee_id, assignee_name, previous_assignee_id}
+     */
+    handleAssignedEvent(message) {
+        const data = message.data;
+        if (Number(data.assignee_id) === Number(this.state.currentUser.id)) {
+            if (data.action !== 'claim') {
+                this.showAlert(`反馈已分配给您: ${data.title}`, 'info');
+            }
+        } else if (Number(data.previous_assignee_id) === Number(this.state.currentUser.id)) {
+            this.showAlert(`您已不再是反馈的处理人: ${data.title}`, 'info');
+        }
+        this.loadFeedbacks();
+    }
+
+    /**
+     * 处理反馈升级事件
+     * 后端推送给反馈创建者、商家组织的所有员工和所有管理员
+     * @param {Object} message - 消息对象，data: {feedback_id, title, reason, escalated_at}
+     */
+    handleEscalatedEvent(message) {
+        const data = message.data;
+        this.showAlert(`反馈已升级到平台，请协助处理: ${data.title}`, 'warning');
+        this.loadFeedbacks();
+    }
+
+    /**
+     * 处理跟进提醒事件
+     * 后端推送给反馈的目标方（提醒目标方回复），已升级的反馈同时推送给所有管理员
+     * @param {Object} message - 消息对象，data: {feedback_id, title, kind, idle_since}
+     */
+    handleFollowUpEvent(message) {
+        const data = message.data;
+        this.showAlert(`反馈长时间未回复，请尽快处理: ${data.title}`, 'warning');
+        this.loadFeedbacks();
+    }
+
+    /**
+     * 处理 SLA 预警和超时事件
+     * 后端推送给反馈的目标方，已升级的反馈同时推送给所有管理员
+     * @param {Object} message - 消息对象，data: {feedback_id, title, kind, due_at}
+     */
+    handleSLAEvent(message) {
+        const data = message.data;
+        const kind = data.kind === 'first_response' ? '首次回复' : '解决';
+        if (message.event === CONFIG.WS_EVENT_TYPE.SLA_BREACHED) {
+            this.showAlert(`反馈${kind}已超时: ${data.title}`, 'danger');
+        } else {
+            this.showAlert(`反馈即将${kind}超时: ${data.title}`, 'warning');
+        }
+        this.loadFeedbacks();
+    }
+
     /**
      * 处理状态变更事件
      * @param {Object} message - 消息对象
@@ -933,7 +1049,7 @@ class AdminApp {
                     }
                 };
 
-                this.appendMessage(wsMessage);
+                this.appendMessage(wsMessage, wsMessage.data.messageType === CONFIG.MESSAGE_TYPE.SYSTEM);
             });
 
             this.scrollChatToBottom();
@@ -1391,7 +1507,7 @@ class AdminApp {
                 case CONFIG.MESSAGE_TYPE.IMAGE:
                     contentHtml = `
                         <div class="message-content message-image-content">
-                            <img src="${message.data.content}" class="message-image" onclick="window.open('${message.data.content}', '_blank')">
+                            <img src="${ImageUtils.variantUrl(message.data.content, 'small')}" class="message-image" onclick="window.open('${message.data.content}', '_blank')">
                         </div>
                     `;
                     break;
@@ -1399,7 +1515,7 @@ class AdminApp {
                 case CONFIG.MESSAGE_TYPE.IMAGE_ARRAY:
                     const imageUrls = JSON.parse(message.data.content);
                     const imagesHtml = imageUrls.map(url =>
-                        `<img src="${url}" class="message-image-multiple" onclick="window.open('${url}', '_blank')">`
+                        `<img src="${ImageUtils.variantUrl(url, 'thumb')}" class="message-image-multiple" onclick="window.open('${url}', '_blank')">`
                     ).join('');
                     contentHtml = `<div class="message-content message-images-content">${imagesHtml}</div>`;
                     break;
@@ -1488,6 +1604,8 @@ class AdminApp {
                 return '<i class="fas fa-spinner me-1"></i>处理中';
             case CONFIG.FEEDBACK_STATUS.RESOLVED:
                 return '<i class="fas fa-check-circle me-1"></i>已解决';
+            case CONFIG.FEEDBACK_STATUS.CLOSED:
+                return '<i class="fas fa-lock me-1"></i>已关闭';
             default:
                 return '<i class="fas fa-question me-1"></i>未知状态';
         }
@@ -1524,6 +1642,8 @@ class AdminApp {
                 return 'status-in-progress';
             case CONFIG.FEEDBACK_STATUS.RESOLVED:
                 return 'status-resolved';
+            case CONFIG.FEEDBACK_STATUS.CLOSED:
+                return 'status-closed';
             default:
                 return 'bg-secondary';
         }
@@ -33,7 +33,8 @@ const CONFIG = {
      * 前后端对接说明：
      * - 后端处理器：pkg/ws/handler.go 中的 WSHandler.HandleConnection() 方法
      * - 路由注册：cmd/main.go 第71行 wsHttpHandler.RegisterRoutes(apiGroup)
-     * - 连接参数：需要传递 user_id, user_type, user_name, token 查询参数
+     * - 连接参数：需要传递 token 查询参数，用户身份以令牌为准（user_id 等参数仅用于调试日志）
+     * - 会话注销后服务端以 1008 关闭码断开连接
      * - 实时通信：用于反馈状态变更、新消息、删除事件等实时推送
      */
     WS_URL: (() => {
@@ -68,13 +69,66 @@ const CONFIG = {
         USER: {
             REGISTER: '/user/register',        // → handler/user.go Register() 方法
             LOGIN: '/user/login',              // → handler/user.go Login() 方法
-            LOGOUT: '/user/logout',            // 前端本地清理，无后端接口
+            LOGIN_2FA: '/user/login/2fa',      // → handler/two_factor.go LoginTwoFactor() 方法，两步验证登录
+            LOGIN_2FA_SETUP: '/user/login/2fa/setup', // → handler/two_factor.go SetupTwoFactorForLogin() 方法，登录时绑定验证器
+            LOGOUT: '/user/logout',            // → handler/user.go Logout() 方法，注销当前会话
+            LOGOUT_ALL: '/user/logout-all',    // → handler/user.go LogoutAll() 方法，注销所有设备
+            REFRESH: '/user/refresh',          // → handler/user.go Refresh() 方法，刷新令牌
+            SESSIONS: '/user/sessions',        // → handler/user.go ListSessions() / RevokeSession() 方法 (注销时拼接会话ID)
+            PASSWORD: '/user/password',        // → handler/user.go ChangePassword() 方法，修改密码
+            PASSWORD_FORGOT: '/user/password/forgot', // → handler/password_reset.go Forgot() 方法，申请重置密码邮件
+            PASSWORD_RESET: '/user/password/reset',   // → handler/password_reset.go Reset() 方法，通过邮件链接重置密码
             CURRENT: '/user/me',               // → handler/user.go GetCurrentUser() 方法
             VALIDATE_TOKEN: '/user/me',        // 同上，用于验证token有效性
-            MERCHANTS: '/user/merchants',      // → handler/user.go GetMerchants() 方法
+            PROFILE: '/user/me',               // → handler/user.go UpdateProfile() 方法 (PUT)，修改个人资料
+            MERCHANTS: '/user/merchants',      // → handler/user.go GetMerchants() 方法，返回商家组织 {id, name}
+            OIDC_CONFIG: '/user/oidc/config',  // → handler/oidc.go Config() 方法，是否启用单点登录
+            OIDC_LOGIN: '/user/oidc/login',    // → handler/oidc.go Login() 方法，浏览器跳转到 IdP 登录
+            OIDC_EXCHANGE: '/user/oidc/exchange', // → handler/oidc.go Exchange() 方法，使用 sso_token 换取令牌
+            OIDC_LINK: '/user/oidc/link',      // → handler/oidc.go Link() 方法，绑定企业账号
+            OIDC_IDENTITIES: '/user/oidc/identities', // → handler/oidc.go ListIdentities() / Unlink() 方法 (解绑时拼接ID)
             GET_BY_ID: '/user/info'            // → handler/user.go GetUserInfo() 方法
         },
 
+        /**
+         * 管理员API
+         * 包括商家邀请码、商家注册审核、创建管理员、用户管理等
+         *
+         * 前后端对接说明：
+         * - 后端处理器：internal/handler/account.go 中的 AccountHandler
+         * - 需要认证且必须是管理员：middleware.AuthMiddleware + middleware.RoleMiddleware("admin")
+         */
+        ADMIN: {
+            INVITES: '/admin/invites',                 // → handler/account.go CreateInvite() / ListInvites() / RevokeInvite() 方法 (撤销时拼接ID)
+            PENDING_MERCHANTS: '/admin/merchants/pending', // → handler/account.go ListPendingMerchants() 方法
+            MERCHANTS: '/admin/merchants/',            // → handler/account.go ApproveMerchant() / RejectMerchant() 方法 (需要拼接ID和/approve、/reject)
+            ADMINS: '/admin/admins',                   // → handler/account.go CreateAdmin() 方法
+            USERS: '/admin/users',                     // → handler/admin_user.go 用户管理 (详情、修改、删除拼接ID，停用、恢复、重置密码再拼接/suspend、/unsuspend、/reset-password)
+            ASSIGNMENT: '/admin/assignment',           // → handler/assignment.go GetSetting() / UpdateSetting() 方法，管理员团队的自动分配设置
+            SLA_POLICIES: '/admin/sla-policies',       // → handler/sla_policy.go SLA 策略管理 (修改、删除拼接ID)
+            CALENDARS: '/admin/calendars',             // → handler/calendar.go 工作日历管理 (修改、删除拼接ID)
+            CALENDAR_ASSIGNMENTS: '/admin/calendar-assignments', // → handler/calendar.go ListAssignments() / Assign() 方法，各团队使用的工作日历
+            CALENDAR: '/admin/calendar',               // → handler/calendar.go TeamCalendar() 方法，管理员团队的工作日历和营业状态
+            JOBS: '/admin/jobs',                       // → handler/job.go 后台定时任务 (运行拼接任务名和/run，运行记录拼接任务名和/runs)
+            AUTOMATION_RULES: '/admin/automation-rules' // → handler/automation.go 自动化规则管理 (修改、删除拼接ID，测试拼接/test)
+        },
+
+        /**
+         * 商家专用API
+         * 包括API密钥管理（商家后端通过 X-API-Key 请求头调用接口）、商家组织和员工管理
+         *
+         * 前后端对接说明：
+         * - 后端处理器：internal/handler/api_key.go 中的 APIKeyHandler
+         * - 需要认证且必须是商家：middleware.AuthMiddleware + middleware.RoleMiddleware("merchant")
+         */
+        MERCHANT: {
+            API_KEYS: '/merchant/api-keys',            // → handler/api_key.go Create() / List() 方法 (轮换拼接ID和/rotate，撤销拼接ID)
+            ORG: '/merchant/org',                      // → handler/organization.go Get() / Update() 方法
+            ORG_MEMBERS: '/merchant/org/members',      // → handler/organization.go CreateMember() 方法 (修改角色和技能、删除拼接ID)
+            ASSIGNMENT: '/merchant/assignment',        // → handler/assignment.go GetSetting() / UpdateSetting() 方法，组织的自动分配设置
+            CALENDAR: '/merchant/calendar'             // → handler/calendar.go TeamCalendar() 方法，组织的工作日历和营业状态
+        },
+
         /**
          * 反馈相关API
          * 包括创建、查询、更新、删除反馈等
@@ -91,7 +145,12 @@ const CONFIG = {
             GET_BY_CREATOR: '/feedback/creator',    // → handler/feedback.go GetByCreator() 方法
             GET_BY_TARGET: '/feedback/target',      // → handler/feedback.go GetByTarget() 方法
             UPDATE_STATUS: '/feedback/',            // → handler/feedback.go UpdateStatus() 方法 (需要拼接ID和/status)
-            DELETE: '/feedback/'                    // → handler/feedback.go Delete() 方法 (需要拼接ID)
+            DELETE: '/feedback/',                   // → handler/feedback.go Delete() 方法 (需要拼接ID)
+            HISTORY: '/history',                    // → handler/feedback.go History() 方法 (拼接在 '/feedback/' + ID 之后)
+            ASSIGN: '/assign',                      // → handler/assignment.go Assign() 方法 (拼接在 '/feedback/' + ID 之后)
+            CLAIM: '/claim',                        // → handler/assignment.go Claim() 方法 (拼接在 '/feedback/' + ID 之后)
+            UNASSIGN: '/unassign',                  // → handler/assignment.go Unassign() 方法 (拼接在 '/feedback/' + ID 之后)
+            ESCALATE: '/escalate'                   // → handler/escalation.go Escalate() 方法 (拼接在 '/feedback/' + ID 之后)
         },
 
         /**
@@ -119,8 +178,10 @@ const CONFIG = {
          * - 后端处理器：internal/handler/upload.go 中的 UploadHandler
          * - 路由注册：cmd/main.go 第82行 authApi.POST("/upload/image", uploadHandler.UploadImage)
          * - 需要认证：需要通过 middleware.AuthMiddleware 认证
-         * - 文件限制：仅支持图片文件，最大5MB
-         * - 存储路径：文件保存在 ./static/uploads/ 目录下
+         * - 文件限制：仅支持JPEG/PNG/GIF（按文件内容识别），最大5MB
+         * - 图片处理：去除EXIF等元数据，返回 variants: {thumb, small, medium} 缩略图地址
+         * - 存储位置：由后端存储驱动决定（本地磁盘或S3兼容存储）
+         * - 访问控制：返回的 url 为限时签名链接（/api/attachments/:id?expires=&signature=），可直接用于<img>
          */
         UPLOAD: {
             IMAGE: '/upload/image'                     // → handler/upload.go UploadImage() 方法
@@ -136,7 +197,8 @@ const CONFIG = {
     FEEDBACK_STATUS: {
         OPEN: 1,           // 待处理
         IN_PROGRESS: 2,    // 处理中
-        RESOLVED: 3        // 已解决
+        RESOLVED: 3,       // 已解决
+        CLOSED: 4          // 已关闭（已解决超过一定时间后自动关闭，不能再修改状态或发送消息）
     },
 
     /**
@@ -200,7 +262,13 @@ const CONFIG = {
         READ: 'read',                 // 已读事件
         STATUS_CHANGE: 'status_change', // 状态变更事件
         FEEDBACK_DELETE: 'feedback_delete', // 反馈删除事件
-        NEW_FEEDBACK: 'new_feedback'  // 新反馈事件
+        NEW_FEEDBACK: 'new_feedback', // 新反馈事件
+        ASSIGNED: 'assigned',         // 处理人变更事件
+        ESCALATED: 'escalated',       // 反馈升级事件
+        SLA_WARNING: 'sla_warning',   // SLA 即将超时事件
+        SLA_BREACHED: 'sla_breached', // SLA 超时事件
+        FOLLOW_UP: 'follow_up',       // 跟进提醒事件
+        NOTIFICATION: 'notification'  // 自动化规则的通知事件
     },
 
     // ==================== 本地存储键名 ====================
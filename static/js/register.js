@@ -9,7 +9,10 @@ class RegisterApp {
             password: document.getElementById('password'),
             confirmPassword: document.getElementById('confirmPassword'),
             contact: document.getElementById('contact'),
+            email: document.getElementById('email'),
             userType: document.getElementById('userType'),
+            inviteCodeGroup: document.getElementById('inviteCodeGroup'),
+            inviteCode: document.getElementById('inviteCode'),
             loginLink: document.getElementById('loginLink'),
             alertContainer: document.getElementById('alertContainer')
         };
@@ -34,6 +37,12 @@ class RegisterApp {
             this.handleRegister();
         });
 
+        // 选择商家时显示邀请码输入框
+        this.elements.userType.addEventListener('change', () => {
+            const isMerchant = parseInt(this.elements.userType.value) === CONFIG.USER_TYPE_NUMBERS.MERCHANT;
+            this.elements.inviteCodeGroup.classList.toggle('d-none', !isMerchant);
+        });
+
         // 登录链接点击
         this.elements.loginLink.addEventListener('click', (e) => {
             e.preventDefault();
@@ -49,7 +58,9 @@ class RegisterApp {
         const password = this.elements.password.value.trim();
         const confirmPassword = this.elements.confirmPassword.value.trim();
         const contact = this.elements.contact.value.trim();
+        const email = this.elements.email.value.trim();
         const userType = parseInt(this.elements.userType.value);
+        const inviteCode = this.elements.inviteCode.value.trim();
 
         // 表单验证
         if (!username || !password || !confirmPassword || !userType) {
@@ -78,12 +89,22 @@ class RegisterApp {
                 username: username,
                 password: password,
                 contact: contact,
+                email: email,
                 user_type: userType
             };
+            if (userType === CONFIG.USER_TYPE_NUMBERS.MERCHANT && inviteCode) {
+                registerData.invite_code = inviteCode;
+            }
 
             // 发送注册请求
             const response = await HttpUtils.post(CONFIG.ENDPOINTS.USER.REGISTER, registerData);
 
+            // 未使用邀请码注册的商家需要等待管理员审核
+            if (response.data && response.data.status === 2) {
+                this.showAlert('注册成功！账号正在等待管理员审核，审核通过后即可登录', 'info');
+                return;
+            }
+
             this.showAlert('注册成功！正在跳转到登录页面...', 'success');
 
             // 延迟跳转到登录页面
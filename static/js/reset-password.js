@@ -0,0 +1,132 @@
+/**
+ * 找回密码页面逻辑
+ * 不带 token 参数时申请重置邮件，通过邮件中的链接（带 token 参数）打开时设置新密码
+ */
+class ResetPasswordApp {
+    constructor() {
+        this.elements = {
+            forgotForm: document.getElementById('forgotForm'),
+            resetForm: document.getElementById('resetForm'),
+            username: document.getElementById('username'),
+            userType: document.getElementById('userType'),
+            newPassword: document.getElementById('newPassword'),
+            confirmPassword: document.getElementById('confirmPassword'),
+            alertContainer: document.getElementById('alertContainer')
+        };
+
+        this.token = new URLSearchParams(window.location.search).get('token');
+
+        this.init();
+    }
+
+    /**
+     * 初始化
+     */
+    init() {
+        if (this.token) {
+            this.elements.forgotForm.classList.add('d-none');
+            this.elements.resetForm.classList.remove('d-none');
+        }
+        this.bindEvents();
+    }
+
+    /**
+     * 绑定事件
+     */
+    bindEvents() {
+        this.elements.forgotForm.addEventListener('submit', (e) => {
+            e.preventDefault();
+            this.handleForgot();
+        });
+
+        this.elements.resetForm.addEventListener('submit', (e) => {
+            e.preventDefault();
+            this.handleReset();
+        });
+    }
+
+    /**
+     * 申请重置密码
+     * 前后端对接：POST /api/user/password/forgot → internal/handler/password_reset.go Forgot()方法
+     */
+    async handleForgot() {
+        const username = this.elements.username.value.trim();
+        const userType = parseInt(this.elements.userType.value);
+
+        if (!username) {
+            this.showAlert('请输入用户名', 'warning');
+            return;
+        }
+
+        try {
+            await HttpUtils.post(CONFIG.ENDPOINTS.USER.PASSWORD_FORGOT, {
+                username: username,
+                user_type: userType
+            });
+            this.showAlert('如果该账号已绑定邮箱，重置链接已发送，请查收邮件', 'success');
+        } catch (error) {
+            console.error('申请重置密码失败:', error);
+            this.showAlert('申请失败: ' + error.message, 'danger');
+        }
+    }
+
+    /**
+     * 设置新密码
+     * 前后端对接：POST /api/user/password/reset → internal/handler/password_reset.go Reset()方法
+     */
+    async handleReset() {
+        const newPassword = this.elements.newPassword.value;
+        const confirmPassword = this.elements.confirmPassword.value;
+
+        if (!newPassword || newPassword !== confirmPassword) {
+            this.showAlert('两次输入的密码不一致', 'warning');
+            return;
+        }
+
+        try {
+            await HttpUtils.post(CONFIG.ENDPOINTS.USER.PASSWORD_RESET, {
+                token: this.token,
+                new_password: newPassword
+            });
+            this.showAlert('密码已重置，请使用新密码登录', 'success');
+            this.elements.resetForm.reset();
+            setTimeout(() => {
+                window.location.href = '/';
+            }, 2000);
+        } catch (error) {
+            console.error('重置密码失败:', error);
+            this.showAlert('重置失败: ' + error.message, 'danger');
+        }
+    }
+
+    /**
+     * 显示提示消息
+     * @param {string} message - 消息内容
+     * @param {string} type - 消息类型 (success, danger, warning, info)
+     */
+    showAlert(message, type = 'info') {
+        const alertId = 'alert-' + Date.now();
+        const alertHtml = `
+            <div id="${alertId}" class="alert alert-${type} alert-dismissible fade show" role="alert">
+                ${message}
+                <button type="button" class="btn-close" data-bs-dismiss="alert"></button>
+            </div>
+        `;
+
+        this.elements.alertContainer.insertAdjacentHTML('beforeend', alertHtml);
+
+        // 自动隐藏提示
+        setTimeout(() => {
+            const alertElement = document.getElementById(alertId);
+            if (alertElement) {
+                const bsAlert = new bootstrap.Alert(alertElement);
+                bsAlert.close();
+            }
+        }, CONFIG.UI.ALERT.AUTO_HIDE_DELAY);
+    }
+}
+
+// 页面加载完成后初始化应用
+document.addEventListener('DOMContentLoaded', () => {
+    new ResetPasswordApp();
+});
@@ -232,8 +232,12 @@ class UserApp {
 
             StorageUtils.setUserData(response.data.user);
             StorageUtils.setToken(response.data.token);
+            StorageUtils.setRefreshToken(response.data.refresh_token);
             StorageUtils.setUserType(CONFIG.USER_TYPE.USER);
 
+            // 需要修改密码时（如默认管理员首次登录），先完成修改
+            await PasswordUtils.ensureChanged(this.state.currentUser, password);
+
             // 更新UI
             this.updateUIAfterLogin();
 
@@ -260,6 +264,9 @@ class UserApp {
      * 处理用户登出
      */
     handleLogout() {
+        // 通知后端注销当前会话
+        HttpUtils.logout();
+
         // 关闭WebSocket连接
         if (this.state.wsConnection) {
             this.state.wsConnection.close();
@@ -317,10 +324,11 @@ class UserApp {
     /**
      * 连接WebSocket
      */
-    connectWebSocket() {
+    async connectWebSocket() {
         if (!this.state.currentUser) return;
 
-        const token = StorageUtils.getToken();
+        // 访问令牌有效期较短，连接前确保令牌未过期
+        const token = await HttpUtils.getFreshToken();
         if (!token) {
             this.showAlert('登录已过期，请重新登录', 'warning');
             this.handleLogout();
@@ -405,6 +413,20 @@ class UserApp {
                     this.handleNewFeedbackEvent(message);
                     break;
 
+                case CONFIG.WS_EVENT_TYPE.ESCALATED:
+                    this.showAlert(`反馈已升级到平台管理员: ${message.data.title}`, 'info');
+                    this.loadFeedbacks();
+                    break;
+
+                case CONFIG.WS_EVENT_TYPE.FOLLOW_UP:
+                    this.showAlert(`请问您的问题解决了吗？如仍需帮助请回复: ${message.data.title}`, 'info');
+                    this.loadFeedbacks();
+                    break;
+
+                case CONFIG.WS_EVENT_TYPE.NOTIFICATION:
+                    this.showAlert(`${message.data.content}: ${message.data.title}`, 'info');
+                    break;
+
                 default:
                     console.warn('未知的WebSocket事件类型:', message.event);
             }
@@ -425,7 +447,7 @@ class UserApp {
                 return;
             }
 
-            this.appendMessage(message);
+            this.appendMessage(message, Number(message.data.messageType) === CONFIG.MESSAGE_TYPE.SYSTEM);
             this.scrollChatToBottom();
 
             // 发送已读回执
@@ -690,7 +712,7 @@ class UserApp {
                     }
                 };
 
-                this.appendMessage(wsMessage);
+                this.appendMessage(wsMessage, wsMessage.data.messageType === CONFIG.MESSAGE_TYPE.SYSTEM);
             });
 
             this.scrollChatToBottom();
@@ -1123,7 +1145,7 @@ class UserApp {
                 case CONFIG.MESSAGE_TYPE.IMAGE:
                     contentHtml = `
                         <div class="message-content message-image-content">
-                            <img src="${message.data.content}" class="message-image" onclick="window.open('${message.data.content}', '_blank')">
+                            <img src="${ImageUtils.variantUrl(message.data.content, 'small')}" class="message-image" onclick="window.open('${message.data.content}', '_blank')">
                         </div>
                     `;
                     break;
@@ -1131,7 +1153,7 @@ class UserApp {
                 case CONFIG.MESSAGE_TYPE.IMAGE_ARRAY:
                     const imageUrls = JSON.parse(message.data.content);
                     const imagesHtml = imageUrls.map(url =>
-                        `<img src="${url}" class="message-image-multiple" onclick="window.open('${url}', '_blank')">`
+                        `<img src="${ImageUtils.variantUrl(url, 'thumb')}" class="message-image-multiple" onclick="window.open('${url}', '_blank')">`
                     ).join('');
                     contentHtml = `<div class="message-content message-images-content">${imagesHtml}</div>`;
                     break;
@@ -1167,14 +1189,16 @@ class UserApp {
      */
     updateMessageInputState(feedbackStatus) {
         const isResolved = feedbackStatus === CONFIG.FEEDBACK_STATUS.RESOLVED; // 状态为3表示已解决
+        const isClosed = feedbackStatus === CONFIG.FEEDBACK_STATUS.CLOSED; // 状态为4表示已关闭
+        const disabled = isResolved || isClosed;
 
         // 禁用或启用输入框和发送按钮
-        this.elements.messageInput.disabled = isResolved;
-        this.elements.sendMessageBtn.disabled = isResolved;
-        this.elements.imageBtn.disabled = isResolved;
+        this.elements.messageInput.disabled = disabled;
+        this.elements.sendMessageBtn.disabled = disabled;
+        this.elements.imageBtn.disabled = disabled;
 
-        if (isResolved) {
-            this.elements.messageInput.placeholder = '反馈已解决，无法发送新消息';
+        if (disabled) {
+            this.elements.messageInput.placeholder = isClosed ? '反馈已关闭，无法发送新消息' : '反馈已解决，无法发送新消息';
             this.elements.messageInputArea.classList.add('disabled');
         } else {
             this.elements.messageInput.placeholder = '输入消息...';
@@ -1219,7 +1243,7 @@ class UserApp {
             merchants.forEach(merchant => {
                 const option = document.createElement('option');
                 option.value = merchant.id;
-                option.textContent = merchant.username;
+                option.textContent = merchant.name;
                 this.elements.merchantSelect.appendChild(option);
             });
         } catch (error) {
@@ -1280,6 +1304,8 @@ class UserApp {
                 return '<i class="fas fa-spinner me-1"></i>处理中';
             case CONFIG.FEEDBACK_STATUS.RESOLVED:
                 return '<i class="fas fa-check-circle me-1"></i>已解决';
+            case CONFIG.FEEDBACK_STATUS.CLOSED:
+                return '<i class="fas fa-lock me-1"></i>已关闭';
             default:
                 return '<i class="fas fa-question me-1"></i>未知状态';
         }
@@ -1298,6 +1324,8 @@ class UserApp {
                 return 'status-in-progress';
             case CONFIG.FEEDBACK_STATUS.RESOLVED:
                 return 'status-resolved';
+            case CONFIG.FEEDBACK_STATUS.CLOSED:
+                return 'status-closed';
             default:
                 return 'bg-secondary';
         }
@@ -59,6 +59,12 @@ class HttpUtils {
 
         try {
             const response = await fetch(fullUrl, requestOptions);
+
+            // 访问令牌过期时用刷新令牌换取新令牌后重试一次
+            if (response.status === 401 && token && !options.retried && await this.refreshToken()) {
+                return this.request(url, { ...options, retried: true });
+            }
+
             return await this.handleResponse(response);
         } catch (error) {
             console.error('HTTP请求错误:', error);
@@ -85,6 +91,79 @@ class HttpUtils {
 
         return data;
     }
+
+    /**
+     * 使用刷新令牌换取新的访问令牌
+     * 刷新令牌每次使用后都会轮换，并发请求共用同一次刷新
+     * 前后端对接：POST /api/user/refresh → internal/handler/user.go Refresh()方法
+     * @returns {Promise<boolean>} 是否刷新成功
+     */
+    static refreshToken() {
+        const refreshToken = StorageUtils.getRefreshToken();
+        if (!refreshToken) {
+            return Promise.resolve(false);
+        }
+
+        if (!this.refreshing) {
+            this.refreshing = fetch(`${CONFIG.API_BASE_URL}${CONFIG.ENDPOINTS.USER.REFRESH}`, {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ refresh_token: refreshToken })
+            })
+                .then(response => response.ok ? response.json() : null)
+                .then(data => {
+                    if (!data || data.code !== CONFIG.ERROR_CODES.SUCCESS) {
+                        return false;
+                    }
+                    StorageUtils.setToken(data.data.token);
+                    StorageUtils.setRefreshToken(data.data.refresh_token);
+                    return true;
+                })
+                .catch(() => false)
+                .finally(() => {
+                    this.refreshing = null;
+                });
+        }
+        return this.refreshing;
+    }
+
+    /**
+     * 获取未过期的访问令牌，即将过期时先刷新
+     * WebSocket连接无法在握手失败后自动刷新，连接前应调用此方法
+     * @returns {Promise<string|null>} 访问令牌
+     */
+    static async getFreshToken() {
+        const token = StorageUtils.getToken();
+        if (!token) {
+            return null;
+        }
+
+        try {
+            const payload = JSON.parse(atob(token.split('.')[1].replace(/-/g, '+').replace(/_/g, '/')));
+            if (payload.exp * 1000 - Date.now() < 30000) {
+                await this.refreshToken();
+            }
+        } catch (error) {
+            console.error('解析令牌失败:', error);
+        }
+        return StorageUtils.getToken();
+    }
+
+    /**
+     * 通知后端注销当前会话
+     * 会话可能已被撤销，失败时忽略，不触发重新登录
+     * 前后端对接：POST /api/user/logout → internal/handler/user.go Logout()方法
+     */
+    static logout() {
+        const token = StorageUtils.getToken();
+        if (!token) {
+            return Promise.resolve();
+        }
+        return fetch(`${CONFIG.API_BASE_URL}${CONFIG.ENDPOINTS.USER.LOGOUT}`, {
+            method: 'POST',
+            headers: { 'Authorization': `Bearer ${token}` }
+        }).catch(() => {});
+    }
 }
 
 /**
@@ -101,6 +180,14 @@ class StorageUtils {
         return sessionStorage.getItem('auth_token');
     }
 
+    static setRefreshToken(refreshToken) {
+        sessionStorage.setItem('refresh_token', refreshToken);
+    }
+
+    static getRefreshToken() {
+        return sessionStorage.getItem('refresh_token');
+    }
+
     static setUserData(userData) {
         // 简化：直接使用固定的用户数据键
         sessionStorage.setItem('user_data', JSON.stringify(userData));
@@ -123,6 +210,7 @@ class StorageUtils {
     static clearUserData() {
         // 简化：只清除当前标签页的sessionStorage数据
         sessionStorage.removeItem('auth_token');
+        sessionStorage.removeItem('refresh_token');
         sessionStorage.removeItem('user_data');
         sessionStorage.removeItem('user_type');
     }
@@ -250,8 +338,187 @@ class ValidationUtils {
     }
 }
 
+/**
+ * 图片工具类
+ */
+class ImageUtils {
+    /**
+     * 获取附件图片的缩略图地址
+     * 后端为上传的图片生成 thumb(160) / small(480) / medium(1024) 三种规格，
+     * 签名对所有规格有效，只需追加 variant 参数；非附件地址原样返回
+     */
+    static variantUrl(url, variant) {
+        if (!url || !url.includes('/api/attachments/')) {
+            return url;
+        }
+        return `${url}${url.includes('?') ? '&' : '?'}variant=${variant}`;
+    }
+}
+
+/**
+ * 两步验证工具类
+ */
+class TwoFactorUtils {
+    /**
+     * 完成两步验证登录
+     * 登录响应中 two_factor_required 为 true 时调用，提示输入验证码后换取令牌；
+     * 强制两步验证且尚未绑定的账号，先展示绑定密钥，确认后显示恢复码
+     * 前后端对接：POST /api/user/login/2fa(/setup) → internal/handler/two_factor.go
+     * @param {Object} data 登录响应数据
+     * @returns {Promise<Object>} 包含 token 和 refresh_token 的登录响应数据
+     */
+    static async complete(data) {
+        if (!data.two_factor_required) {
+            return data;
+        }
+
+        if (data.two_factor_setup_required) {
+            const setup = await this.post(CONFIG.ENDPOINTS.USER.LOGIN_2FA_SETUP, { mfa_token: data.mfa_token });
+            window.prompt('当前账号必须启用两步验证，请在验证器应用中添加以下密钥（或复制 otpauth 地址）：', setup.otpauth_url);
+        }
+
+        const code = window.prompt('请输入验证器应用中的6位验证码或恢复码：');
+        if (!code) {
+            throw new Error('已取消两步验证');
+        }
+
+        const result = await this.post(CONFIG.ENDPOINTS.USER.LOGIN_2FA, { mfa_token: data.mfa_token, code: code.trim() });
+        if (result.recovery_codes && result.recovery_codes.length) {
+            window.alert('两步验证已启用，请妥善保存以下恢复码（只显示一次）：\n' + result.recovery_codes.join('\n'));
+        }
+        return result;
+    }
+
+    /**
+     * 发送未认证的请求，验证码错误时不触发 HttpUtils 的重新登录逻辑
+     */
+    static async post(url, body) {
+        const response = await fetch(`${CONFIG.API_BASE_URL}${url}`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(body)
+        });
+        const data = await response.json();
+        if (!response.ok || data.code !== CONFIG.ERROR_CODES.SUCCESS) {
+            throw new Error(data.message || '请求失败');
+        }
+        return data.data;
+    }
+}
+
+/**
+ * 密码工具类
+ */
+class PasswordUtils {
+    /**
+     * 登录后检查是否需要修改密码（如使用默认密码的管理员），需要时提示输入新密码
+     * 修改密码前，后端只允许该会话访问修改密码、获取当前用户和退出登录接口
+     * 前后端对接：POST /api/user/password → internal/handler/user.go ChangePassword()方法
+     * @param {Object} user 登录响应中的用户信息
+     * @param {string} oldPassword 登录时输入的密码
+     */
+    static async ensureChanged(user, oldPassword) {
+        while (user.must_change_password) {
+            const newPassword = window.prompt('当前密码为默认密码或已被重置，请设置新密码：');
+            if (!newPassword) {
+                await HttpUtils.logout();
+                StorageUtils.clearUserData();
+                throw new Error('需要修改密码后才能继续使用');
+            }
+            try {
+                await HttpUtils.post(CONFIG.ENDPOINTS.USER.PASSWORD, {
+                    old_password: oldPassword,
+                    new_password: newPassword
+                });
+                user.must_change_password = false;
+                StorageUtils.setUserData(user);
+            } catch (error) {
+                window.alert(error.message);
+            }
+        }
+    }
+}
+
+/**
+ * 单点登录工具类
+ * 前后端对接：/api/user/oidc/* → internal/handler/oidc.go
+ */
+class SSOUtils {
+    /**
+     * 单点登录失败原因（回调地址中的 sso_error 参数）对应的提示
+     */
+    static ERROR_MESSAGES = {
+        disabled: '未启用单点登录',
+        expired: '登录已超时，请重新登录',
+        forbidden: '当前企业账号没有权限登录',
+        no_account: '企业账号尚未绑定本系统账号，请联系管理员',
+        linked: '该企业账号已绑定其他账号',
+        pending: '账号正在等待管理员审核',
+        suspended: '账号已被停用',
+        denied: '已取消登录'
+    };
+
+    /**
+     * 启用单点登录时显示单点登录按钮，点击后跳转到 IdP 登录，完成后返回当前页面
+     * @param {HTMLElement} button 单点登录按钮，默认隐藏
+     * @param {number} userType 用户类型数字（2=商家, 3=管理员）
+     */
+    static async setupButton(button, userType) {
+        if (!button) {
+            return;
+        }
+        const response = await fetch(`${CONFIG.API_BASE_URL}${CONFIG.ENDPOINTS.USER.OIDC_CONFIG}`);
+        const config = (await response.json()).data || {};
+        const allowed = userType === CONFIG.USER_TYPE_NUMBERS.ADMIN ? config.admin : config.merchant;
+        if (!config.enabled || !allowed) {
+            return;
+        }
+
+        button.querySelector('span').textContent = `使用${config.provider_name}登录`;
+        button.classList.remove('d-none');
+        button.addEventListener('click', () => {
+            const params = new URLSearchParams({ user_type: userType, return: window.location.pathname });
+            window.location.href = `${CONFIG.API_BASE_URL}${CONFIG.ENDPOINTS.USER.OIDC_LOGIN}?${params}`;
+        });
+    }
+
+    /**
+     * 处理从 IdP 返回时地址中携带的参数，并从地址栏中移除
+     * 携带 sso_token 时换取登录令牌（需要时完成两步验证），携带 sso_error 时抛出错误
+     * @returns {Promise<Object|null>} 登录响应数据，没有单点登录结果时返回 null
+     */
+    static async consume() {
+        const params = new URLSearchParams(window.location.search);
+        const token = params.get('sso_token');
+        const error = params.get('sso_error');
+        const linked = params.get('sso_linked');
+        if (!token && !error && !linked) {
+            return null;
+        }
+
+        ['sso_token', 'sso_error', 'sso_linked'].forEach(key => params.delete(key));
+        const query = params.toString();
+        window.history.replaceState(null, '', window.location.pathname + (query ? `?${query}` : '') + window.location.hash);
+
+        if (error) {
+            throw new Error(this.ERROR_MESSAGES[error] || '请稍后重试');
+        }
+        if (linked) {
+            window.alert('企业账号绑定成功，之后可以使用单点登录');
+            return null;
+        }
+
+        const data = await TwoFactorUtils.post(CONFIG.ENDPOINTS.USER.OIDC_EXCHANGE, { token: token });
+        return TwoFactorUtils.complete(data);
+    }
+}
+
 // 导出工具类
 window.HttpUtils = HttpUtils;
 window.StorageUtils = StorageUtils;
 window.DateTimeUtils = DateTimeUtils;
 window.ValidationUtils = ValidationUtils;
+window.ImageUtils = ImageUtils;
+window.TwoFactorUtils = TwoFactorUtils;
+window.PasswordUtils = PasswordUtils;
+window.SSOUtils = SSOUtils;